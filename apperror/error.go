@@ -0,0 +1,70 @@
+package apperror
+
+import "errors"
+
+// Kind classifies an error by its outcome for the caller, independent of the transport.
+type Kind string
+
+const (
+	Internal    Kind = "internal"
+	NotFound    Kind = "not_found"
+	Conflict    Kind = "conflict"
+	Validation  Kind = "validation"
+	Unavailable Kind = "unavailable"
+	// Note : PreconditionFailed means the client's If-Match version is stale.
+	PreconditionFailed Kind = "precondition_failed"
+	// Note : Unsupported means the request is well-formed but uses a format or operation that is not supported.
+	Unsupported Kind = "unsupported"
+	// Note : Unprocessable means the request is understood but contradicts earlier state, e.g. a reused Idempotency-Key.
+	Unprocessable Kind = "unprocessable"
+)
+
+type Error struct {
+	Kind Kind
+	// Note : Message is safe to show to clients, Err is the underlying cause and is only meant for logs and traces.
+	Message string
+	Err     error
+}
+
+func New(kind Kind, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+func Wrap(kind Kind, err error, message string) *Error {
+	return &Error{Kind: kind, Message: message, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	return e.Message + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// KindOf returns the kind of the first *Error in err's chain, or Internal when there is none.
+func KindOf(err error) Kind {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Kind
+	}
+	return Internal
+}
+
+// MessageOf returns the client-safe message of err. Internal errors never expose their cause.
+func MessageOf(err error) string {
+	var appErr *Error
+	if errors.As(err, &appErr) && appErr.Kind != Internal {
+		return appErr.Message
+	}
+	return "internal server error"
+}
+
+// IsServerError reports whether err is the server's fault rather than the caller's.
+func IsServerError(err error) bool {
+	kind := KindOf(err)
+	return kind == Internal || kind == Unavailable
+}
@@ -4,3 +4,6 @@ const (
 	APP_PACKAGE = "github.com/indrabrata/observability-playground"
 	APP_NAME    = "observability-playground"
 )
+
+// Note : Prefix of the RFC 7807 problem "type" URI, followed by the apperror kind.
+const PROBLEM_TYPE_PREFIX = "urn:" + APP_NAME + ":problem:"
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/indrabrata/observability-playground/service"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type AlertHandler struct {
+	service *service.LowStockService
+	trace   trace.Tracer
+}
+
+func NewAlertHandler(service *service.LowStockService, trace trace.Tracer) *AlertHandler {
+	return &AlertHandler{
+		service: service,
+		trace:   trace,
+	}
+}
+
+// @Summary List low stock alerts
+// @Description Lists the products whose quantity is below their reorder point, oldest alert first. Alerts are raised by a background evaluator shortly after a stock change, so a product can take a moment to appear or disappear.
+// @Tags Alerts
+// @Produce json
+// @Produce application/problem+json
+// @Success 200 {object} model.LowStockAlertListResponse
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /alerts/low-stock [get]
+func (h *AlertHandler) ListLowStockAlerts(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.ListLowStockAlerts", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	alerts, err := h.service.ListLowStockAlerts(ctx)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("low stock alerts retrieved", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int("alertCount", len(alerts.Data)))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(alerts)
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/service"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type AuditHandler struct {
+	service *service.AuditService
+	trace   trace.Tracer
+}
+
+func NewAuditHandler(service *service.AuditService, trace trace.Tracer) *AuditHandler {
+	return &AuditHandler{
+		service: service,
+		trace:   trace,
+	}
+}
+
+// @Summary List audit log
+// @Description Lists who changed an entity and how, newest first, page by page using an opaque cursor. Each entry holds only the fields the change touched, with their value before and after it. The actor is the X-Actor header of the request that made the change.
+// @Tags Audit
+// @Produce json
+// @Produce application/problem+json
+// @Param entity query string true "Entity type" Enums(product)
+// @Param id query int false "Only the entries of this entity"
+// @Param from query string false "Only the entries at or after this time (RFC 3339)"
+// @Param to query string false "Only the entries before this time (RFC 3339)"
+// @Param limit query int false "Page size (1-100)" default(20)
+// @Param cursor query string false "next_cursor from the previous page"
+// @Success 200 {object} model.AuditLogResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /audit [get]
+func (h *AuditHandler) ListAuditLog(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.ListAuditLog", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	query := r.URL.Query()
+	req := model.AuditLogRequest{Entity: query.Get("entity"), Cursor: query.Get("cursor")}
+
+	var errs model.ValidationErrors
+	if value := query.Get("id"); value != "" {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			errs.Add("id", "id must be an integer")
+		} else {
+			req.EntityId = &id
+		}
+	}
+	for _, param := range []struct {
+		name   string
+		target **time.Time
+	}{{"from", &req.From}, {"to", &req.To}} {
+		if value := query.Get(param.name); value != "" {
+			at, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				errs.Add(param.name, param.name+" must be an RFC 3339 time")
+			} else {
+				*param.target = &at
+			}
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			errs.Add("limit", "limit must be an integer")
+		} else {
+			req.Limit = limit
+		}
+	}
+	if err := errs.Err(); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request validation failed"))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request validation failed"))
+		return
+	}
+
+	entries, err := h.service.ListAuditLog(ctx, req)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("audit log retrieved", zap.String("requestId", ctx.Value("requestId").(string)), zap.String("entity", req.Entity), zap.Int("entryCount", len(entries.Data)))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(entries)
+}
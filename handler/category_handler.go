@@ -0,0 +1,280 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/service"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type CategoryHandler struct {
+	service *service.CategoryService
+	trace   trace.Tracer
+}
+
+func NewCategoryHandler(service *service.CategoryService, trace trace.Tracer) *CategoryHandler {
+	return &CategoryHandler{
+		service: service,
+		trace:   trace,
+	}
+}
+
+// @Summary Create category
+// @Description Creates a category, optionally below a parent category. Names are unique among siblings, ignoring case.
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Produce application/problem+json
+// @Param CreateCategory body model.CategoryRequest true "Category"
+// @Success 201 {object} model.CategoryResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /categories [post]
+func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.CreateCategory", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	var req model.CategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request body is not valid JSON"))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request validation failed"))
+		return
+	}
+
+	category, err := h.service.CreateCategory(ctx, req)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("category created", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("categoryId", category.Id))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(category)
+}
+
+// @Summary List categories
+// @Description Lists every category, parents before their children
+// @Tags Categories
+// @Produce json
+// @Produce application/problem+json
+// @Success 200 {object} model.CategoryListResponse
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /categories [get]
+func (h *CategoryHandler) ListCategories(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.ListCategories", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	categories, err := h.service.ListCategories(ctx)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("categories retrieved", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int("categoryCount", len(categories.Data)))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(categories)
+}
+
+// @Summary Get category
+// @Description Retrieves a category
+// @Tags Categories
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Success 200 {object} model.CategoryResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /categories/{id} [get]
+func (h *CategoryHandler) GetCategory(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.GetCategory", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	id, err := parseIdParam(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	category, err := h.service.GetCategory(ctx, id)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(category)
+}
+
+// @Summary Update category
+// @Description Renames a category or moves it below another parent. A category cannot be moved below itself or one of its descendants.
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Param UpdateCategory body model.CategoryRequest true "Category"
+// @Success 200 {object} model.CategoryResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /categories/{id} [put]
+func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.UpdateCategory", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	id, err := parseIdParam(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	var req model.CategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request body is not valid JSON"))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request validation failed"))
+		return
+	}
+
+	category, err := h.service.UpdateCategory(ctx, id, req)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("category updated", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("categoryId", id))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(category)
+}
+
+// @Summary Delete category
+// @Description Deletes a category that has no subcategories and no pricing rules. Its products are unlinked from it, not deleted.
+// @Tags Categories
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Success 204
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /categories/{id} [delete]
+func (h *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.DeleteCategory", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	id, err := parseIdParam(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	if err := h.service.DeleteCategory(ctx, id); err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("category deleted", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("categoryId", id))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary List category products
+// @Description Lists the products of a category and of all of its subcategories, page by page in product id order
+// @Tags Categories
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Param limit query int false "Page size (1-100)" default(20)
+// @Param cursor query string false "next_cursor from the previous page"
+// @Success 200 {object} model.ProductListResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /categories/{id}/products [get]
+func (h *CategoryHandler) ListCategoryProducts(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.ListCategoryProducts", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	id, err := parseIdParam(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	req := model.CategoryProductsRequest{Cursor: r.URL.Query().Get("cursor")}
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			writeError(ctx, w, apperror.Wrap(apperror.Validation, model.ValidationErrors{{Field: "limit", Message: "limit must be an integer"}}, "request validation failed"))
+			return
+		}
+		req.Limit = limit
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request validation failed"))
+		return
+	}
+
+	products, err := h.service.ListCategoryProducts(ctx, id, req)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("category products retrieved", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("categoryId", id), zap.Int("productCount", len(products.Data)), zap.Bool("hasMore", products.Paging.HasMore))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(products)
+}
@@ -0,0 +1,13 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/indrabrata/observability-playground/problem"
+)
+
+// writeError writes err as an RFC 7807 problem, see problem.Write.
+func writeError(ctx context.Context, w http.ResponseWriter, err error) {
+	problem.Write(ctx, w, err)
+}
@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/service"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type OrderHandler struct {
+	service *service.OrderService
+	trace   trace.Tracer
+}
+
+func NewOrderHandler(service *service.OrderService, trace trace.Tracer) *OrderHandler {
+	return &OrderHandler{
+		service: service,
+		trace:   trace,
+	}
+}
+
+// @Summary Place order
+// @Description Places an order for one or more products at their current prices and takes the ordered units out of stock. Either every line is placed or none is. Every line must be priced in the same currency.
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Produce application/problem+json
+// @Param PlaceOrder body model.OrderRequest true "Ordered lines"
+// @Success 201 {object} model.OrderResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 422 {object} model.ProblemDetail "Unprocessable Entity"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /orders [post]
+func (h *OrderHandler) PlaceOrder(w http.ResponseWriter, r *http.Request) {
+	zap.L().Info("placing order", zap.String("requestId", r.Context().Value("requestId").(string)))
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.PlaceOrder", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	var req model.OrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request body is not valid JSON"))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request validation failed"))
+		return
+	}
+
+	order, err := h.service.PlaceOrder(ctx, req)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("order placed", zap.String("requestId", r.Context().Value("requestId").(string)), zap.Int64("orderId", order.Id), zap.Stringer("total", order.Total), zap.String("currency", order.Currency))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(order)
+}
+
+// @Summary List orders
+// @Description Lists the orders, newest first, page by page using an opaque cursor. Lines and status history are left out, get an order for them.
+// @Tags Orders
+// @Produce json
+// @Produce application/problem+json
+// @Param status query string false "Only orders with this status" Enums(pending, paid, shipped, cancelled)
+// @Param limit query int false "Page size (1-100)" default(20)
+// @Param cursor query string false "next_cursor from the previous page"
+// @Success 200 {object} model.OrderListResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /orders [get]
+func (h *OrderHandler) ListOrders(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.ListOrders", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	req := model.OrderListRequest{Status: r.URL.Query().Get("status"), Cursor: r.URL.Query().Get("cursor")}
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			writeError(ctx, w, apperror.Wrap(apperror.Validation, model.ValidationErrors{{Field: "limit", Message: "limit must be an integer"}}, "request validation failed"))
+			return
+		}
+		req.Limit = limit
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request validation failed"))
+		return
+	}
+
+	orders, err := h.service.ListOrders(ctx, req)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("orders retrieved", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int("orderCount", len(orders.Data)))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(orders)
+}
+
+// @Summary Get order
+// @Description Retrieves an order with its lines and status history
+// @Tags Orders
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Success 200 {object} model.OrderResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /orders/{id} [get]
+func (h *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
+	h.orderAction(w, r, "Handler.GetOrder", h.service.GetOrder)
+}
+
+// @Summary Pay order
+// @Description Records the payment of a pending order
+// @Tags Orders
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Success 200 {object} model.OrderResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /orders/{id}/pay [post]
+func (h *OrderHandler) PayOrder(w http.ResponseWriter, r *http.Request) {
+	h.orderAction(w, r, "Handler.PayOrder", h.service.PayOrder)
+}
+
+// @Summary Ship order
+// @Description Records the shipment of a paid order
+// @Tags Orders
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Success 200 {object} model.OrderResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /orders/{id}/ship [post]
+func (h *OrderHandler) ShipOrder(w http.ResponseWriter, r *http.Request) {
+	h.orderAction(w, r, "Handler.ShipOrder", h.service.ShipOrder)
+}
+
+// @Summary Cancel order
+// @Description Cancels a pending or paid order and puts its units back in stock. Shipped orders cannot be cancelled.
+// @Tags Orders
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Success 200 {object} model.OrderResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /orders/{id}/cancel [post]
+func (h *OrderHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	h.orderAction(w, r, "Handler.CancelOrder", h.service.CancelOrder)
+}
+
+func (h *OrderHandler) orderAction(w http.ResponseWriter, r *http.Request, spanName string, action func(ctx context.Context, id int64) (model.OrderResponse, error)) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, spanName, trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	id, err := parseIdParam(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	order, err := action(ctx, id)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("order handled", zap.String("requestId", ctx.Value("requestId").(string)), zap.String("action", spanName), zap.Int64("orderId", id), zap.String("status", order.Status))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(order)
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/model"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// @Summary Get price history
+// @Description Lists every price of a product, oldest first, and the prices scheduled for it that have not been applied yet
+// @Tags Prices
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Success 200 {object} model.PriceHistoryResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /products/{id}/prices [get]
+func (h *ProductHandler) GetPriceHistory(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.GetPriceHistory", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	id, err := parseIdParam(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	history, err := h.service.GetPriceHistory(ctx, id)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("price history retrieved", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("productId", id), zap.Int("changeCount", len(history.History)))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(history)
+}
+
+// @Summary Schedule price
+// @Description Schedules a future price for a product. The price scheduler applies it once effective_at has passed.
+// @Tags Prices
+// @Accept json
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Param SchedulePrice body model.ScheduledPriceRequest true "Scheduled price"
+// @Success 201 {object} model.ScheduledPriceResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /products/{id}/prices/scheduled [post]
+func (h *ProductHandler) SchedulePrice(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.SchedulePrice", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	id, err := parseIdParam(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	var req model.ScheduledPriceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request body is not valid JSON"))
+		return
+	}
+
+	if err := req.Validate(time.Now()); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request validation failed"))
+		return
+	}
+
+	scheduled, err := h.service.SchedulePrice(ctx, id, req)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("price scheduled", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("productId", id), zap.Int64("scheduledPriceId", scheduled.Id), zap.Time("effectiveAt", scheduled.EffectiveAt))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(scheduled)
+}
+
+// @Summary Cancel scheduled price
+// @Description Cancels a scheduled price that has not been applied yet
+// @Tags Prices
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Param scheduledId path int true "scheduled price id"
+// @Success 204
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /products/{id}/prices/scheduled/{scheduledId} [delete]
+func (h *ProductHandler) CancelScheduledPrice(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.CancelScheduledPrice", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	id, err := parseIdParam(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	scheduledId, err := parseInt64Param(r, "scheduledId")
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	if err := h.service.CancelScheduledPrice(ctx, id, scheduledId); err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("scheduled price cancelled", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("productId", id), zap.Int64("scheduledPriceId", scheduledId))
+
+	w.WriteHeader(http.StatusNoContent)
+}
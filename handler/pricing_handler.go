@@ -0,0 +1,293 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/service"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type PricingHandler struct {
+	service *service.PricingService
+	trace   trace.Tracer
+}
+
+func NewPricingHandler(service *service.PricingService, trace trace.Tracer) *PricingHandler {
+	return &PricingHandler{
+		service: service,
+		trace:   trace,
+	}
+}
+
+// @Summary Create pricing rule
+// @Description Creates a percentage or fixed discount on a product, or on every product of a category and its subcategories. Tiers make it a bulk discount, starts_at and ends_at a promotion.
+// @Tags Pricing
+// @Accept json
+// @Produce json
+// @Produce application/problem+json
+// @Param CreatePricingRule body model.PricingRuleRequest true "Pricing rule"
+// @Success 201 {object} model.PricingRuleResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /pricing-rules [post]
+func (h *PricingHandler) CreatePricingRule(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.CreatePricingRule", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	var req model.PricingRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request body is not valid JSON"))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request validation failed"))
+		return
+	}
+
+	rule, err := h.service.CreatePricingRule(ctx, req)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("pricing rule created", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("ruleId", rule.Id))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rule)
+}
+
+// @Summary List pricing rules
+// @Description Lists every pricing rule, running or not, by id
+// @Tags Pricing
+// @Produce json
+// @Produce application/problem+json
+// @Success 200 {object} model.PricingRuleListResponse
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /pricing-rules [get]
+func (h *PricingHandler) ListPricingRules(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.ListPricingRules", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	rules, err := h.service.ListPricingRules(ctx)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("pricing rules retrieved", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int("ruleCount", len(rules.Data)))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(rules)
+}
+
+// @Summary Get pricing rule
+// @Description Retrieves a pricing rule with its tiers
+// @Tags Pricing
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Success 200 {object} model.PricingRuleResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /pricing-rules/{id} [get]
+func (h *PricingHandler) GetPricingRule(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.GetPricingRule", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	id, err := parseIdParam(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	rule, err := h.service.GetPricingRule(ctx, id)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("pricing rule retrieved", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("ruleId", id))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(rule)
+}
+
+// @Summary Update pricing rule
+// @Description Replaces a pricing rule, tiers included
+// @Tags Pricing
+// @Accept json
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Param UpdatePricingRule body model.PricingRuleRequest true "Pricing rule"
+// @Success 200 {object} model.PricingRuleResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /pricing-rules/{id} [put]
+func (h *PricingHandler) UpdatePricingRule(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.UpdatePricingRule", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	id, err := parseIdParam(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	var req model.PricingRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request body is not valid JSON"))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request validation failed"))
+		return
+	}
+
+	rule, err := h.service.UpdatePricingRule(ctx, id, req)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("pricing rule updated", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("ruleId", id))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(rule)
+}
+
+// @Summary Delete pricing rule
+// @Description Deletes a pricing rule. Orders already placed keep the prices they were placed at.
+// @Tags Pricing
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Success 204
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /pricing-rules/{id} [delete]
+func (h *PricingHandler) DeletePricingRule(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.DeletePricingRule", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	id, err := parseIdParam(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	if err := h.service.DeletePricingRule(ctx, id); err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("pricing rule deleted", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("ruleId", id))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Quote price
+// @Description Prices a quantity of a product, or of one of its variants, with the pricing rules running now and explains which rules applied. Percentage rules apply first, each on the price left by the ones before it, then fixed rules. Every rule applies the highest tier the quantity reaches.
+// @Tags Pricing
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Param qty query int false "Quantity (1-1000000)" default(1)
+// @Param variant_id query int false "Quote a variant of the product"
+// @Success 200 {object} model.PriceQuoteResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 422 {object} model.ProblemDetail "Unprocessable Entity"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /products/{id}/price [get]
+func (h *PricingHandler) QuotePrice(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.QuotePrice", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	id, err := parseIdParam(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	var req model.PriceQuoteRequest
+	var errs model.ValidationErrors
+	if value := r.URL.Query().Get("qty"); value != "" {
+		quantity, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			errs.Add("qty", "qty must be an integer")
+		} else {
+			req.Quantity = quantity
+		}
+	}
+	if value := r.URL.Query().Get("variant_id"); value != "" {
+		variantId, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			errs.Add("variant_id", "variant_id must be an integer")
+		} else {
+			req.VariantId = &variantId
+		}
+	}
+	if err := errs.Err(); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request validation failed"))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request validation failed"))
+		return
+	}
+
+	quote, err := h.service.QuotePrice(ctx, id, req)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("price quoted", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("productId", id), zap.Int64("quantity", quote.Quantity), zap.Stringer("unitPrice", quote.UnitPrice), zap.Int("appliedRuleCount", len(quote.AppliedRules)))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(quote)
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/service"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type ProductEventHandler struct {
+	service      *service.ProductEventStream
+	trace        trace.Tracer
+	writeTimeout time.Duration
+}
+
+// NewProductEventHandler serves the product event stream. A subscriber that does not take a write within
+// writeTimeout is disconnected, so a stuck client does not keep its connection open forever.
+func NewProductEventHandler(service *service.ProductEventStream, trace trace.Tracer, writeTimeout time.Duration) *ProductEventHandler {
+	return &ProductEventHandler{
+		service:      service,
+		trace:        trace,
+		writeTimeout: writeTimeout,
+	}
+}
+
+// deadlineWriter gives every write to the client writeTimeout to complete.
+type deadlineWriter struct {
+	controller   *http.ResponseController
+	w            http.ResponseWriter
+	writeTimeout time.Duration
+}
+
+func (dw *deadlineWriter) Write(p []byte) (int, error) {
+	dw.extend()
+	return dw.w.Write(p)
+}
+
+func (dw *deadlineWriter) Flush() error {
+	dw.extend()
+	return dw.controller.Flush()
+}
+
+func (dw *deadlineWriter) extend() {
+	// Note : Not every connection supports deadlines (e.g. httptest.ResponseRecorder), the stream works without one.
+	if err := dw.controller.SetWriteDeadline(time.Now().Add(dw.writeTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		zap.L().Warn("failed to set product event stream write deadline", zap.Error(err))
+	}
+}
+
+// @Summary Stream product events
+// @Description Streams product changes as server-sent events: the event id is the outbox event id, which always increases, the event name is the event type and the data is the event as JSON. Without Last-Event-ID only new changes are sent; with it every change after that event is sent first. When those changes are no longer kept a reset event is sent instead, after which the client should reload the products. A comment line is sent as a heartbeat while there are no changes.
+// @Tags Products
+// @Produce text/event-stream
+// @Produce application/problem+json
+// @Param Last-Event-ID header int false "Id of the last event received, sent by browsers when they reconnect"
+// @Param last_event_id query int false "Same as the Last-Event-ID header, for clients that cannot set headers"
+// @Success 200 {string} string "Product event stream"
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /products/events [get]
+func (h *ProductEventHandler) StreamProductEvents(w http.ResponseWriter, r *http.Request) {
+	// Note : No timeout here, the stream stays open until the client disconnects.
+	ctx, span := h.trace.Start(r.Context(), "Handler.StreamProductEvents", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	field, value := "Last-Event-ID", r.Header.Get("Last-Event-ID")
+	if value == "" {
+		field, value = "last_event_id", r.URL.Query().Get("last_event_id")
+	}
+	var lastEventId *int64
+	if value != "" {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || id < 0 {
+			writeError(ctx, w, apperror.Wrap(apperror.Validation, model.ValidationErrors{{Field: field, Message: field + " must be a non-negative integer"}}, "request validation failed"))
+			return
+		}
+		lastEventId = &id
+		span.SetAttributes(attribute.Int64("lastEventId", id))
+	}
+
+	zap.L().Info("product event subscriber connected", zap.String("requestId", r.Context().Value("requestId").(string)))
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	writer := &deadlineWriter{controller: http.NewResponseController(w), w: w, writeTimeout: h.writeTimeout}
+	defer writer.controller.SetWriteDeadline(time.Time{})
+
+	written, err := h.service.StreamProductEvents(ctx, lastEventId, writer, writer.Flush)
+	if err != nil {
+		if written == 0 {
+			w.Header().Del("Cache-Control")
+			w.Header().Del("X-Accel-Buffering")
+			writeError(ctx, w, err)
+			return
+		}
+
+		// Note : Most often the client stopped reading and the write deadline passed, the client reconnects
+		// with Last-Event-ID and misses nothing.
+		zap.L().Warn("product event stream aborted", zap.Error(err), zap.Int64("byteCount", written), zap.String("requestId", r.Context().Value("requestId").(string)))
+		panic(http.ErrAbortHandler)
+	}
+
+	zap.L().Info("product event subscriber disconnected", zap.String("requestId", r.Context().Value("requestId").(string)), zap.Int64("byteCount", written))
+}
@@ -3,13 +3,21 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/indrabrata/observability-playground/apperror"
 	"github.com/indrabrata/observability-playground/model"
 	"github.com/indrabrata/observability-playground/service"
+	"github.com/indrabrata/observability-playground/utility"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
@@ -32,8 +40,15 @@ func New(service *service.ProductService, trace trace.Tracer) *ProductHandler {
 // @Tags Products
 // @Accept json
 // @Produce json
+// @Produce application/problem+json
+// @Param Idempotency-Key header string false "Retries with the same key replay the first response instead of creating another product"
 // @Param CreateProduct body model.ProductRequest true "Product details"
-// @Success 200 {object} model.ProductResponse
+// @Success 201 {object} model.ProductResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 422 {object} model.ProblemDetail "Unprocessable Entity"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
 // @Router /products [post]
 func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 	zap.L().Info("creating product", zap.String("requestId", r.Context().Value("requestId").(string)))
@@ -48,35 +63,51 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 	var req model.ProductRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		zap.L().Error("failed to decode product request", zap.Error(err), zap.String("requestId", r.Context().Value("requestId").(string)))
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request body is not valid JSON"))
 		return
 	}
 
 	if err := req.Validate(); err != nil {
 		zap.L().Error("failed to validate product request", zap.Error(err), zap.String("requestId", r.Context().Value("requestId").(string)))
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request validation failed"))
 		return
 	}
 
 	product, err := h.service.CreateProduct(ctx, req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(ctx, w, err)
 		return
 	}
 
 	zap.L().Info("product created", zap.String("requestId", r.Context().Value("requestId").(string)), zap.Int64("productId", product.Id))
 
+	w.Header().Set("ETag", utility.FormatETag(product.Version))
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(product)
 }
 
 // @Summary Get products
-// @Description Retrieves all products
+// @Description Retrieves products page by page using an opaque cursor, with optional filters and sorting
 // @Tags Products
 // @Accept json
 // @Produce json
-// @Success 200 {object} []model.ProductResponse
+// @Produce application/problem+json
+// @Param limit query int false "Page size (1-100)" default(20)
+// @Param cursor query string false "Opaque cursor taken from paging.next_cursor of the previous page"
+// @Param sort_by query string false "Sort field" Enums(name, price, quantity, created_at) default(name)
+// @Param sort_order query string false "Sort direction" Enums(asc, desc) default(asc)
+// @Param name_contains query string false "Only products whose name contains this text"
+// @Param min_price query string false "Minimum price (inclusive), a decimal in the currency filter"
+// @Param max_price query string false "Maximum price (inclusive), a decimal in the currency filter"
+// @Param currency query string false "Only products priced in this ISO 4217 currency; defaults to USD when a price bound is given"
+// @Param in_stock query bool false "Only products with (true) or without (false) stock"
+// @Param tags query string false "Comma separated tags; only products carrying every one of them"
+// @Param include_deleted query bool false "Also list soft deleted products" default(false)
+// @Success 200 {object} model.ProductListResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
 // @Router /products [get]
 func (h *ProductHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
 	zap.L().Info("get products", zap.String("requestId", r.Context().Value("requestId").(string)))
@@ -87,26 +118,217 @@ func (h *ProductHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
 	ctx, span := h.trace.Start(ctx, "Handler.GetProducts", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
 	defer span.End()
 
-	products, err := h.service.GetProducts(ctx)
+	req, err := parseProductListRequest(r)
+	if err != nil {
+		zap.L().Error("failed to parse product list request", zap.Error(err), zap.String("requestId", r.Context().Value("requestId").(string)))
+		writeError(ctx, w, err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		zap.L().Error("failed to validate product list request", zap.Error(err), zap.String("requestId", r.Context().Value("requestId").(string)))
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request validation failed"))
+		return
+	}
+
+	products, err := h.service.GetProducts(ctx, req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(ctx, w, err)
 		return
 	}
 
-	zap.L().Info("products retrieved", zap.String("requestId", r.Context().Value("requestId").(string)), zap.Int("productCount", len(products)))
+	zap.L().Info("products retrieved", zap.String("requestId", r.Context().Value("requestId").(string)), zap.Int("productCount", len(products.Data)), zap.Bool("hasMore", products.Paging.HasMore))
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(products)
 }
 
+// @Summary Import products
+// @Description Creates products in bulk from a CSV file (header with name, quantity, price) or from JSON Lines (one product object per line). Every invalid line is reported with its line number. In partial mode valid rows are created; in all_or_nothing mode nothing is created unless every row is valid.
+// @Tags Products
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Produce json
+// @Produce application/problem+json
+// @Param mode query string false "Import mode" Enums(partial, all_or_nothing) default(partial)
+// @Param ImportProducts body string true "CSV or JSON Lines document"
+// @Success 200 {object} model.ProductImportResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 415 {object} model.ProblemDetail "Unsupported Media Type"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /products:import [post]
+func (h *ProductHandler) ImportProducts(w http.ResponseWriter, r *http.Request) {
+	zap.L().Info("importing products", zap.String("requestId", r.Context().Value("requestId").(string)))
+
+	// Note : Imports may carry tens of thousands of rows, so they get a longer deadline than single product calls.
+	ctx, cancel := context.WithTimeout(r.Context(), time.Minute)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.ImportProducts", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = model.ImportModePartial
+	}
+	if mode != model.ImportModePartial && mode != model.ImportModeAllOrNothing {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, model.ValidationErrors{{Field: "mode", Message: "mode must be partial or all_or_nothing"}}, "request validation failed"))
+		return
+	}
+
+	var rows model.ProductRowReader
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case model.CSVContentType:
+		reader, err := model.NewCSVProductRowReader(r.Body)
+		if err != nil {
+			var validationErrors model.ValidationErrors
+			if errors.As(err, &validationErrors) {
+				writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request validation failed"))
+			} else {
+				writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "import body could not be read"))
+			}
+			return
+		}
+		rows = reader
+	case model.NDJSONContentType:
+		rows = model.NewNDJSONProductRowReader(r.Body)
+	default:
+		writeError(ctx, w, apperror.New(apperror.Unsupported, fmt.Sprintf("content type %q is not a supported import format, use %s or %s", mediaType, model.CSVContentType, model.NDJSONContentType)))
+		return
+	}
+
+	report, err := h.service.ImportProducts(ctx, rows, mode)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("products imported", zap.String("requestId", r.Context().Value("requestId").(string)), zap.String("mode", mode), zap.Int64("createdCount", report.Created), zap.Int64("failedCount", report.Failed))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
+
+// @Summary Export products
+// @Description Streams the whole catalog (soft deleted products excluded) as CSV or JSON Lines, flushed in chunks so memory use stays flat
+// @Tags Products
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce application/problem+json
+// @Param format query string false "Export format" Enums(csv, ndjson) default(csv)
+// @Success 200 {string} string "Exported products"
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /products:export [get]
+func (h *ProductHandler) ExportProducts(w http.ResponseWriter, r *http.Request) {
+	zap.L().Info("exporting products", zap.String("requestId", r.Context().Value("requestId").(string)))
+
+	// Note : No timeout here, an export runs as long as the client keeps reading and stops when it disconnects.
+	// The service bounds every chunk it reads instead.
+	ctx, span := h.trace.Start(r.Context(), "Handler.ExportProducts", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = model.ExportFormatCSV
+	}
+	contentType := model.ExportContentType(format)
+	if contentType == "" {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, model.ValidationErrors{{Field: "format", Message: "format must be csv or ndjson"}}, "request validation failed"))
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="products.%s"`, format))
+
+	written, err := h.service.ExportProducts(ctx, format, w, http.NewResponseController(w).Flush)
+	if err != nil {
+		if written == 0 {
+			w.Header().Del("Content-Disposition")
+			writeError(ctx, w, err)
+			return
+		}
+
+		// Note : The status line is already sent, aborting the handler makes net/http cut the connection so the
+		// client sees a truncated transfer instead of a complete but partial file.
+		zap.L().Error("product export aborted", zap.Error(err), zap.Int64("byteCount", written), zap.String("requestId", r.Context().Value("requestId").(string)))
+		panic(http.ErrAbortHandler)
+	}
+
+	zap.L().Info("products exported", zap.String("requestId", r.Context().Value("requestId").(string)), zap.String("format", format), zap.Int64("byteCount", written))
+}
+
+// @Summary Search products
+// @Description Full-text search over product names, ranked by BM25 relevance with highlighted snippets
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Produce application/problem+json
+// @Param q query string true "Search text; the last term matches as a prefix"
+// @Param limit query int false "Maximum number of results (1-100)" default(20)
+// @Success 200 {object} model.ProductSearchResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /products/search [get]
+func (h *ProductHandler) SearchProducts(w http.ResponseWriter, r *http.Request) {
+	zap.L().Info("search products", zap.String("requestId", r.Context().Value("requestId").(string)))
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.SearchProducts", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	req := model.ProductSearchRequest{Query: r.URL.Query().Get("q")}
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			zap.L().Error("failed to parse limit", zap.Error(err), zap.String("requestId", r.Context().Value("requestId").(string)))
+			writeError(ctx, w, apperror.Wrap(apperror.Validation, model.ValidationErrors{{Field: "limit", Message: "limit must be an integer"}}, "request validation failed"))
+			return
+		}
+		req.Limit = limit
+	}
+
+	if err := req.Validate(); err != nil {
+		zap.L().Error("failed to validate product search request", zap.Error(err), zap.String("requestId", r.Context().Value("requestId").(string)))
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request validation failed"))
+		return
+	}
+
+	results, err := h.service.SearchProducts(ctx, req)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("products searched", zap.String("requestId", r.Context().Value("requestId").(string)), zap.Int("resultCount", len(results.Data)))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(results)
+}
+
 // @Summary Get product by ID
-// @Description Retrieves product based on given ID
+// @Description Retrieves product based on given ID. The ETag header carries the product version.
 // @Tags Products
 // @Accept json
 // @Produce json
+// @Produce application/problem+json
 // @Param id path int true "id"
+// @Param If-None-Match header string false "ETag of a cached copy; answers 304 when it is still current"
 // @Success 200 {object} model.ProductResponse
+// @Success 304 "Not Modified"
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
 // @Router /products/{id} [get]
 func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
@@ -115,10 +337,10 @@ func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 	ctx, span := h.trace.Start(ctx, "Handler.GetProduct", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
 	defer span.End()
 
-	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	id, err := parseIdParam(r)
 	if err != nil {
 		zap.L().Error("failed to parse id", zap.String("requestId", ctx.Value("requestId").(string)), zap.Error(err))
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(ctx, w, err)
 		return
 	}
 
@@ -126,25 +348,110 @@ func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 
 	product, err := h.service.GetProduct(ctx, id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(ctx, w, err)
 		return
 	}
 
 	zap.L().Info("product retrieved", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("productId", id))
 
+	w.Header().Set("ETag", utility.FormatETag(product.Version))
+	if inm := r.Header.Get("If-None-Match"); inm == "*" || slices.Contains(utility.ParseETags(inm, true), product.Version) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(product)
+}
+
+// @Summary Get product by SKU
+// @Description Retrieves the product with the given SKU, ignoring case. The ETag header carries the product version.
+// @Tags Products
+// @Produce json
+// @Produce application/problem+json
+// @Param sku path string true "sku"
+// @Success 200 {object} model.ProductResponse
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /products/by-sku/{sku} [get]
+func (h *ProductHandler) GetProductBySku(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.GetProductBySku", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	product, err := h.service.GetProductBySku(ctx, chi.URLParam(r, "sku"))
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("product retrieved by sku", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("productId", product.Id))
+
+	w.Header().Set("ETag", utility.FormatETag(product.Version))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(product)
+}
+
+// @Summary Get product by barcode
+// @Description Retrieves the product with the given GTIN barcode (EAN-13, UPC-A, ...). The ETag header carries the product version.
+// @Tags Products
+// @Produce json
+// @Produce application/problem+json
+// @Param code path string true "GTIN barcode"
+// @Success 200 {object} model.ProductResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /products/by-barcode/{code} [get]
+func (h *ProductHandler) GetProductByBarcode(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.GetProductByBarcode", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	code := chi.URLParam(r, "code")
+	if !model.ValidGTIN(code) {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, model.ValidationErrors{{Field: "code", Message: "code must be an 8, 12, 13 or 14 digit GTIN with a valid check digit"}}, "request validation failed"))
+		return
+	}
+
+	product, err := h.service.GetProductByBarcode(ctx, code)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("product retrieved by barcode", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("productId", product.Id))
+
+	w.Header().Set("ETag", utility.FormatETag(product.Version))
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(product)
 }
 
 // @Summary Update product
-// @Description Updates a product
+// @Description Updates a product. Send the ETag from a previous read as If-Match to reject the update when someone else changed the product in the meantime.
 // @Tags Products
 // @Accept json
 // @Produce json
+// @Produce application/problem+json
 // @Param id path int true "id"
+// @Param If-Match header string false "ETag the update is based on"
 // @Param UpdateProduct body model.ProductRequest true "Product details"
 // @Success 200 {object} model.ProductResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 412 {object} model.ProblemDetail "Precondition Failed"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
 // @Router /products/{id} [put]
 func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
@@ -153,9 +460,9 @@ func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	ctx, span := h.trace.Start(ctx, "Handler.UpdateProduct", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
 	defer span.End()
 
-	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	id, err := parseIdParam(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(ctx, w, err)
 		return
 	}
 
@@ -163,35 +470,124 @@ func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 
 	var req model.ProductRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request body is not valid JSON"))
 		return
 	}
 
 	if err := req.Validate(); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request validation failed"))
 		return
 	}
 
-	product, err := h.service.UpdateProduct(ctx, id, req)
+	product, err := h.service.UpdateProduct(ctx, id, req, parsePrecondition(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(ctx, w, err)
 		return
 	}
 
 	zap.L().Info("product updated", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("productId", id))
 
+	w.Header().Set("ETag", utility.FormatETag(product.Version))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(product)
+}
+
+// @Summary Patch product
+// @Description Partially updates a product. Accepts a JSON Merge Patch (application/merge-patch+json) or a JSON Patch (application/json-patch+json) with add, replace, remove and test operations. Only supplied fields are written. A null member or a remove operation clears sku, barcode or reorder_point.
+// @Tags Products
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Param If-Match header string false "ETag the patch is based on"
+// @Param PatchProduct body model.ProductPatchRequest true "Fields to change"
+// @Success 200 {object} model.ProductResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 412 {object} model.ProblemDetail "Precondition Failed"
+// @Failure 415 {object} model.ProblemDetail "Unsupported Media Type"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /products/{id} [patch]
+func (h *ProductHandler) PatchProduct(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.PatchProduct", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	id, err := parseIdParam(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("patching product", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("productId", id))
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "failed to read request body"))
+		return
+	}
+
+	var parse func([]byte) (model.ProductPatchRequest, error)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case model.MergePatchContentType:
+		parse = model.ParseMergePatch
+	case model.JSONPatchContentType:
+		parse = model.ParseJSONPatch
+	default:
+		w.Header().Set("Accept-Patch", model.MergePatchContentType+", "+model.JSONPatchContentType)
+		writeError(ctx, w, apperror.New(apperror.Unsupported, fmt.Sprintf("content type %q is not a supported patch format", mediaType)))
+		return
+	}
+
+	req, err := parse(body)
+	if err == nil {
+		err = req.Validate()
+	}
+	if err != nil {
+		var validationErrors model.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request validation failed"))
+		} else {
+			writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request body is not a valid patch document"))
+		}
+		return
+	}
+
+	product, err := h.service.PatchProduct(ctx, id, req, parsePrecondition(r))
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("product patched", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("productId", id))
+
+	w.Header().Set("ETag", utility.FormatETag(product.Version))
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(product)
 }
 
 // @Summary Delete product
-// @Description Deletes a product
+// @Description Soft deletes a product; it can be restored until the purge removes it. Send If-Match to only delete the version you have seen.
 // @Tags Products
 // @Accept json
 // @Produce json
+// @Produce application/problem+json
 // @Param id path int true "id"
+// @Param If-Match header string false "ETag the delete is based on"
 // @Success 204
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 412 {object} model.ProblemDetail "Precondition Failed"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
 // @Router /products/{id} [delete]
 func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
@@ -200,17 +596,17 @@ func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	ctx, span := h.trace.Start(ctx, "Handler.DeleteProduct", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
 	defer span.End()
 
-	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	id, err := parseIdParam(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(ctx, w, err)
 		return
 	}
 
 	zap.L().Info("deleting product", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("productId", id))
 
-	err = h.service.DeleteProduct(ctx, id)
+	err = h.service.DeleteProduct(ctx, id, parsePrecondition(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(ctx, w, err)
 		return
 	}
 
@@ -218,3 +614,108 @@ func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// @Summary Restore product
+// @Description Restores a soft deleted product that has not been purged yet
+// @Tags Products
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Success 200 {object} model.ProductResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /products/{id}/restore [post]
+func (h *ProductHandler) RestoreProduct(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.RestoreProduct", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	id, err := parseIdParam(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("restoring product", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("productId", id))
+
+	product, err := h.service.RestoreProduct(ctx, id)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("product restored", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("productId", id))
+
+	w.Header().Set("ETag", utility.FormatETag(product.Version))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(product)
+}
+
+func parseProductListRequest(r *http.Request) (model.ProductListRequest, error) {
+	query := r.URL.Query()
+
+	req := model.ProductListRequest{
+		Cursor:       query.Get("cursor"),
+		SortBy:       query.Get("sort_by"),
+		SortOrder:    query.Get("sort_order"),
+		NameContains: query.Get("name_contains"),
+		Currency:     query.Get("currency"),
+	}
+
+	var errs model.ValidationErrors
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			errs.Add("limit", "limit must be an integer")
+		}
+		req.Limit = limit
+	}
+
+	if value := query.Get("min_price"); value != "" {
+		minPrice, err := model.ParseMoney(value)
+		if err != nil {
+			errs.Add("min_price", "min_price must be a decimal number")
+		}
+		req.MinPrice = &minPrice
+	}
+
+	if value := query.Get("max_price"); value != "" {
+		maxPrice, err := model.ParseMoney(value)
+		if err != nil {
+			errs.Add("max_price", "max_price must be a decimal number")
+		}
+		req.MaxPrice = &maxPrice
+	}
+
+	if value := query.Get("in_stock"); value != "" {
+		inStock, err := strconv.ParseBool(value)
+		if err != nil {
+			errs.Add("in_stock", "in_stock must be true or false")
+		}
+		req.InStock = &inStock
+	}
+
+	for _, value := range query["tags"] {
+		req.Tags = append(req.Tags, strings.Split(value, ",")...)
+	}
+
+	if value := query.Get("include_deleted"); value != "" {
+		includeDeleted, err := strconv.ParseBool(value)
+		if err != nil {
+			errs.Add("include_deleted", "include_deleted must be true or false")
+		}
+		req.IncludeDeleted = includeDeleted
+	}
+
+	if err := errs.Err(); err != nil {
+		return req, apperror.Wrap(apperror.Validation, err, "request validation failed")
+	}
+
+	return req, nil
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/utility"
+)
+
+func parseIdParam(r *http.Request) (int64, error) {
+	return parseInt64Param(r, "id")
+}
+
+func parseInt64Param(r *http.Request, name string) (int64, error) {
+	value, err := strconv.ParseInt(chi.URLParam(r, name), 10, 64)
+	if err != nil {
+		return 0, apperror.Wrap(apperror.Validation, model.ValidationErrors{{Field: name, Message: name + " must be an integer"}}, "request validation failed")
+	}
+
+	return value, nil
+}
+
+func parsePrecondition(r *http.Request) model.Precondition {
+	header := r.Header.Get("If-Match")
+	if header == "" || header == "*" {
+		return model.Precondition{}
+	}
+
+	return model.Precondition{IfMatch: true, Versions: utility.ParseETags(header, false)}
+}
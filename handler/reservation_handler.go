@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/service"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type ReservationHandler struct {
+	service *service.ReservationService
+	trace   trace.Tracer
+}
+
+func NewReservationHandler(service *service.ReservationService, trace trace.Tracer) *ReservationHandler {
+	return &ReservationHandler{
+		service: service,
+		trace:   trace,
+	}
+}
+
+// @Summary Create reservation
+// @Description Holds stock of one or more products for a checkout. Either every item is reserved or none is. Held units are released when the reservation is cancelled or expires.
+// @Tags Reservations
+// @Accept json
+// @Produce json
+// @Produce application/problem+json
+// @Param CreateReservation body model.ReservationRequest true "Reserved items"
+// @Success 201 {object} model.ReservationResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /reservations [post]
+func (h *ReservationHandler) CreateReservation(w http.ResponseWriter, r *http.Request) {
+	zap.L().Info("creating reservation", zap.String("requestId", r.Context().Value("requestId").(string)))
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.CreateReservation", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	var req model.ReservationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request body is not valid JSON"))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request validation failed"))
+		return
+	}
+
+	reservation, err := h.service.CreateReservation(ctx, req)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("reservation created", zap.String("requestId", r.Context().Value("requestId").(string)), zap.Int64("reservationId", reservation.Id))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(reservation)
+}
+
+// @Summary Get reservation
+// @Description Retrieves a reservation and its items
+// @Tags Reservations
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Success 200 {object} model.ReservationResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /reservations/{id} [get]
+func (h *ReservationHandler) GetReservation(w http.ResponseWriter, r *http.Request) {
+	h.reservationAction(w, r, "Handler.GetReservation", h.service.GetReservation)
+}
+
+// @Summary Confirm reservation
+// @Description Confirms an active reservation once payment succeeded; the held units stay out of stock
+// @Tags Reservations
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Success 200 {object} model.ReservationResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /reservations/{id}/confirm [post]
+func (h *ReservationHandler) ConfirmReservation(w http.ResponseWriter, r *http.Request) {
+	h.reservationAction(w, r, "Handler.ConfirmReservation", h.service.ConfirmReservation)
+}
+
+// @Summary Cancel reservation
+// @Description Cancels an active reservation and puts the held units back in stock
+// @Tags Reservations
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Success 200 {object} model.ReservationResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /reservations/{id}/cancel [post]
+func (h *ReservationHandler) CancelReservation(w http.ResponseWriter, r *http.Request) {
+	h.reservationAction(w, r, "Handler.CancelReservation", h.service.CancelReservation)
+}
+
+func (h *ReservationHandler) reservationAction(w http.ResponseWriter, r *http.Request, spanName string, action func(ctx context.Context, id int64) (model.ReservationResponse, error)) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, spanName, trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	id, err := parseIdParam(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	reservation, err := action(ctx, id)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("reservation handled", zap.String("requestId", ctx.Value("requestId").(string)), zap.String("action", spanName), zap.Int64("reservationId", id), zap.String("status", reservation.Status))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(reservation)
+}
@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/utility"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// @Summary Adjust stock
+// @Description Adds (positive delta) or removes (negative delta) stock with a reason, e.g. after a stock count. Stock never goes below zero.
+// @Tags Stock
+// @Accept json
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Param AdjustStock body model.StockAdjustRequest true "Stock adjustment"
+// @Success 200 {object} model.StockAdjustResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /products/{id}/stock/adjust [post]
+func (h *ProductHandler) AdjustStock(w http.ResponseWriter, r *http.Request) {
+	h.moveStock(w, r, "Handler.AdjustStock", func(r *http.Request) (model.StockAdjustRequest, error) {
+		var req model.StockAdjustRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return req, apperror.Wrap(apperror.Validation, err, "request body is not valid JSON")
+		}
+		if err := req.Validate(); err != nil {
+			return req, apperror.Wrap(apperror.Validation, err, "request validation failed")
+		}
+		return req, nil
+	})
+}
+
+// @Summary Receive stock
+// @Description Adds received units to the stock of a product, e.g. from a purchase order
+// @Tags Stock
+// @Accept json
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Param ReceiveStock body model.StockQuantityRequest true "Received units"
+// @Success 200 {object} model.StockAdjustResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /products/{id}/stock/receive [post]
+func (h *ProductHandler) ReceiveStock(w http.ResponseWriter, r *http.Request) {
+	h.moveStock(w, r, "Handler.ReceiveStock", stockQuantityRequest(model.StockReasonReceive, 1))
+}
+
+// @Summary Ship stock
+// @Description Removes shipped units from the stock of a product. Shipping more than is in stock is refused.
+// @Tags Stock
+// @Accept json
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Param ShipStock body model.StockQuantityRequest true "Shipped units"
+// @Success 200 {object} model.StockAdjustResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /products/{id}/stock/ship [post]
+func (h *ProductHandler) ShipStock(w http.ResponseWriter, r *http.Request) {
+	h.moveStock(w, r, "Handler.ShipStock", stockQuantityRequest(model.StockReasonShip, -1))
+}
+
+// stockQuantityRequest decodes a receive or ship body into an adjustment with a fixed reason and direction.
+func stockQuantityRequest(reason string, sign int64) func(r *http.Request) (model.StockAdjustRequest, error) {
+	return func(r *http.Request) (model.StockAdjustRequest, error) {
+		var req model.StockQuantityRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return model.StockAdjustRequest{}, apperror.Wrap(apperror.Validation, err, "request body is not valid JSON")
+		}
+		if err := req.Validate(); err != nil {
+			return model.StockAdjustRequest{}, apperror.Wrap(apperror.Validation, err, "request validation failed")
+		}
+		return model.StockAdjustRequest{VariantId: req.VariantId, WarehouseId: req.WarehouseId, Delta: sign * req.Quantity, Reason: reason, Reference: req.Reference, Actor: req.Actor}, nil
+	}
+}
+
+func (h *ProductHandler) moveStock(w http.ResponseWriter, r *http.Request, spanName string, parse func(r *http.Request) (model.StockAdjustRequest, error)) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, spanName, trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	id, err := parseIdParam(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	req, err := parse(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("moving stock", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("productId", id), zap.Int64("delta", req.Delta), zap.String("reason", req.Reason))
+
+	result, err := h.service.AdjustStock(ctx, id, req)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("stock moved", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("productId", id), zap.Int64("quantityAfter", result.Movement.QuantityAfter))
+
+	w.Header().Set("ETag", utility.FormatETag(result.Product.Version))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+// @Summary Get stock history
+// @Description Lists the stock movements of a product, newest first, page by page using an opaque cursor
+// @Tags Stock
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Param limit query int false "Page size (1-100)" default(20)
+// @Param cursor query string false "next_cursor from the previous page"
+// @Success 200 {object} model.StockHistoryResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /products/{id}/stock/history [get]
+func (h *ProductHandler) GetStockHistory(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.GetStockHistory", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	id, err := parseIdParam(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	req := model.StockHistoryRequest{Cursor: r.URL.Query().Get("cursor")}
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			writeError(ctx, w, apperror.Wrap(apperror.Validation, model.ValidationErrors{{Field: "limit", Message: "limit must be an integer"}}, "request validation failed"))
+			return
+		}
+		req.Limit = limit
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request validation failed"))
+		return
+	}
+
+	history, err := h.service.GetStockHistory(ctx, id, req)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("stock history retrieved", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("productId", id), zap.Int("movementCount", len(history.Data)))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(history)
+}
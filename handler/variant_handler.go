@@ -0,0 +1,246 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/model"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// @Summary List variants
+// @Description Lists the variants of a product in the order they were created
+// @Tags Variants
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Success 200 {object} model.VariantListResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /products/{id}/variants [get]
+func (h *ProductHandler) ListVariants(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.ListVariants", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	id, err := parseIdParam(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	variants, err := h.service.ListVariants(ctx, id)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(variants)
+}
+
+// @Summary Create variant
+// @Description Adds a variant with its own SKU, options, optional price override and quantity. The quantity of the product becomes the sum of its variants, so the first variant can only be added to a product without stock or active reservations.
+// @Tags Variants
+// @Accept json
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Param CreateVariant body model.VariantRequest true "Variant"
+// @Success 201 {object} model.VariantResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /products/{id}/variants [post]
+func (h *ProductHandler) CreateVariant(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.CreateVariant", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	id, err := parseIdParam(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	var req model.VariantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request body is not valid JSON"))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request validation failed"))
+		return
+	}
+
+	variant, err := h.service.CreateVariant(ctx, id, req)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("variant created", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("productId", id), zap.Int64("variantId", variant.Id))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(variant)
+}
+
+// @Summary Get variant
+// @Description Retrieves a variant of a product
+// @Tags Variants
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Param variantId path int true "variant id"
+// @Success 200 {object} model.VariantResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /products/{id}/variants/{variantId} [get]
+func (h *ProductHandler) GetVariant(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.GetVariant", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	id, err := parseIdParam(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	variantId, err := parseInt64Param(r, "variantId")
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	variant, err := h.service.GetVariant(ctx, id, variantId)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(variant)
+}
+
+// @Summary Update variant
+// @Description Replaces a variant. A changed quantity moves the quantity of the product by the same amount and is recorded in the stock history.
+// @Tags Variants
+// @Accept json
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Param variantId path int true "variant id"
+// @Param UpdateVariant body model.VariantRequest true "Variant"
+// @Success 200 {object} model.VariantResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /products/{id}/variants/{variantId} [put]
+func (h *ProductHandler) UpdateVariant(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.UpdateVariant", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	id, err := parseIdParam(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	variantId, err := parseInt64Param(r, "variantId")
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	var req model.VariantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request body is not valid JSON"))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request validation failed"))
+		return
+	}
+
+	variant, err := h.service.UpdateVariant(ctx, id, variantId, req)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("variant updated", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("productId", id), zap.Int64("variantId", variantId))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(variant)
+}
+
+// @Summary Delete variant
+// @Description Deletes a variant that has no stock, no active reservations and no pending or paid orders. Its stock history keeps the variant id.
+// @Tags Variants
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Param variantId path int true "variant id"
+// @Success 204
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /products/{id}/variants/{variantId} [delete]
+func (h *ProductHandler) DeleteVariant(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.DeleteVariant", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	id, err := parseIdParam(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	variantId, err := parseInt64Param(r, "variantId")
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	if err := h.service.DeleteVariant(ctx, id, variantId); err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("variant deleted", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("productId", id), zap.Int64("variantId", variantId))
+
+	w.WriteHeader(http.StatusNoContent)
+}
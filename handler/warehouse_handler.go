@@ -0,0 +1,305 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/service"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type WarehouseHandler struct {
+	service *service.WarehouseService
+	trace   trace.Tracer
+}
+
+func NewWarehouseHandler(service *service.WarehouseService, trace trace.Tracer) *WarehouseHandler {
+	return &WarehouseHandler{
+		service: service,
+		trace:   trace,
+	}
+}
+
+// @Summary Create warehouse
+// @Description Creates a stock location. Codes are unique ignoring case and label the warehouse metrics.
+// @Tags Warehouses
+// @Accept json
+// @Produce json
+// @Produce application/problem+json
+// @Param CreateWarehouse body model.WarehouseRequest true "Warehouse"
+// @Success 201 {object} model.WarehouseResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /warehouses [post]
+func (h *WarehouseHandler) CreateWarehouse(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.CreateWarehouse", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	var req model.WarehouseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request body is not valid JSON"))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request validation failed"))
+		return
+	}
+
+	warehouse, err := h.service.CreateWarehouse(ctx, req)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("warehouse created", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("warehouseId", warehouse.Id))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(warehouse)
+}
+
+// @Summary List warehouses
+// @Description Lists every warehouse ordered by code
+// @Tags Warehouses
+// @Produce json
+// @Produce application/problem+json
+// @Success 200 {object} model.WarehouseListResponse
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /warehouses [get]
+func (h *WarehouseHandler) ListWarehouses(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.ListWarehouses", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	warehouses, err := h.service.ListWarehouses(ctx)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("warehouses retrieved", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int("warehouseCount", len(warehouses.Data)))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(warehouses)
+}
+
+// @Summary Get warehouse
+// @Description Retrieves a warehouse
+// @Tags Warehouses
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Success 200 {object} model.WarehouseResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /warehouses/{id} [get]
+func (h *WarehouseHandler) GetWarehouse(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.GetWarehouse", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	id, err := parseIdParam(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	warehouse, err := h.service.GetWarehouse(ctx, id)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(warehouse)
+}
+
+// @Summary Update warehouse
+// @Description Replaces the code and name of a warehouse
+// @Tags Warehouses
+// @Accept json
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Param UpdateWarehouse body model.WarehouseRequest true "Warehouse"
+// @Success 200 {object} model.WarehouseResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /warehouses/{id} [put]
+func (h *WarehouseHandler) UpdateWarehouse(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.UpdateWarehouse", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	id, err := parseIdParam(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	var req model.WarehouseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request body is not valid JSON"))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request validation failed"))
+		return
+	}
+
+	warehouse, err := h.service.UpdateWarehouse(ctx, id, req)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("warehouse updated", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("warehouseId", id))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(warehouse)
+}
+
+// @Summary Delete warehouse
+// @Description Deletes a warehouse that holds no stock, no active reservations and no pending or paid orders. Its stock history keeps the warehouse id.
+// @Tags Warehouses
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Success 204
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /warehouses/{id} [delete]
+func (h *WarehouseHandler) DeleteWarehouse(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.DeleteWarehouse", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	id, err := parseIdParam(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	if err := h.service.DeleteWarehouse(ctx, id); err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("warehouse deleted", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("warehouseId", id))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Create transfer
+// @Description Moves stock between two locations in one transaction: every item moves or none does. Leaving out from_warehouse_id takes the stock from the unallocated quantity, leaving out to_warehouse_id puts it back there. Product totals do not change.
+// @Tags Warehouses
+// @Accept json
+// @Produce json
+// @Produce application/problem+json
+// @Param Idempotency-Key header string false "Retries with the same key replay the first response instead of moving the stock again"
+// @Param CreateTransfer body model.TransferRequest true "Transfer"
+// @Success 201 {object} model.TransferResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /transfers [post]
+func (h *WarehouseHandler) CreateTransfer(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.CreateTransfer", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	var req model.TransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request body is not valid JSON"))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request validation failed"))
+		return
+	}
+
+	transfer, err := h.service.CreateTransfer(ctx, req)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("transfer created", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("transferId", transfer.Id), zap.Int("itemCount", len(transfer.Items)))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(transfer)
+}
+
+// @Summary Get transfer
+// @Description Retrieves a transfer and its items
+// @Tags Warehouses
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Success 200 {object} model.TransferResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /transfers/{id} [get]
+func (h *WarehouseHandler) GetTransfer(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.GetTransfer", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	id, err := parseIdParam(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	transfer, err := h.service.GetTransfer(ctx, id)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(transfer)
+}
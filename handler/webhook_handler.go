@@ -0,0 +1,322 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/service"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type WebhookHandler struct {
+	service *service.WebhookService
+	trace   trace.Tracer
+}
+
+func NewWebhookHandler(service *service.WebhookService, trace trace.Tracer) *WebhookHandler {
+	return &WebhookHandler{
+		service: service,
+		trace:   trace,
+	}
+}
+
+// @Summary Create webhook
+// @Description Subscribes a URL to product events. Every delivery is a JSON POST of the event, signed in the X-Webhook-Signature header as sha256=<hex HMAC-SHA256 of "<X-Webhook-Timestamp>.<body>"> with the secret. A secret is generated when none is given; it is only returned by this response.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Produce application/problem+json
+// @Param CreateWebhook body model.WebhookRequest true "Webhook"
+// @Success 201 {object} model.WebhookResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /webhooks [post]
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.CreateWebhook", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	var req model.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request body is not valid JSON"))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request validation failed"))
+		return
+	}
+
+	webhook, err := h.service.CreateWebhook(ctx, req)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("webhook created", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("webhookId", webhook.Id))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(webhook)
+}
+
+// @Summary List webhooks
+// @Description Lists every webhook subscription without its secret
+// @Tags Webhooks
+// @Produce json
+// @Produce application/problem+json
+// @Success 200 {object} model.WebhookListResponse
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /webhooks [get]
+func (h *WebhookHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.ListWebhooks", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	webhooks, err := h.service.ListWebhooks(ctx)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("webhooks retrieved", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int("webhookCount", len(webhooks.Data)))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(webhooks)
+}
+
+// @Summary Get webhook
+// @Description Retrieves a webhook subscription without its secret
+// @Tags Webhooks
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Success 200 {object} model.WebhookResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /webhooks/{id} [get]
+func (h *WebhookHandler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.GetWebhook", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	id, err := parseIdParam(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	webhook, err := h.service.GetWebhook(ctx, id)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(webhook)
+}
+
+// @Summary Update webhook
+// @Description Replaces the URL and event types of a webhook. The secret is only replaced when one is given, and then returned once.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Param UpdateWebhook body model.WebhookRequest true "Webhook"
+// @Success 200 {object} model.WebhookResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /webhooks/{id} [put]
+func (h *WebhookHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.UpdateWebhook", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	id, err := parseIdParam(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	var req model.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request body is not valid JSON"))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request validation failed"))
+		return
+	}
+
+	webhook, err := h.service.UpdateWebhook(ctx, id, req)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("webhook updated", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("webhookId", id))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(webhook)
+}
+
+// @Summary Delete webhook
+// @Description Deletes a webhook subscription and its delivery log. Pending deliveries are dropped.
+// @Tags Webhooks
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Success 204
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.DeleteWebhook", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	id, err := parseIdParam(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	if err := h.service.DeleteWebhook(ctx, id); err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("webhook deleted", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("webhookId", id))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary List webhook deliveries
+// @Description Lists the deliveries of a webhook, newest first, page by page using an opaque cursor. A pending delivery is retried with exponential backoff; after 8 failed attempts it is dead.
+// @Tags Webhooks
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Param status query string false "Only deliveries with this status" Enums(pending, delivered, dead)
+// @Param limit query int false "Page size (1-100)" default(20)
+// @Param cursor query string false "next_cursor from the previous page"
+// @Success 200 {object} model.WebhookDeliveryListResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.ListWebhookDeliveries", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	id, err := parseIdParam(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	req := model.WebhookDeliveryListRequest{Status: r.URL.Query().Get("status"), Cursor: r.URL.Query().Get("cursor")}
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			writeError(ctx, w, apperror.Wrap(apperror.Validation, model.ValidationErrors{{Field: "limit", Message: "limit must be an integer"}}, "request validation failed"))
+			return
+		}
+		req.Limit = limit
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(ctx, w, apperror.Wrap(apperror.Validation, err, "request validation failed"))
+		return
+	}
+
+	deliveries, err := h.service.ListWebhookDeliveries(ctx, id, req)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("webhook deliveries retrieved", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("webhookId", id), zap.Int("deliveryCount", len(deliveries.Data)))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// @Summary Retry webhook delivery
+// @Description Sends a dead delivery again with a fresh set of attempts
+// @Tags Webhooks
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "id"
+// @Param deliveryId path int true "deliveryId"
+// @Success 200 {object} model.WebhookDeliveryResponse
+// @Failure 400 {object} model.ProblemDetail "Bad Request"
+// @Failure 404 {object} model.ProblemDetail "Not Found"
+// @Failure 409 {object} model.ProblemDetail "Conflict"
+// @Failure 500 {object} model.ProblemDetail "Internal Server Error"
+// @Failure 503 {object} model.ProblemDetail "Service Unavailable"
+// @Router /webhooks/{id}/deliveries/{deliveryId}/retry [post]
+func (h *WebhookHandler) RetryWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	ctx, span := h.trace.Start(ctx, "Handler.RetryWebhookDelivery", trace.WithAttributes(attribute.String("requestId", r.Context().Value("requestId").(string))))
+	defer span.End()
+
+	id, err := parseIdParam(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+	deliveryId, err := parseInt64Param(r, "deliveryId")
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	delivery, err := h.service.RetryWebhookDelivery(ctx, id, deliveryId)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	zap.L().Info("webhook delivery retried", zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("webhookId", id), zap.Int64("deliveryId", deliveryId))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(delivery)
+}
@@ -8,6 +8,9 @@ import (
 	"go.uber.org/zap"
 )
 
+// moneyMigrationVersion is 00009_money.sql, which rounds REAL prices to whole cents.
+const moneyMigrationVersion = 9
+
 func RunMigrations(db *sql.DB, migrations fs.FS) {
 	goose.SetBaseFS(migrations)
 
@@ -15,9 +18,45 @@ func RunMigrations(db *sql.DB, migrations fs.FS) {
 		zap.L().Fatal("failed to set goose dialect", zap.Error(err))
 	}
 
+	before, err := goose.GetDBVersion(db)
+	if err != nil {
+		zap.L().Fatal("failed to read migration version", zap.Error(err))
+	}
+
 	if err := goose.Up(db, "sql/migrations"); err != nil {
 		zap.L().Fatal("failed to run migrations", zap.Error(err))
 	}
 
+	if before < moneyMigrationVersion {
+		logMoneyRoundings(db)
+	}
+
 	zap.L().Info("migrations applied successfully")
 }
+
+// logMoneyRoundings logs every price the money migration rounded in this run, so they can be reviewed and fixed up
+// with a plain price update.
+func logMoneyRoundings(db *sql.DB) {
+	rows, err := db.Query(`SELECT table_name, row_id, column_name, original_price, rounded_price_minor FROM money_migration_roundings ORDER BY id`)
+	if err != nil {
+		zap.L().Warn("failed to read money migration roundings", zap.Error(err))
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var table, column string
+		var rowId, rounded int64
+		var original float64
+		if err := rows.Scan(&table, &rowId, &column, &original, &rounded); err != nil {
+			zap.L().Warn("failed to read money migration roundings", zap.Error(err))
+			return
+		}
+		zap.L().Warn("price rounded to whole cents by the money migration",
+			zap.String("table", table), zap.Int64("rowId", rowId), zap.String("column", column),
+			zap.Float64("originalPrice", original), zap.Int64("roundedPriceMinor", rounded))
+	}
+	if err := rows.Err(); err != nil {
+		zap.L().Warn("failed to read money migration roundings", zap.Error(err))
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/indrabrata/observability-playground/middleware"
+	"github.com/indrabrata/observability-playground/service"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -24,5 +25,14 @@ func NewPrometheusMetric(ctx context.Context) *prometheus.Registry {
 	middleware.Latency = Latency
 
 	promReg.MustRegister(TotalRequest, Latency)
+	promReg.MustRegister(service.QueryLatency, service.PurgedProducts, service.ExportedRows, service.ExportedBytes)
+	promReg.MustRegister(service.ActiveReservations, service.ConfirmedReservations, service.CancelledReservations, service.ExpiredReservations)
+	promReg.MustRegister(service.AppliedScheduledPrices)
+	promReg.MustRegister(service.WarehouseStock, service.WarehouseMovedUnits)
+	promReg.MustRegister(service.ProductsBelowReorderPoint, service.LowStockNotifications)
+	promReg.MustRegister(service.OutboxBacklog, service.OutboxOldestPendingAge, service.OutboxDispatchLag, service.OutboxPublished)
+	promReg.MustRegister(service.WebhookDeliveries, service.WebhookDeliveriesByStatus, service.WebhookDeliveryDuration)
+	promReg.MustRegister(service.ProductEventSubscribers, service.ProductEventsStreamed)
+	promReg.MustRegister(service.OrderTransitions, service.OrdersByStatus, service.OrderStatusDuration)
 	return promReg
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"os"
+	"strings"
 
 	"github.com/XSAM/otelsql"
 	_ "github.com/mattn/go-sqlite3"
@@ -14,7 +15,14 @@ import (
 func SqlLite3DBConnect(ctx context.Context) *sql.DB {
 	sqlite3Path := os.Getenv("SQLITE3_PATH")
 
-	db, err := otelsql.Open("sqlite3", sqlite3Path,
+	// Note : SQLite only enforces foreign keys when asked to, per connection. The DSN option applies it to every
+	// connection of the pool, which a PRAGMA statement would not.
+	dsn := sqlite3Path + "?_foreign_keys=on"
+	if strings.Contains(sqlite3Path, "?") {
+		dsn = sqlite3Path + "&_foreign_keys=on"
+	}
+
+	db, err := otelsql.Open("sqlite3", dsn,
 		otelsql.WithAttributes(semconv.DBSystemSqlite),
 		otelsql.WithSpanOptions(otelsql.SpanOptions{DisableQuery: false}),
 	)
@@ -5,6 +5,7 @@ import (
 	"embed"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/indrabrata/observability-playground/docs"
@@ -12,6 +13,7 @@ import (
 	"github.com/indrabrata/observability-playground/infrastructure"
 	"github.com/indrabrata/observability-playground/middleware"
 	"github.com/indrabrata/observability-playground/repository"
+	idempotencyrepository "github.com/indrabrata/observability-playground/repository/idempotency"
 	productrepository "github.com/indrabrata/observability-playground/repository/product"
 	"github.com/indrabrata/observability-playground/service"
 	"github.com/joho/godotenv"
@@ -47,6 +49,7 @@ func main() {
 
 	router := chi.NewRouter()
 	router.Use(middleware.RequestIdMiddleware)
+	router.Use(middleware.ClientMiddleware)
 	router.Use(middleware.MetricsMiddleware)
 	router.Use(middleware.RequestMiddleware)
 	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -57,11 +60,151 @@ func main() {
 	productSService := service.New(repository.NewBaseRepository(db, productRepository), trace.Tracer("Product.Service"))
 	productHandler := handler.New(productSService, trace.Tracer("Product.Handler"))
 
-	router.Post("/products", productHandler.CreateProduct)
+	go productSService.RunPurge(ctx, durationEnv("PRODUCT_PURGE_INTERVAL", time.Hour), durationEnv("PRODUCT_RETENTION", 30*24*time.Hour))
+	go productSService.RunPriceScheduler(ctx, durationEnv("PRICE_SCHEDULER_INTERVAL", time.Minute))
+
+	lowStockNotifiers := []service.LowStockNotifier{service.LogLowStockNotifier{}}
+	if url := os.Getenv("LOW_STOCK_WEBHOOK_URL"); url != "" {
+		lowStockNotifiers = append(lowStockNotifiers, service.NewWebhookLowStockNotifier(url, 5*time.Second))
+	}
+	lowStockService := service.NewLowStockService(repository.NewBaseRepository(db, productRepository), trace.Tracer("LowStock.Service"), lowStockNotifiers...)
+	alertHandler := handler.NewAlertHandler(lowStockService, trace.Tracer("Alert.Handler"))
+	productSService.SetLowStockService(lowStockService)
+
+	go lowStockService.Run(ctx, durationEnv("LOW_STOCK_EVALUATION_INTERVAL", time.Minute))
+
+	webhookService := service.NewWebhookService(repository.NewBaseRepository(db, productRepository), trace.Tracer("Webhook.Service"), durationEnv("WEBHOOK_TIMEOUT", 10*time.Second))
+	webhookHandler := handler.NewWebhookHandler(webhookService, trace.Tracer("Webhook.Handler"))
+
+	if err := webhookService.RefreshMetrics(ctx); err != nil {
+		zap.L().Warn("failed to load webhook metrics", zap.Error(err))
+	}
+
+	go webhookService.Run(ctx, durationEnv("WEBHOOK_DELIVERY_INTERVAL", 5*time.Second))
+
+	productEventStream := service.NewProductEventStream(repository.NewBaseRepository(db, productRepository), trace.Tracer("ProductEvent.Stream"), durationEnv("PRODUCT_EVENTS_HEARTBEAT", 15*time.Second))
+	productEventHandler := handler.NewProductEventHandler(productEventStream, trace.Tracer("ProductEvent.Handler"), durationEnv("PRODUCT_EVENTS_WRITE_TIMEOUT", 10*time.Second))
+
+	outboxRelay := service.NewOutboxRelay(repository.NewBaseRepository(db, productRepository), trace.Tracer("Outbox.Relay"), service.LogOutboxSink{}, webhookService, productEventStream)
+
+	if err := outboxRelay.RefreshMetrics(ctx); err != nil {
+		zap.L().Warn("failed to load outbox metrics", zap.Error(err))
+	}
+
+	go outboxRelay.Run(ctx, durationEnv("OUTBOX_RELAY_INTERVAL", time.Second))
+	go outboxRelay.RunCleanup(ctx, time.Hour, durationEnv("OUTBOX_RETENTION", 7*24*time.Hour))
+
+	auditService := service.NewAuditService(repository.NewBaseRepository(db, productRepository), trace.Tracer("Audit.Service"))
+	auditHandler := handler.NewAuditHandler(auditService, trace.Tracer("Audit.Handler"))
+
+	idempotency := middleware.NewIdempotency(idempotencyrepository.New(db), durationEnv("IDEMPOTENCY_KEY_TTL", 24*time.Hour))
+	go idempotency.RunCleanup(ctx, time.Hour)
+
+	router.With(idempotency.Middleware).Post("/products", productHandler.CreateProduct)
 	router.Get("/products", productHandler.GetProducts)
+	router.Get("/products/search", productHandler.SearchProducts)
+	router.Get("/products/events", productEventHandler.StreamProductEvents)
+	router.Post("/products:import", productHandler.ImportProducts)
+	router.Get("/products:export", productHandler.ExportProducts)
+	router.Get("/products/by-sku/{sku}", productHandler.GetProductBySku)
+	router.Get("/products/by-barcode/{code}", productHandler.GetProductByBarcode)
 	router.Get("/products/{id}", productHandler.GetProduct)
 	router.Put("/products/{id}", productHandler.UpdateProduct)
+	router.Patch("/products/{id}", productHandler.PatchProduct)
 	router.Delete("/products/{id}", productHandler.DeleteProduct)
+	router.Post("/products/{id}/restore", productHandler.RestoreProduct)
+	router.Post("/products/{id}/stock/adjust", productHandler.AdjustStock)
+	router.Post("/products/{id}/stock/receive", productHandler.ReceiveStock)
+	router.Post("/products/{id}/stock/ship", productHandler.ShipStock)
+	router.Get("/products/{id}/stock/history", productHandler.GetStockHistory)
+	router.Get("/products/{id}/prices", productHandler.GetPriceHistory)
+	router.Post("/products/{id}/prices/scheduled", productHandler.SchedulePrice)
+	router.Delete("/products/{id}/prices/scheduled/{scheduledId}", productHandler.CancelScheduledPrice)
+	router.Get("/products/{id}/categories", productHandler.GetProductCategories)
+	router.Put("/products/{id}/categories", productHandler.SetProductCategories)
+	router.Get("/products/{id}/tags", productHandler.GetProductTags)
+	router.Put("/products/{id}/tags", productHandler.SetProductTags)
+	router.Get("/products/{id}/variants", productHandler.ListVariants)
+	router.Post("/products/{id}/variants", productHandler.CreateVariant)
+	router.Get("/products/{id}/variants/{variantId}", productHandler.GetVariant)
+	router.Put("/products/{id}/variants/{variantId}", productHandler.UpdateVariant)
+	router.Delete("/products/{id}/variants/{variantId}", productHandler.DeleteVariant)
+
+	reservationService := service.NewReservationService(repository.NewBaseRepository(db, productRepository), trace.Tracer("Reservation.Service"), durationEnv("RESERVATION_TTL", 15*time.Minute))
+	reservationHandler := handler.NewReservationHandler(reservationService, trace.Tracer("Reservation.Handler"))
+
+	if err := reservationService.RefreshMetrics(ctx); err != nil {
+		zap.L().Warn("failed to load reservation metrics", zap.Error(err))
+	}
+
+	go reservationService.RunExpiry(ctx, durationEnv("RESERVATION_SWEEP_INTERVAL", 30*time.Second))
+
+	router.Post("/reservations", reservationHandler.CreateReservation)
+	router.Get("/reservations/{id}", reservationHandler.GetReservation)
+	router.Post("/reservations/{id}/confirm", reservationHandler.ConfirmReservation)
+	router.Post("/reservations/{id}/cancel", reservationHandler.CancelReservation)
+
+	orderService := service.NewOrderService(repository.NewBaseRepository(db, productRepository), trace.Tracer("Order.Service"))
+	orderHandler := handler.NewOrderHandler(orderService, trace.Tracer("Order.Handler"))
+
+	if err := orderService.RefreshMetrics(ctx); err != nil {
+		zap.L().Warn("failed to load order metrics", zap.Error(err))
+	}
+
+	router.Post("/orders", orderHandler.PlaceOrder)
+	router.Get("/orders", orderHandler.ListOrders)
+	router.Get("/orders/{id}", orderHandler.GetOrder)
+	router.Post("/orders/{id}/pay", orderHandler.PayOrder)
+	router.Post("/orders/{id}/ship", orderHandler.ShipOrder)
+	router.Post("/orders/{id}/cancel", orderHandler.CancelOrder)
+
+	categoryService := service.NewCategoryService(repository.NewBaseRepository(db, productRepository), trace.Tracer("Category.Service"))
+	categoryHandler := handler.NewCategoryHandler(categoryService, trace.Tracer("Category.Handler"))
+
+	router.Post("/categories", categoryHandler.CreateCategory)
+	router.Get("/categories", categoryHandler.ListCategories)
+	router.Get("/categories/{id}", categoryHandler.GetCategory)
+	router.Put("/categories/{id}", categoryHandler.UpdateCategory)
+	router.Delete("/categories/{id}", categoryHandler.DeleteCategory)
+	router.Get("/categories/{id}/products", categoryHandler.ListCategoryProducts)
+
+	pricingService := service.NewPricingService(repository.NewBaseRepository(db, productRepository), trace.Tracer("Pricing.Service"))
+	pricingHandler := handler.NewPricingHandler(pricingService, trace.Tracer("Pricing.Handler"))
+
+	router.Post("/pricing-rules", pricingHandler.CreatePricingRule)
+	router.Get("/pricing-rules", pricingHandler.ListPricingRules)
+	router.Get("/pricing-rules/{id}", pricingHandler.GetPricingRule)
+	router.Put("/pricing-rules/{id}", pricingHandler.UpdatePricingRule)
+	router.Delete("/pricing-rules/{id}", pricingHandler.DeletePricingRule)
+	router.Get("/products/{id}/price", pricingHandler.QuotePrice)
+
+	warehouseService := service.NewWarehouseService(repository.NewBaseRepository(db, productRepository), trace.Tracer("Warehouse.Service"))
+	warehouseHandler := handler.NewWarehouseHandler(warehouseService, trace.Tracer("Warehouse.Handler"))
+
+	if err := warehouseService.RefreshMetrics(ctx); err != nil {
+		zap.L().Warn("failed to load warehouse metrics", zap.Error(err))
+	}
+
+	router.Post("/warehouses", warehouseHandler.CreateWarehouse)
+	router.Get("/warehouses", warehouseHandler.ListWarehouses)
+	router.Get("/warehouses/{id}", warehouseHandler.GetWarehouse)
+	router.Put("/warehouses/{id}", warehouseHandler.UpdateWarehouse)
+	router.Delete("/warehouses/{id}", warehouseHandler.DeleteWarehouse)
+	router.With(idempotency.Middleware).Post("/transfers", warehouseHandler.CreateTransfer)
+	router.Get("/transfers/{id}", warehouseHandler.GetTransfer)
+
+	router.Get("/alerts/low-stock", alertHandler.ListLowStockAlerts)
+
+	router.Post("/webhooks", webhookHandler.CreateWebhook)
+	router.Get("/webhooks", webhookHandler.ListWebhooks)
+	router.Get("/webhooks/{id}", webhookHandler.GetWebhook)
+	router.Put("/webhooks/{id}", webhookHandler.UpdateWebhook)
+	router.Delete("/webhooks/{id}", webhookHandler.DeleteWebhook)
+	router.Get("/webhooks/{id}/deliveries", webhookHandler.ListWebhookDeliveries)
+	router.Post("/webhooks/{id}/deliveries/{deliveryId}/retry", webhookHandler.RetryWebhookDelivery)
+
+	router.Get("/audit", auditHandler.ListAuditLog)
+
 	router.Get("/metrics", promhttp.HandlerFor(metric, promhttp.HandlerOpts{}).ServeHTTP)
 
 	port := os.Getenv("PORT")
@@ -80,3 +223,17 @@ func main() {
 
 	http.ListenAndServe(":"+port, router)
 }
+
+func durationEnv(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		zap.L().Fatal("invalid duration in "+key, zap.String("value", value), zap.Error(err))
+	}
+
+	return duration
+}
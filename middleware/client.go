@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const (
+	// ActorHeader names the caller of a request, e.g. a user name set by the gateway in front of the API.
+	ActorHeader = "X-Actor"
+
+	maxActorLength = 128
+)
+
+// ClientMiddleware puts the caller of a request in its context: the actor from the X-Actor header, if any, and the
+// client IP taken from the connection. The audit log records both.
+func ClientMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		if actor := strings.TrimSpace(r.Header.Get(ActorHeader)); actor != "" {
+			if len(actor) > maxActorLength {
+				actor = actor[:maxActorLength]
+			}
+			ctx = context.WithValue(ctx, "actor", actor)
+		}
+
+		// Note : X-Forwarded-For is not trusted here since any client can set it, put a middleware such as chi's
+		// RealIP in front when the API runs behind a proxy that sets it.
+		clientIp, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			clientIp = r.RemoteAddr
+		}
+		ctx = context.WithValue(ctx, "clientIp", clientIp)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,199 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/problem"
+	idempotencyrepository "github.com/indrabrata/observability-playground/repository/idempotency"
+	"go.uber.org/zap"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// Note : Only these response headers are stored and replayed, per-request headers like X-Request-Id are not.
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+type Idempotency struct {
+	query *idempotencyrepository.Queries
+	ttl   time.Duration
+}
+
+func NewIdempotency(query *idempotencyrepository.Queries, ttl time.Duration) *Idempotency {
+	return &Idempotency{
+		query: query,
+		ttl:   ttl,
+	}
+}
+
+// idempotencyRecorder passes the response through to the client and keeps a copy so it can be stored.
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (ir *idempotencyRecorder) WriteHeader(statusCode int) {
+	ir.statusCode = statusCode
+	ir.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (ir *idempotencyRecorder) Write(b []byte) (int, error) {
+	ir.body.Write(b)
+	return ir.ResponseWriter.Write(b)
+}
+
+// Middleware makes a request with an Idempotency-Key header run at most once while the key lives: the first
+// response is stored and replayed for every retry with the same key and the same request.
+func (i *Idempotency) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := r.Context()
+		requestId := ctx.Value("requestId").(string)
+
+		if len(key) > maxIdempotencyKeyLength {
+			problem.Write(ctx, w, apperror.New(apperror.Validation, "Idempotency-Key must be at most "+strconv.Itoa(maxIdempotencyKeyLength)+" characters"))
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			problem.Write(ctx, w, apperror.Wrap(apperror.Validation, err, "failed to read request body"))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Note : The hash covers the target as well as the body, so a key reused on another endpoint is also rejected.
+		sum := sha256.Sum256(append([]byte(r.Method+" "+r.URL.Path+"\n"), body...))
+		requestHash := hex.EncodeToString(sum[:])
+
+		now := time.Now().UTC()
+		claimed, err := i.query.ClaimIdempotencyKey(ctx, idempotencyrepository.ClaimIdempotencyKeyParams{
+			Key:         key,
+			RequestHash: requestHash,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(i.ttl),
+		})
+		if err != nil {
+			zap.L().Error("failed to claim idempotency key", zap.Error(err), zap.String("requestId", requestId))
+			problem.Write(ctx, w, apperror.Wrap(apperror.Unavailable, err, "idempotency key could not be stored, retry later"))
+			return
+		}
+
+		if claimed == 0 {
+			i.replay(ctx, w, key, requestHash)
+			return
+		}
+
+		// Note : Server errors are not stored, the key is released so that a retry can run the request again.
+		// Context is detached so that a client disconnect does not leave the key claimed.
+		storeCtx := context.WithoutCancel(ctx)
+
+		// Note : A handler that panics stored no response, so the key is released as for a server error before the
+		// panic goes on to whatever recovers it.
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				i.release(storeCtx, key, requestId)
+				panic(recovered)
+			}
+		}()
+
+		recorder := &idempotencyRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		if recorder.statusCode >= http.StatusInternalServerError {
+			i.release(storeCtx, key, requestId)
+			return
+		}
+
+		headers := make(map[string]string, len(replayedHeaders))
+		for _, name := range replayedHeaders {
+			if value := recorder.Header().Get(name); value != "" {
+				headers[name] = value
+			}
+		}
+		encodedHeaders, _ := json.Marshal(headers)
+
+		err = i.query.CompleteIdempotencyKey(storeCtx, idempotencyrepository.CompleteIdempotencyKeyParams{
+			StatusCode:      sql.NullInt64{Int64: int64(recorder.statusCode), Valid: true},
+			ResponseHeaders: sql.NullString{String: string(encodedHeaders), Valid: true},
+			ResponseBody:    recorder.body.Bytes(),
+			Key:             key,
+		})
+		if err != nil {
+			zap.L().Error("failed to store idempotent response", zap.Error(err), zap.String("requestId", requestId))
+		}
+	})
+}
+
+func (i *Idempotency) release(ctx context.Context, key, requestId string) {
+	if err := i.query.ReleaseIdempotencyKey(ctx, key); err != nil {
+		zap.L().Error("failed to release idempotency key", zap.Error(err), zap.String("requestId", requestId))
+	}
+}
+
+func (i *Idempotency) replay(ctx context.Context, w http.ResponseWriter, key, requestHash string) {
+	requestId := ctx.Value("requestId").(string)
+
+	stored, err := i.query.GetIdempotencyKey(ctx, key)
+	if err != nil {
+		zap.L().Error("failed to load idempotency key", zap.Error(err), zap.String("requestId", requestId))
+		problem.Write(ctx, w, apperror.Wrap(apperror.Unavailable, err, "idempotency key could not be read, retry later"))
+		return
+	}
+
+	if stored.RequestHash != requestHash {
+		problem.Write(ctx, w, apperror.New(apperror.Unprocessable, "Idempotency-Key was already used with a different request"))
+		return
+	}
+	if !stored.StatusCode.Valid {
+		problem.Write(ctx, w, apperror.New(apperror.Conflict, "a request with this Idempotency-Key is still being processed"))
+		return
+	}
+
+	var headers map[string]string
+	json.Unmarshal([]byte(stored.ResponseHeaders.String), &headers)
+	for name, value := range headers {
+		w.Header().Set(name, value)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+
+	zap.L().Info("replaying idempotent response", zap.String("requestId", requestId), zap.Int64("status", stored.StatusCode.Int64))
+
+	w.WriteHeader(int(stored.StatusCode.Int64))
+	w.Write(stored.ResponseBody)
+}
+
+// RunCleanup deletes expired idempotency keys every interval until ctx is cancelled.
+func (i *Idempotency) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := i.query.DeleteExpiredIdempotencyKeys(ctx, time.Now().UTC())
+			if err != nil {
+				zap.L().Error("failed to delete expired idempotency keys", zap.Error(err))
+			} else if deleted > 0 {
+				zap.L().Info("deleted expired idempotency keys", zap.Int64("deletedCount", deleted))
+			}
+		}
+	}
+}
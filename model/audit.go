@@ -0,0 +1,91 @@
+package model
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/indrabrata/observability-playground/utility"
+)
+
+const (
+	AuditEntityProduct = "product"
+
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+
+	// AuditActorPriceScheduler is the actor of the price changes made by the price scheduler.
+	AuditActorPriceScheduler = "price-scheduler"
+)
+
+// AuditEntities lists every entity the audit log records.
+var AuditEntities = []string{AuditEntityProduct}
+
+type AuditLogRequest struct {
+	Entity   string
+	EntityId *int64
+	From     *time.Time
+	To       *time.Time
+	Limit    int64
+	Cursor   string
+
+	// Note : After is the decoded form of Cursor, populated by Validate.
+	After *utility.Cursor
+}
+
+func (alr *AuditLogRequest) Validate() error {
+	if alr.Limit == 0 {
+		alr.Limit = DefaultProductListLimit
+	}
+
+	var errs ValidationErrors
+	if alr.Entity == "" {
+		errs.Add("entity", "entity is required")
+	} else if !slices.Contains(AuditEntities, alr.Entity) {
+		errs.Add("entity", "entity must be one of "+strings.Join(AuditEntities, ", "))
+	}
+	if alr.EntityId != nil && *alr.EntityId <= 0 {
+		errs.Add("id", "id must be greater than 0")
+	}
+	if alr.From != nil && alr.To != nil && !alr.From.Before(*alr.To) {
+		errs.Add("to", "to must be after from")
+	}
+	if alr.Limit < 1 || alr.Limit > MaxProductListLimit {
+		errs.Add("limit", "limit must be between 1 and 100")
+	}
+	if alr.Cursor != "" {
+		cursor, err := utility.DecodeCursor(alr.Cursor)
+		if err != nil {
+			errs.Add("cursor", err.Error())
+		} else if cursor.SortBy != "id" || cursor.SortOrder != "desc" {
+			errs.Add("cursor", "cursor does not belong to an audit log")
+		} else {
+			alr.After = &cursor
+		}
+	}
+	return errs.Err()
+}
+
+// AuditEntryResponse is one change to an entity. Before and After hold only the fields the change touched, with
+// their value before and after it; Before is left out for a created entity.
+type AuditEntryResponse struct {
+	Id        int64           `json:"id" example:"57"`
+	Entity    string          `json:"entity" example:"product"`
+	EntityId  int64           `json:"entity_id" example:"1"`
+	Action    string          `json:"action" example:"update"`
+	Actor     string          `json:"actor,omitempty" example:"jane.doe"`
+	Before    json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After     json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+	RequestId string          `json:"request_id,omitempty" example:"8c1f3c5e-4b8e-4c1a-9f3e-2d6b7a9c0e11"`
+	TraceId   string          `json:"trace_id,omitempty" example:"4bf92f3577b34da6a3ce929d0e0e4736"`
+	ClientIp  string          `json:"client_ip,omitempty" example:"203.0.113.7"`
+	CreatedAt time.Time       `json:"created_at" example:"2025-01-02T15:04:05Z"`
+}
+
+type AuditLogResponse struct {
+	Data   []AuditEntryResponse `json:"data"`
+	Paging Paging               `json:"paging"`
+}
@@ -0,0 +1,150 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/indrabrata/observability-playground/utility"
+)
+
+const (
+	MaxCategoryNameLength = 100
+	MaxProductCategories  = 20
+
+	MaxTagLength   = 50
+	MaxProductTags = 20
+)
+
+type CategoryRequest struct {
+	Name string `json:"name" example:"Running shoes"`
+	// Note : ParentId places the category under another one, a category without it is a top level category.
+	ParentId *int64 `json:"parent_id,omitempty" example:"1"`
+}
+
+func (cr *CategoryRequest) Validate() error {
+	cr.Name = strings.TrimSpace(cr.Name)
+
+	var errs ValidationErrors
+	if cr.Name == "" {
+		errs.Add("name", "name is required")
+	} else if len(cr.Name) > MaxCategoryNameLength {
+		errs.Add("name", "name must be at most "+strconv.Itoa(MaxCategoryNameLength)+" characters")
+	}
+	if cr.ParentId != nil && *cr.ParentId <= 0 {
+		errs.Add("parent_id", "parent_id must be greater than 0")
+	}
+	return errs.Err()
+}
+
+type CategoryResponse struct {
+	Id        int64      `json:"id" example:"2"`
+	Name      string     `json:"name" example:"Running shoes"`
+	ParentId  *int64     `json:"parent_id,omitempty" example:"1"`
+	CreatedAt time.Time  `json:"created_at" example:"2025-01-02T15:04:05Z"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty" example:"2025-01-03T15:04:05Z"`
+}
+
+type CategoryListResponse struct {
+	Data []CategoryResponse `json:"data"`
+}
+
+type CategoryProductsRequest struct {
+	Limit  int64
+	Cursor string
+
+	// Note : After is the decoded form of Cursor, populated by Validate.
+	After *utility.Cursor
+}
+
+func (cpr *CategoryProductsRequest) Validate() error {
+	if cpr.Limit == 0 {
+		cpr.Limit = DefaultProductListLimit
+	}
+
+	var errs ValidationErrors
+	if cpr.Limit < 1 || cpr.Limit > MaxProductListLimit {
+		errs.Add("limit", "limit must be between 1 and 100")
+	}
+	if cpr.Cursor != "" {
+		cursor, err := utility.DecodeCursor(cpr.Cursor)
+		if err != nil {
+			errs.Add("cursor", err.Error())
+		} else if cursor.SortBy != "id" || cursor.SortOrder != "asc" {
+			errs.Add("cursor", "cursor does not belong to a category product list")
+		} else {
+			cpr.After = &cursor
+		}
+	}
+	return errs.Err()
+}
+
+type ProductCategoriesRequest struct {
+	CategoryIds []int64 `json:"category_ids" example:"1,2"`
+}
+
+func (pcr *ProductCategoriesRequest) Validate() error {
+	var errs ValidationErrors
+	if len(pcr.CategoryIds) > MaxProductCategories {
+		errs.Add("category_ids", "category_ids must contain at most "+strconv.Itoa(MaxProductCategories)+" categories")
+	}
+
+	seen := make(map[int64]bool, len(pcr.CategoryIds))
+	for i, id := range pcr.CategoryIds {
+		field := "category_ids[" + strconv.Itoa(i) + "]"
+		if id <= 0 {
+			errs.Add(field, "category id must be greater than 0")
+		} else if seen[id] {
+			errs.Add(field, "category id must not be repeated")
+		}
+		seen[id] = true
+	}
+	return errs.Err()
+}
+
+type ProductCategoriesResponse struct {
+	ProductId  int64              `json:"product_id" example:"1"`
+	Categories []CategoryResponse `json:"categories"`
+}
+
+type ProductTagsRequest struct {
+	// Note : Tags are trimmed and lower cased, so "Sale" and " sale" are the same tag. Repeated tags are kept once.
+	Tags []string `json:"tags" example:"sale,waterproof"`
+}
+
+func (ptr *ProductTagsRequest) Validate() error {
+	var errs ValidationErrors
+	ptr.Tags = normalizeTags(&errs, "tags", ptr.Tags)
+	if len(ptr.Tags) > MaxProductTags {
+		errs.Add("tags", "tags must contain at most "+strconv.Itoa(MaxProductTags)+" tags")
+	}
+	return errs.Err()
+}
+
+type ProductTagsResponse struct {
+	ProductId int64    `json:"product_id" example:"1"`
+	Tags      []string `json:"tags" example:"sale,waterproof"`
+}
+
+// normalizeTags trims and lower cases every tag, drops repeats and reports the tags that cannot be stored.
+// Commas are refused because the tags filter of the product list is a comma separated list.
+func normalizeTags(errs *ValidationErrors, field string, tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for i, tag := range tags {
+		tagField := field + "[" + strconv.Itoa(i) + "]"
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		switch {
+		case tag == "":
+			errs.Add(tagField, "tag must not be empty")
+		case len(tag) > MaxTagLength:
+			errs.Add(tagField, "tag must be at most "+strconv.Itoa(MaxTagLength)+" characters")
+		case strings.Contains(tag, ","):
+			errs.Add(tagField, "tag must not contain a comma")
+		case !seen[tag]:
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
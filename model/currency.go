@@ -0,0 +1,50 @@
+package model
+
+import (
+	"errors"
+	"strings"
+)
+
+// Note : Products created without a currency are priced in DefaultCurrency, which is also what existing prices were migrated to.
+const DefaultCurrency = "USD"
+
+var ErrUnknownCurrency = errors.New("currency must be an ISO 4217 currency code")
+
+// currencyExponents maps the active ISO 4217 currency codes to their number of minor unit digits.
+// Codes without a minor unit (precious metals, XDR, XXX, ...) are left out because they cannot price a product.
+var currencyExponents = map[string]int{}
+
+func init() {
+	groups := map[int]string{
+		0: "BIF CLP DJF GNF ISK JPY KMF KRW PYG RWF UGX UYI VND VUV XAF XOF XPF",
+		2: "AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BMD BND BOB BOV BRL BSD BTN BWP BYN BZD " +
+			"CAD CDF CHE CHF CHW CNY COP COU CRC CUP CVE CZK DKK DOP DZD EGP ERN ETB EUR FJD FKP GBP GEL GHS " +
+			"GIP GMD GTQ GYD HKD HNL HTG HUF IDR ILS INR IRR JMD KES KGS KHR KPW KYD KZT LAK LBP LKR LRD LSL " +
+			"MAD MDL MGA MKD MMK MNT MOP MRU MUR MVR MWK MXN MXV MYR MZN NAD NGN NIO NOK NPR NZD PAB PEN PGK " +
+			"PHP PKR PLN QAR RON RSD RUB SAR SBD SCR SDG SEK SGD SHP SLE SOS SRD SSP STN SVC SYP SZL THB TJS " +
+			"TMT TOP TRY TTD TWD TZS UAH USD USN UYU UZS VED VES WST XCD XCG YER ZAR ZMW ZWG",
+		3: "BHD IQD JOD KWD LYD OMR TND",
+		4: "CLF UYW",
+	}
+	for exponent, codes := range groups {
+		for _, code := range strings.Fields(codes) {
+			currencyExponents[code] = exponent
+		}
+	}
+}
+
+// CurrencyExponent returns the number of minor unit digits of an ISO 4217 code, e.g. 2 for USD and 0 for JPY.
+// Codes are upper case; the second result is false for unknown codes.
+func CurrencyExponent(code string) (int, bool) {
+	exponent, ok := currencyExponents[code]
+	return exponent, ok
+}
+
+// validateCurrency checks that currency is a known ISO 4217 code.
+func validateCurrency(errs *ValidationErrors, field, currency string) bool {
+	if _, ok := CurrencyExponent(currency); !ok {
+		errs.Add(field, field+" must be an ISO 4217 currency code")
+		return false
+	}
+	return true
+}
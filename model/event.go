@@ -0,0 +1,44 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AggregateProduct = "product"
+
+	EventProductCreated  = "product.created"
+	EventProductUpdated  = "product.updated"
+	EventProductDeleted  = "product.deleted"
+	EventProductRestored = "product.restored"
+)
+
+// DomainEvent is a change to an aggregate as it is handed to the outbox sinks. Id increases with every event, so
+// it orders the events and lets a consumer drop the duplicates that at-least-once delivery can produce.
+// TraceParent and TraceState are the W3C trace context of the request that made the change.
+type DomainEvent struct {
+	Id            int64           `json:"id" example:"42"`
+	Type          string          `json:"type" example:"product.updated"`
+	AggregateType string          `json:"aggregate_type" example:"product"`
+	AggregateId   int64           `json:"aggregate_id" example:"1"`
+	Payload       json.RawMessage `json:"payload" swaggertype:"object"`
+	RequestId     string          `json:"request_id,omitempty" example:"0b5e7c1a-3d7f-4d38-9f6c-2a1e8f0c9b11"`
+	TraceParent   string          `json:"traceparent,omitempty" example:"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"`
+	TraceState    string          `json:"tracestate,omitempty"`
+	OccurredAt    time.Time       `json:"occurred_at" example:"2025-01-02T15:04:05Z"`
+}
+
+// ProductDeletedPayload is the payload of a product.deleted event, the other product events carry the ProductResponse.
+type ProductDeletedPayload struct {
+	Id        int64     `json:"id" example:"1"`
+	DeletedAt time.Time `json:"deleted_at" example:"2025-01-02T15:04:05Z"`
+}
+
+// EventStreamReset is sent on the product event stream instead of the missed events when a subscriber resumes
+// from an event that is no longer kept. The subscriber should reload the products and continue from LastEventId.
+const EventStreamReset = "reset"
+
+type EventStreamResetData struct {
+	LastEventId int64 `json:"last_event_id" example:"42"`
+}
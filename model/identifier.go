@@ -0,0 +1,57 @@
+package model
+
+import "strconv"
+
+const MaxSkuLength = 64
+
+// validateSku checks that sku is short and only uses characters that survive a URL path and a scanner keyboard
+// wedge unchanged: letters, digits, '-', '_' and '.'. SKUs are compared ignoring case.
+func validateSku(errs *ValidationErrors, field, sku string) {
+	if sku == "" {
+		errs.Add(field, field+" must not be empty")
+		return
+	}
+	if len(sku) > MaxSkuLength {
+		errs.Add(field, field+" must be at most "+strconv.Itoa(MaxSkuLength)+" characters")
+		return
+	}
+	for _, r := range sku {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			errs.Add(field, field+" may only contain letters, digits, '-', '_' and '.'")
+			return
+		}
+	}
+}
+
+// validateBarcode checks that barcode is a GTIN-8, GTIN-12 (UPC-A), GTIN-13 (EAN-13) or GTIN-14 with a valid check digit.
+func validateBarcode(errs *ValidationErrors, field, barcode string) {
+	if !ValidGTIN(barcode) {
+		errs.Add(field, field+" must be an 8, 12, 13 or 14 digit GTIN with a valid check digit")
+	}
+}
+
+// ValidGTIN reports whether code is a GTIN-8, -12, -13 or -14 whose last digit is the GS1 mod 10 check digit
+// of the others.
+func ValidGTIN(code string) bool {
+	switch len(code) {
+	case 8, 12, 13, 14:
+	default:
+		return false
+	}
+
+	// Note : Weights alternate 3, 1, 3, ... starting from the digit left of the check digit.
+	sum := 0
+	for i := len(code) - 2; i >= 0; i-- {
+		digit := int(code[i]) - '0'
+		if digit < 0 || digit > 9 {
+			return false
+		}
+		if (len(code)-2-i)%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+
+	check := int(code[len(code)-1]) - '0'
+	return check >= 0 && check <= 9 && (10-sum%10)%10 == check
+}
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// LowStockAlertResponse is an open low stock alert: the product's quantity fell below its reorder point at
+// TriggeredAt and has not recovered since. Quantity and ReorderPoint are the current values.
+type LowStockAlertResponse struct {
+	Id           int64     `json:"id" example:"3"`
+	ProductId    int64     `json:"product_id" example:"1"`
+	Name         string    `json:"name" example:"Product A"`
+	Sku          string    `json:"sku,omitempty" example:"TSHIRT-RED-M"`
+	Quantity     int64     `json:"quantity" example:"2"`
+	ReorderPoint int64     `json:"reorder_point" example:"5"`
+	TriggeredAt  time.Time `json:"triggered_at" example:"2025-01-02T15:04:05Z"`
+}
+
+type LowStockAlertListResponse struct {
+	Data []LowStockAlertResponse `json:"data"`
+}
+
+func validateReorderPoint(errs *ValidationErrors, field string, reorderPoint *int64) {
+	if reorderPoint != nil && *reorderPoint < 0 {
+		errs.Add(field, field+" must not be negative")
+	}
+}
@@ -0,0 +1,183 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Note : 18 digits always fit in an int64, so a parsed amount can never overflow.
+const maxMoneyDigits = 18
+
+var (
+	ErrInvalidMoney  = errors.New("amount must be a decimal number such as 10.99")
+	ErrMoneyScale    = errors.New("amount has more decimal places than its currency allows")
+	ErrMoneyOverflow = errors.New("amount is too large")
+)
+
+// Money is an exact decimal amount, kept as an integer coefficient and a number of decimal places so
+// that 10.99 is {1099, 2} and never turns into 10.989999. It is read from and written to JSON as a
+// decimal string; a plain JSON number is also read, from its text, so older clients keep working.
+type Money struct {
+	coefficient int64
+	scale       int
+}
+
+// NewMoney returns the amount of minorUnits in currency, e.g. 1099 USD cents is 10.99.
+func NewMoney(minorUnits int64, currency string) Money {
+	exponent, _ := CurrencyExponent(currency)
+	return Money{coefficient: minorUnits, scale: exponent}
+}
+
+// ParseMoney reads a plain decimal such as "10", "10.5" or "-0.99". Exponents and thousand separators are refused.
+func ParseMoney(value string) (Money, error) {
+	digits := strings.TrimPrefix(value, "-")
+	negative := len(digits) != len(value)
+
+	whole, fraction, hasPoint := strings.Cut(digits, ".")
+	if whole == "" || (hasPoint && fraction == "") || len(whole)+len(fraction) > maxMoneyDigits {
+		return Money{}, ErrInvalidMoney
+	}
+	for _, r := range whole + fraction {
+		if r < '0' || r > '9' {
+			return Money{}, ErrInvalidMoney
+		}
+	}
+
+	coefficient, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return Money{}, ErrInvalidMoney
+	}
+	if negative {
+		coefficient = -coefficient
+	}
+
+	return Money{coefficient: coefficient, scale: len(fraction)}, nil
+}
+
+// MustParseMoney is ParseMoney for amounts known to be valid, such as constants. It panics on an invalid amount.
+func MustParseMoney(value string) Money {
+	money, err := ParseMoney(value)
+	if err != nil {
+		panic(err)
+	}
+	return money
+}
+
+// MinorUnits returns the amount as an integer number of minor units of currency, e.g. 10.99 USD is 1099.
+// Trailing zeros are ignored, so 10.50 is accepted for USD, but 10.999 is not.
+func (m Money) MinorUnits(currency string) (int64, error) {
+	exponent, ok := CurrencyExponent(currency)
+	if !ok {
+		return 0, ErrUnknownCurrency
+	}
+	return m.rescale(exponent)
+}
+
+// NewPercentage returns basisPoints hundredths of a percent as a decimal, e.g. 1250 is 12.50.
+func NewPercentage(basisPoints int64) Money {
+	return Money{coefficient: basisPoints, scale: 2}
+}
+
+// BasisPoints returns the amount, read as a percentage, in hundredths of a percent, e.g. 12.5 is 1250.
+func (m Money) BasisPoints() (int64, error) {
+	return m.rescale(2)
+}
+
+// rescale returns the coefficient of the amount written with exactly exponent decimal places.
+func (m Money) rescale(exponent int) (int64, error) {
+	coefficient, scale := m.coefficient, m.scale
+	for scale > exponent && coefficient%10 == 0 {
+		coefficient /= 10
+		scale--
+	}
+	if scale > exponent {
+		return 0, ErrMoneyScale
+	}
+
+	for ; scale < exponent; scale++ {
+		if coefficient > math.MaxInt64/10 || coefficient < math.MinInt64/10 {
+			return 0, ErrMoneyOverflow
+		}
+		coefficient *= 10
+	}
+
+	return coefficient, nil
+}
+
+// Sign returns -1, 0 or +1 depending on whether the amount is negative, zero or positive.
+func (m Money) Sign() int {
+	switch {
+	case m.coefficient < 0:
+		return -1
+	case m.coefficient > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (m Money) String() string {
+	digits := strconv.FormatInt(m.coefficient, 10)
+	sign := ""
+	if m.coefficient < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	if m.scale == 0 {
+		return sign + digits
+	}
+
+	if len(digits) <= m.scale {
+		digits = strings.Repeat("0", m.scale-len(digits)+1) + digits
+	}
+	point := len(digits) - m.scale
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	text := string(data)
+	if strings.HasPrefix(text, `"`) {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	}
+
+	parsed, err := ParseMoney(text)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// validatePrice checks that price is a positive amount that currency can represent.
+func validatePrice(errs *ValidationErrors, field string, price Money, currency string) {
+	if price.Sign() <= 0 {
+		errs.Add(field, field+" must be greater than 0")
+		return
+	}
+
+	// Note : An unknown currency is reported on its own field, the amount cannot be checked against it.
+	if _, err := price.MinorUnits(currency); err != nil && !errors.Is(err, ErrUnknownCurrency) {
+		errs.Add(field, moneyErrorMessage(field, currency, err))
+	}
+}
+
+func moneyErrorMessage(field, currency string, err error) string {
+	if errors.Is(err, ErrMoneyScale) {
+		exponent, _ := CurrencyExponent(currency)
+		return field + " must have at most " + strconv.Itoa(exponent) + " decimal places in " + currency
+	}
+	return field + " is too large"
+}
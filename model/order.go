@@ -0,0 +1,150 @@
+package model
+
+import (
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/indrabrata/observability-playground/utility"
+)
+
+const (
+	OrderStatusPending   = "pending"
+	OrderStatusPaid      = "paid"
+	OrderStatusShipped   = "shipped"
+	OrderStatusCancelled = "cancelled"
+
+	StockReasonOrder       = "order"
+	StockReasonOrderCancel = "order_cancel"
+
+	MaxOrderLines = 100
+)
+
+// orderTransitions is the order state machine: the statuses an order may move to from each status. Shipped and
+// cancelled orders are final.
+var orderTransitions = map[string][]string{
+	OrderStatusPending: {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:    {OrderStatusShipped, OrderStatusCancelled},
+}
+
+// CanTransitionOrder reports whether an order with status from may move to status to.
+func CanTransitionOrder(from, to string) bool {
+	return slices.Contains(orderTransitions[from], to)
+}
+
+// OrderLineRequest orders Quantity units of a product. Products with variants are ordered per variant, named by
+// VariantId. WarehouseId ships the units from a warehouse instead of the unallocated stock.
+type OrderLineRequest struct {
+	ProductId   int64  `json:"product_id" example:"1"`
+	VariantId   *int64 `json:"variant_id,omitempty" example:"7"`
+	WarehouseId *int64 `json:"warehouse_id,omitempty" example:"1"`
+	Quantity    int64  `json:"quantity" example:"2"`
+}
+
+type OrderRequest struct {
+	Lines     []OrderLineRequest `json:"lines"`
+	Reference string             `json:"reference,omitempty" example:"web-10293"`
+}
+
+func (or *OrderRequest) Validate() error {
+	var errs ValidationErrors
+	if len(or.Lines) == 0 {
+		errs.Add("lines", "lines must contain at least one product")
+	} else if len(or.Lines) > MaxOrderLines {
+		errs.Add("lines", "lines must contain at most "+strconv.Itoa(MaxOrderLines)+" products")
+	}
+
+	// Note : A product may be listed once per variant, the variant id is 0 for a line without one.
+	seen := make(map[[2]int64]bool, len(or.Lines))
+	for i, line := range or.Lines {
+		field := "lines[" + strconv.Itoa(i) + "]"
+		validateVariantId(&errs, field+".variant_id", line.VariantId)
+		validateWarehouseId(&errs, field+".warehouse_id", line.WarehouseId)
+		key := [2]int64{line.ProductId, 0}
+		if line.VariantId != nil {
+			key[1] = *line.VariantId
+		}
+		if line.ProductId <= 0 {
+			errs.Add(field+".product_id", "product_id is required")
+		} else if seen[key] {
+			errs.Add(field+".product_id", "product_id must not be repeated for the same variant")
+		}
+		seen[key] = true
+		if line.Quantity <= 0 {
+			errs.Add(field+".quantity", "quantity must be greater than 0")
+		}
+	}
+	return errs.Err()
+}
+
+type OrderListRequest struct {
+	Status string
+	Limit  int64
+	Cursor string
+
+	// Note : After is the decoded form of Cursor, populated by Validate.
+	After *utility.Cursor
+}
+
+func (olr *OrderListRequest) Validate() error {
+	if olr.Limit == 0 {
+		olr.Limit = DefaultProductListLimit
+	}
+
+	var errs ValidationErrors
+	switch olr.Status {
+	case "", OrderStatusPending, OrderStatusPaid, OrderStatusShipped, OrderStatusCancelled:
+	default:
+		errs.Add("status", "status must be pending, paid, shipped or cancelled")
+	}
+	if olr.Limit < 1 || olr.Limit > MaxProductListLimit {
+		errs.Add("limit", "limit must be between 1 and 100")
+	}
+	if olr.Cursor != "" {
+		cursor, err := utility.DecodeCursor(olr.Cursor)
+		if err != nil {
+			errs.Add("cursor", err.Error())
+		} else if cursor.SortBy != "id" || cursor.SortOrder != "desc" {
+			errs.Add("cursor", "cursor does not belong to an order list")
+		} else {
+			olr.After = &cursor
+		}
+	}
+	return errs.Err()
+}
+
+// OrderLineResponse is an ordered product with the price it had when the order was placed.
+type OrderLineResponse struct {
+	ProductId   int64  `json:"product_id" example:"1"`
+	VariantId   *int64 `json:"variant_id,omitempty" example:"7"`
+	WarehouseId *int64 `json:"warehouse_id,omitempty" example:"1"`
+	Quantity    int64  `json:"quantity" example:"2"`
+	UnitPrice   Money  `json:"unit_price" swaggertype:"string" example:"10.99"`
+	Amount      Money  `json:"amount" swaggertype:"string" example:"21.98"`
+}
+
+// OrderTransitionResponse is a status change of an order. From is left out for the placement of the order.
+type OrderTransitionResponse struct {
+	From      string    `json:"from,omitempty" example:"pending"`
+	To        string    `json:"to" example:"paid"`
+	RequestId string    `json:"request_id,omitempty" example:"8c1f3c5e-4b8e-4c1a-9f3e-2d6b7a9c0e11"`
+	CreatedAt time.Time `json:"created_at" example:"2025-01-02T15:04:05Z"`
+}
+
+// OrderResponse is an order with its lines and status history, which are left out of order lists.
+type OrderResponse struct {
+	Id          int64                     `json:"id" example:"1"`
+	Status      string                    `json:"status" example:"pending"`
+	Reference   string                    `json:"reference,omitempty" example:"web-10293"`
+	Total       Money                     `json:"total" swaggertype:"string" example:"21.98"`
+	Currency    string                    `json:"currency" example:"USD"`
+	CreatedAt   time.Time                 `json:"created_at" example:"2025-01-02T15:04:05Z"`
+	UpdatedAt   *time.Time                `json:"updated_at,omitempty" example:"2025-01-03T15:04:05Z"`
+	Lines       []OrderLineResponse       `json:"lines,omitempty"`
+	Transitions []OrderTransitionResponse `json:"transitions,omitempty"`
+}
+
+type OrderListResponse struct {
+	Data   []OrderResponse `json:"data"`
+	Paging Paging          `json:"paging"`
+}
@@ -0,0 +1,14 @@
+package model
+
+import "slices"
+
+// Precondition is the parsed If-Match header of a conditional write.
+type Precondition struct {
+	// Note : IfMatch is false when the client sent no If-Match header or "*", in which case any version matches.
+	IfMatch  bool
+	Versions []int64
+}
+
+func (p Precondition) Matches(version int64) bool {
+	return !p.IfMatch || slices.Contains(p.Versions, version)
+}
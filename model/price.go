@@ -0,0 +1,75 @@
+package model
+
+import "time"
+
+const (
+	ScheduledPriceStatusPending   = "pending"
+	ScheduledPriceStatusApplied   = "applied"
+	ScheduledPriceStatusCancelled = "cancelled"
+	// Note : A scheduled price is skipped when its product was deleted before the price became effective.
+	ScheduledPriceStatusSkipped = "skipped"
+)
+
+// ScheduledPriceRequest changes the price of a product to Price once EffectiveAt has passed.
+type ScheduledPriceRequest struct {
+	Price Money `json:"price" swaggertype:"string" example:"8.99"`
+	// Note : Currency falls back to the currency of the product when empty.
+	Currency    string    `json:"currency,omitempty" example:"USD"`
+	EffectiveAt time.Time `json:"effective_at" example:"2025-02-01T00:00:00Z"`
+}
+
+// Validate checks the request on its own. A price without a currency is checked against the product's
+// currency by the service, once the product has been read.
+func (spr *ScheduledPriceRequest) Validate(now time.Time) error {
+	var errs ValidationErrors
+	if spr.Currency != "" {
+		if validateCurrency(&errs, "currency", spr.Currency) {
+			validatePrice(&errs, "price", spr.Price, spr.Currency)
+		}
+	} else if spr.Price.Sign() <= 0 {
+		errs.Add("price", "price must be greater than 0")
+	}
+	if spr.EffectiveAt.IsZero() {
+		errs.Add("effective_at", "effective_at is required")
+	} else if !spr.EffectiveAt.After(now) {
+		errs.Add("effective_at", "effective_at must be in the future")
+	}
+	return errs.Err()
+}
+
+// PriceMinorUnits returns Price in minor units of currency, or the validation errors that prevent it.
+func (spr *ScheduledPriceRequest) PriceMinorUnits(currency string) (int64, error) {
+	var errs ValidationErrors
+	validatePrice(&errs, "price", spr.Price, currency)
+	if err := errs.Err(); err != nil {
+		return 0, err
+	}
+	return spr.Price.MinorUnits(currency)
+}
+
+type ScheduledPriceResponse struct {
+	Id          int64      `json:"id" example:"3"`
+	ProductId   int64      `json:"product_id" example:"1"`
+	Price       Money      `json:"price" swaggertype:"string" example:"8.99"`
+	Currency    string     `json:"currency" example:"USD"`
+	EffectiveAt time.Time  `json:"effective_at" example:"2025-02-01T00:00:00Z"`
+	Status      string     `json:"status" example:"pending"`
+	CreatedAt   time.Time  `json:"created_at" example:"2025-01-02T15:04:05Z"`
+	AppliedAt   *time.Time `json:"applied_at,omitempty" example:"2025-02-01T00:00:12Z"`
+}
+
+// PriceChangeResponse is one entry of the price timeline. OldPrice is absent for the price a product was created with.
+type PriceChangeResponse struct {
+	Id          int64     `json:"id" example:"7"`
+	OldPrice    *Money    `json:"old_price,omitempty" swaggertype:"string" example:"10.99"`
+	OldCurrency string    `json:"old_currency,omitempty" example:"USD"`
+	NewPrice    Money     `json:"new_price" swaggertype:"string" example:"8.99"`
+	Currency    string    `json:"currency" example:"USD"`
+	ChangedAt   time.Time `json:"changed_at" example:"2025-02-01T00:00:12Z"`
+}
+
+type PriceHistoryResponse struct {
+	ProductId int64                    `json:"product_id" example:"1"`
+	History   []PriceChangeResponse    `json:"history"`
+	Scheduled []ScheduledPriceResponse `json:"scheduled"`
+}
@@ -0,0 +1,195 @@
+package model
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	PricingRuleTypePercentage = "percentage"
+	PricingRuleTypeFixed      = "fixed"
+
+	PricingRuleScopeProduct  = "product"
+	PricingRuleScopeCategory = "category"
+
+	MaxPricingRuleNameLength = 100
+	MaxPricingRuleTiers      = 10
+	MaxPriceQuoteQuantity    = 1000000
+)
+
+// PricingTierRequest is one step of a rule: the discount Value applies from MinQuantity units on. Value is a
+// percentage for percentage rules, e.g. 12.5, and an amount off every unit in the rule currency for fixed rules.
+type PricingTierRequest struct {
+	MinQuantity int64 `json:"min_quantity" example:"10"`
+	Value       Money `json:"value" swaggertype:"string" example:"12.5"`
+}
+
+// PricingRuleRequest creates or replaces a discount on one product, or on every product of a category and of its
+// descendants. A rule with a single tier from 1 unit is a plain discount, more tiers make it a bulk discount, and
+// StartsAt and EndsAt make it a promotion that only runs for a while.
+type PricingRuleRequest struct {
+	Name       string `json:"name" example:"Bulk mugs"`
+	Type       string `json:"type" example:"percentage" enums:"percentage,fixed"`
+	ProductId  *int64 `json:"product_id,omitempty" example:"1"`
+	CategoryId *int64 `json:"category_id,omitempty" example:"3"`
+	// Note : Currency is required for fixed rules, which only apply to prices in that currency.
+	Currency string               `json:"currency,omitempty" example:"USD"`
+	Tiers    []PricingTierRequest `json:"tiers"`
+	StartsAt *time.Time           `json:"starts_at,omitempty" example:"2025-06-01T00:00:00Z"`
+	EndsAt   *time.Time           `json:"ends_at,omitempty" example:"2025-07-01T00:00:00Z"`
+}
+
+func (prr *PricingRuleRequest) Validate() error {
+	prr.Name = strings.TrimSpace(prr.Name)
+
+	var errs ValidationErrors
+	if prr.Name == "" {
+		errs.Add("name", "name is required")
+	} else if len(prr.Name) > MaxPricingRuleNameLength {
+		errs.Add("name", "name must be at most "+strconv.Itoa(MaxPricingRuleNameLength)+" characters")
+	}
+
+	validCurrency := false
+	switch prr.Type {
+	case PricingRuleTypePercentage:
+		if prr.Currency != "" {
+			errs.Add("currency", "currency is only allowed for fixed rules")
+		}
+	case PricingRuleTypeFixed:
+		if prr.Currency == "" {
+			errs.Add("currency", "currency is required for fixed rules")
+		} else {
+			validCurrency = validateCurrency(&errs, "currency", prr.Currency)
+		}
+	default:
+		errs.Add("type", "type must be percentage or fixed")
+	}
+
+	if (prr.ProductId == nil) == (prr.CategoryId == nil) {
+		errs.Add("product_id", "exactly one of product_id and category_id is required")
+	} else if prr.ProductId != nil && *prr.ProductId <= 0 {
+		errs.Add("product_id", "product_id must be greater than 0")
+	} else if prr.CategoryId != nil && *prr.CategoryId <= 0 {
+		errs.Add("category_id", "category_id must be greater than 0")
+	}
+
+	if len(prr.Tiers) == 0 {
+		errs.Add("tiers", "tiers must contain at least one tier")
+	} else if len(prr.Tiers) > MaxPricingRuleTiers {
+		errs.Add("tiers", "tiers must contain at most "+strconv.Itoa(MaxPricingRuleTiers)+" tiers")
+	}
+	seen := make(map[int64]bool, len(prr.Tiers))
+	for i, tier := range prr.Tiers {
+		field := "tiers[" + strconv.Itoa(i) + "]"
+		if tier.MinQuantity <= 0 {
+			errs.Add(field+".min_quantity", "min_quantity must be greater than 0")
+		} else if seen[tier.MinQuantity] {
+			errs.Add(field+".min_quantity", "min_quantity must not be repeated")
+		}
+		seen[tier.MinQuantity] = true
+
+		switch prr.Type {
+		case PricingRuleTypePercentage:
+			if basisPoints, err := tier.Value.BasisPoints(); err != nil {
+				errs.Add(field+".value", "value must be a percentage with at most 2 decimal places")
+			} else if basisPoints <= 0 || basisPoints > 10000 {
+				errs.Add(field+".value", "value must be greater than 0 and at most 100")
+			}
+		case PricingRuleTypeFixed:
+			if validCurrency {
+				validatePrice(&errs, field+".value", tier.Value, prr.Currency)
+			}
+		}
+	}
+	// Note : Tiers are kept in ascending order of quantity, so responses list them the same way whatever the request order.
+	slices.SortFunc(prr.Tiers, func(a, b PricingTierRequest) int {
+		return cmp.Compare(a.MinQuantity, b.MinQuantity)
+	})
+
+	if prr.StartsAt != nil && prr.EndsAt != nil && !prr.EndsAt.After(*prr.StartsAt) {
+		errs.Add("ends_at", "ends_at must be after starts_at")
+	}
+	return errs.Err()
+}
+
+// TierValue returns the value of a validated tier as stored: basis points for percentage rules, minor units of
+// the rule currency for fixed rules.
+func (prr *PricingRuleRequest) TierValue(tier PricingTierRequest) (int64, error) {
+	if prr.Type == PricingRuleTypePercentage {
+		return tier.Value.BasisPoints()
+	}
+	return tier.Value.MinorUnits(prr.Currency)
+}
+
+type PricingTierResponse struct {
+	MinQuantity int64 `json:"min_quantity" example:"10"`
+	Value       Money `json:"value" swaggertype:"string" example:"12.50"`
+}
+
+type PricingRuleResponse struct {
+	Id         int64                 `json:"id" example:"1"`
+	Name       string                `json:"name" example:"Bulk mugs"`
+	Type       string                `json:"type" example:"percentage"`
+	ProductId  *int64                `json:"product_id,omitempty" example:"1"`
+	CategoryId *int64                `json:"category_id,omitempty" example:"3"`
+	Currency   string                `json:"currency,omitempty" example:"USD"`
+	Tiers      []PricingTierResponse `json:"tiers"`
+	StartsAt   *time.Time            `json:"starts_at,omitempty" example:"2025-06-01T00:00:00Z"`
+	EndsAt     *time.Time            `json:"ends_at,omitempty" example:"2025-07-01T00:00:00Z"`
+	CreatedAt  time.Time             `json:"created_at" example:"2025-01-02T15:04:05Z"`
+	UpdatedAt  *time.Time            `json:"updated_at,omitempty" example:"2025-01-03T15:04:05Z"`
+}
+
+type PricingRuleListResponse struct {
+	Data []PricingRuleResponse `json:"data"`
+}
+
+// PriceQuoteRequest asks for the price of Quantity units of a product, or of one of its variants.
+type PriceQuoteRequest struct {
+	Quantity  int64
+	VariantId *int64
+}
+
+func (pqr *PriceQuoteRequest) Validate() error {
+	if pqr.Quantity == 0 {
+		pqr.Quantity = 1
+	}
+
+	var errs ValidationErrors
+	if pqr.Quantity < 1 || pqr.Quantity > MaxPriceQuoteQuantity {
+		errs.Add("qty", "qty must be between 1 and "+strconv.Itoa(MaxPriceQuoteQuantity))
+	}
+	validateVariantId(&errs, "variant_id", pqr.VariantId)
+	return errs.Err()
+}
+
+// AppliedPricingRuleResponse explains one rule of a quote: the tier the quantity reached and how much it took off
+// every unit.
+type AppliedPricingRuleResponse struct {
+	RuleId       int64  `json:"rule_id" example:"1"`
+	Name         string `json:"name" example:"Bulk mugs"`
+	Type         string `json:"type" example:"percentage"`
+	Scope        string `json:"scope" example:"product" enums:"product,category"`
+	CategoryId   *int64 `json:"category_id,omitempty" example:"3"`
+	MinQuantity  int64  `json:"min_quantity" example:"10"`
+	Value        Money  `json:"value" swaggertype:"string" example:"12.50"`
+	UnitDiscount Money  `json:"unit_discount" swaggertype:"string" example:"1.37"`
+}
+
+// PriceQuoteResponse is the price of Quantity units once the running pricing rules are applied. BasePrice is the
+// list price of one unit, UnitPrice what one unit costs after the discounts.
+type PriceQuoteResponse struct {
+	ProductId    int64                        `json:"product_id" example:"1"`
+	VariantId    *int64                       `json:"variant_id,omitempty" example:"7"`
+	Quantity     int64                        `json:"quantity" example:"10"`
+	Currency     string                       `json:"currency" example:"USD"`
+	BasePrice    Money                        `json:"base_price" swaggertype:"string" example:"10.99"`
+	UnitPrice    Money                        `json:"unit_price" swaggertype:"string" example:"9.62"`
+	Discount     Money                        `json:"discount" swaggertype:"string" example:"13.70"`
+	Total        Money                        `json:"total" swaggertype:"string" example:"96.20"`
+	AppliedRules []AppliedPricingRuleResponse `json:"applied_rules"`
+	QuotedAt     time.Time                    `json:"quoted_at" example:"2025-01-02T15:04:05Z"`
+}
@@ -0,0 +1,12 @@
+package model
+
+// ProblemDetail is an RFC 7807 (application/problem+json) error body.
+type ProblemDetail struct {
+	Type     string       `json:"type" example:"urn:observability-playground:problem:validation"`
+	Title    string       `json:"title" example:"Bad Request"`
+	Status   int          `json:"status" example:"400"`
+	Detail   string       `json:"detail,omitempty" example:"request validation failed"`
+	Instance string       `json:"instance,omitempty" example:"8c1f3c5e-4b8e-4c1a-9f3e-2d6b7a9c0e11"`
+	TraceId  string       `json:"trace_id,omitempty" example:"4bf92f3577b34da6a3ce929d0e0e4736"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
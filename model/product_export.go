@@ -0,0 +1,69 @@
+package model
+
+import (
+	"strconv"
+	"time"
+)
+
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+
+	// Note : The response is flushed to the client every ProductExportChunkSize rows.
+	ProductExportChunkSize = 1000
+)
+
+// ProductExportColumns is the CSV header, in the order of ProductExportRow.CSVRecord.
+var ProductExportColumns = []string{"id", "name", "quantity", "price", "currency", "sku", "barcode", "reorder_point", "version", "created_at", "updated_at"}
+
+// ProductExportRow is one exported product, the same shape for every export format.
+type ProductExportRow struct {
+	Id           int64      `json:"id"`
+	Name         string     `json:"name"`
+	Quantity     int64      `json:"quantity"`
+	Price        Money      `json:"price"`
+	Currency     string     `json:"currency"`
+	Sku          string     `json:"sku,omitempty"`
+	Barcode      string     `json:"barcode,omitempty"`
+	ReorderPoint *int64     `json:"reorder_point,omitempty"`
+	Version      int64      `json:"version"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    *time.Time `json:"updated_at,omitempty"`
+}
+
+func (per ProductExportRow) CSVRecord() []string {
+	updatedAt := ""
+	if per.UpdatedAt != nil {
+		updatedAt = per.UpdatedAt.Format(time.RFC3339)
+	}
+	reorderPoint := ""
+	if per.ReorderPoint != nil {
+		reorderPoint = strconv.FormatInt(*per.ReorderPoint, 10)
+	}
+
+	return []string{
+		strconv.FormatInt(per.Id, 10),
+		per.Name,
+		strconv.FormatInt(per.Quantity, 10),
+		per.Price.String(),
+		per.Currency,
+		per.Sku,
+		per.Barcode,
+		reorderPoint,
+		strconv.FormatInt(per.Version, 10),
+		per.CreatedAt.Format(time.RFC3339),
+		updatedAt,
+	}
+}
+
+// ExportContentType returns the media type of an export format, or "" when the format is unknown.
+func ExportContentType(format string) string {
+	switch format {
+	case ExportFormatCSV:
+		return CSVContentType
+	case ExportFormatNDJSON:
+		return NDJSONContentType
+	default:
+		return ""
+	}
+}
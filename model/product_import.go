@@ -0,0 +1,165 @@
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	CSVContentType    = "text/csv"
+	NDJSONContentType = "application/x-ndjson"
+
+	ImportModePartial      = "partial"
+	ImportModeAllOrNothing = "all_or_nothing"
+
+	// Note : Rows are inserted in transactions of this size so a large file neither holds one huge transaction nor commits row by row.
+	ProductImportBatchSize = 500
+)
+
+// ProductRowReader streams product rows out of an import file. Read returns io.EOF after the last row.
+// A row that cannot be decoded is returned as ValidationErrors together with its line so the import can continue.
+type ProductRowReader interface {
+	Read() (line int, request ProductRequest, err error)
+}
+
+type csvProductRowReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+// NewCSVProductRowReader reads a CSV file whose header names the name, quantity and price columns, in any order.
+// Optional currency, sku and barcode columns fill the matching ProductRequest fields.
+func NewCSVProductRowReader(r io.Reader) (ProductRowReader, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, ValidationErrors{{Field: "body", Message: "csv header is required"}}
+		}
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+
+	var errs ValidationErrors
+	for _, column := range []string{"name", "quantity", "price"} {
+		if _, ok := columns[column]; !ok {
+			errs.Add("body", "csv header is missing the "+column+" column")
+		}
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+
+	// Note : Rows may carry extra columns, only the header's known columns are read.
+	reader.FieldsPerRecord = len(header)
+
+	return &csvProductRowReader{reader: reader, columns: columns}, nil
+}
+
+func (cr *csvProductRowReader) Read() (int, ProductRequest, error) {
+	record, err := cr.reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return parseErr.StartLine, ProductRequest{}, ValidationErrors{{Field: "row", Message: parseErr.Err.Error()}}
+		}
+		return 0, ProductRequest{}, err
+	}
+	line, _ := cr.reader.FieldPos(0)
+
+	var request ProductRequest
+	var errs ValidationErrors
+	request.Name = record[cr.columns["name"]]
+	if value := record[cr.columns["quantity"]]; value != "" {
+		quantity, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			errs.Add("quantity", "quantity must be an integer")
+		}
+		request.Quantity = quantity
+	}
+	if value := record[cr.columns["price"]]; value != "" {
+		price, err := ParseMoney(value)
+		if err != nil {
+			errs.Add("price", "price must be a decimal number")
+		}
+		request.Price = price
+	}
+	if column, ok := cr.columns["currency"]; ok {
+		request.Currency = record[column]
+	}
+	if column, ok := cr.columns["sku"]; ok {
+		request.Sku = record[column]
+	}
+	if column, ok := cr.columns["barcode"]; ok {
+		request.Barcode = record[column]
+	}
+	if column, ok := cr.columns["reorder_point"]; ok && record[column] != "" {
+		reorderPoint, err := strconv.ParseInt(record[column], 10, 64)
+		if err != nil {
+			errs.Add("reorder_point", "reorder_point must be an integer")
+		}
+		request.ReorderPoint = &reorderPoint
+	}
+
+	return line, request, errs.Err()
+}
+
+type ndjsonProductRowReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewNDJSONProductRowReader reads one ProductRequest JSON object per line. Blank lines are skipped.
+func NewNDJSONProductRowReader(r io.Reader) ProductRowReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	return &ndjsonProductRowReader{scanner: scanner}
+}
+
+func (nr *ndjsonProductRowReader) Read() (int, ProductRequest, error) {
+	for nr.scanner.Scan() {
+		nr.line++
+		data := bytes.TrimSpace(nr.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		var request ProductRequest
+		if err := json.Unmarshal(data, &request); err != nil {
+			return nr.line, ProductRequest{}, ValidationErrors{{Field: "row", Message: "row is not valid JSON"}}
+		}
+		return nr.line, request, nil
+	}
+	if err := nr.scanner.Err(); err != nil {
+		return nr.line + 1, ProductRequest{}, fmt.Errorf("line %d: %w", nr.line+1, err)
+	}
+
+	return 0, ProductRequest{}, io.EOF
+}
+
+type ProductImportResponse struct {
+	Mode    string               `json:"mode" example:"partial"`
+	Created int64                `json:"created" example:"498"`
+	Failed  int64                `json:"failed" example:"2"`
+	Errors  []ProductImportError `json:"errors"`
+}
+
+type ProductImportError struct {
+	Line   int          `json:"line" example:"17"`
+	Errors []FieldError `json:"errors"`
+}
@@ -0,0 +1,257 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+const (
+	MergePatchContentType = "application/merge-patch+json"
+	JSONPatchContentType  = "application/json-patch+json"
+)
+
+var patchableFields = []string{"name", "quantity", "price", "currency", "sku", "barcode", "reorder_point"}
+
+// ProductPatchRequest is a partial product update. Nil fields are left untouched by the UPDATE.
+type ProductPatchRequest struct {
+	Name     *string `json:"name,omitempty" example:"Product A"`
+	Quantity *int64  `json:"quantity,omitempty" example:"10"`
+	Price    *Money  `json:"price,omitempty" swaggertype:"string" example:"10.99"`
+	// Note : Currency can only change together with Price, an amount is meaningless in another currency.
+	Currency     *string `json:"currency,omitempty" example:"EUR"`
+	Sku          *string `json:"sku,omitempty" example:"TSHIRT-RED-M"`
+	Barcode      *string `json:"barcode,omitempty" example:"4006381333931"`
+	ReorderPoint *int64  `json:"reorder_point,omitempty" example:"5"`
+
+	// Note : The Clear fields remove an optional field, set by a null in a merge patch or a JSON Patch remove.
+	ClearSku          bool `json:"-"`
+	ClearBarcode      bool `json:"-"`
+	ClearReorderPoint bool `json:"-"`
+
+	// Note : Tests are JSON Patch "test" operations, checked against the current product before it is changed.
+	Tests []PatchTest `json:"-"`
+}
+
+type PatchTest struct {
+	Field string
+	Value json.RawMessage
+}
+
+// ParseMergePatch decodes an RFC 7396 JSON Merge Patch document. A null removes sku, barcode or reorder_point.
+func ParseMergePatch(data []byte) (ProductPatchRequest, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return ProductPatchRequest{}, err
+	}
+
+	var patch ProductPatchRequest
+	var errs ValidationErrors
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		patch.set(name, fields[name], &errs)
+	}
+
+	return patch, errs.Err()
+}
+
+type jsonPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+// ParseJSONPatch decodes an RFC 6902 JSON Patch document. Only add, replace, remove and test are supported, and
+// only sku, barcode and reorder_point can be removed since the other fields are required.
+func ParseJSONPatch(data []byte) (ProductPatchRequest, error) {
+	var operations []jsonPatchOperation
+	if err := json.Unmarshal(data, &operations); err != nil {
+		return ProductPatchRequest{}, err
+	}
+
+	var patch ProductPatchRequest
+	var errs ValidationErrors
+	for _, operation := range operations {
+		name := strings.TrimPrefix(operation.Path, "/")
+		if !strings.HasPrefix(operation.Path, "/") || !slices.Contains(patchableFields, name) {
+			errs.Add("path", "path must be one of /name, /quantity, /price, /currency, /sku, /barcode, /reorder_point")
+			continue
+		}
+
+		switch operation.Op {
+		case "add", "replace":
+			patch.set(name, operation.Value, &errs)
+		case "test":
+			patch.Tests = append(patch.Tests, PatchTest{Field: name, Value: operation.Value})
+		case "remove":
+			patch.remove(name, &errs)
+		default:
+			errs.Add("op", "op must be one of add, replace, remove, test")
+		}
+	}
+
+	return patch, errs.Err()
+}
+
+func (ppr *ProductPatchRequest) set(name string, value json.RawMessage, errs *ValidationErrors) {
+	var target any
+	switch name {
+	case "name":
+		target = &ppr.Name
+	case "quantity":
+		target = &ppr.Quantity
+	case "price":
+		target = &ppr.Price
+	case "currency":
+		target = &ppr.Currency
+	case "sku":
+		target = &ppr.Sku
+	case "barcode":
+		target = &ppr.Barcode
+	case "reorder_point":
+		target = &ppr.ReorderPoint
+	default:
+		errs.Add(name, "unknown field "+name)
+		return
+	}
+
+	// Note : In a merge patch null means "remove the member", which only the optional fields allow.
+	if len(value) == 0 || bytes.Equal(value, []byte("null")) {
+		ppr.remove(name, errs)
+		return
+	}
+	if err := json.Unmarshal(value, target); err != nil {
+		errs.Add(name, name+" has an invalid type")
+	}
+	if clear := ppr.clearFlag(name); clear != nil {
+		*clear = false
+	}
+}
+
+// remove clears an optional field. The stored column becomes NULL.
+func (ppr *ProductPatchRequest) remove(name string, errs *ValidationErrors) {
+	clear := ppr.clearFlag(name)
+	if clear == nil {
+		errs.Add(name, name+" cannot be removed")
+		return
+	}
+	*clear = true
+	switch name {
+	case "sku":
+		ppr.Sku = nil
+	case "barcode":
+		ppr.Barcode = nil
+	case "reorder_point":
+		ppr.ReorderPoint = nil
+	}
+}
+
+// clearFlag returns the Clear field of an optional field, or nil for a required one.
+func (ppr *ProductPatchRequest) clearFlag(name string) *bool {
+	switch name {
+	case "sku":
+		return &ppr.ClearSku
+	case "barcode":
+		return &ppr.ClearBarcode
+	case "reorder_point":
+		return &ppr.ClearReorderPoint
+	}
+	return nil
+}
+
+// Validate only checks the supplied fields, using the same rules as ProductRequest. A price without a
+// currency can only be checked against the product's currency, which PriceMinorUnits does once it is known.
+func (ppr *ProductPatchRequest) Validate() error {
+	var errs ValidationErrors
+	if ppr.Name == nil && ppr.Quantity == nil && ppr.Price == nil && ppr.Currency == nil && ppr.Sku == nil && ppr.Barcode == nil && ppr.ReorderPoint == nil &&
+		!ppr.ClearSku && !ppr.ClearBarcode && !ppr.ClearReorderPoint {
+		errs.Add("body", "patch must change at least one field")
+	}
+	if ppr.Name != nil && *ppr.Name == "" {
+		errs.Add("name", "name is required")
+	}
+	if ppr.Quantity != nil && *ppr.Quantity < 0 {
+		errs.Add("quantity", "quantity must not be negative")
+	}
+	if ppr.Currency != nil {
+		if ppr.Price == nil {
+			errs.Add("currency", "currency can only be changed together with price")
+		} else if validateCurrency(&errs, "currency", *ppr.Currency) {
+			validatePrice(&errs, "price", *ppr.Price, *ppr.Currency)
+		}
+	} else if ppr.Price != nil && ppr.Price.Sign() <= 0 {
+		errs.Add("price", "price must be greater than 0")
+	}
+	if ppr.Sku != nil {
+		*ppr.Sku = strings.TrimSpace(*ppr.Sku)
+		validateSku(&errs, "sku", *ppr.Sku)
+	}
+	if ppr.Barcode != nil {
+		*ppr.Barcode = strings.TrimSpace(*ppr.Barcode)
+		validateBarcode(&errs, "barcode", *ppr.Barcode)
+	}
+	validateReorderPoint(&errs, "reorder_point", ppr.ReorderPoint)
+	return errs.Err()
+}
+
+// PriceMinorUnits returns the patched price in minor units of currency, the currency the product has after
+// the patch, or the validation errors that prevent it.
+func (ppr *ProductPatchRequest) PriceMinorUnits(currency string) (int64, error) {
+	var errs ValidationErrors
+	validatePrice(&errs, "price", *ppr.Price, currency)
+	if err := errs.Err(); err != nil {
+		return 0, err
+	}
+	return ppr.Price.MinorUnits(currency)
+}
+
+// Matches reports whether the field named by the test currently holds the tested value.
+func (pt PatchTest) Matches(product ProductResponse) bool {
+	var current any
+	switch pt.Field {
+	case "name":
+		current = product.Name
+	case "quantity":
+		current = product.Quantity
+	case "price":
+		// Note : Prices are compared as amounts, so "10.5", "10.50" and 10.5 all match a price of 10.50.
+		var expected Money
+		if json.Unmarshal(pt.Value, &expected) != nil {
+			return false
+		}
+		expectedMinorUnits, err := expected.MinorUnits(product.Currency)
+		if err != nil {
+			return false
+		}
+		actualMinorUnits, err := product.Price.MinorUnits(product.Currency)
+		return err == nil && expectedMinorUnits == actualMinorUnits
+	case "currency":
+		current = product.Currency
+	case "sku":
+		current = product.Sku
+	case "barcode":
+		current = product.Barcode
+	case "reorder_point":
+		current = product.ReorderPoint
+	}
+
+	// Note : Both sides go through JSON so that 10 and 10.0 compare equal, as RFC 6902 requires.
+	encoded, err := json.Marshal(current)
+	if err != nil {
+		return false
+	}
+
+	var expected, actual any
+	if json.Unmarshal(pt.Value, &expected) != nil || json.Unmarshal(encoded, &actual) != nil {
+		return false
+	}
+	return reflect.DeepEqual(expected, actual)
+}
@@ -1,22 +1,175 @@
 package model
 
-import "errors"
+import (
+	"strings"
+
+	"github.com/indrabrata/observability-playground/utility"
+)
+
+const (
+	DefaultProductListLimit = 20
+	MaxProductListLimit     = 100
+)
 
 type ProductRequest struct {
-	Name     string  `json:"name" example:"Product A"`
-	Quantity int64   `json:"quantity" example:"10"`
-	Price    float64 `json:"price" example:"10.99"`
+	Name     string `json:"name" example:"Product A"`
+	Quantity int64  `json:"quantity" example:"10"`
+	Price    Money  `json:"price" swaggertype:"string" example:"10.99"`
+	// Note : Currency falls back to DefaultCurrency when empty.
+	Currency string `json:"currency,omitempty" example:"USD"`
+	// Note : Sku is unique ignoring case. Barcode is a GTIN (EAN-13, UPC-A, ...) and is unique too.
+	Sku     string `json:"sku,omitempty" example:"TSHIRT-RED-M"`
+	Barcode string `json:"barcode,omitempty" example:"4006381333931"`
+	// Note : A low stock alert is raised once the quantity falls below ReorderPoint. Without one the product is never alerted on.
+	ReorderPoint *int64 `json:"reorder_point,omitempty" example:"5"`
 }
 
 func (pr *ProductRequest) Validate() error {
+	pr.Currency = pr.PriceCurrency()
+	pr.Sku = strings.TrimSpace(pr.Sku)
+	pr.Barcode = strings.TrimSpace(pr.Barcode)
+
+	var errs ValidationErrors
 	if pr.Name == "" {
-		return errors.New("name is required")
+		errs.Add("name", "name is required")
+	}
+	// Note : Out of stock products are valid, and a product with variants always reads 0 until its first variant
+	// gets stock, so a full update has to be able to send 0 back.
+	if pr.Quantity < 0 {
+		errs.Add("quantity", "quantity must not be negative")
+	}
+	validateCurrency(&errs, "currency", pr.Currency)
+	validatePrice(&errs, "price", pr.Price, pr.Currency)
+	if pr.Sku != "" {
+		validateSku(&errs, "sku", pr.Sku)
+	}
+	if pr.Barcode != "" {
+		validateBarcode(&errs, "barcode", pr.Barcode)
+	}
+	validateReorderPoint(&errs, "reorder_point", pr.ReorderPoint)
+	return errs.Err()
+}
+
+// PriceCurrency returns Currency, or DefaultCurrency when the request does not name one.
+func (pr *ProductRequest) PriceCurrency() string {
+	if pr.Currency == "" {
+		return DefaultCurrency
+	}
+	return pr.Currency
+}
+
+// PriceMinorUnits returns Price in minor units of PriceCurrency. It only fails for a request that did not pass Validate.
+func (pr *ProductRequest) PriceMinorUnits() (int64, error) {
+	return pr.Price.MinorUnits(pr.PriceCurrency())
+}
+
+type ProductListRequest struct {
+	Limit        int64
+	Cursor       string
+	SortBy       string
+	SortOrder    string
+	NameContains string
+	MinPrice     *Money
+	MaxPrice     *Money
+	// Note : Currency only lists products priced in it. Price bounds are compared in minor units, so they
+	// imply Currency, which then falls back to DefaultCurrency.
+	Currency string
+	InStock  *bool
+	// Note : Tags only lists products that carry every one of them.
+	Tags []string
+
+	// Note : IncludeDeleted also lists soft deleted products, which then carry deleted_at.
+	IncludeDeleted bool
+
+	// Note : After is the decoded form of Cursor, populated by Validate.
+	After *utility.Cursor
+	// Note : MinPriceMinor and MaxPriceMinor are the price bounds in minor units of Currency, populated by Validate.
+	MinPriceMinor *int64
+	MaxPriceMinor *int64
+}
+
+func (plr *ProductListRequest) Validate() error {
+	if plr.Limit == 0 {
+		plr.Limit = DefaultProductListLimit
+	}
+	if plr.SortBy == "" {
+		plr.SortBy = "name"
+	}
+	if plr.SortOrder == "" {
+		plr.SortOrder = "asc"
 	}
-	if pr.Quantity <= 0 {
-		return errors.New("quantity must be greater than 0")
+
+	var errs ValidationErrors
+	if plr.Limit < 1 || plr.Limit > MaxProductListLimit {
+		errs.Add("limit", "limit must be between 1 and 100")
+	}
+	switch plr.SortBy {
+	case "name", "price", "quantity", "created_at":
+	default:
+		errs.Add("sort_by", "sort_by must be one of name, price, quantity, created_at")
+	}
+	if plr.SortOrder != "asc" && plr.SortOrder != "desc" {
+		errs.Add("sort_order", "sort_order must be asc or desc")
+	}
+	if plr.Currency == "" && (plr.MinPrice != nil || plr.MaxPrice != nil) {
+		plr.Currency = DefaultCurrency
+	}
+	if plr.Currency != "" && validateCurrency(&errs, "currency", plr.Currency) {
+		plr.MinPriceMinor = plr.priceBound(&errs, "min_price", plr.MinPrice)
+		plr.MaxPriceMinor = plr.priceBound(&errs, "max_price", plr.MaxPrice)
+		if plr.MinPriceMinor != nil && plr.MaxPriceMinor != nil && *plr.MinPriceMinor > *plr.MaxPriceMinor {
+			errs.Add("min_price", "min_price must not be greater than max_price")
+		}
+	}
+	plr.Tags = normalizeTags(&errs, "tags", plr.Tags)
+	if plr.Cursor != "" {
+		cursor, err := utility.DecodeCursor(plr.Cursor)
+		if err != nil {
+			errs.Add("cursor", err.Error())
+		} else if cursor.SortBy != plr.SortBy || cursor.SortOrder != plr.SortOrder {
+			errs.Add("cursor", "cursor does not match sort_by and sort_order")
+		} else {
+			plr.After = &cursor
+		}
+	}
+	return errs.Err()
+}
+
+// priceBound checks a min_price or max_price filter and returns it in minor units of Currency, or nil when
+// the bound is absent or invalid.
+func (plr *ProductListRequest) priceBound(errs *ValidationErrors, field string, bound *Money) *int64 {
+	if bound == nil {
+		return nil
+	}
+	if bound.Sign() < 0 {
+		errs.Add(field, field+" must not be negative")
+		return nil
+	}
+
+	minorUnits, err := bound.MinorUnits(plr.Currency)
+	if err != nil {
+		errs.Add(field, moneyErrorMessage(field, plr.Currency, err))
+		return nil
+	}
+	return &minorUnits
+}
+
+type ProductSearchRequest struct {
+	Query string
+	Limit int64
+}
+
+func (psr *ProductSearchRequest) Validate() error {
+	if psr.Limit == 0 {
+		psr.Limit = DefaultProductListLimit
+	}
+
+	var errs ValidationErrors
+	if strings.TrimSpace(psr.Query) == "" {
+		errs.Add("q", "q is required")
 	}
-	if pr.Price <= 0 {
-		return errors.New("price must be greater than 0")
+	if psr.Limit < 1 || psr.Limit > MaxProductListLimit {
+		errs.Add("limit", "limit must be between 1 and 100")
 	}
-	return nil
+	return errs.Err()
 }
@@ -1,8 +1,40 @@
 package model
 
+import "time"
+
 type ProductResponse struct {
-	Id       int64   `json:"id" example:"1"`
-	Name     string  `json:"name" example:"Product A"`
-	Quantity int64   `json:"quantity" example:"10"`
-	Price    float64 `json:"price" example:"10.99"`
+	Id           int64      `json:"id" example:"1"`
+	Name         string     `json:"name" example:"Product A"`
+	Quantity     int64      `json:"quantity" example:"10"`
+	Price        Money      `json:"price" swaggertype:"string" example:"10.99"`
+	Currency     string     `json:"currency" example:"USD"`
+	Sku          string     `json:"sku,omitempty" example:"TSHIRT-RED-M"`
+	Barcode      string     `json:"barcode,omitempty" example:"4006381333931"`
+	ReorderPoint *int64     `json:"reorder_point,omitempty" example:"5"`
+	Version      int64      `json:"version" example:"1"`
+	DeletedAt    *time.Time `json:"deleted_at,omitempty" example:"2025-01-02T15:04:05Z"`
+	// Note : Stock is only filled in when a single product is read.
+	Stock *ProductStockResponse `json:"stock,omitempty"`
+}
+
+type ProductListResponse struct {
+	Data   []ProductResponse `json:"data"`
+	Paging Paging            `json:"paging"`
+}
+
+type Paging struct {
+	Limit      int64  `json:"limit" example:"20"`
+	NextCursor string `json:"next_cursor,omitempty" example:"eyJzIjoibmFtZSIsIm8iOiJhc2MiLCJ2IjoiUHJvZHVjdCBBIiwiaSI6MX0"`
+	HasMore    bool   `json:"has_more" example:"true"`
+}
+
+type ProductSearchResult struct {
+	ProductResponse
+	// Note : Highlight is HTML: the name is escaped and only the matched terms are wrapped in <mark>.
+	Highlight string  `json:"highlight" example:"<mark>Product</mark> A"`
+	Score     float64 `json:"score" example:"1.37"`
+}
+
+type ProductSearchResponse struct {
+	Data []ProductSearchResult `json:"data"`
 }
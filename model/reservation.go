@@ -0,0 +1,83 @@
+package model
+
+import (
+	"strconv"
+	"time"
+)
+
+const (
+	ReservationStatusActive    = "active"
+	ReservationStatusConfirmed = "confirmed"
+	ReservationStatusCancelled = "cancelled"
+	ReservationStatusExpired   = "expired"
+
+	StockReasonReserve = "reserve"
+	StockReasonRelease = "release"
+
+	MaxReservationTTLSeconds = 3600
+)
+
+// ReservationItemRequest holds Quantity units of a product. Products with variants are reserved per variant,
+// named by VariantId. WarehouseId takes the units from a warehouse instead of the unallocated stock.
+type ReservationItemRequest struct {
+	ProductId   int64  `json:"product_id" example:"1"`
+	VariantId   *int64 `json:"variant_id,omitempty" example:"7"`
+	WarehouseId *int64 `json:"warehouse_id,omitempty" example:"1"`
+	Quantity    int64  `json:"quantity" example:"2"`
+}
+
+type ReservationRequest struct {
+	Items []ReservationItemRequest `json:"items"`
+	// Note : TTLSeconds falls back to the configured default reservation TTL when 0.
+	TTLSeconds int64  `json:"ttl_seconds,omitempty" example:"600"`
+	Reference  string `json:"reference,omitempty" example:"checkout-7f3a"`
+}
+
+func (rr *ReservationRequest) Validate() error {
+	var errs ValidationErrors
+	if len(rr.Items) == 0 {
+		errs.Add("items", "items must contain at least one product")
+	}
+
+	// Note : A product may be listed once per variant, the variant id is 0 for an item without one.
+	seen := make(map[[2]int64]bool, len(rr.Items))
+	for i, item := range rr.Items {
+		field := "items[" + strconv.Itoa(i) + "]"
+		validateVariantId(&errs, field+".variant_id", item.VariantId)
+		validateWarehouseId(&errs, field+".warehouse_id", item.WarehouseId)
+		key := [2]int64{item.ProductId, 0}
+		if item.VariantId != nil {
+			key[1] = *item.VariantId
+		}
+		if item.ProductId <= 0 {
+			errs.Add(field+".product_id", "product_id is required")
+		} else if seen[key] {
+			errs.Add(field+".product_id", "product_id must not be repeated for the same variant")
+		}
+		seen[key] = true
+		if item.Quantity <= 0 {
+			errs.Add(field+".quantity", "quantity must be greater than 0")
+		}
+	}
+
+	if rr.TTLSeconds < 0 || rr.TTLSeconds > MaxReservationTTLSeconds {
+		errs.Add("ttl_seconds", "ttl_seconds must be between 1 and "+strconv.Itoa(MaxReservationTTLSeconds))
+	}
+	return errs.Err()
+}
+
+type ReservationItemResponse struct {
+	ProductId   int64  `json:"product_id" example:"1"`
+	VariantId   *int64 `json:"variant_id,omitempty" example:"7"`
+	WarehouseId *int64 `json:"warehouse_id,omitempty" example:"1"`
+	Quantity    int64  `json:"quantity" example:"2"`
+}
+
+type ReservationResponse struct {
+	Id        int64                     `json:"id" example:"1"`
+	Status    string                    `json:"status" example:"active"`
+	Reference string                    `json:"reference,omitempty" example:"checkout-7f3a"`
+	ExpiresAt time.Time                 `json:"expires_at" example:"2025-01-02T15:14:05Z"`
+	CreatedAt time.Time                 `json:"created_at" example:"2025-01-02T15:04:05Z"`
+	Items     []ReservationItemResponse `json:"items"`
+}
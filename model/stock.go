@@ -0,0 +1,114 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/indrabrata/observability-playground/utility"
+)
+
+// Note : Reasons written by the service itself; adjustments carry the free text reason given by the caller.
+const (
+	StockReasonInitial = "initial"
+	StockReasonUpdate  = "update"
+	StockReasonReceive = "receive"
+	StockReasonShip    = "ship"
+)
+
+// StockAdjustRequest changes the stock of a product by Delta, which may be negative. Products with variants
+// move stock per variant, named by VariantId. WarehouseId names the warehouse the units enter or leave;
+// without it they are unallocated.
+type StockAdjustRequest struct {
+	VariantId   *int64 `json:"variant_id,omitempty" example:"7"`
+	WarehouseId *int64 `json:"warehouse_id,omitempty" example:"1"`
+	Delta       int64  `json:"delta" example:"-3"`
+	Reason      string `json:"reason" example:"damaged in warehouse"`
+	Reference   string `json:"reference,omitempty" example:"INC-1042"`
+	Actor       string `json:"actor,omitempty" example:"jane.doe"`
+}
+
+func (sar *StockAdjustRequest) Validate() error {
+	var errs ValidationErrors
+	validateVariantId(&errs, "variant_id", sar.VariantId)
+	validateWarehouseId(&errs, "warehouse_id", sar.WarehouseId)
+	if sar.Delta == 0 {
+		errs.Add("delta", "delta must not be 0")
+	}
+	if strings.TrimSpace(sar.Reason) == "" {
+		errs.Add("reason", "reason is required")
+	}
+	return errs.Err()
+}
+
+// StockQuantityRequest receives or ships Quantity units of a product.
+type StockQuantityRequest struct {
+	VariantId   *int64 `json:"variant_id,omitempty" example:"7"`
+	WarehouseId *int64 `json:"warehouse_id,omitempty" example:"1"`
+	Quantity    int64  `json:"quantity" example:"40"`
+	Reference   string `json:"reference,omitempty" example:"PO-2024-118"`
+	Actor       string `json:"actor,omitempty" example:"jane.doe"`
+}
+
+func (sqr *StockQuantityRequest) Validate() error {
+	var errs ValidationErrors
+	validateVariantId(&errs, "variant_id", sqr.VariantId)
+	validateWarehouseId(&errs, "warehouse_id", sqr.WarehouseId)
+	if sqr.Quantity <= 0 {
+		errs.Add("quantity", "quantity must be greater than 0")
+	}
+	return errs.Err()
+}
+
+type StockMovementResponse struct {
+	Id            int64     `json:"id" example:"12"`
+	ProductId     int64     `json:"product_id" example:"1"`
+	VariantId     *int64    `json:"variant_id,omitempty" example:"7"`
+	WarehouseId   *int64    `json:"warehouse_id,omitempty" example:"1"`
+	Delta         int64     `json:"delta" example:"-40"`
+	QuantityAfter int64     `json:"quantity_after" example:"60"`
+	Reason        string    `json:"reason" example:"ship"`
+	Reference     string    `json:"reference,omitempty" example:"SO-5531"`
+	Actor         string    `json:"actor,omitempty" example:"jane.doe"`
+	RequestId     string    `json:"request_id,omitempty" example:"8c1f3c5e-4b8e-4c1a-9f3e-2d6b7a9c0e11"`
+	CreatedAt     time.Time `json:"created_at" example:"2025-01-02T15:04:05Z"`
+}
+
+type StockAdjustResponse struct {
+	Product  ProductResponse       `json:"product"`
+	Movement StockMovementResponse `json:"movement"`
+}
+
+type StockHistoryRequest struct {
+	Limit  int64
+	Cursor string
+
+	// Note : After is the decoded form of Cursor, populated by Validate.
+	After *utility.Cursor
+}
+
+func (shr *StockHistoryRequest) Validate() error {
+	if shr.Limit == 0 {
+		shr.Limit = DefaultProductListLimit
+	}
+
+	var errs ValidationErrors
+	if shr.Limit < 1 || shr.Limit > MaxProductListLimit {
+		errs.Add("limit", "limit must be between 1 and 100")
+	}
+	if shr.Cursor != "" {
+		cursor, err := utility.DecodeCursor(shr.Cursor)
+		if err != nil {
+			errs.Add("cursor", err.Error())
+		} else if cursor.SortBy != "id" || cursor.SortOrder != "desc" {
+			errs.Add("cursor", "cursor does not belong to a stock history")
+		} else {
+			shr.After = &cursor
+		}
+	}
+	return errs.Err()
+}
+
+type StockHistoryResponse struct {
+	Data   []StockMovementResponse `json:"data"`
+	Paging Paging                  `json:"paging"`
+}
@@ -0,0 +1,31 @@
+package model
+
+import "strings"
+
+type FieldError struct {
+	Field   string `json:"field" example:"price"`
+	Message string `json:"message" example:"price must be greater than 0"`
+}
+
+// ValidationErrors collects every invalid field of a request instead of stopping at the first one.
+type ValidationErrors []FieldError
+
+func (ve *ValidationErrors) Add(field, message string) {
+	*ve = append(*ve, FieldError{Field: field, Message: message})
+}
+
+// Err returns nil when no field is invalid, so Validate methods can end with `return errs.Err()`.
+func (ve ValidationErrors) Err() error {
+	if len(ve) == 0 {
+		return nil
+	}
+	return ve
+}
+
+func (ve ValidationErrors) Error() string {
+	messages := make([]string, 0, len(ve))
+	for _, fieldError := range ve {
+		messages = append(messages, fieldError.Message)
+	}
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,120 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MaxVariantOptions     = 10
+	MaxOptionNameLength   = 30
+	MaxOptionValueLength  = 50
+	MaxVariantsPerProduct = 100
+)
+
+// VariantRequest creates or replaces a variant of a product, e.g. one size and colour of a shirt.
+type VariantRequest struct {
+	Sku string `json:"sku" example:"TSHIRT-RED-M"`
+	// Note : Option names are trimmed and lower cased, values are trimmed. No two variants of a product may have
+	// the same options.
+	Options map[string]string `json:"options" example:"size:M,colour:red"`
+	// Note : Price overrides the price of the product when set. Currency falls back to the product's currency
+	// and may only be given with a price.
+	Price    *Money `json:"price,omitempty" swaggertype:"string" example:"12.99"`
+	Currency string `json:"currency,omitempty" example:"USD"`
+	Quantity int64  `json:"quantity" example:"5"`
+}
+
+// Validate checks the request on its own. A price without a currency is checked against the product's
+// currency by the service, once the product has been read.
+func (vr *VariantRequest) Validate() error {
+	vr.Sku = strings.TrimSpace(vr.Sku)
+
+	var errs ValidationErrors
+	validateSku(&errs, "sku", vr.Sku)
+	vr.Options = normalizeOptions(&errs, "options", vr.Options)
+	if vr.Price != nil {
+		if vr.Currency != "" {
+			if validateCurrency(&errs, "currency", vr.Currency) {
+				validatePrice(&errs, "price", *vr.Price, vr.Currency)
+			}
+		} else if vr.Price.Sign() <= 0 {
+			errs.Add("price", "price must be greater than 0")
+		}
+	} else if vr.Currency != "" {
+		errs.Add("currency", "currency is only allowed together with a price")
+	}
+	if vr.Quantity < 0 {
+		errs.Add("quantity", "quantity must not be negative")
+	}
+	return errs.Err()
+}
+
+// PriceMinorUnits returns Price in minor units of currency, or the validation errors that prevent it.
+func (vr *VariantRequest) PriceMinorUnits(currency string) (int64, error) {
+	var errs ValidationErrors
+	validatePrice(&errs, "price", *vr.Price, currency)
+	if err := errs.Err(); err != nil {
+		return 0, err
+	}
+	return vr.Price.MinorUnits(currency)
+}
+
+// normalizeOptions trims and lower cases option names and trims values. Names that collide once normalized are refused.
+func normalizeOptions(errs *ValidationErrors, field string, options map[string]string) map[string]string {
+	if len(options) == 0 {
+		errs.Add(field, field+" must contain at least one option")
+		return options
+	}
+	if len(options) > MaxVariantOptions {
+		errs.Add(field, field+" must contain at most "+strconv.Itoa(MaxVariantOptions)+" options")
+		return options
+	}
+
+	normalized := make(map[string]string, len(options))
+	for name, value := range options {
+		name = strings.ToLower(strings.TrimSpace(name))
+		value = strings.TrimSpace(value)
+		switch {
+		case name == "":
+			errs.Add(field, "option names must not be empty")
+		case len(name) > MaxOptionNameLength:
+			errs.Add(field+"."+name, "option names must be at most "+strconv.Itoa(MaxOptionNameLength)+" characters")
+		case value == "":
+			errs.Add(field+"."+name, "option values must not be empty")
+		case len(value) > MaxOptionValueLength:
+			errs.Add(field+"."+name, "option values must be at most "+strconv.Itoa(MaxOptionValueLength)+" characters")
+		default:
+			if _, ok := normalized[name]; ok {
+				errs.Add(field+"."+name, "option "+name+" is given more than once")
+			}
+		}
+		normalized[name] = value
+	}
+	return normalized
+}
+
+func validateVariantId(errs *ValidationErrors, field string, variantId *int64) {
+	if variantId != nil && *variantId <= 0 {
+		errs.Add(field, field+" must be greater than 0")
+	}
+}
+
+type VariantResponse struct {
+	Id        int64             `json:"id" example:"7"`
+	ProductId int64             `json:"product_id" example:"1"`
+	Sku       string            `json:"sku" example:"TSHIRT-RED-M"`
+	Options   map[string]string `json:"options" example:"size:M,colour:red"`
+	// Note : Price and Currency are only set when the variant overrides the price of its product.
+	Price     *Money     `json:"price,omitempty" swaggertype:"string" example:"12.99"`
+	Currency  string     `json:"currency,omitempty" example:"USD"`
+	Quantity  int64      `json:"quantity" example:"5"`
+	CreatedAt time.Time  `json:"created_at" example:"2025-01-02T15:04:05Z"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty" example:"2025-01-02T15:04:05Z"`
+}
+
+type VariantListResponse struct {
+	ProductId int64             `json:"product_id" example:"1"`
+	Data      []VariantResponse `json:"data"`
+}
@@ -0,0 +1,148 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MaxWarehouseCodeLength = 20
+	MaxWarehouseNameLength = 100
+	MaxTransferItems       = 100
+
+	StockReasonTransferOut = "transfer_out"
+	StockReasonTransferIn  = "transfer_in"
+)
+
+type WarehouseRequest struct {
+	// Note : Code is unique ignoring case and labels the warehouse metrics, so keep it short and stable.
+	Code string `json:"code" example:"AMS-1"`
+	Name string `json:"name" example:"Amsterdam"`
+}
+
+func (wr *WarehouseRequest) Validate() error {
+	wr.Code = strings.TrimSpace(wr.Code)
+	wr.Name = strings.TrimSpace(wr.Name)
+
+	var errs ValidationErrors
+	if wr.Code == "" {
+		errs.Add("code", "code is required")
+	} else if len(wr.Code) > MaxWarehouseCodeLength {
+		errs.Add("code", "code must be at most "+strconv.Itoa(MaxWarehouseCodeLength)+" characters")
+	} else {
+		for _, r := range wr.Code {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+				errs.Add("code", "code may only contain letters, digits, '-' and '_'")
+				break
+			}
+		}
+	}
+	if wr.Name == "" {
+		errs.Add("name", "name is required")
+	} else if len(wr.Name) > MaxWarehouseNameLength {
+		errs.Add("name", "name must be at most "+strconv.Itoa(MaxWarehouseNameLength)+" characters")
+	}
+	return errs.Err()
+}
+
+type WarehouseResponse struct {
+	Id        int64      `json:"id" example:"1"`
+	Code      string     `json:"code" example:"AMS-1"`
+	Name      string     `json:"name" example:"Amsterdam"`
+	CreatedAt time.Time  `json:"created_at" example:"2025-01-02T15:04:05Z"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty" example:"2025-01-03T15:04:05Z"`
+}
+
+type WarehouseListResponse struct {
+	Data []WarehouseResponse `json:"data"`
+}
+
+type TransferItemRequest struct {
+	ProductId int64  `json:"product_id" example:"1"`
+	VariantId *int64 `json:"variant_id,omitempty" example:"7"`
+	Quantity  int64  `json:"quantity" example:"10"`
+}
+
+// TransferRequest moves stock from one location to another. A location without a warehouse id is the
+// unallocated stock, so stock is put into a warehouse by leaving FromWarehouseId out.
+type TransferRequest struct {
+	FromWarehouseId *int64                `json:"from_warehouse_id,omitempty" example:"1"`
+	ToWarehouseId   *int64                `json:"to_warehouse_id,omitempty" example:"2"`
+	Items           []TransferItemRequest `json:"items"`
+	Reference       string                `json:"reference,omitempty" example:"TR-2025-014"`
+	Actor           string                `json:"actor,omitempty" example:"jane.doe"`
+}
+
+func (tr *TransferRequest) Validate() error {
+	var errs ValidationErrors
+	validateWarehouseId(&errs, "from_warehouse_id", tr.FromWarehouseId)
+	validateWarehouseId(&errs, "to_warehouse_id", tr.ToWarehouseId)
+	if tr.FromWarehouseId == nil && tr.ToWarehouseId == nil {
+		errs.Add("to_warehouse_id", "from_warehouse_id or to_warehouse_id is required")
+	} else if tr.FromWarehouseId != nil && tr.ToWarehouseId != nil && *tr.FromWarehouseId == *tr.ToWarehouseId {
+		errs.Add("to_warehouse_id", "to_warehouse_id must differ from from_warehouse_id")
+	}
+
+	if len(tr.Items) == 0 {
+		errs.Add("items", "items must contain at least one product")
+	} else if len(tr.Items) > MaxTransferItems {
+		errs.Add("items", "items must contain at most "+strconv.Itoa(MaxTransferItems)+" products")
+	}
+
+	// Note : A product may be listed once per variant, the variant id is 0 for an item without one.
+	seen := make(map[[2]int64]bool, len(tr.Items))
+	for i, item := range tr.Items {
+		field := "items[" + strconv.Itoa(i) + "]"
+		validateVariantId(&errs, field+".variant_id", item.VariantId)
+		key := [2]int64{item.ProductId, 0}
+		if item.VariantId != nil {
+			key[1] = *item.VariantId
+		}
+		if item.ProductId <= 0 {
+			errs.Add(field+".product_id", "product_id is required")
+		} else if seen[key] {
+			errs.Add(field+".product_id", "product_id must not be repeated for the same variant")
+		}
+		seen[key] = true
+		if item.Quantity <= 0 {
+			errs.Add(field+".quantity", "quantity must be greater than 0")
+		}
+	}
+	return errs.Err()
+}
+
+type TransferItemResponse struct {
+	ProductId int64  `json:"product_id" example:"1"`
+	VariantId *int64 `json:"variant_id,omitempty" example:"7"`
+	Quantity  int64  `json:"quantity" example:"10"`
+}
+
+type TransferResponse struct {
+	Id              int64                  `json:"id" example:"4"`
+	FromWarehouseId *int64                 `json:"from_warehouse_id,omitempty" example:"1"`
+	ToWarehouseId   *int64                 `json:"to_warehouse_id,omitempty" example:"2"`
+	Reference       string                 `json:"reference,omitempty" example:"TR-2025-014"`
+	Actor           string                 `json:"actor,omitempty" example:"jane.doe"`
+	CreatedAt       time.Time              `json:"created_at" example:"2025-01-02T15:04:05Z"`
+	Items           []TransferItemResponse `json:"items"`
+}
+
+// ProductStockResponse breaks the quantity of a product down by warehouse. Unallocated is the part that is in no warehouse.
+type ProductStockResponse struct {
+	Total       int64                   `json:"total" example:"60"`
+	Unallocated int64                   `json:"unallocated" example:"5"`
+	Locations   []StockLocationResponse `json:"locations"`
+}
+
+type StockLocationResponse struct {
+	WarehouseId   int64  `json:"warehouse_id" example:"1"`
+	WarehouseCode string `json:"warehouse_code" example:"AMS-1"`
+	Quantity      int64  `json:"quantity" example:"55"`
+}
+
+func validateWarehouseId(errs *ValidationErrors, field string, warehouseId *int64) {
+	if warehouseId != nil && *warehouseId <= 0 {
+		errs.Add(field, field+" must be greater than 0")
+	}
+}
@@ -0,0 +1,135 @@
+package model
+
+import (
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/indrabrata/observability-playground/utility"
+)
+
+const (
+	MaxWebhookUrlLength    = 2048
+	MinWebhookSecretLength = 16
+	MaxWebhookSecretLength = 128
+
+	WebhookDeliveryStatusPending   = "pending"
+	WebhookDeliveryStatusDelivered = "delivered"
+	WebhookDeliveryStatusDead      = "dead"
+)
+
+// EventTypes lists every event type a webhook can subscribe to.
+var EventTypes = []string{EventProductCreated, EventProductUpdated, EventProductDeleted, EventProductRestored}
+
+// WebhookRequest subscribes a URL to event types. Every delivery is signed with Secret, which is generated when
+// left out of a create and kept when left out of an update.
+type WebhookRequest struct {
+	Url        string   `json:"url" example:"https://partner.example.com/hooks/products"`
+	EventTypes []string `json:"event_types" example:"product.created,product.updated"`
+	Secret     string   `json:"secret,omitempty" example:"4f9c2a7e61b84d0fa3c5e8b1d7f20a96"`
+}
+
+func (wr *WebhookRequest) Validate() error {
+	wr.Url = strings.TrimSpace(wr.Url)
+
+	var errs ValidationErrors
+	if wr.Url == "" {
+		errs.Add("url", "url is required")
+	} else if len(wr.Url) > MaxWebhookUrlLength {
+		errs.Add("url", "url must be at most "+strconv.Itoa(MaxWebhookUrlLength)+" characters")
+	} else if parsed, err := url.Parse(wr.Url); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		errs.Add("url", "url must be an absolute http or https URL")
+	}
+
+	if len(wr.EventTypes) == 0 {
+		errs.Add("event_types", "event_types must contain at least one event type")
+	}
+	seen := make(map[string]bool, len(wr.EventTypes))
+	for i, eventType := range wr.EventTypes {
+		field := "event_types[" + strconv.Itoa(i) + "]"
+		if !slices.Contains(EventTypes, eventType) {
+			errs.Add(field, "event type must be one of "+strings.Join(EventTypes, ", "))
+		} else if seen[eventType] {
+			errs.Add(field, "event type must not be repeated")
+		}
+		seen[eventType] = true
+	}
+
+	if wr.Secret != "" && (len(wr.Secret) < MinWebhookSecretLength || len(wr.Secret) > MaxWebhookSecretLength) {
+		errs.Add("secret", "secret must be between "+strconv.Itoa(MinWebhookSecretLength)+" and "+strconv.Itoa(MaxWebhookSecretLength)+" characters")
+	}
+	return errs.Err()
+}
+
+// WebhookResponse is a webhook subscription. The secret is only returned by the request that set it.
+type WebhookResponse struct {
+	Id         int64      `json:"id" example:"2"`
+	Url        string     `json:"url" example:"https://partner.example.com/hooks/products"`
+	EventTypes []string   `json:"event_types" example:"product.created,product.updated"`
+	Secret     string     `json:"secret,omitempty" example:"4f9c2a7e61b84d0fa3c5e8b1d7f20a96"`
+	CreatedAt  time.Time  `json:"created_at" example:"2025-01-02T15:04:05Z"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty" example:"2025-01-03T15:04:05Z"`
+}
+
+type WebhookListResponse struct {
+	Data []WebhookResponse `json:"data"`
+}
+
+type WebhookDeliveryListRequest struct {
+	Status string
+	Limit  int64
+	Cursor string
+
+	// Note : After is the decoded form of Cursor, populated by Validate.
+	After *utility.Cursor
+}
+
+func (wdlr *WebhookDeliveryListRequest) Validate() error {
+	if wdlr.Limit == 0 {
+		wdlr.Limit = DefaultProductListLimit
+	}
+
+	var errs ValidationErrors
+	switch wdlr.Status {
+	case "", WebhookDeliveryStatusPending, WebhookDeliveryStatusDelivered, WebhookDeliveryStatusDead:
+	default:
+		errs.Add("status", "status must be pending, delivered or dead")
+	}
+	if wdlr.Limit < 1 || wdlr.Limit > MaxProductListLimit {
+		errs.Add("limit", "limit must be between 1 and 100")
+	}
+	if wdlr.Cursor != "" {
+		cursor, err := utility.DecodeCursor(wdlr.Cursor)
+		if err != nil {
+			errs.Add("cursor", err.Error())
+		} else if cursor.SortBy != "id" || cursor.SortOrder != "desc" {
+			errs.Add("cursor", "cursor does not belong to a delivery log")
+		} else {
+			wdlr.After = &cursor
+		}
+	}
+	return errs.Err()
+}
+
+// WebhookDeliveryResponse is one event sent, or still to be sent, to a webhook. A pending delivery is retried
+// at NextAttemptAt; a dead one ran out of attempts and is only sent again when it is retried by hand.
+type WebhookDeliveryResponse struct {
+	Id             int64      `json:"id" example:"31"`
+	WebhookId      int64      `json:"webhook_id" example:"2"`
+	EventId        int64      `json:"event_id" example:"42"`
+	EventType      string     `json:"event_type" example:"product.updated"`
+	Status         string     `json:"status" example:"pending"`
+	Attempts       int64      `json:"attempts" example:"3"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty" example:"2025-01-02T15:05:05Z"`
+	ResponseStatus *int64     `json:"response_status,omitempty" example:"503"`
+	LastError      string     `json:"last_error,omitempty" example:"webhook answered 503 Service Unavailable"`
+	CreatedAt      time.Time  `json:"created_at" example:"2025-01-02T15:04:05Z"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty" example:"2025-01-02T15:04:06Z"`
+}
+
+type WebhookDeliveryListResponse struct {
+	Data   []WebhookDeliveryResponse `json:"data"`
+	Paging Paging                    `json:"paging"`
+}
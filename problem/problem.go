@@ -0,0 +1,72 @@
+// Package problem writes errors as RFC 7807 problems. It sits below handler and middleware so both answer in the
+// same format without middleware depending on handler.
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/constant"
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/utility"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// statusCode is the single place where domain error kinds are mapped to HTTP status codes.
+func statusCode(err error) int {
+	switch apperror.KindOf(err) {
+	case apperror.Validation:
+		return http.StatusBadRequest
+	case apperror.NotFound:
+		return http.StatusNotFound
+	case apperror.Conflict:
+		return http.StatusConflict
+	case apperror.PreconditionFailed:
+		return http.StatusPreconditionFailed
+	case apperror.Unsupported:
+		return http.StatusUnsupportedMediaType
+	case apperror.Unprocessable:
+		return http.StatusUnprocessableEntity
+	case apperror.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Write writes err as an RFC 7807 problem. The request ID and trace ID are included so a
+// client-side report can be looked up directly in Loki and Tempo.
+func Write(ctx context.Context, w http.ResponseWriter, err error) {
+	status := statusCode(err)
+
+	span := trace.SpanFromContext(ctx)
+	utility.RecordSpanError(span, err)
+	span.SetAttributes(attribute.Int("http.response.status_code", status))
+
+	problem := model.ProblemDetail{
+		Type:   constant.PROBLEM_TYPE_PREFIX + strings.ReplaceAll(string(apperror.KindOf(err)), "_", "-"),
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: apperror.MessageOf(err),
+	}
+	if requestId, ok := ctx.Value("requestId").(string); ok {
+		problem.Instance = requestId
+	}
+	if span.SpanContext().HasTraceID() {
+		problem.TraceId = span.SpanContext().TraceID().String()
+	}
+
+	var validationErrors model.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		problem.Errors = validationErrors
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(problem)
+}
@@ -1,6 +1,9 @@
 package repository
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+)
 
 type BaseRepository[T any] struct {
 	DB    *sql.DB
@@ -13,3 +16,18 @@ func NewBaseRepository[T any](db *sql.DB, client T) *BaseRepository[T] {
 		Query: client,
 	}
 }
+
+// Transaction runs fn inside a database transaction. It commits when fn returns nil and rolls back otherwise.
+func (br *BaseRepository[T]) Transaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := br.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
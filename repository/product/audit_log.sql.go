@@ -0,0 +1,111 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: audit_log.sql
+
+package product
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const createAuditLogEntry = `-- name: CreateAuditLogEntry :exec
+INSERT INTO audit_log (
+  entity_type, entity_id, action, actor, before, after, request_id, trace_id, client_ip, created_at
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+`
+
+type CreateAuditLogEntryParams struct {
+	EntityType string
+	EntityID   int64
+	Action     string
+	Actor      sql.NullString
+	Before     sql.NullString
+	After      sql.NullString
+	RequestID  sql.NullString
+	TraceID    sql.NullString
+	ClientIp   sql.NullString
+	CreatedAt  time.Time
+}
+
+func (q *Queries) CreateAuditLogEntry(ctx context.Context, arg CreateAuditLogEntryParams) error {
+	_, err := q.db.ExecContext(ctx, createAuditLogEntry,
+		arg.EntityType,
+		arg.EntityID,
+		arg.Action,
+		arg.Actor,
+		arg.Before,
+		arg.After,
+		arg.RequestID,
+		arg.TraceID,
+		arg.ClientIp,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const listAuditLogEntries = `-- name: ListAuditLogEntries :many
+SELECT id, entity_type, entity_id, "action", actor, "before", "after", request_id, trace_id, client_ip, created_at FROM audit_log
+WHERE entity_type = ?1
+  AND (CAST(?2 AS INTEGER) IS NULL OR entity_id = ?2)
+  AND created_at >= ?3
+  AND created_at < ?4
+  AND (CAST(?5 AS INTEGER) IS NULL OR id < ?5)
+ORDER BY id DESC
+LIMIT ?6
+`
+
+type ListAuditLogEntriesParams struct {
+	EntityType  string
+	EntityID    sql.NullInt64
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	BeforeID    sql.NullInt64
+	PageSize    int64
+}
+
+func (q *Queries) ListAuditLogEntries(ctx context.Context, arg ListAuditLogEntriesParams) ([]AuditLog, error) {
+	rows, err := q.db.QueryContext(ctx, listAuditLogEntries,
+		arg.EntityType,
+		arg.EntityID,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.BeforeID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditLog
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.EntityType,
+			&i.EntityID,
+			&i.Action,
+			&i.Actor,
+			&i.Before,
+			&i.After,
+			&i.RequestID,
+			&i.TraceID,
+			&i.ClientIp,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
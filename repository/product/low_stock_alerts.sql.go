@@ -0,0 +1,136 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: low_stock_alerts.sql
+
+package product
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const deletePurgeableLowStockAlerts = `-- name: DeletePurgeableLowStockAlerts :exec
+DELETE FROM low_stock_alerts
+WHERE product_id IN (
+  SELECT id FROM purgeable_products
+  WHERE purgeable_products.deleted_at < ?1)
+`
+
+func (q *Queries) DeletePurgeableLowStockAlerts(ctx context.Context, deletedBefore sql.NullTime) error {
+	_, err := q.db.ExecContext(ctx, deletePurgeableLowStockAlerts, deletedBefore)
+	return err
+}
+
+const listOpenLowStockAlerts = `-- name: ListOpenLowStockAlerts :many
+SELECT low_stock_alerts.id, low_stock_alerts.product_id, products.name, products.sku,
+  products.quantity, CAST(products.reorder_point AS INTEGER) AS reorder_point, low_stock_alerts.triggered_at
+FROM low_stock_alerts
+JOIN products ON products.id = low_stock_alerts.product_id
+WHERE low_stock_alerts.resolved_at IS NULL
+ORDER BY low_stock_alerts.triggered_at, low_stock_alerts.id
+`
+
+type ListOpenLowStockAlertsRow struct {
+	ID           int64
+	ProductID    int64
+	Name         string
+	Sku          sql.NullString
+	Quantity     int64
+	ReorderPoint int64
+	TriggeredAt  time.Time
+}
+
+func (q *Queries) ListOpenLowStockAlerts(ctx context.Context) ([]ListOpenLowStockAlertsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listOpenLowStockAlerts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOpenLowStockAlertsRow
+	for rows.Next() {
+		var i ListOpenLowStockAlertsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductID,
+			&i.Name,
+			&i.Sku,
+			&i.Quantity,
+			&i.ReorderPoint,
+			&i.TriggeredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const openLowStockAlerts = `-- name: OpenLowStockAlerts :many
+INSERT INTO low_stock_alerts (
+  product_id, quantity, reorder_point, triggered_at
+)
+SELECT id, quantity, reorder_point, ?1
+FROM products
+WHERE deleted_at IS NULL
+  AND reorder_point IS NOT NULL
+  AND quantity < reorder_point
+ON CONFLICT DO NOTHING
+RETURNING id, product_id, quantity, reorder_point, triggered_at, resolved_at
+`
+
+func (q *Queries) OpenLowStockAlerts(ctx context.Context, triggeredAt time.Time) ([]LowStockAlert, error) {
+	rows, err := q.db.QueryContext(ctx, openLowStockAlerts, triggeredAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LowStockAlert
+	for rows.Next() {
+		var i LowStockAlert
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductID,
+			&i.Quantity,
+			&i.ReorderPoint,
+			&i.TriggeredAt,
+			&i.ResolvedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const resolveLowStockAlerts = `-- name: ResolveLowStockAlerts :execrows
+UPDATE low_stock_alerts
+set resolved_at = ?1
+WHERE resolved_at IS NULL
+  AND product_id NOT IN (
+    SELECT id FROM products
+    WHERE deleted_at IS NULL
+      AND reorder_point IS NOT NULL
+      AND quantity < reorder_point)
+`
+
+func (q *Queries) ResolveLowStockAlerts(ctx context.Context, resolvedAt sql.NullTime) (int64, error) {
+	result, err := q.db.ExecContext(ctx, resolveLowStockAlerts, resolvedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
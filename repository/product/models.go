@@ -9,11 +9,278 @@ import (
 	"time"
 )
 
+type AuditLog struct {
+	ID         int64
+	EntityType string
+	EntityID   int64
+	Action     string
+	Actor      sql.NullString
+	Before     sql.NullString
+	After      sql.NullString
+	RequestID  sql.NullString
+	TraceID    sql.NullString
+	ClientIp   sql.NullString
+	CreatedAt  time.Time
+}
+
+type Category struct {
+	ID        int64
+	Name      string
+	ParentID  sql.NullInt64
+	CreatedAt time.Time
+	UpdatedAt sql.NullTime
+}
+
+type IdempotencyKey struct {
+	Key             string
+	RequestHash     string
+	StatusCode      sql.NullInt64
+	ResponseHeaders sql.NullString
+	ResponseBody    []byte
+	CreatedAt       time.Time
+	ExpiresAt       time.Time
+}
+
+type LowStockAlert struct {
+	ID           int64
+	ProductID    int64
+	Quantity     int64
+	ReorderPoint int64
+	TriggeredAt  time.Time
+	ResolvedAt   sql.NullTime
+}
+
+type MoneyMigrationRounding struct {
+	ID                int64
+	TableName         string
+	RowID             int64
+	ColumnName        string
+	OriginalPrice     float64
+	RoundedPriceMinor int64
+}
+
+type Order struct {
+	ID         int64
+	Status     string
+	Reference  sql.NullString
+	TotalMinor int64
+	Currency   string
+	CreatedAt  time.Time
+	UpdatedAt  sql.NullTime
+}
+
+type OrderLine struct {
+	OrderID        int64
+	Line           int64
+	ProductID      int64
+	VariantID      sql.NullInt64
+	WarehouseID    sql.NullInt64
+	Quantity       int64
+	UnitPriceMinor int64
+}
+
+type OrderTransition struct {
+	ID         int64
+	OrderID    int64
+	FromStatus sql.NullString
+	ToStatus   string
+	RequestID  sql.NullString
+	CreatedAt  time.Time
+}
+
+type OutboxEvent struct {
+	ID            int64
+	AggregateType string
+	AggregateID   int64
+	EventType     string
+	Payload       string
+	RequestID     sql.NullString
+	TraceParent   sql.NullString
+	TraceState    sql.NullString
+	CreatedAt     time.Time
+	Attempts      int64
+	NextAttemptAt time.Time
+	LastError     sql.NullString
+	DispatchedAt  sql.NullTime
+}
+
+type PriceHistory struct {
+	ID            int64
+	ProductID     int64
+	ChangedAt     time.Time
+	OldPriceMinor sql.NullInt64
+	OldCurrency   sql.NullString
+	NewPriceMinor int64
+	NewCurrency   string
+}
+
+type PricingRule struct {
+	ID         int64
+	Name       string
+	Type       string
+	ProductID  sql.NullInt64
+	CategoryID sql.NullInt64
+	Currency   sql.NullString
+	StartsAt   sql.NullTime
+	EndsAt     sql.NullTime
+	CreatedAt  time.Time
+	UpdatedAt  sql.NullTime
+}
+
+type PricingRuleTier struct {
+	RuleID      int64
+	MinQuantity int64
+	Value       int64
+}
+
 type Product struct {
+	ID           int64
+	Name         string
+	Quantity     int64
+	CreatedAt    time.Time
+	UpdatedAt    sql.NullTime
+	Version      int64
+	DeletedAt    sql.NullTime
+	PriceMinor   int64
+	Currency     string
+	Sku          sql.NullString
+	Barcode      sql.NullString
+	ReorderPoint sql.NullInt64
+}
+
+type ProductCategory struct {
+	ProductID  int64
+	CategoryID int64
+}
+
+type ProductTag struct {
+	ProductID int64
+	TagID     int64
+}
+
+type ProductVariant struct {
+	ID         int64
+	ProductID  int64
+	Sku        string
+	Options    string
+	PriceMinor sql.NullInt64
+	Currency   sql.NullString
+	Quantity   int64
+	CreatedAt  time.Time
+	UpdatedAt  sql.NullTime
+	DeletedAt  sql.NullTime
+}
+
+type ProductsSearch struct {
+	Name string
+}
+
+type PurgeableProduct struct {
+	ID        int64
+	DeletedAt sql.NullTime
+}
+
+type Reservation struct {
+	ID        int64
+	Status    string
+	Reference sql.NullString
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UpdatedAt sql.NullTime
+}
+
+type ReservationItem struct {
+	ReservationID int64
+	ProductID     int64
+	VariantID     sql.NullInt64
+	Quantity      int64
+	WarehouseID   sql.NullInt64
+}
+
+type ScheduledPrice struct {
+	ID          int64
+	ProductID   int64
+	EffectiveAt time.Time
+	Status      string
+	CreatedAt   time.Time
+	AppliedAt   sql.NullTime
+	PriceMinor  int64
+	Currency    string
+}
+
+type StockMovement struct {
+	ID            int64
+	ProductID     int64
+	Delta         int64
+	QuantityAfter int64
+	Reason        string
+	Reference     sql.NullString
+	Actor         sql.NullString
+	RequestID     sql.NullString
+	CreatedAt     time.Time
+	VariantID     sql.NullInt64
+	WarehouseID   sql.NullInt64
+}
+
+type StockTransfer struct {
+	ID              int64
+	FromWarehouseID sql.NullInt64
+	ToWarehouseID   sql.NullInt64
+	Reference       sql.NullString
+	Actor           sql.NullString
+	CreatedAt       time.Time
+}
+
+type StockTransferItem struct {
+	TransferID int64
+	ProductID  int64
+	VariantID  sql.NullInt64
+	Quantity   int64
+}
+
+type Tag struct {
+	ID   int64
+	Name string
+}
+
+type Warehouse struct {
 	ID        int64
+	Code      string
 	Name      string
-	Quantity  int64
-	Price     float64
 	CreatedAt time.Time
 	UpdatedAt sql.NullTime
+	DeletedAt sql.NullTime
+}
+
+type WarehouseStock struct {
+	WarehouseID int64
+	ProductID   int64
+	VariantID   sql.NullInt64
+	Quantity    int64
+}
+
+type WebhookDelivery struct {
+	ID             int64
+	SubscriptionID int64
+	EventID        int64
+	EventType      string
+	Payload        string
+	TraceParent    sql.NullString
+	TraceState     sql.NullString
+	Status         string
+	Attempts       int64
+	NextAttemptAt  time.Time
+	ResponseStatus sql.NullInt64
+	LastError      sql.NullString
+	CreatedAt      time.Time
+	DeliveredAt    sql.NullTime
+}
+
+type WebhookSubscription struct {
+	ID         int64
+	Url        string
+	EventTypes string
+	Secret     string
+	CreatedAt  time.Time
+	UpdatedAt  sql.NullTime
 }
@@ -0,0 +1,324 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: orders.sql
+
+package product
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const countOpenOrderLines = `-- name: CountOpenOrderLines :one
+SELECT COUNT(*) FROM order_lines
+JOIN orders ON orders.id = order_lines.order_id
+WHERE orders.status IN ('pending', 'paid')
+  AND (CAST(?1 AS INTEGER) IS NULL OR order_lines.variant_id = ?1)
+  AND (CAST(?2 AS INTEGER) IS NULL OR order_lines.warehouse_id = ?2)
+`
+
+type CountOpenOrderLinesParams struct {
+	VariantID   sql.NullInt64
+	WarehouseID sql.NullInt64
+}
+
+func (q *Queries) CountOpenOrderLines(ctx context.Context, arg CountOpenOrderLinesParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countOpenOrderLines, arg.VariantID, arg.WarehouseID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countOrdersByStatus = `-- name: CountOrdersByStatus :many
+SELECT status, COUNT(*) AS count FROM orders
+GROUP BY status
+`
+
+type CountOrdersByStatusRow struct {
+	Status string
+	Count  int64
+}
+
+func (q *Queries) CountOrdersByStatus(ctx context.Context) ([]CountOrdersByStatusRow, error) {
+	rows, err := q.db.QueryContext(ctx, countOrdersByStatus)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountOrdersByStatusRow
+	for rows.Next() {
+		var i CountOrdersByStatusRow
+		if err := rows.Scan(&i.Status, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createOrder = `-- name: CreateOrder :one
+INSERT INTO orders (
+  status, reference, total_minor, currency, created_at
+) VALUES (
+  'pending', ?, ?, ?, ?
+) RETURNING id, status, reference, total_minor, currency, created_at, updated_at
+`
+
+type CreateOrderParams struct {
+	Reference  sql.NullString
+	TotalMinor int64
+	Currency   string
+	CreatedAt  time.Time
+}
+
+func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order, error) {
+	row := q.db.QueryRowContext(ctx, createOrder,
+		arg.Reference,
+		arg.TotalMinor,
+		arg.Currency,
+		arg.CreatedAt,
+	)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.Status,
+		&i.Reference,
+		&i.TotalMinor,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createOrderLine = `-- name: CreateOrderLine :exec
+INSERT INTO order_lines (
+  order_id, line, product_id, variant_id, warehouse_id, quantity, unit_price_minor
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?
+)
+`
+
+type CreateOrderLineParams struct {
+	OrderID        int64
+	Line           int64
+	ProductID      int64
+	VariantID      sql.NullInt64
+	WarehouseID    sql.NullInt64
+	Quantity       int64
+	UnitPriceMinor int64
+}
+
+func (q *Queries) CreateOrderLine(ctx context.Context, arg CreateOrderLineParams) error {
+	_, err := q.db.ExecContext(ctx, createOrderLine,
+		arg.OrderID,
+		arg.Line,
+		arg.ProductID,
+		arg.VariantID,
+		arg.WarehouseID,
+		arg.Quantity,
+		arg.UnitPriceMinor,
+	)
+	return err
+}
+
+const createOrderTransition = `-- name: CreateOrderTransition :exec
+INSERT INTO order_transitions (
+  order_id, from_status, to_status, request_id, created_at
+) VALUES (
+  ?, ?, ?, ?, ?
+)
+`
+
+type CreateOrderTransitionParams struct {
+	OrderID    int64
+	FromStatus sql.NullString
+	ToStatus   string
+	RequestID  sql.NullString
+	CreatedAt  time.Time
+}
+
+func (q *Queries) CreateOrderTransition(ctx context.Context, arg CreateOrderTransitionParams) error {
+	_, err := q.db.ExecContext(ctx, createOrderTransition,
+		arg.OrderID,
+		arg.FromStatus,
+		arg.ToStatus,
+		arg.RequestID,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const getOrder = `-- name: GetOrder :one
+SELECT id, status, reference, total_minor, currency, created_at, updated_at FROM orders
+WHERE id = ? LIMIT 1
+`
+
+func (q *Queries) GetOrder(ctx context.Context, id int64) (Order, error) {
+	row := q.db.QueryRowContext(ctx, getOrder, id)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.Status,
+		&i.Reference,
+		&i.TotalMinor,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listOrderLines = `-- name: ListOrderLines :many
+SELECT order_id, line, product_id, variant_id, warehouse_id, quantity, unit_price_minor FROM order_lines
+WHERE order_id = ?
+ORDER BY line
+`
+
+func (q *Queries) ListOrderLines(ctx context.Context, orderID int64) ([]OrderLine, error) {
+	rows, err := q.db.QueryContext(ctx, listOrderLines, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OrderLine
+	for rows.Next() {
+		var i OrderLine
+		if err := rows.Scan(
+			&i.OrderID,
+			&i.Line,
+			&i.ProductID,
+			&i.VariantID,
+			&i.WarehouseID,
+			&i.Quantity,
+			&i.UnitPriceMinor,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrderTransitions = `-- name: ListOrderTransitions :many
+SELECT id, order_id, from_status, to_status, request_id, created_at FROM order_transitions
+WHERE order_id = ?
+ORDER BY id
+`
+
+func (q *Queries) ListOrderTransitions(ctx context.Context, orderID int64) ([]OrderTransition, error) {
+	rows, err := q.db.QueryContext(ctx, listOrderTransitions, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OrderTransition
+	for rows.Next() {
+		var i OrderTransition
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.FromStatus,
+			&i.ToStatus,
+			&i.RequestID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrders = `-- name: ListOrders :many
+SELECT id, status, reference, total_minor, currency, created_at, updated_at FROM orders
+WHERE (CAST(?1 AS TEXT) IS NULL OR status = ?1)
+  AND (CAST(?2 AS INTEGER) IS NULL OR id < ?2)
+ORDER BY id DESC
+LIMIT ?3
+`
+
+type ListOrdersParams struct {
+	Status   sql.NullString
+	BeforeID sql.NullInt64
+	PageSize int64
+}
+
+func (q *Queries) ListOrders(ctx context.Context, arg ListOrdersParams) ([]Order, error) {
+	rows, err := q.db.QueryContext(ctx, listOrders, arg.Status, arg.BeforeID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.Status,
+			&i.Reference,
+			&i.TotalMinor,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const transitionOrder = `-- name: TransitionOrder :execrows
+UPDATE orders
+set status = ?1,
+updated_at = ?2
+WHERE id = ?3
+  AND status = ?4
+`
+
+type TransitionOrderParams struct {
+	ToStatus   string
+	UpdatedAt  sql.NullTime
+	ID         int64
+	FromStatus string
+}
+
+func (q *Queries) TransitionOrder(ctx context.Context, arg TransitionOrderParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, transitionOrder,
+		arg.ToStatus,
+		arg.UpdatedAt,
+		arg.ID,
+		arg.FromStatus,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
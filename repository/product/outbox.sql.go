@@ -0,0 +1,254 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: outbox.sql
+
+package product
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const countPendingOutboxEvents = `-- name: CountPendingOutboxEvents :one
+SELECT COUNT(*) FROM outbox_events
+WHERE dispatched_at IS NULL
+`
+
+func (q *Queries) CountPendingOutboxEvents(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countPendingOutboxEvents)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createOutboxEvent = `-- name: CreateOutboxEvent :exec
+INSERT INTO outbox_events (
+  aggregate_type, aggregate_id, event_type, payload, request_id, trace_parent, trace_state, created_at, next_attempt_at
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?8, ?8
+)
+`
+
+type CreateOutboxEventParams struct {
+	AggregateType string
+	AggregateID   int64
+	EventType     string
+	Payload       string
+	RequestID     sql.NullString
+	TraceParent   sql.NullString
+	TraceState    sql.NullString
+	CreatedAt     time.Time
+}
+
+func (q *Queries) CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) error {
+	_, err := q.db.ExecContext(ctx, createOutboxEvent,
+		arg.AggregateType,
+		arg.AggregateID,
+		arg.EventType,
+		arg.Payload,
+		arg.RequestID,
+		arg.TraceParent,
+		arg.TraceState,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const deleteDispatchedOutboxEvents = `-- name: DeleteDispatchedOutboxEvents :execrows
+DELETE FROM outbox_events
+WHERE dispatched_at IS NOT NULL
+  AND dispatched_at < ?1
+`
+
+func (q *Queries) DeleteDispatchedOutboxEvents(ctx context.Context, dispatchedBefore sql.NullTime) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteDispatchedOutboxEvents, dispatchedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getOldestPendingOutboxEvent = `-- name: GetOldestPendingOutboxEvent :one
+SELECT id, aggregate_type, aggregate_id, event_type, payload, request_id, trace_parent, trace_state, created_at, attempts, next_attempt_at, last_error, dispatched_at FROM outbox_events
+WHERE dispatched_at IS NULL
+ORDER BY id
+LIMIT 1
+`
+
+func (q *Queries) GetOldestPendingOutboxEvent(ctx context.Context) (OutboxEvent, error) {
+	row := q.db.QueryRowContext(ctx, getOldestPendingOutboxEvent)
+	var i OutboxEvent
+	err := row.Scan(
+		&i.ID,
+		&i.AggregateType,
+		&i.AggregateID,
+		&i.EventType,
+		&i.Payload,
+		&i.RequestID,
+		&i.TraceParent,
+		&i.TraceState,
+		&i.CreatedAt,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastError,
+		&i.DispatchedAt,
+	)
+	return i, err
+}
+
+const getOutboxEventIdRange = `-- name: GetOutboxEventIdRange :one
+SELECT CAST(COALESCE(MIN(id), 0) AS INTEGER) AS oldest_id, CAST(COALESCE(MAX(id), 0) AS INTEGER) AS latest_id
+FROM outbox_events
+`
+
+type GetOutboxEventIdRangeRow struct {
+	OldestID int64
+	LatestID int64
+}
+
+func (q *Queries) GetOutboxEventIdRange(ctx context.Context) (GetOutboxEventIdRangeRow, error) {
+	row := q.db.QueryRowContext(ctx, getOutboxEventIdRange)
+	var i GetOutboxEventIdRangeRow
+	err := row.Scan(&i.OldestID, &i.LatestID)
+	return i, err
+}
+
+const listOutboxEventsAfter = `-- name: ListOutboxEventsAfter :many
+SELECT id, aggregate_type, aggregate_id, event_type, payload, request_id, trace_parent, trace_state, created_at, attempts, next_attempt_at, last_error, dispatched_at FROM outbox_events
+WHERE aggregate_type = ?1
+  AND id > ?2
+ORDER BY id
+LIMIT ?3
+`
+
+type ListOutboxEventsAfterParams struct {
+	AggregateType string
+	AfterID       int64
+	PageSize      int64
+}
+
+func (q *Queries) ListOutboxEventsAfter(ctx context.Context, arg ListOutboxEventsAfterParams) ([]OutboxEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listOutboxEventsAfter, arg.AggregateType, arg.AfterID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OutboxEvent
+	for rows.Next() {
+		var i OutboxEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.AggregateType,
+			&i.AggregateID,
+			&i.EventType,
+			&i.Payload,
+			&i.RequestID,
+			&i.TraceParent,
+			&i.TraceState,
+			&i.CreatedAt,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastError,
+			&i.DispatchedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingOutboxEvents = `-- name: ListPendingOutboxEvents :many
+SELECT id, aggregate_type, aggregate_id, event_type, payload, request_id, trace_parent, trace_state, created_at, attempts, next_attempt_at, last_error, dispatched_at FROM outbox_events
+WHERE dispatched_at IS NULL
+  AND next_attempt_at <= ?1
+ORDER BY id
+LIMIT ?2
+`
+
+type ListPendingOutboxEventsParams struct {
+	Now      time.Time
+	PageSize int64
+}
+
+func (q *Queries) ListPendingOutboxEvents(ctx context.Context, arg ListPendingOutboxEventsParams) ([]OutboxEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listPendingOutboxEvents, arg.Now, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OutboxEvent
+	for rows.Next() {
+		var i OutboxEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.AggregateType,
+			&i.AggregateID,
+			&i.EventType,
+			&i.Payload,
+			&i.RequestID,
+			&i.TraceParent,
+			&i.TraceState,
+			&i.CreatedAt,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastError,
+			&i.DispatchedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markOutboxEventDispatched = `-- name: MarkOutboxEventDispatched :exec
+UPDATE outbox_events
+set dispatched_at = ?1,
+  attempts = attempts + 1,
+  last_error = NULL
+WHERE id = ?2
+`
+
+type MarkOutboxEventDispatchedParams struct {
+	DispatchedAt sql.NullTime
+	ID           int64
+}
+
+func (q *Queries) MarkOutboxEventDispatched(ctx context.Context, arg MarkOutboxEventDispatchedParams) error {
+	_, err := q.db.ExecContext(ctx, markOutboxEventDispatched, arg.DispatchedAt, arg.ID)
+	return err
+}
+
+const markOutboxEventFailed = `-- name: MarkOutboxEventFailed :exec
+UPDATE outbox_events
+set attempts = attempts + 1,
+  next_attempt_at = ?1,
+  last_error = ?2
+WHERE id = ?3
+`
+
+type MarkOutboxEventFailedParams struct {
+	NextAttemptAt time.Time
+	LastError     sql.NullString
+	ID            int64
+}
+
+func (q *Queries) MarkOutboxEventFailed(ctx context.Context, arg MarkOutboxEventFailedParams) error {
+	_, err := q.db.ExecContext(ctx, markOutboxEventFailed, arg.NextAttemptAt, arg.LastError, arg.ID)
+	return err
+}
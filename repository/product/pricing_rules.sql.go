@@ -0,0 +1,394 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: pricing_rules.sql
+
+package product
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const countCategoryPricingRules = `-- name: CountCategoryPricingRules :one
+SELECT COUNT(*) FROM pricing_rules
+WHERE category_id = ?
+`
+
+func (q *Queries) CountCategoryPricingRules(ctx context.Context, categoryID sql.NullInt64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countCategoryPricingRules, categoryID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createPricingRule = `-- name: CreatePricingRule :one
+INSERT INTO pricing_rules (
+  name, type, product_id, category_id, currency, starts_at, ends_at, created_at
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?
+) RETURNING id, name, type, product_id, category_id, currency, starts_at, ends_at, created_at, updated_at
+`
+
+type CreatePricingRuleParams struct {
+	Name       string
+	Type       string
+	ProductID  sql.NullInt64
+	CategoryID sql.NullInt64
+	Currency   sql.NullString
+	StartsAt   sql.NullTime
+	EndsAt     sql.NullTime
+	CreatedAt  time.Time
+}
+
+func (q *Queries) CreatePricingRule(ctx context.Context, arg CreatePricingRuleParams) (PricingRule, error) {
+	row := q.db.QueryRowContext(ctx, createPricingRule,
+		arg.Name,
+		arg.Type,
+		arg.ProductID,
+		arg.CategoryID,
+		arg.Currency,
+		arg.StartsAt,
+		arg.EndsAt,
+		arg.CreatedAt,
+	)
+	var i PricingRule
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Type,
+		&i.ProductID,
+		&i.CategoryID,
+		&i.Currency,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createPricingRuleTier = `-- name: CreatePricingRuleTier :exec
+INSERT INTO pricing_rule_tiers (
+  rule_id, min_quantity, value
+) VALUES (
+  ?, ?, ?
+)
+`
+
+type CreatePricingRuleTierParams struct {
+	RuleID      int64
+	MinQuantity int64
+	Value       int64
+}
+
+func (q *Queries) CreatePricingRuleTier(ctx context.Context, arg CreatePricingRuleTierParams) error {
+	_, err := q.db.ExecContext(ctx, createPricingRuleTier, arg.RuleID, arg.MinQuantity, arg.Value)
+	return err
+}
+
+const deletePricingRule = `-- name: DeletePricingRule :execrows
+DELETE FROM pricing_rules
+WHERE id = ?
+`
+
+func (q *Queries) DeletePricingRule(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deletePricingRule, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deletePricingRuleTiers = `-- name: DeletePricingRuleTiers :exec
+DELETE FROM pricing_rule_tiers
+WHERE rule_id = ?
+`
+
+func (q *Queries) DeletePricingRuleTiers(ctx context.Context, ruleID int64) error {
+	_, err := q.db.ExecContext(ctx, deletePricingRuleTiers, ruleID)
+	return err
+}
+
+const deletePurgeablePricingRuleTiers = `-- name: DeletePurgeablePricingRuleTiers :exec
+DELETE FROM pricing_rule_tiers
+WHERE rule_id IN (
+  SELECT id FROM pricing_rules
+  WHERE product_id IN (
+    SELECT id FROM purgeable_products
+    WHERE purgeable_products.deleted_at < ?1
+  )
+)
+`
+
+func (q *Queries) DeletePurgeablePricingRuleTiers(ctx context.Context, deletedBefore sql.NullTime) error {
+	_, err := q.db.ExecContext(ctx, deletePurgeablePricingRuleTiers, deletedBefore)
+	return err
+}
+
+const deletePurgeablePricingRules = `-- name: DeletePurgeablePricingRules :exec
+DELETE FROM pricing_rules
+WHERE product_id IN (
+  SELECT id FROM purgeable_products
+  WHERE purgeable_products.deleted_at < ?1
+)
+`
+
+func (q *Queries) DeletePurgeablePricingRules(ctx context.Context, deletedBefore sql.NullTime) error {
+	_, err := q.db.ExecContext(ctx, deletePurgeablePricingRules, deletedBefore)
+	return err
+}
+
+const getPricingRule = `-- name: GetPricingRule :one
+SELECT id, name, type, product_id, category_id, currency, starts_at, ends_at, created_at, updated_at FROM pricing_rules
+WHERE id = ? LIMIT 1
+`
+
+func (q *Queries) GetPricingRule(ctx context.Context, id int64) (PricingRule, error) {
+	row := q.db.QueryRowContext(ctx, getPricingRule, id)
+	var i PricingRule
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Type,
+		&i.ProductID,
+		&i.CategoryID,
+		&i.Currency,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listAllPricingRuleTiers = `-- name: ListAllPricingRuleTiers :many
+SELECT rule_id, min_quantity, value FROM pricing_rule_tiers
+ORDER BY rule_id, min_quantity
+`
+
+func (q *Queries) ListAllPricingRuleTiers(ctx context.Context) ([]PricingRuleTier, error) {
+	rows, err := q.db.QueryContext(ctx, listAllPricingRuleTiers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PricingRuleTier
+	for rows.Next() {
+		var i PricingRuleTier
+		if err := rows.Scan(&i.RuleID, &i.MinQuantity, &i.Value); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listApplicablePricingRules = `-- name: ListApplicablePricingRules :many
+WITH RECURSIVE ancestors AS (
+  SELECT product_categories.category_id AS id FROM product_categories
+  WHERE product_categories.product_id = CAST(?1 AS INTEGER)
+  UNION
+  SELECT categories.parent_id FROM categories
+  JOIN ancestors ON categories.id = ancestors.id
+  WHERE categories.parent_id IS NOT NULL
+)
+SELECT pricing_rules.id, pricing_rules.name, pricing_rules.type, pricing_rules.product_id, pricing_rules.category_id, pricing_rules.currency, pricing_rules.starts_at, pricing_rules.ends_at, pricing_rules.created_at, pricing_rules.updated_at, pricing_rule_tiers.min_quantity, pricing_rule_tiers.value FROM pricing_rules
+JOIN pricing_rule_tiers ON pricing_rule_tiers.rule_id = pricing_rules.id
+WHERE (pricing_rules.product_id = CAST(?1 AS INTEGER) OR pricing_rules.category_id IN (SELECT id FROM ancestors))
+  AND (pricing_rules.starts_at IS NULL OR pricing_rules.starts_at <= ?2)
+  AND (pricing_rules.ends_at IS NULL OR pricing_rules.ends_at > ?2)
+  AND pricing_rule_tiers.min_quantity = (
+    SELECT MAX(tiers.min_quantity) FROM pricing_rule_tiers AS tiers
+    WHERE tiers.rule_id = pricing_rules.id AND tiers.min_quantity <= ?3
+  )
+ORDER BY pricing_rules.id
+`
+
+type ListApplicablePricingRulesParams struct {
+	ProductID int64
+	Now       sql.NullTime
+	Quantity  int64
+}
+
+type ListApplicablePricingRulesRow struct {
+	ID          int64
+	Name        string
+	Type        string
+	ProductID   sql.NullInt64
+	CategoryID  sql.NullInt64
+	Currency    sql.NullString
+	StartsAt    sql.NullTime
+	EndsAt      sql.NullTime
+	CreatedAt   time.Time
+	UpdatedAt   sql.NullTime
+	MinQuantity int64
+	Value       int64
+}
+
+// Note : Returns the rules of a product, and of its categories and their ancestors, that are running at now
+// together with the highest tier the quantity reaches. Rules whose lowest tier is not reached are left out.
+func (q *Queries) ListApplicablePricingRules(ctx context.Context, arg ListApplicablePricingRulesParams) ([]ListApplicablePricingRulesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listApplicablePricingRules, arg.ProductID, arg.Now, arg.Quantity)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListApplicablePricingRulesRow
+	for rows.Next() {
+		var i ListApplicablePricingRulesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Type,
+			&i.ProductID,
+			&i.CategoryID,
+			&i.Currency,
+			&i.StartsAt,
+			&i.EndsAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.MinQuantity,
+			&i.Value,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPricingRuleTiers = `-- name: ListPricingRuleTiers :many
+SELECT rule_id, min_quantity, value FROM pricing_rule_tiers
+WHERE rule_id = ?
+ORDER BY min_quantity
+`
+
+func (q *Queries) ListPricingRuleTiers(ctx context.Context, ruleID int64) ([]PricingRuleTier, error) {
+	rows, err := q.db.QueryContext(ctx, listPricingRuleTiers, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PricingRuleTier
+	for rows.Next() {
+		var i PricingRuleTier
+		if err := rows.Scan(&i.RuleID, &i.MinQuantity, &i.Value); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPricingRules = `-- name: ListPricingRules :many
+SELECT id, name, type, product_id, category_id, currency, starts_at, ends_at, created_at, updated_at FROM pricing_rules
+ORDER BY id
+`
+
+func (q *Queries) ListPricingRules(ctx context.Context) ([]PricingRule, error) {
+	rows, err := q.db.QueryContext(ctx, listPricingRules)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PricingRule
+	for rows.Next() {
+		var i PricingRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Type,
+			&i.ProductID,
+			&i.CategoryID,
+			&i.Currency,
+			&i.StartsAt,
+			&i.EndsAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updatePricingRule = `-- name: UpdatePricingRule :one
+UPDATE pricing_rules
+set name = ?,
+type = ?,
+product_id = ?,
+category_id = ?,
+currency = ?,
+starts_at = ?,
+ends_at = ?,
+updated_at = ?
+WHERE id = ?
+RETURNING id, name, type, product_id, category_id, currency, starts_at, ends_at, created_at, updated_at
+`
+
+type UpdatePricingRuleParams struct {
+	Name       string
+	Type       string
+	ProductID  sql.NullInt64
+	CategoryID sql.NullInt64
+	Currency   sql.NullString
+	StartsAt   sql.NullTime
+	EndsAt     sql.NullTime
+	UpdatedAt  sql.NullTime
+	ID         int64
+}
+
+func (q *Queries) UpdatePricingRule(ctx context.Context, arg UpdatePricingRuleParams) (PricingRule, error) {
+	row := q.db.QueryRowContext(ctx, updatePricingRule,
+		arg.Name,
+		arg.Type,
+		arg.ProductID,
+		arg.CategoryID,
+		arg.Currency,
+		arg.StartsAt,
+		arg.EndsAt,
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	var i PricingRule
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Type,
+		&i.ProductID,
+		&i.CategoryID,
+		&i.Currency,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
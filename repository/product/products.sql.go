@@ -13,24 +13,32 @@ import (
 
 const createProduct = `-- name: CreateProduct :one
 INSERT INTO products (
-  name, quantity, price, created_at
+  name, quantity, price_minor, currency, sku, barcode, reorder_point, created_at
 ) VALUES (
-  ?, ?, ?, ?
-) RETURNING id, name, quantity, price, created_at, updated_at
+  ?, ?, ?, ?, ?, ?, ?, ?
+) RETURNING id, name, quantity, created_at, updated_at, version, deleted_at, price_minor, currency, sku, barcode, reorder_point
 `
 
 type CreateProductParams struct {
-	Name      string
-	Quantity  int64
-	Price     float64
-	CreatedAt time.Time
+	Name         string
+	Quantity     int64
+	PriceMinor   int64
+	Currency     string
+	Sku          sql.NullString
+	Barcode      sql.NullString
+	ReorderPoint sql.NullInt64
+	CreatedAt    time.Time
 }
 
 func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (Product, error) {
 	row := q.db.QueryRowContext(ctx, createProduct,
 		arg.Name,
 		arg.Quantity,
-		arg.Price,
+		arg.PriceMinor,
+		arg.Currency,
+		arg.Sku,
+		arg.Barcode,
+		arg.ReorderPoint,
 		arg.CreatedAt,
 	)
 	var i Product
@@ -38,26 +46,94 @@ func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (P
 		&i.ID,
 		&i.Name,
 		&i.Quantity,
-		&i.Price,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.PriceMinor,
+		&i.Currency,
+		&i.Sku,
+		&i.Barcode,
+		&i.ReorderPoint,
 	)
 	return i, err
 }
 
-const deleteProduct = `-- name: DeleteProduct :exec
-DELETE FROM products
-WHERE id = ?
+const deleteProduct = `-- name: DeleteProduct :execrows
+UPDATE products
+set deleted_at = ?1,
+version = version + 1
+WHERE id = ?2
+  AND deleted_at IS NULL
+  AND (CAST(?3 AS INTEGER) IS NULL OR version = ?3)
 `
 
-func (q *Queries) DeleteProduct(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteProduct, id)
-	return err
+type DeleteProductParams struct {
+	DeletedAt       sql.NullTime
+	ID              int64
+	ExpectedVersion sql.NullInt64
+}
+
+func (q *Queries) DeleteProduct(ctx context.Context, arg DeleteProductParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteProduct, arg.DeletedAt, arg.ID, arg.ExpectedVersion)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const exportProducts = `-- name: ExportProducts :many
+SELECT id, name, quantity, created_at, updated_at, version, deleted_at, price_minor, currency, sku, barcode, reorder_point FROM products
+WHERE deleted_at IS NULL
+  AND id > ?1
+ORDER BY id
+LIMIT ?2
+`
+
+type ExportProductsParams struct {
+	AfterID int64
+	Limit   int64
+}
+
+func (q *Queries) ExportProducts(ctx context.Context, arg ExportProductsParams) ([]Product, error) {
+	rows, err := q.db.QueryContext(ctx, exportProducts, arg.AfterID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Product
+	for rows.Next() {
+		var i Product
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Quantity,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.DeletedAt,
+			&i.PriceMinor,
+			&i.Currency,
+			&i.Sku,
+			&i.Barcode,
+			&i.ReorderPoint,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const getProduct = `-- name: GetProduct :one
-SELECT id, name, quantity, price, created_at, updated_at FROM products
-WHERE id = ? LIMIT 1
+SELECT id, name, quantity, created_at, updated_at, version, deleted_at, price_minor, currency, sku, barcode, reorder_point FROM products
+WHERE id = ? AND deleted_at IS NULL LIMIT 1
 `
 
 func (q *Queries) GetProduct(ctx context.Context, id int64) (Product, error) {
@@ -67,34 +143,182 @@ func (q *Queries) GetProduct(ctx context.Context, id int64) (Product, error) {
 		&i.ID,
 		&i.Name,
 		&i.Quantity,
-		&i.Price,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.PriceMinor,
+		&i.Currency,
+		&i.Sku,
+		&i.Barcode,
+		&i.ReorderPoint,
+	)
+	return i, err
+}
+
+const getProductByBarcode = `-- name: GetProductByBarcode :one
+SELECT id, name, quantity, created_at, updated_at, version, deleted_at, price_minor, currency, sku, barcode, reorder_point FROM products
+WHERE barcode = ? AND deleted_at IS NULL LIMIT 1
+`
+
+func (q *Queries) GetProductByBarcode(ctx context.Context, barcode sql.NullString) (Product, error) {
+	row := q.db.QueryRowContext(ctx, getProductByBarcode, barcode)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Quantity,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.PriceMinor,
+		&i.Currency,
+		&i.Sku,
+		&i.Barcode,
+		&i.ReorderPoint,
+	)
+	return i, err
+}
+
+const getProductBySku = `-- name: GetProductBySku :one
+SELECT id, name, quantity, created_at, updated_at, version, deleted_at, price_minor, currency, sku, barcode, reorder_point FROM products
+WHERE sku = ? COLLATE NOCASE AND deleted_at IS NULL LIMIT 1
+`
+
+func (q *Queries) GetProductBySku(ctx context.Context, sku sql.NullString) (Product, error) {
+	row := q.db.QueryRowContext(ctx, getProductBySku, sku)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Quantity,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.PriceMinor,
+		&i.Currency,
+		&i.Sku,
+		&i.Barcode,
+		&i.ReorderPoint,
+	)
+	return i, err
+}
+
+const getProductIncludingDeleted = `-- name: GetProductIncludingDeleted :one
+SELECT id, name, quantity, created_at, updated_at, version, deleted_at, price_minor, currency, sku, barcode, reorder_point FROM products
+WHERE id = ? LIMIT 1
+`
+
+func (q *Queries) GetProductIncludingDeleted(ctx context.Context, id int64) (Product, error) {
+	row := q.db.QueryRowContext(ctx, getProductIncludingDeleted, id)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Quantity,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.PriceMinor,
+		&i.Currency,
+		&i.Sku,
+		&i.Barcode,
+		&i.ReorderPoint,
 	)
 	return i, err
 }
 
-const getProducts = `-- name: GetProducts :many
-SELECT id, name, quantity, price, created_at, updated_at FROM products
-ORDER BY name
+const listProductsAscending = `-- name: ListProductsAscending :many
+SELECT products.id, products.name, products.quantity, products.created_at, products.updated_at, products.version, products.deleted_at, products.price_minor, products.currency, products.sku, products.barcode, products.reorder_point,
+  CASE CAST(?1 AS TEXT)
+    WHEN 'price' THEN price_minor
+    WHEN 'quantity' THEN quantity
+    WHEN 'created_at' THEN created_at
+    ELSE name
+  END AS sort_key
+FROM products
+WHERE (CAST(?2 AS BOOLEAN) OR deleted_at IS NULL)
+  AND (CAST(?3 AS TEXT) IS NULL OR name LIKE '%' || ?3 || '%')
+  AND (CAST(?4 AS TEXT) IS NULL OR currency = ?4)
+  AND (CAST(?5 AS INTEGER) IS NULL OR price_minor >= ?5)
+  AND (CAST(?6 AS INTEGER) IS NULL OR price_minor <= ?6)
+  AND (CAST(?7 AS BOOLEAN) IS NULL OR (quantity > 0) = ?7)
+  AND (CAST(?8 AS TEXT) IS NULL OR id IN (
+    SELECT product_tags.product_id FROM product_tags
+    JOIN tags ON tags.id = product_tags.tag_id
+    WHERE instr(',' || ?8 || ',', ',' || tags.name || ',') > 0
+    GROUP BY product_tags.product_id
+    HAVING COUNT(*) = CAST(?9 AS INTEGER)))
+  AND (?10 IS NULL OR (
+    CASE ?1
+      WHEN 'price' THEN price_minor
+      WHEN 'quantity' THEN quantity
+      WHEN 'created_at' THEN created_at
+      ELSE name
+    END, id) > (?10, CAST(?11 AS INTEGER)))
+ORDER BY sort_key ASC, id ASC
+LIMIT ?12
 `
 
-func (q *Queries) GetProducts(ctx context.Context) ([]Product, error) {
-	rows, err := q.db.QueryContext(ctx, getProducts)
+type ListProductsAscendingParams struct {
+	SortBy         string
+	IncludeDeleted bool
+	NameContains   sql.NullString
+	Currency       sql.NullString
+	MinPriceMinor  sql.NullInt64
+	MaxPriceMinor  sql.NullInt64
+	InStock        sql.NullBool
+	Tags           sql.NullString
+	TagCount       int64
+	CursorValue    interface{}
+	CursorID       int64
+	PageSize       int64
+}
+
+type ListProductsAscendingRow struct {
+	Product Product
+	SortKey interface{}
+}
+
+func (q *Queries) ListProductsAscending(ctx context.Context, arg ListProductsAscendingParams) ([]ListProductsAscendingRow, error) {
+	rows, err := q.db.QueryContext(ctx, listProductsAscending,
+		arg.SortBy,
+		arg.IncludeDeleted,
+		arg.NameContains,
+		arg.Currency,
+		arg.MinPriceMinor,
+		arg.MaxPriceMinor,
+		arg.InStock,
+		arg.Tags,
+		arg.TagCount,
+		arg.CursorValue,
+		arg.CursorID,
+		arg.PageSize,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Product
+	var items []ListProductsAscendingRow
 	for rows.Next() {
-		var i Product
+		var i ListProductsAscendingRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.Name,
-			&i.Quantity,
-			&i.Price,
-			&i.CreatedAt,
-			&i.UpdatedAt,
+			&i.Product.ID,
+			&i.Product.Name,
+			&i.Product.Quantity,
+			&i.Product.CreatedAt,
+			&i.Product.UpdatedAt,
+			&i.Product.Version,
+			&i.Product.DeletedAt,
+			&i.Product.PriceMinor,
+			&i.Product.Currency,
+			&i.Product.Sku,
+			&i.Product.Barcode,
+			&i.Product.ReorderPoint,
+			&i.SortKey,
 		); err != nil {
 			return nil, err
 		}
@@ -109,30 +333,344 @@ func (q *Queries) GetProducts(ctx context.Context) ([]Product, error) {
 	return items, nil
 }
 
-const updateProduct = `-- name: UpdateProduct :exec
+const listProductsDescending = `-- name: ListProductsDescending :many
+SELECT products.id, products.name, products.quantity, products.created_at, products.updated_at, products.version, products.deleted_at, products.price_minor, products.currency, products.sku, products.barcode, products.reorder_point,
+  CASE CAST(?1 AS TEXT)
+    WHEN 'price' THEN price_minor
+    WHEN 'quantity' THEN quantity
+    WHEN 'created_at' THEN created_at
+    ELSE name
+  END AS sort_key
+FROM products
+WHERE (CAST(?2 AS BOOLEAN) OR deleted_at IS NULL)
+  AND (CAST(?3 AS TEXT) IS NULL OR name LIKE '%' || ?3 || '%')
+  AND (CAST(?4 AS TEXT) IS NULL OR currency = ?4)
+  AND (CAST(?5 AS INTEGER) IS NULL OR price_minor >= ?5)
+  AND (CAST(?6 AS INTEGER) IS NULL OR price_minor <= ?6)
+  AND (CAST(?7 AS BOOLEAN) IS NULL OR (quantity > 0) = ?7)
+  AND (CAST(?8 AS TEXT) IS NULL OR id IN (
+    SELECT product_tags.product_id FROM product_tags
+    JOIN tags ON tags.id = product_tags.tag_id
+    WHERE instr(',' || ?8 || ',', ',' || tags.name || ',') > 0
+    GROUP BY product_tags.product_id
+    HAVING COUNT(*) = CAST(?9 AS INTEGER)))
+  AND (?10 IS NULL OR (
+    CASE ?1
+      WHEN 'price' THEN price_minor
+      WHEN 'quantity' THEN quantity
+      WHEN 'created_at' THEN created_at
+      ELSE name
+    END, id) < (?10, CAST(?11 AS INTEGER)))
+ORDER BY sort_key DESC, id DESC
+LIMIT ?12
+`
+
+type ListProductsDescendingParams struct {
+	SortBy         string
+	IncludeDeleted bool
+	NameContains   sql.NullString
+	Currency       sql.NullString
+	MinPriceMinor  sql.NullInt64
+	MaxPriceMinor  sql.NullInt64
+	InStock        sql.NullBool
+	Tags           sql.NullString
+	TagCount       int64
+	CursorValue    interface{}
+	CursorID       int64
+	PageSize       int64
+}
+
+type ListProductsDescendingRow struct {
+	Product Product
+	SortKey interface{}
+}
+
+func (q *Queries) ListProductsDescending(ctx context.Context, arg ListProductsDescendingParams) ([]ListProductsDescendingRow, error) {
+	rows, err := q.db.QueryContext(ctx, listProductsDescending,
+		arg.SortBy,
+		arg.IncludeDeleted,
+		arg.NameContains,
+		arg.Currency,
+		arg.MinPriceMinor,
+		arg.MaxPriceMinor,
+		arg.InStock,
+		arg.Tags,
+		arg.TagCount,
+		arg.CursorValue,
+		arg.CursorID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListProductsDescendingRow
+	for rows.Next() {
+		var i ListProductsDescendingRow
+		if err := rows.Scan(
+			&i.Product.ID,
+			&i.Product.Name,
+			&i.Product.Quantity,
+			&i.Product.CreatedAt,
+			&i.Product.UpdatedAt,
+			&i.Product.Version,
+			&i.Product.DeletedAt,
+			&i.Product.PriceMinor,
+			&i.Product.Currency,
+			&i.Product.Sku,
+			&i.Product.Barcode,
+			&i.Product.ReorderPoint,
+			&i.SortKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const patchProduct = `-- name: PatchProduct :one
 UPDATE products
-set name = ?,
-quantity = ?,
-price = ?,
-updated_at = ?
-WHERE id = ?
+set name = COALESCE(CAST(?1 AS TEXT), name),
+quantity = COALESCE(CAST(?2 AS INTEGER), quantity),
+price_minor = COALESCE(CAST(?3 AS INTEGER), price_minor),
+currency = COALESCE(CAST(?4 AS TEXT), currency),
+sku = CASE WHEN CAST(?5 AS BOOLEAN) THEN NULL ELSE COALESCE(CAST(?6 AS TEXT), sku) END,
+barcode = CASE WHEN CAST(?7 AS BOOLEAN) THEN NULL ELSE COALESCE(CAST(?8 AS TEXT), barcode) END,
+reorder_point = CASE WHEN CAST(?9 AS BOOLEAN) THEN NULL ELSE COALESCE(CAST(?10 AS INTEGER), reorder_point) END,
+updated_at = ?11,
+version = version + 1
+WHERE id = ?12
+  AND deleted_at IS NULL
+  AND (CAST(?13 AS INTEGER) IS NULL OR version = ?13)
+RETURNING id, name, quantity, created_at, updated_at, version, deleted_at, price_minor, currency, sku, barcode, reorder_point
 `
 
-type UpdateProductParams struct {
-	Name      string
-	Quantity  int64
-	Price     float64
+type PatchProductParams struct {
+	Name              sql.NullString
+	Quantity          sql.NullInt64
+	PriceMinor        sql.NullInt64
+	Currency          sql.NullString
+	ClearSku          bool
+	Sku               sql.NullString
+	ClearBarcode      bool
+	Barcode           sql.NullString
+	ClearReorderPoint bool
+	ReorderPoint      sql.NullInt64
+	UpdatedAt         sql.NullTime
+	ID                int64
+	ExpectedVersion   sql.NullInt64
+}
+
+func (q *Queries) PatchProduct(ctx context.Context, arg PatchProductParams) (Product, error) {
+	row := q.db.QueryRowContext(ctx, patchProduct,
+		arg.Name,
+		arg.Quantity,
+		arg.PriceMinor,
+		arg.Currency,
+		arg.ClearSku,
+		arg.Sku,
+		arg.ClearBarcode,
+		arg.Barcode,
+		arg.ClearReorderPoint,
+		arg.ReorderPoint,
+		arg.UpdatedAt,
+		arg.ID,
+		arg.ExpectedVersion,
+	)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Quantity,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.PriceMinor,
+		&i.Currency,
+		&i.Sku,
+		&i.Barcode,
+		&i.ReorderPoint,
+	)
+	return i, err
+}
+
+const purgeDeletedProducts = `-- name: PurgeDeletedProducts :execrows
+DELETE FROM products
+WHERE id IN (
+  SELECT id FROM purgeable_products
+  WHERE purgeable_products.deleted_at < ?1
+)
+`
+
+func (q *Queries) PurgeDeletedProducts(ctx context.Context, deletedBefore sql.NullTime) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeDeletedProducts, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreProduct = `-- name: RestoreProduct :one
+UPDATE products
+set deleted_at = NULL,
+updated_at = ?1,
+version = version + 1
+WHERE id = ?2
+  AND deleted_at IS NOT NULL
+RETURNING id, name, quantity, created_at, updated_at, version, deleted_at, price_minor, currency, sku, barcode, reorder_point
+`
+
+type RestoreProductParams struct {
 	UpdatedAt sql.NullTime
 	ID        int64
 }
 
-func (q *Queries) UpdateProduct(ctx context.Context, arg UpdateProductParams) error {
-	_, err := q.db.ExecContext(ctx, updateProduct,
+func (q *Queries) RestoreProduct(ctx context.Context, arg RestoreProductParams) (Product, error) {
+	row := q.db.QueryRowContext(ctx, restoreProduct, arg.UpdatedAt, arg.ID)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Quantity,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.PriceMinor,
+		&i.Currency,
+		&i.Sku,
+		&i.Barcode,
+		&i.ReorderPoint,
+	)
+	return i, err
+}
+
+const searchProducts = `-- name: SearchProducts :many
+SELECT products.id, products.name, products.quantity, products.created_at, products.updated_at, products.version, products.deleted_at, products.price_minor, products.currency, products.sku, products.barcode, products.reorder_point,
+  snippet(products_search, 0, char(2), char(3), '...', 16) AS highlight,
+  bm25(products_search) AS score
+FROM products_search
+JOIN products ON products.id = products_search.rowid
+WHERE products_search.name MATCH ?1
+  AND products.deleted_at IS NULL
+ORDER BY score
+LIMIT ?2
+`
+
+type SearchProductsParams struct {
+	Query    string
+	PageSize int64
+}
+
+type SearchProductsRow struct {
+	Product   Product
+	Highlight string
+	Score     float64
+}
+
+func (q *Queries) SearchProducts(ctx context.Context, arg SearchProductsParams) ([]SearchProductsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchProducts, arg.Query, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchProductsRow
+	for rows.Next() {
+		var i SearchProductsRow
+		if err := rows.Scan(
+			&i.Product.ID,
+			&i.Product.Name,
+			&i.Product.Quantity,
+			&i.Product.CreatedAt,
+			&i.Product.UpdatedAt,
+			&i.Product.Version,
+			&i.Product.DeletedAt,
+			&i.Product.PriceMinor,
+			&i.Product.Currency,
+			&i.Product.Sku,
+			&i.Product.Barcode,
+			&i.Product.ReorderPoint,
+			&i.Highlight,
+			&i.Score,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateProduct = `-- name: UpdateProduct :one
+UPDATE products
+set name = ?1,
+quantity = ?2,
+price_minor = ?3,
+currency = ?4,
+sku = ?5,
+barcode = ?6,
+reorder_point = ?7,
+updated_at = ?8,
+version = version + 1
+WHERE id = ?9
+  AND deleted_at IS NULL
+  AND (CAST(?10 AS INTEGER) IS NULL OR version = ?10)
+RETURNING id, name, quantity, created_at, updated_at, version, deleted_at, price_minor, currency, sku, barcode, reorder_point
+`
+
+type UpdateProductParams struct {
+	Name            string
+	Quantity        int64
+	PriceMinor      int64
+	Currency        string
+	Sku             sql.NullString
+	Barcode         sql.NullString
+	ReorderPoint    sql.NullInt64
+	UpdatedAt       sql.NullTime
+	ID              int64
+	ExpectedVersion sql.NullInt64
+}
+
+func (q *Queries) UpdateProduct(ctx context.Context, arg UpdateProductParams) (Product, error) {
+	row := q.db.QueryRowContext(ctx, updateProduct,
 		arg.Name,
 		arg.Quantity,
-		arg.Price,
+		arg.PriceMinor,
+		arg.Currency,
+		arg.Sku,
+		arg.Barcode,
+		arg.ReorderPoint,
 		arg.UpdatedAt,
 		arg.ID,
+		arg.ExpectedVersion,
+	)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Quantity,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.PriceMinor,
+		&i.Currency,
+		&i.Sku,
+		&i.Barcode,
+		&i.ReorderPoint,
 	)
-	return err
+	return i, err
 }
@@ -0,0 +1,510 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: warehouses.sql
+
+package product
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const addWarehouseStock = `-- name: AddWarehouseStock :one
+INSERT INTO warehouse_stock (
+  warehouse_id, product_id, variant_id, quantity
+) VALUES (
+  ?, ?, ?, ?
+)
+ON CONFLICT (warehouse_id, product_id, COALESCE(variant_id, 0)) DO UPDATE SET quantity = quantity + excluded.quantity
+RETURNING warehouse_id, product_id, variant_id, quantity
+`
+
+type AddWarehouseStockParams struct {
+	WarehouseID int64
+	ProductID   int64
+	VariantID   sql.NullInt64
+	Quantity    int64
+}
+
+func (q *Queries) AddWarehouseStock(ctx context.Context, arg AddWarehouseStockParams) (WarehouseStock, error) {
+	row := q.db.QueryRowContext(ctx, addWarehouseStock,
+		arg.WarehouseID,
+		arg.ProductID,
+		arg.VariantID,
+		arg.Quantity,
+	)
+	var i WarehouseStock
+	err := row.Scan(
+		&i.WarehouseID,
+		&i.ProductID,
+		&i.VariantID,
+		&i.Quantity,
+	)
+	return i, err
+}
+
+const countActiveWarehouseReservations = `-- name: CountActiveWarehouseReservations :one
+SELECT COUNT(*) FROM reservation_items
+JOIN reservations ON reservations.id = reservation_items.reservation_id
+WHERE reservations.status = 'active'
+  AND reservation_items.warehouse_id = ?
+`
+
+func (q *Queries) CountActiveWarehouseReservations(ctx context.Context, warehouseID sql.NullInt64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countActiveWarehouseReservations, warehouseID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countAllocatedStock = `-- name: CountAllocatedStock :one
+SELECT CAST(COALESCE(SUM(quantity), 0) AS INTEGER) FROM warehouse_stock
+WHERE product_id = ?1
+  AND COALESCE(variant_id, 0) = CAST(?2 AS INTEGER)
+`
+
+type CountAllocatedStockParams struct {
+	ProductID  int64
+	VariantKey int64
+}
+
+func (q *Queries) CountAllocatedStock(ctx context.Context, arg CountAllocatedStockParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAllocatedStock, arg.ProductID, arg.VariantKey)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const countWarehouseStock = `-- name: CountWarehouseStock :one
+SELECT CAST(COALESCE(SUM(quantity), 0) AS INTEGER) FROM warehouse_stock
+WHERE warehouse_id = ?
+`
+
+func (q *Queries) CountWarehouseStock(ctx context.Context, warehouseID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countWarehouseStock, warehouseID)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const createStockTransfer = `-- name: CreateStockTransfer :one
+INSERT INTO stock_transfers (
+  from_warehouse_id, to_warehouse_id, reference, actor, created_at
+) VALUES (
+  ?, ?, ?, ?, ?
+) RETURNING id, from_warehouse_id, to_warehouse_id, reference, actor, created_at
+`
+
+type CreateStockTransferParams struct {
+	FromWarehouseID sql.NullInt64
+	ToWarehouseID   sql.NullInt64
+	Reference       sql.NullString
+	Actor           sql.NullString
+	CreatedAt       time.Time
+}
+
+func (q *Queries) CreateStockTransfer(ctx context.Context, arg CreateStockTransferParams) (StockTransfer, error) {
+	row := q.db.QueryRowContext(ctx, createStockTransfer,
+		arg.FromWarehouseID,
+		arg.ToWarehouseID,
+		arg.Reference,
+		arg.Actor,
+		arg.CreatedAt,
+	)
+	var i StockTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromWarehouseID,
+		&i.ToWarehouseID,
+		&i.Reference,
+		&i.Actor,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createStockTransferItem = `-- name: CreateStockTransferItem :exec
+INSERT INTO stock_transfer_items (
+  transfer_id, product_id, variant_id, quantity
+) VALUES (
+  ?, ?, ?, ?
+)
+`
+
+type CreateStockTransferItemParams struct {
+	TransferID int64
+	ProductID  int64
+	VariantID  sql.NullInt64
+	Quantity   int64
+}
+
+func (q *Queries) CreateStockTransferItem(ctx context.Context, arg CreateStockTransferItemParams) error {
+	_, err := q.db.ExecContext(ctx, createStockTransferItem,
+		arg.TransferID,
+		arg.ProductID,
+		arg.VariantID,
+		arg.Quantity,
+	)
+	return err
+}
+
+const createWarehouse = `-- name: CreateWarehouse :one
+INSERT INTO warehouses (
+  code, name, created_at
+) VALUES (
+  ?, ?, ?
+) RETURNING id, code, name, created_at, updated_at, deleted_at
+`
+
+type CreateWarehouseParams struct {
+	Code      string
+	Name      string
+	CreatedAt time.Time
+}
+
+func (q *Queries) CreateWarehouse(ctx context.Context, arg CreateWarehouseParams) (Warehouse, error) {
+	row := q.db.QueryRowContext(ctx, createWarehouse, arg.Code, arg.Name, arg.CreatedAt)
+	var i Warehouse
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const deleteEmptyWarehouseStock = `-- name: DeleteEmptyWarehouseStock :exec
+DELETE FROM warehouse_stock
+WHERE warehouse_id = ? AND quantity = 0
+`
+
+func (q *Queries) DeleteEmptyWarehouseStock(ctx context.Context, warehouseID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteEmptyWarehouseStock, warehouseID)
+	return err
+}
+
+const deletePurgeableWarehouseStock = `-- name: DeletePurgeableWarehouseStock :exec
+DELETE FROM warehouse_stock
+WHERE product_id IN (
+  SELECT id FROM purgeable_products
+  WHERE purgeable_products.deleted_at < ?1
+)
+`
+
+func (q *Queries) DeletePurgeableWarehouseStock(ctx context.Context, deletedBefore sql.NullTime) error {
+	_, err := q.db.ExecContext(ctx, deletePurgeableWarehouseStock, deletedBefore)
+	return err
+}
+
+const deleteVariantWarehouseStock = `-- name: DeleteVariantWarehouseStock :exec
+DELETE FROM warehouse_stock
+WHERE product_id = ? AND variant_id = ?
+`
+
+type DeleteVariantWarehouseStockParams struct {
+	ProductID int64
+	VariantID sql.NullInt64
+}
+
+func (q *Queries) DeleteVariantWarehouseStock(ctx context.Context, arg DeleteVariantWarehouseStockParams) error {
+	_, err := q.db.ExecContext(ctx, deleteVariantWarehouseStock, arg.ProductID, arg.VariantID)
+	return err
+}
+
+const deleteWarehouse = `-- name: DeleteWarehouse :execrows
+UPDATE warehouses
+set deleted_at = ?
+WHERE id = ? AND deleted_at IS NULL
+`
+
+type DeleteWarehouseParams struct {
+	DeletedAt sql.NullTime
+	ID        int64
+}
+
+func (q *Queries) DeleteWarehouse(ctx context.Context, arg DeleteWarehouseParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteWarehouse, arg.DeletedAt, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getStockTransfer = `-- name: GetStockTransfer :one
+SELECT id, from_warehouse_id, to_warehouse_id, reference, actor, created_at FROM stock_transfers
+WHERE id = ? LIMIT 1
+`
+
+func (q *Queries) GetStockTransfer(ctx context.Context, id int64) (StockTransfer, error) {
+	row := q.db.QueryRowContext(ctx, getStockTransfer, id)
+	var i StockTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromWarehouseID,
+		&i.ToWarehouseID,
+		&i.Reference,
+		&i.Actor,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getWarehouse = `-- name: GetWarehouse :one
+SELECT id, code, name, created_at, updated_at, deleted_at FROM warehouses
+WHERE id = ? AND deleted_at IS NULL LIMIT 1
+`
+
+func (q *Queries) GetWarehouse(ctx context.Context, id int64) (Warehouse, error) {
+	row := q.db.QueryRowContext(ctx, getWarehouse, id)
+	var i Warehouse
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getWarehouseStockQuantity = `-- name: GetWarehouseStockQuantity :one
+SELECT CAST(COALESCE(SUM(quantity), 0) AS INTEGER) FROM warehouse_stock
+WHERE warehouse_id = ?1
+  AND product_id = ?2
+  AND COALESCE(variant_id, 0) = CAST(?3 AS INTEGER)
+`
+
+type GetWarehouseStockQuantityParams struct {
+	WarehouseID int64
+	ProductID   int64
+	VariantKey  int64
+}
+
+func (q *Queries) GetWarehouseStockQuantity(ctx context.Context, arg GetWarehouseStockQuantityParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getWarehouseStockQuantity, arg.WarehouseID, arg.ProductID, arg.VariantKey)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const listProductStockLocations = `-- name: ListProductStockLocations :many
+SELECT warehouses.id, warehouses.code, CAST(SUM(warehouse_stock.quantity) AS INTEGER) AS quantity
+FROM warehouse_stock
+JOIN warehouses ON warehouses.id = warehouse_stock.warehouse_id
+WHERE warehouse_stock.product_id = ?
+GROUP BY warehouses.id, warehouses.code
+HAVING SUM(warehouse_stock.quantity) > 0
+ORDER BY warehouses.code
+`
+
+type ListProductStockLocationsRow struct {
+	ID       int64
+	Code     string
+	Quantity int64
+}
+
+func (q *Queries) ListProductStockLocations(ctx context.Context, productID int64) ([]ListProductStockLocationsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listProductStockLocations, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListProductStockLocationsRow
+	for rows.Next() {
+		var i ListProductStockLocationsRow
+		if err := rows.Scan(&i.ID, &i.Code, &i.Quantity); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStockTransferItems = `-- name: ListStockTransferItems :many
+SELECT transfer_id, product_id, variant_id, quantity FROM stock_transfer_items
+WHERE transfer_id = ?
+ORDER BY product_id, variant_id
+`
+
+func (q *Queries) ListStockTransferItems(ctx context.Context, transferID int64) ([]StockTransferItem, error) {
+	rows, err := q.db.QueryContext(ctx, listStockTransferItems, transferID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []StockTransferItem
+	for rows.Next() {
+		var i StockTransferItem
+		if err := rows.Scan(
+			&i.TransferID,
+			&i.ProductID,
+			&i.VariantID,
+			&i.Quantity,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWarehouseStockLevels = `-- name: ListWarehouseStockLevels :many
+SELECT warehouses.id, warehouses.code, CAST(COALESCE(SUM(warehouse_stock.quantity), 0) AS INTEGER) AS quantity
+FROM warehouses
+LEFT JOIN warehouse_stock ON warehouse_stock.warehouse_id = warehouses.id
+WHERE warehouses.deleted_at IS NULL
+GROUP BY warehouses.id, warehouses.code
+ORDER BY warehouses.code
+`
+
+type ListWarehouseStockLevelsRow struct {
+	ID       int64
+	Code     string
+	Quantity int64
+}
+
+func (q *Queries) ListWarehouseStockLevels(ctx context.Context) ([]ListWarehouseStockLevelsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listWarehouseStockLevels)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListWarehouseStockLevelsRow
+	for rows.Next() {
+		var i ListWarehouseStockLevelsRow
+		if err := rows.Scan(&i.ID, &i.Code, &i.Quantity); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWarehouses = `-- name: ListWarehouses :many
+SELECT id, code, name, created_at, updated_at, deleted_at FROM warehouses
+WHERE deleted_at IS NULL
+ORDER BY code
+`
+
+func (q *Queries) ListWarehouses(ctx context.Context) ([]Warehouse, error) {
+	rows, err := q.db.QueryContext(ctx, listWarehouses)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Warehouse
+	for rows.Next() {
+		var i Warehouse
+		if err := rows.Scan(
+			&i.ID,
+			&i.Code,
+			&i.Name,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const removeWarehouseStock = `-- name: RemoveWarehouseStock :one
+UPDATE warehouse_stock
+set quantity = quantity - ?1
+WHERE warehouse_id = ?2
+  AND product_id = ?3
+  AND COALESCE(variant_id, 0) = CAST(?4 AS INTEGER)
+  AND quantity >= ?1
+RETURNING warehouse_id, product_id, variant_id, quantity
+`
+
+type RemoveWarehouseStockParams struct {
+	Quantity    int64
+	WarehouseID int64
+	ProductID   int64
+	VariantKey  int64
+}
+
+func (q *Queries) RemoveWarehouseStock(ctx context.Context, arg RemoveWarehouseStockParams) (WarehouseStock, error) {
+	row := q.db.QueryRowContext(ctx, removeWarehouseStock,
+		arg.Quantity,
+		arg.WarehouseID,
+		arg.ProductID,
+		arg.VariantKey,
+	)
+	var i WarehouseStock
+	err := row.Scan(
+		&i.WarehouseID,
+		&i.ProductID,
+		&i.VariantID,
+		&i.Quantity,
+	)
+	return i, err
+}
+
+const updateWarehouse = `-- name: UpdateWarehouse :one
+UPDATE warehouses
+set code = ?,
+name = ?,
+updated_at = ?
+WHERE id = ? AND deleted_at IS NULL
+RETURNING id, code, name, created_at, updated_at, deleted_at
+`
+
+type UpdateWarehouseParams struct {
+	Code      string
+	Name      string
+	UpdatedAt sql.NullTime
+	ID        int64
+}
+
+func (q *Queries) UpdateWarehouse(ctx context.Context, arg UpdateWarehouseParams) (Warehouse, error) {
+	row := q.db.QueryRowContext(ctx, updateWarehouse,
+		arg.Code,
+		arg.Name,
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	var i Warehouse
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
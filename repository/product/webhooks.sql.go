@@ -0,0 +1,461 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: webhooks.sql
+
+package product
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const countWebhookDeliveriesByStatus = `-- name: CountWebhookDeliveriesByStatus :many
+SELECT status, COUNT(*) AS deliveries
+FROM webhook_deliveries
+GROUP BY status
+`
+
+type CountWebhookDeliveriesByStatusRow struct {
+	Status     string
+	Deliveries int64
+}
+
+func (q *Queries) CountWebhookDeliveriesByStatus(ctx context.Context) ([]CountWebhookDeliveriesByStatusRow, error) {
+	rows, err := q.db.QueryContext(ctx, countWebhookDeliveriesByStatus)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountWebhookDeliveriesByStatusRow
+	for rows.Next() {
+		var i CountWebhookDeliveriesByStatusRow
+		if err := rows.Scan(&i.Status, &i.Deliveries); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createWebhookDeliveries = `-- name: CreateWebhookDeliveries :execrows
+INSERT INTO webhook_deliveries (
+  subscription_id, event_id, event_type, payload, trace_parent, trace_state, next_attempt_at, created_at
+)
+SELECT webhook_subscriptions.id, ?1, ?2, ?3,
+  ?4, ?5, ?6, ?6
+FROM webhook_subscriptions
+WHERE EXISTS (
+  SELECT 1 FROM json_each(webhook_subscriptions.event_types)
+  WHERE json_each.value = ?2)
+ON CONFLICT DO NOTHING
+`
+
+type CreateWebhookDeliveriesParams struct {
+	EventID     int64
+	EventType   string
+	Payload     string
+	TraceParent sql.NullString
+	TraceState  sql.NullString
+	CreatedAt   time.Time
+}
+
+func (q *Queries) CreateWebhookDeliveries(ctx context.Context, arg CreateWebhookDeliveriesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createWebhookDeliveries,
+		arg.EventID,
+		arg.EventType,
+		arg.Payload,
+		arg.TraceParent,
+		arg.TraceState,
+		arg.CreatedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const createWebhookSubscription = `-- name: CreateWebhookSubscription :one
+INSERT INTO webhook_subscriptions (
+  url, event_types, secret, created_at
+) VALUES (
+  ?, ?, ?, ?
+)
+RETURNING id, url, event_types, secret, created_at, updated_at
+`
+
+type CreateWebhookSubscriptionParams struct {
+	Url        string
+	EventTypes string
+	Secret     string
+	CreatedAt  time.Time
+}
+
+func (q *Queries) CreateWebhookSubscription(ctx context.Context, arg CreateWebhookSubscriptionParams) (WebhookSubscription, error) {
+	row := q.db.QueryRowContext(ctx, createWebhookSubscription,
+		arg.Url,
+		arg.EventTypes,
+		arg.Secret,
+		arg.CreatedAt,
+	)
+	var i WebhookSubscription
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.EventTypes,
+		&i.Secret,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deleteWebhookDeliveries = `-- name: DeleteWebhookDeliveries :exec
+DELETE FROM webhook_deliveries
+WHERE subscription_id = ?
+`
+
+func (q *Queries) DeleteWebhookDeliveries(ctx context.Context, subscriptionID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteWebhookDeliveries, subscriptionID)
+	return err
+}
+
+const deleteWebhookSubscription = `-- name: DeleteWebhookSubscription :execrows
+DELETE FROM webhook_subscriptions
+WHERE id = ?
+`
+
+func (q *Queries) DeleteWebhookSubscription(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteWebhookSubscription, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const finishWebhookDelivery = `-- name: FinishWebhookDelivery :exec
+UPDATE webhook_deliveries
+set status = ?1,
+attempts = attempts + 1,
+next_attempt_at = ?2,
+response_status = ?3,
+last_error = ?4,
+delivered_at = ?5
+WHERE id = ?6
+`
+
+type FinishWebhookDeliveryParams struct {
+	Status         string
+	NextAttemptAt  time.Time
+	ResponseStatus sql.NullInt64
+	LastError      sql.NullString
+	DeliveredAt    sql.NullTime
+	ID             int64
+}
+
+func (q *Queries) FinishWebhookDelivery(ctx context.Context, arg FinishWebhookDeliveryParams) error {
+	_, err := q.db.ExecContext(ctx, finishWebhookDelivery,
+		arg.Status,
+		arg.NextAttemptAt,
+		arg.ResponseStatus,
+		arg.LastError,
+		arg.DeliveredAt,
+		arg.ID,
+	)
+	return err
+}
+
+const getWebhookDelivery = `-- name: GetWebhookDelivery :one
+SELECT id, subscription_id, event_id, event_type, payload, trace_parent, trace_state, status, attempts, next_attempt_at, response_status, last_error, created_at, delivered_at FROM webhook_deliveries
+WHERE id = ?1
+  AND subscription_id = ?2
+LIMIT 1
+`
+
+type GetWebhookDeliveryParams struct {
+	ID             int64
+	SubscriptionID int64
+}
+
+func (q *Queries) GetWebhookDelivery(ctx context.Context, arg GetWebhookDeliveryParams) (WebhookDelivery, error) {
+	row := q.db.QueryRowContext(ctx, getWebhookDelivery, arg.ID, arg.SubscriptionID)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.SubscriptionID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.TraceParent,
+		&i.TraceState,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.ResponseStatus,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.DeliveredAt,
+	)
+	return i, err
+}
+
+const getWebhookSubscription = `-- name: GetWebhookSubscription :one
+SELECT id, url, event_types, secret, created_at, updated_at FROM webhook_subscriptions
+WHERE id = ? LIMIT 1
+`
+
+func (q *Queries) GetWebhookSubscription(ctx context.Context, id int64) (WebhookSubscription, error) {
+	row := q.db.QueryRowContext(ctx, getWebhookSubscription, id)
+	var i WebhookSubscription
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.EventTypes,
+		&i.Secret,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listDueWebhookDeliveries = `-- name: ListDueWebhookDeliveries :many
+SELECT webhook_deliveries.id, webhook_deliveries.subscription_id, webhook_deliveries.event_id,
+  webhook_deliveries.event_type, webhook_deliveries.payload, webhook_deliveries.trace_parent,
+  webhook_deliveries.trace_state, webhook_deliveries.attempts, webhook_subscriptions.url, webhook_subscriptions.secret
+FROM webhook_deliveries
+JOIN webhook_subscriptions ON webhook_subscriptions.id = webhook_deliveries.subscription_id
+WHERE webhook_deliveries.status = 'pending'
+  AND webhook_deliveries.next_attempt_at <= ?1
+ORDER BY webhook_deliveries.id
+LIMIT ?2
+`
+
+type ListDueWebhookDeliveriesParams struct {
+	Now      time.Time
+	PageSize int64
+}
+
+type ListDueWebhookDeliveriesRow struct {
+	ID             int64
+	SubscriptionID int64
+	EventID        int64
+	EventType      string
+	Payload        string
+	TraceParent    sql.NullString
+	TraceState     sql.NullString
+	Attempts       int64
+	Url            string
+	Secret         string
+}
+
+func (q *Queries) ListDueWebhookDeliveries(ctx context.Context, arg ListDueWebhookDeliveriesParams) ([]ListDueWebhookDeliveriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDueWebhookDeliveries, arg.Now, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDueWebhookDeliveriesRow
+	for rows.Next() {
+		var i ListDueWebhookDeliveriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.EventID,
+			&i.EventType,
+			&i.Payload,
+			&i.TraceParent,
+			&i.TraceState,
+			&i.Attempts,
+			&i.Url,
+			&i.Secret,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookDeliveries = `-- name: ListWebhookDeliveries :many
+SELECT id, subscription_id, event_id, event_type, payload, trace_parent, trace_state, status, attempts, next_attempt_at, response_status, last_error, created_at, delivered_at FROM webhook_deliveries
+WHERE subscription_id = ?1
+  AND (CAST(?2 AS TEXT) IS NULL OR status = ?2)
+  AND (CAST(?3 AS INTEGER) IS NULL OR id < ?3)
+ORDER BY id DESC
+LIMIT ?4
+`
+
+type ListWebhookDeliveriesParams struct {
+	SubscriptionID int64
+	Status         sql.NullString
+	BeforeID       sql.NullInt64
+	PageSize       int64
+}
+
+func (q *Queries) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	rows, err := q.db.QueryContext(ctx, listWebhookDeliveries,
+		arg.SubscriptionID,
+		arg.Status,
+		arg.BeforeID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WebhookDelivery
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.EventID,
+			&i.EventType,
+			&i.Payload,
+			&i.TraceParent,
+			&i.TraceState,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.ResponseStatus,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.DeliveredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookSubscriptions = `-- name: ListWebhookSubscriptions :many
+SELECT id, url, event_types, secret, created_at, updated_at FROM webhook_subscriptions
+ORDER BY id
+`
+
+func (q *Queries) ListWebhookSubscriptions(ctx context.Context) ([]WebhookSubscription, error) {
+	rows, err := q.db.QueryContext(ctx, listWebhookSubscriptions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WebhookSubscription
+	for rows.Next() {
+		var i WebhookSubscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.Url,
+			&i.EventTypes,
+			&i.Secret,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const retryWebhookDelivery = `-- name: RetryWebhookDelivery :one
+UPDATE webhook_deliveries
+set status = 'pending',
+attempts = 0,
+next_attempt_at = ?1
+WHERE id = ?2
+  AND subscription_id = ?3
+  AND status = 'dead'
+RETURNING id, subscription_id, event_id, event_type, payload, trace_parent, trace_state, status, attempts, next_attempt_at, response_status, last_error, created_at, delivered_at
+`
+
+type RetryWebhookDeliveryParams struct {
+	NextAttemptAt  time.Time
+	ID             int64
+	SubscriptionID int64
+}
+
+func (q *Queries) RetryWebhookDelivery(ctx context.Context, arg RetryWebhookDeliveryParams) (WebhookDelivery, error) {
+	row := q.db.QueryRowContext(ctx, retryWebhookDelivery, arg.NextAttemptAt, arg.ID, arg.SubscriptionID)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.SubscriptionID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.TraceParent,
+		&i.TraceState,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.ResponseStatus,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.DeliveredAt,
+	)
+	return i, err
+}
+
+const updateWebhookSubscription = `-- name: UpdateWebhookSubscription :one
+UPDATE webhook_subscriptions
+set url = ?1,
+event_types = ?2,
+secret = ?3,
+updated_at = ?4
+WHERE id = ?5
+RETURNING id, url, event_types, secret, created_at, updated_at
+`
+
+type UpdateWebhookSubscriptionParams struct {
+	Url        string
+	EventTypes string
+	Secret     string
+	UpdatedAt  sql.NullTime
+	ID         int64
+}
+
+func (q *Queries) UpdateWebhookSubscription(ctx context.Context, arg UpdateWebhookSubscriptionParams) (WebhookSubscription, error) {
+	row := q.db.QueryRowContext(ctx, updateWebhookSubscription,
+		arg.Url,
+		arg.EventTypes,
+		arg.Secret,
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	var i WebhookSubscription
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.EventTypes,
+		&i.Secret,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/repository"
+	productrepository "github.com/indrabrata/observability-playground/repository/product"
+	"github.com/indrabrata/observability-playground/utility"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Note : The bounds used for a time range left open, created_at is compared as text so both must be in UTC.
+var (
+	auditLogStart = time.Time{}
+	auditLogEnd   = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+)
+
+// recordProductAudit writes an audit entry for a product change through query, which must belong to the
+// transaction that makes the change, so the entry is stored exactly when the change is committed. before is nil
+// for a created product. The caller is taken from ctx, as put there by the client and request id middlewares.
+func recordProductAudit(ctx context.Context, query *productrepository.Queries, action string, before *productrepository.Product, after productrepository.Product, at time.Time) error {
+	var beforeFields map[string]json.RawMessage
+	if before != nil {
+		fields, err := auditFields(productResponse(*before))
+		if err != nil {
+			return err
+		}
+		beforeFields = fields
+	}
+	afterFields, err := auditFields(productResponse(after))
+	if err != nil {
+		return err
+	}
+
+	changedBefore, changedAfter := auditDiff(beforeFields, afterFields)
+	beforeJson, err := json.Marshal(changedBefore)
+	if err != nil {
+		return err
+	}
+	afterJson, err := json.Marshal(changedAfter)
+	if err != nil {
+		return err
+	}
+
+	var traceId string
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		traceId = spanContext.TraceID().String()
+	}
+
+	return query.CreateAuditLogEntry(ctx, productrepository.CreateAuditLogEntryParams{
+		EntityType: model.AuditEntityProduct,
+		EntityID:   after.ID,
+		Action:     action,
+		Actor:      contextString(ctx, "actor"),
+		Before:     sql.NullString{String: string(beforeJson), Valid: before != nil},
+		After:      sql.NullString{String: string(afterJson), Valid: true},
+		RequestID:  requestIdOf(ctx),
+		TraceID:    sql.NullString{String: traceId, Valid: traceId != ""},
+		ClientIp:   contextString(ctx, "clientIp"),
+		CreatedAt:  at.UTC(),
+	})
+}
+
+func auditFields(response any) (map[string]json.RawMessage, error) {
+	body, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	return fields, json.Unmarshal(body, &fields)
+}
+
+// auditDiff keeps the fields whose value differs between before and after. A field missing on one side, e.g.
+// deleted_at before a delete, is null there.
+func auditDiff(before, after map[string]json.RawMessage) (map[string]json.RawMessage, map[string]json.RawMessage) {
+	changedBefore := map[string]json.RawMessage{}
+	changedAfter := map[string]json.RawMessage{}
+	null := json.RawMessage("null")
+
+	for name, value := range after {
+		previous, ok := before[name]
+		if !ok {
+			previous = null
+		}
+		if !bytes.Equal(previous, value) {
+			changedBefore[name] = previous
+			changedAfter[name] = value
+		}
+	}
+	for name, previous := range before {
+		if _, ok := after[name]; !ok {
+			changedBefore[name] = previous
+			changedAfter[name] = null
+		}
+	}
+	return changedBefore, changedAfter
+}
+
+func contextString(ctx context.Context, key string) sql.NullString {
+	value, _ := ctx.Value(key).(string)
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+// AuditService reads the audit log, which the services write in the transactions that make the changes.
+type AuditService struct {
+	repository *repository.BaseRepository[*productrepository.Queries]
+	trace      trace.Tracer
+}
+
+func NewAuditService(repository *repository.BaseRepository[*productrepository.Queries], trace trace.Tracer) *AuditService {
+	return &AuditService{
+		repository: repository,
+		trace:      trace,
+	}
+}
+
+// ListAuditLog lists the audit entries of an entity type, newest first, page by page using an opaque cursor.
+func (s *AuditService) ListAuditLog(ctx context.Context, request model.AuditLogRequest) (model.AuditLogResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.ListAuditLog", trace.WithAttributes(
+		attribute.String("entity", request.Entity),
+		attribute.Int64("limit", request.Limit),
+		attribute.Bool("hasCursor", request.After != nil),
+	))
+	defer span.End()
+
+	params := productrepository.ListAuditLogEntriesParams{
+		EntityType:  request.Entity,
+		CreatedFrom: auditLogStart,
+		CreatedTo:   auditLogEnd,
+		PageSize:    request.Limit + 1,
+	}
+	if request.EntityId != nil {
+		params.EntityID = sql.NullInt64{Int64: *request.EntityId, Valid: true}
+		span.SetAttributes(attribute.Int64("entityId", *request.EntityId))
+	}
+	if request.From != nil {
+		params.CreatedFrom = request.From.UTC()
+	}
+	if request.To != nil {
+		params.CreatedTo = request.To.UTC()
+	}
+	if request.After != nil {
+		params.BeforeID = sql.NullInt64{Int64: request.After.Id, Valid: true}
+	}
+
+	data, err := s.repository.Query.ListAuditLogEntries(ctx, params)
+	if err != nil {
+		err = translateError(err, "audit log")
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to list audit log", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.AuditLogResponse{}, err
+	}
+
+	paging := model.Paging{Limit: request.Limit}
+	if int64(len(data)) > request.Limit {
+		data = data[:request.Limit]
+
+		last := data[len(data)-1]
+		cursor, err := utility.EncodeCursor(utility.Cursor{SortBy: "id", SortOrder: "desc", Value: last.ID, Id: last.ID})
+		if err != nil {
+			err = translateError(err, "audit log")
+			utility.RecordSpanError(span, err)
+			zap.L().Error("failed to encode cursor", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+			return model.AuditLogResponse{}, err
+		}
+
+		paging.NextCursor = cursor
+		paging.HasMore = true
+	}
+
+	entries := make([]model.AuditEntryResponse, 0, len(data))
+	for _, entry := range data {
+		entries = append(entries, auditEntryResponse(entry))
+	}
+
+	return model.AuditLogResponse{Data: entries, Paging: paging}, nil
+}
+
+func auditEntryResponse(entry productrepository.AuditLog) model.AuditEntryResponse {
+	response := model.AuditEntryResponse{
+		Id:        entry.ID,
+		Entity:    entry.EntityType,
+		EntityId:  entry.EntityID,
+		Action:    entry.Action,
+		Actor:     entry.Actor.String,
+		RequestId: entry.RequestID.String,
+		TraceId:   entry.TraceID.String,
+		ClientIp:  entry.ClientIp.String,
+		CreatedAt: entry.CreatedAt,
+	}
+	if entry.Before.Valid {
+		response.Before = json.RawMessage(entry.Before.String)
+	}
+	if entry.After.Valid {
+		response.After = json.RawMessage(entry.After.String)
+	}
+	return response
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/repository"
+	productrepository "github.com/indrabrata/observability-playground/repository/product"
+	"github.com/indrabrata/observability-playground/utility"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// CategoryService manages the category tree. A category points to its parent, top level categories have none.
+type CategoryService struct {
+	repository *repository.BaseRepository[*productrepository.Queries]
+	trace      trace.Tracer
+}
+
+func NewCategoryService(repository *repository.BaseRepository[*productrepository.Queries], trace trace.Tracer) *CategoryService {
+	return &CategoryService{
+		repository: repository,
+		trace:      trace,
+	}
+}
+
+func (s *CategoryService) CreateCategory(ctx context.Context, request model.CategoryRequest) (model.CategoryResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.CreateCategory")
+	defer span.End()
+
+	var category productrepository.Category
+	err := s.repository.Transaction(ctx, func(tx *sql.Tx) error {
+		query := s.repository.Query.WithTx(tx)
+
+		if err := checkParentCategory(ctx, query, 0, request.ParentId); err != nil {
+			return err
+		}
+
+		var err error
+		category, err = query.CreateCategory(ctx, productrepository.CreateCategoryParams{
+			Name:      request.Name,
+			ParentID:  nullInt64(request.ParentId),
+			CreatedAt: time.Now().UTC(),
+		})
+		return err
+	})
+	if err != nil {
+		err = translateError(err, "category")
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to create category", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.CategoryResponse{}, err
+	}
+
+	span.SetAttributes(attribute.Int64("categoryId", category.ID))
+
+	return categoryResponse(category), nil
+}
+
+func (s *CategoryService) GetCategory(ctx context.Context, id int64) (model.CategoryResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.GetCategory", trace.WithAttributes(attribute.Int64("categoryId", id)))
+	defer span.End()
+
+	category, err := s.repository.Query.GetCategory(ctx, id)
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("category %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to get category", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.CategoryResponse{}, err
+	}
+
+	return categoryResponse(category), nil
+}
+
+// ListCategories returns the whole tree, parents before their children, so clients can build it in one pass.
+func (s *CategoryService) ListCategories(ctx context.Context) (model.CategoryListResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.ListCategories")
+	defer span.End()
+
+	categories, err := s.repository.Query.ListCategories(ctx)
+	if err != nil {
+		err = translateError(err, "category")
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to list categories", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.CategoryListResponse{}, err
+	}
+
+	return model.CategoryListResponse{Data: categoryResponses(categories)}, nil
+}
+
+// UpdateCategory renames a category and moves it to another parent. Moving a category below itself or one of
+// its descendants would cut that part of the tree off, so it is refused.
+func (s *CategoryService) UpdateCategory(ctx context.Context, id int64, request model.CategoryRequest) (model.CategoryResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.UpdateCategory", trace.WithAttributes(attribute.Int64("categoryId", id)))
+	defer span.End()
+
+	var category productrepository.Category
+	err := s.repository.Transaction(ctx, func(tx *sql.Tx) error {
+		query := s.repository.Query.WithTx(tx)
+
+		if _, err := query.GetCategory(ctx, id); err != nil {
+			return err
+		}
+		if err := checkParentCategory(ctx, query, id, request.ParentId); err != nil {
+			return err
+		}
+
+		var err error
+		category, err = query.UpdateCategory(ctx, productrepository.UpdateCategoryParams{
+			Name:      request.Name,
+			ParentID:  nullInt64(request.ParentId),
+			UpdatedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+			ID:        id,
+		})
+		return err
+	})
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("category %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to update category", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.CategoryResponse{}, err
+	}
+
+	return categoryResponse(category), nil
+}
+
+// DeleteCategory removes a category without subcategories and unlinks its products. The products themselves are kept.
+func (s *CategoryService) DeleteCategory(ctx context.Context, id int64) error {
+	ctx, span := s.trace.Start(ctx, "Service.DeleteCategory", trace.WithAttributes(attribute.Int64("categoryId", id)))
+	defer span.End()
+
+	err := s.repository.Transaction(ctx, func(tx *sql.Tx) error {
+		query := s.repository.Query.WithTx(tx)
+
+		children, err := query.CountChildCategories(ctx, sql.NullInt64{Int64: id, Valid: true})
+		if err != nil {
+			return err
+		}
+		if children > 0 {
+			return apperror.New(apperror.Conflict, fmt.Sprintf("category %d still has %d subcategories, move or delete them first", id, children))
+		}
+
+		rules, err := query.CountCategoryPricingRules(ctx, sql.NullInt64{Int64: id, Valid: true})
+		if err != nil {
+			return err
+		}
+		if rules > 0 {
+			return apperror.New(apperror.Conflict, fmt.Sprintf("category %d still has %d pricing rules, delete them first", id, rules))
+		}
+
+		if err := query.DeleteCategoryProducts(ctx, id); err != nil {
+			return err
+		}
+
+		deleted, err := query.DeleteCategory(ctx, id)
+		if err == nil && deleted == 0 {
+			err = sql.ErrNoRows
+		}
+		return err
+	})
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("category %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to delete category", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return err
+	}
+
+	return nil
+}
+
+// ListCategoryProducts pages through the products of a category and of all of its descendants, by product id.
+func (s *CategoryService) ListCategoryProducts(ctx context.Context, id int64, request model.CategoryProductsRequest) (model.ProductListResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.ListCategoryProducts", trace.WithAttributes(
+		attribute.Int64("categoryId", id),
+		attribute.Int64("limit", request.Limit),
+		attribute.Bool("hasCursor", request.After != nil),
+	))
+	defer span.End()
+
+	// Note : One extra row is fetched to know whether there is a next page without running a COUNT query.
+	params := productrepository.ListCategoryProductsParams{
+		CategoryID: id,
+		PageSize:   request.Limit + 1,
+	}
+	if request.After != nil {
+		params.AfterID = request.After.Id
+	}
+
+	_, err := s.repository.Query.GetCategory(ctx, id)
+	var data []productrepository.Product
+	if err == nil {
+		data, err = s.repository.Query.ListCategoryProducts(ctx, params)
+	}
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("category %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to list category products", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.ProductListResponse{}, err
+	}
+
+	paging := model.Paging{Limit: request.Limit}
+	if int64(len(data)) > request.Limit {
+		data = data[:request.Limit]
+
+		last := data[len(data)-1]
+		cursor, err := utility.EncodeCursor(utility.Cursor{SortBy: "id", SortOrder: "asc", Value: last.ID, Id: last.ID})
+		if err != nil {
+			err = translateError(err, "product")
+			utility.RecordSpanError(span, err)
+			zap.L().Error("failed to encode cursor", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+			return model.ProductListResponse{}, err
+		}
+
+		paging.NextCursor = cursor
+		paging.HasMore = true
+	}
+
+	products := make([]model.ProductResponse, 0, len(data))
+	for _, product := range data {
+		products = append(products, productResponse(product))
+	}
+
+	return model.ProductListResponse{Data: products, Paging: paging}, nil
+}
+
+// checkParentCategory checks that parentId, when given, names an existing category that is neither id nor one of its descendants.
+// id is 0 for a category that does not exist yet.
+func checkParentCategory(ctx context.Context, query *productrepository.Queries, id int64, parentId *int64) error {
+	if parentId == nil {
+		return nil
+	}
+	if *parentId == id {
+		return apperror.New(apperror.Conflict, fmt.Sprintf("category %d cannot be its own parent", id))
+	}
+
+	if _, err := query.GetCategory(ctx, *parentId); err != nil {
+		return translateError(err, fmt.Sprintf("parent category %d", *parentId))
+	}
+	if id == 0 {
+		return nil
+	}
+
+	below, err := query.CountCategoryDescendant(ctx, productrepository.CountCategoryDescendantParams{
+		AncestorID: id,
+		CategoryID: *parentId,
+	})
+	if err != nil {
+		return err
+	}
+	if below > 0 {
+		return apperror.New(apperror.Conflict, fmt.Sprintf("category %d cannot be moved below its descendant %d", id, *parentId))
+	}
+	return nil
+}
+
+func categoryResponse(category productrepository.Category) model.CategoryResponse {
+	response := model.CategoryResponse{
+		Id:        category.ID,
+		Name:      category.Name,
+		CreatedAt: category.CreatedAt,
+	}
+	if category.ParentID.Valid {
+		response.ParentId = &category.ParentID.Int64
+	}
+	if category.UpdatedAt.Valid {
+		response.UpdatedAt = &category.UpdatedAt.Time
+	}
+	return response
+}
+
+func categoryResponses(categories []productrepository.Category) []model.CategoryResponse {
+	responses := make([]model.CategoryResponse, 0, len(categories))
+	for _, category := range categories {
+		responses = append(responses, categoryResponse(category))
+	}
+	return responses
+}
+
+func nullInt64(value *int64) sql.NullInt64 {
+	if value == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *value, Valid: true}
+}
+
+func nullInt64Pointer(value sql.NullInt64) *int64 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Int64
+}
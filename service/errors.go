@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/mattn/go-sqlite3"
+)
+
+// uniqueConstraintMessages explains the unique constraints a client can run into, keyed by the column SQLite
+// names in the error. Other constraint failures get a generic conflict message.
+var uniqueConstraintMessages = map[string]string{
+	"products.sku":     "sku is already used by another product",
+	"products.barcode": "barcode is already used by another product",
+
+	"product_variants.sku": "sku is already used by another variant",
+	"product_variants.product_id, product_variants.options": "the product already has a variant with these options",
+
+	"warehouses.code": "code is already used by another warehouse",
+}
+
+// translateError maps repository errors to domain errors so handlers never see driver specifics.
+// resource names what was queried, e.g. "product 42", and is used in the not found message.
+func translateError(err error, resource string) error {
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		return err
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return apperror.Wrap(apperror.NotFound, err, resource+" not found")
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return apperror.Wrap(apperror.Unavailable, err, "request timed out")
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code {
+		case sqlite3.ErrConstraint:
+			if message, ok := uniqueConstraintMessages[strings.TrimPrefix(sqliteErr.Error(), "UNIQUE constraint failed: ")]; ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+				return apperror.Wrap(apperror.Conflict, err, message)
+			}
+			return apperror.Wrap(apperror.Conflict, err, "request conflicts with existing data")
+		case sqlite3.ErrBusy, sqlite3.ErrLocked:
+			return apperror.Wrap(apperror.Unavailable, err, "database is busy, retry later")
+		}
+	}
+
+	return apperror.Wrap(apperror.Internal, err, "internal server error")
+}
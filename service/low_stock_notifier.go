@@ -0,0 +1,82 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/indrabrata/observability-playground/model"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.uber.org/zap"
+)
+
+// LowStockNotifier is told about a product once each time its quantity falls below its reorder point.
+type LowStockNotifier interface {
+	// Name labels the notifier in metrics and logs.
+	Name() string
+	NotifyLowStock(ctx context.Context, alert model.LowStockAlertResponse) error
+}
+
+// LogLowStockNotifier writes every alert as a warning to the application log.
+type LogLowStockNotifier struct{}
+
+func (LogLowStockNotifier) Name() string {
+	return "log"
+}
+
+func (LogLowStockNotifier) NotifyLowStock(ctx context.Context, alert model.LowStockAlertResponse) error {
+	zap.L().Warn("product below reorder point",
+		zap.Int64("alertId", alert.Id),
+		zap.Int64("productId", alert.ProductId),
+		zap.String("name", alert.Name),
+		zap.Int64("quantity", alert.Quantity),
+		zap.Int64("reorderPoint", alert.ReorderPoint))
+	return nil
+}
+
+// WebhookLowStockNotifier posts every alert as JSON to a URL. The trace context is sent along, so the receiver's
+// spans join the trace of the evaluation that raised the alert.
+type WebhookLowStockNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookLowStockNotifier(url string, timeout time.Duration) *WebhookLowStockNotifier {
+	return &WebhookLowStockNotifier{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (n *WebhookLowStockNotifier) Name() string {
+	return "webhook"
+}
+
+func (n *WebhookLowStockNotifier) NotifyLowStock(ctx context.Context, alert model.LowStockAlertResponse) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	res, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("webhook answered %s", res.Status)
+	}
+	return nil
+}
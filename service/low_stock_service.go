@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/repository"
+	productrepository "github.com/indrabrata/observability-playground/repository/product"
+	"github.com/indrabrata/observability-playground/utility"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// LowStockService raises an alert when the quantity of a product falls below its reorder point and resolves it
+// once the quantity is back. An alert is only raised when a product crosses its reorder point, so the notifiers
+// hear about a crossing once, however many stock changes follow while the product stays low.
+type LowStockService struct {
+	repository *repository.BaseRepository[*productrepository.Queries]
+	trace      trace.Tracer
+	notifiers  []LowStockNotifier
+
+	// Note : changed holds at most one pending evaluation, so a burst of stock changes is evaluated once.
+	changed chan struct{}
+}
+
+func NewLowStockService(repository *repository.BaseRepository[*productrepository.Queries], trace trace.Tracer, notifiers ...LowStockNotifier) *LowStockService {
+	return &LowStockService{
+		repository: repository,
+		trace:      trace,
+		notifiers:  notifiers,
+		changed:    make(chan struct{}, 1),
+	}
+}
+
+// StockChanged asks Run for an evaluation without waiting for it. It is safe to call on a nil service.
+func (s *LowStockService) StockChanged() {
+	if s == nil {
+		return
+	}
+
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+// EvaluateLowStock opens an alert for every product that fell below its reorder point, resolves the alerts of
+// products that recovered, were deleted or lost their reorder point, and notifies about the opened alerts.
+// It returns how many alerts were opened.
+func (s *LowStockService) EvaluateLowStock(ctx context.Context) (int64, error) {
+	ctx, span := s.trace.Start(ctx, "Service.EvaluateLowStock")
+	defer span.End()
+
+	now := time.Now().UTC()
+
+	var opened []productrepository.LowStockAlert
+	var resolved int64
+	err := s.repository.Transaction(ctx, func(tx *sql.Tx) error {
+		query := s.repository.Query.WithTx(tx)
+
+		var err error
+		// Note : The unique index on open alerts skips products that already have one, so only crossings are returned.
+		opened, err = query.OpenLowStockAlerts(ctx, now)
+		if err != nil {
+			return err
+		}
+
+		resolved, err = query.ResolveLowStockAlerts(ctx, sql.NullTime{Time: now, Valid: true})
+		return err
+	})
+	var alerts []model.LowStockAlertResponse
+	if err == nil {
+		alerts, err = s.listLowStockAlerts(ctx)
+	}
+	if err != nil {
+		err = translateError(err, "low stock alert")
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to evaluate low stock", zap.Error(err))
+		return 0, err
+	}
+
+	span.SetAttributes(
+		attribute.Int("openedCount", len(opened)),
+		attribute.Int64("resolvedCount", resolved),
+	)
+	ProductsBelowReorderPoint.Set(float64(len(alerts)))
+
+	isOpened := make(map[int64]bool, len(opened))
+	for _, alert := range opened {
+		isOpened[alert.ID] = true
+	}
+	for _, alert := range alerts {
+		if isOpened[alert.Id] {
+			s.notify(ctx, alert)
+		}
+	}
+
+	return int64(len(opened)), nil
+}
+
+func (s *LowStockService) ListLowStockAlerts(ctx context.Context) (model.LowStockAlertListResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.ListLowStockAlerts")
+	defer span.End()
+
+	alerts, err := s.listLowStockAlerts(ctx)
+	if err != nil {
+		err = translateError(err, "low stock alert")
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to list low stock alerts", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.LowStockAlertListResponse{}, err
+	}
+
+	return model.LowStockAlertListResponse{Data: alerts}, nil
+}
+
+// Run evaluates low stock after every stock change reported by StockChanged, and every interval to catch changes
+// made elsewhere, such as reservations, until ctx is cancelled.
+func (s *LowStockService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.changed:
+		case <-ticker.C:
+		}
+
+		opened, err := s.EvaluateLowStock(ctx)
+		if err == nil && opened > 0 {
+			zap.L().Info("opened low stock alerts", zap.Int64("openedCount", opened))
+		}
+	}
+}
+
+func (s *LowStockService) listLowStockAlerts(ctx context.Context) ([]model.LowStockAlertResponse, error) {
+	rows, err := s.repository.Query.ListOpenLowStockAlerts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	alerts := make([]model.LowStockAlertResponse, 0, len(rows))
+	for _, row := range rows {
+		alerts = append(alerts, model.LowStockAlertResponse{
+			Id:           row.ID,
+			ProductId:    row.ProductID,
+			Name:         row.Name,
+			Sku:          row.Sku.String,
+			Quantity:     row.Quantity,
+			ReorderPoint: row.ReorderPoint,
+			TriggeredAt:  row.TriggeredAt,
+		})
+	}
+	return alerts, nil
+}
+
+// notify hands an opened alert to every notifier. A failed notification is logged and counted but not retried,
+// the alert stays listed until the product recovers.
+func (s *LowStockService) notify(ctx context.Context, alert model.LowStockAlertResponse) {
+	for _, notifier := range s.notifiers {
+		ctx, span := s.trace.Start(ctx, "Service.NotifyLowStock", trace.WithAttributes(
+			attribute.String("notifier", notifier.Name()),
+			attribute.Int64("productId", alert.ProductId),
+		))
+
+		if err := notifier.NotifyLowStock(ctx, alert); err != nil {
+			utility.RecordSpanError(span, err)
+			zap.L().Error("failed to send low stock notification", zap.Error(err), zap.String("notifier", notifier.Name()), zap.Int64("productId", alert.ProductId))
+			LowStockNotifications.WithLabelValues(notifier.Name(), "failed").Inc()
+		} else {
+			LowStockNotifications.WithLabelValues(notifier.Name(), "sent").Inc()
+		}
+		span.End()
+	}
+}
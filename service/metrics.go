@@ -0,0 +1,119 @@
+package service
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Note : Service metrics are created here so the service works without a registry (e.g. in tests); infrastructure registers them on startup.
+var (
+	QueryLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "product_query_duration_seconds",
+		Help:    "Product read query latency distribution by operation",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"operation"})
+	PurgedProducts = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "products_purged_total",
+		Help: "Total number of soft deleted products permanently removed by the purge",
+	})
+	ExportedRows = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "products_exported_rows_total",
+		Help: "Total number of product rows streamed by exports, by format",
+	}, []string{"format"})
+	ExportedBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "products_exported_bytes_total",
+		Help: "Total number of bytes written by product exports, by format",
+	}, []string{"format"})
+	ActiveReservations = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "reservations_active",
+		Help: "Number of reservations currently holding stock",
+	})
+	ConfirmedReservations = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "reservations_confirmed_total",
+		Help: "Total number of confirmed reservations",
+	})
+	CancelledReservations = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "reservations_cancelled_total",
+		Help: "Total number of cancelled reservations",
+	})
+	ExpiredReservations = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "reservations_expired_total",
+		Help: "Total number of reservations released by the expiry sweeper",
+	})
+	AppliedScheduledPrices = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "scheduled_prices_applied_total",
+		Help: "Total number of scheduled prices handled by the price scheduler, by outcome",
+	}, []string{"status"})
+	WarehouseStock = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "warehouse_stock_units",
+		Help: "Units in stock per warehouse",
+	}, []string{"warehouse"})
+	WarehouseMovedUnits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "warehouse_stock_moved_units_total",
+		Help: "Total number of units moved into or out of each warehouse, by direction",
+	}, []string{"warehouse", "direction"})
+	ProductsBelowReorderPoint = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "products_below_reorder_point",
+		Help: "Number of products whose quantity is below their reorder point",
+	})
+	LowStockNotifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "low_stock_notifications_total",
+		Help: "Total number of low stock notifications, by notifier and outcome",
+	}, []string{"notifier", "status"})
+	OutboxBacklog = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "outbox_backlog_events",
+		Help: "Number of outbox events waiting to be dispatched",
+	})
+	OutboxOldestPendingAge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "outbox_oldest_pending_event_age_seconds",
+		Help: "Age of the oldest outbox event waiting to be dispatched, 0 when there is none",
+	})
+	OutboxDispatchLag = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "outbox_dispatch_lag_seconds",
+		Help:    "Time from writing an outbox event to dispatching it to every sink",
+		Buckets: []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 300, 900, 3600},
+	})
+	OutboxPublished = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "outbox_published_events_total",
+		Help: "Total number of outbox events handed to each sink, by sink and outcome",
+	}, []string{"sink", "status"})
+	WebhookDeliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "webhook_deliveries_total",
+		Help: "Total number of webhook delivery attempts, by outcome",
+	}, []string{"status"})
+	WebhookDeliveriesByStatus = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "webhook_deliveries",
+		Help: "Number of webhook deliveries in the delivery log, by status",
+	}, []string{"status"})
+	WebhookDeliveryDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "webhook_delivery_duration_seconds",
+		Help:    "Time a webhook partner took to answer a delivery",
+		Buckets: prometheus.DefBuckets,
+	})
+	ProductEventSubscribers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "product_event_subscribers",
+		Help: "Number of clients connected to the product event stream",
+	})
+	ProductEventsStreamed = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "product_events_streamed_total",
+		Help: "Total number of product events sent to the subscribers of the product event stream",
+	})
+	OrderTransitions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "order_transitions_total",
+		Help: "Total number of order status changes, by previous and new status (from is none for a placed order)",
+	}, []string{"from", "to"})
+	OrdersByStatus = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "orders",
+		Help: "Number of orders, by status",
+	}, []string{"status"})
+	OrderStatusDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "order_status_duration_seconds",
+		Help:    "Time an order spent in a status before it moved on, by status",
+		Buckets: []float64{1, 10, 60, 300, 900, 3600, 4 * 3600, 24 * 3600, 3 * 24 * 3600, 7 * 24 * 3600},
+	}, []string{"status"})
+)
+
+func observeQueryLatency(operation string, start time.Time) {
+	QueryLatency.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
@@ -2,6 +2,7 @@ package mockx
 
 import (
 	"context"
+	"io"
 
 	"github.com/indrabrata/observability-playground/model"
 	"github.com/stretchr/testify/mock"
@@ -20,9 +21,14 @@ func (m *ProductServiceMock) CreateProduct(ctx context.Context, product model.Pr
 	return args.Get(0).(model.ProductResponse), args.Error(1)
 }
 
-func (m *ProductServiceMock) GetProducts(ctx context.Context) ([]model.ProductResponse, error) {
-	args := m.Called(ctx)
-	return args.Get(0).([]model.ProductResponse), args.Error(1)
+func (m *ProductServiceMock) GetProducts(ctx context.Context, request model.ProductListRequest) (model.ProductListResponse, error) {
+	args := m.Called(ctx, request)
+	return args.Get(0).(model.ProductListResponse), args.Error(1)
+}
+
+func (m *ProductServiceMock) SearchProducts(ctx context.Context, request model.ProductSearchRequest) (model.ProductSearchResponse, error) {
+	args := m.Called(ctx, request)
+	return args.Get(0).(model.ProductSearchResponse), args.Error(1)
 }
 
 func (m *ProductServiceMock) GetProduct(ctx context.Context, id int64) (model.ProductResponse, error) {
@@ -30,12 +36,112 @@ func (m *ProductServiceMock) GetProduct(ctx context.Context, id int64) (model.Pr
 	return args.Get(0).(model.ProductResponse), args.Error(1)
 }
 
-func (m *ProductServiceMock) UpdateProduct(ctx context.Context, product model.ProductRequest) (model.ProductResponse, error) {
-	args := m.Called(ctx, product)
+func (m *ProductServiceMock) UpdateProduct(ctx context.Context, id int64, product model.ProductRequest, precondition model.Precondition) (model.ProductResponse, error) {
+	args := m.Called(ctx, id, product, precondition)
 	return args.Get(0).(model.ProductResponse), args.Error(1)
 }
 
-func (m *ProductServiceMock) DeleteProduct(ctx context.Context, id int64) error {
+func (m *ProductServiceMock) PatchProduct(ctx context.Context, id int64, product model.ProductPatchRequest, precondition model.Precondition) (model.ProductResponse, error) {
+	args := m.Called(ctx, id, product, precondition)
+	return args.Get(0).(model.ProductResponse), args.Error(1)
+}
+
+func (m *ProductServiceMock) DeleteProduct(ctx context.Context, id int64, precondition model.Precondition) error {
+	args := m.Called(ctx, id, precondition)
+	return args.Error(0)
+}
+
+func (m *ProductServiceMock) RestoreProduct(ctx context.Context, id int64) (model.ProductResponse, error) {
 	args := m.Called(ctx, id)
+	return args.Get(0).(model.ProductResponse), args.Error(1)
+}
+
+func (m *ProductServiceMock) ImportProducts(ctx context.Context, rows model.ProductRowReader, mode string) (model.ProductImportResponse, error) {
+	args := m.Called(ctx, rows, mode)
+	return args.Get(0).(model.ProductImportResponse), args.Error(1)
+}
+
+func (m *ProductServiceMock) ExportProducts(ctx context.Context, format string, w io.Writer, flush func() error) (int64, error) {
+	args := m.Called(ctx, format, w, flush)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *ProductServiceMock) AdjustStock(ctx context.Context, id int64, request model.StockAdjustRequest) (model.StockAdjustResponse, error) {
+	args := m.Called(ctx, id, request)
+	return args.Get(0).(model.StockAdjustResponse), args.Error(1)
+}
+
+func (m *ProductServiceMock) GetStockHistory(ctx context.Context, id int64, request model.StockHistoryRequest) (model.StockHistoryResponse, error) {
+	args := m.Called(ctx, id, request)
+	return args.Get(0).(model.StockHistoryResponse), args.Error(1)
+}
+
+func (m *ProductServiceMock) GetPriceHistory(ctx context.Context, id int64) (model.PriceHistoryResponse, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(model.PriceHistoryResponse), args.Error(1)
+}
+
+func (m *ProductServiceMock) SchedulePrice(ctx context.Context, id int64, request model.ScheduledPriceRequest) (model.ScheduledPriceResponse, error) {
+	args := m.Called(ctx, id, request)
+	return args.Get(0).(model.ScheduledPriceResponse), args.Error(1)
+}
+
+func (m *ProductServiceMock) CancelScheduledPrice(ctx context.Context, id, scheduledPriceId int64) error {
+	args := m.Called(ctx, id, scheduledPriceId)
+	return args.Error(0)
+}
+
+func (m *ProductServiceMock) GetProductCategories(ctx context.Context, id int64) (model.ProductCategoriesResponse, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(model.ProductCategoriesResponse), args.Error(1)
+}
+
+func (m *ProductServiceMock) SetProductCategories(ctx context.Context, id int64, request model.ProductCategoriesRequest) (model.ProductCategoriesResponse, error) {
+	args := m.Called(ctx, id, request)
+	return args.Get(0).(model.ProductCategoriesResponse), args.Error(1)
+}
+
+func (m *ProductServiceMock) GetProductTags(ctx context.Context, id int64) (model.ProductTagsResponse, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(model.ProductTagsResponse), args.Error(1)
+}
+
+func (m *ProductServiceMock) SetProductTags(ctx context.Context, id int64, request model.ProductTagsRequest) (model.ProductTagsResponse, error) {
+	args := m.Called(ctx, id, request)
+	return args.Get(0).(model.ProductTagsResponse), args.Error(1)
+}
+
+func (m *ProductServiceMock) GetProductBySku(ctx context.Context, sku string) (model.ProductResponse, error) {
+	args := m.Called(ctx, sku)
+	return args.Get(0).(model.ProductResponse), args.Error(1)
+}
+
+func (m *ProductServiceMock) GetProductByBarcode(ctx context.Context, barcode string) (model.ProductResponse, error) {
+	args := m.Called(ctx, barcode)
+	return args.Get(0).(model.ProductResponse), args.Error(1)
+}
+
+func (m *ProductServiceMock) ListVariants(ctx context.Context, id int64) (model.VariantListResponse, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(model.VariantListResponse), args.Error(1)
+}
+
+func (m *ProductServiceMock) GetVariant(ctx context.Context, id, variantId int64) (model.VariantResponse, error) {
+	args := m.Called(ctx, id, variantId)
+	return args.Get(0).(model.VariantResponse), args.Error(1)
+}
+
+func (m *ProductServiceMock) CreateVariant(ctx context.Context, id int64, request model.VariantRequest) (model.VariantResponse, error) {
+	args := m.Called(ctx, id, request)
+	return args.Get(0).(model.VariantResponse), args.Error(1)
+}
+
+func (m *ProductServiceMock) UpdateVariant(ctx context.Context, id, variantId int64, request model.VariantRequest) (model.VariantResponse, error) {
+	args := m.Called(ctx, id, variantId, request)
+	return args.Get(0).(model.VariantResponse), args.Error(1)
+}
+
+func (m *ProductServiceMock) DeleteVariant(ctx context.Context, id, variantId int64) error {
+	args := m.Called(ctx, id, variantId)
 	return args.Error(0)
 }
@@ -0,0 +1,472 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/repository"
+	productrepository "github.com/indrabrata/observability-playground/repository/product"
+	"github.com/indrabrata/observability-playground/utility"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// OrderService places orders and moves them through their statuses. Placing an order takes the ordered units out
+// of stock through the stock ledger in the same transaction, so an order is only placed when every line is in
+// stock; cancelling it puts them back. Every status change is checked against model.CanTransitionOrder and
+// recorded in the order's history.
+// Note : The order queries are generated into the product package rather than a package of their own like the
+// idempotency keys, since an order and the stock it moves are written with the one Queries bound to a transaction.
+type OrderService struct {
+	repository *repository.BaseRepository[*productrepository.Queries]
+	trace      trace.Tracer
+}
+
+func NewOrderService(repository *repository.BaseRepository[*productrepository.Queries], trace trace.Tracer) *OrderService {
+	return &OrderService{
+		repository: repository,
+		trace:      trace,
+	}
+}
+
+// PlaceOrder prices every line at the current price of its product, or of its variant, and takes the units out
+// of stock. Either every line is placed or none of them.
+func (s *OrderService) PlaceOrder(ctx context.Context, request model.OrderRequest) (model.OrderResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.PlaceOrder", trace.WithAttributes(attribute.Int("lineCount", len(request.Lines))))
+	defer span.End()
+
+	now := time.Now().UTC()
+
+	var response model.OrderResponse
+	var movements []productrepository.StockMovement
+	var appliedRules []int64
+	err := s.repository.Transaction(ctx, func(tx *sql.Tx) error {
+		query := s.repository.Query.WithTx(tx)
+
+		// Note : Lines are priced before the order is written, the total is part of the order row. Every line
+		// gets the pricing rules running now for its quantity.
+		lines := make([]productrepository.OrderLine, 0, len(request.Lines))
+		var currency string
+		var total int64
+		for i, line := range request.Lines {
+			unitPrice, lineCurrency, applied, err := orderLinePrice(ctx, query, line, now)
+			if err != nil {
+				return err
+			}
+			appliedRules = append(appliedRules, appliedRuleIds(applied)...)
+			if currency == "" {
+				currency = lineCurrency
+			} else if lineCurrency != currency {
+				return apperror.New(apperror.Unprocessable, fmt.Sprintf("lines[%d] is priced in %s, every line of an order must be priced in %s", i, lineCurrency, currency))
+			}
+			amount, err := lineAmount(unitPrice, lineCurrency, line.Quantity)
+			if err != nil {
+				return err
+			}
+			if total > math.MaxInt64-amount {
+				return apperror.New(apperror.Unprocessable, "the order total is more than a price can hold")
+			}
+			total += amount
+
+			lines = append(lines, productrepository.OrderLine{
+				Line:           int64(i + 1),
+				ProductID:      line.ProductId,
+				VariantID:      nullInt64(line.VariantId),
+				WarehouseID:    nullInt64(line.WarehouseId),
+				Quantity:       line.Quantity,
+				UnitPriceMinor: unitPrice,
+			})
+		}
+
+		order, err := query.CreateOrder(ctx, productrepository.CreateOrderParams{
+			Reference:  sql.NullString{String: request.Reference, Valid: request.Reference != ""},
+			TotalMinor: total,
+			Currency:   currency,
+			CreatedAt:  now,
+		})
+		if err != nil {
+			return err
+		}
+
+		for i := range lines {
+			lines[i].OrderID = order.ID
+			err := query.CreateOrderLine(ctx, productrepository.CreateOrderLineParams{
+				OrderID:        order.ID,
+				Line:           lines[i].Line,
+				ProductID:      lines[i].ProductID,
+				VariantID:      lines[i].VariantID,
+				WarehouseID:    lines[i].WarehouseID,
+				Quantity:       lines[i].Quantity,
+				UnitPriceMinor: lines[i].UnitPriceMinor,
+			})
+			if err != nil {
+				return err
+			}
+
+			movement, err := moveOrderedStock(ctx, query, lines[i], -lines[i].Quantity, model.StockReasonOrder, now)
+			if errors.Is(err, sql.ErrNoRows) {
+				// Note : No row was updated, either the product or variant does not exist or it has too little stock.
+				return stockShortageError(ctx, query, lines[i].ProductID, lines[i].VariantID, "order", lines[i].Quantity)
+			}
+			if err != nil {
+				return err
+			}
+			movements = append(movements, movement)
+		}
+
+		if err := recordOrderTransition(ctx, query, order.ID, "", model.OrderStatusPending, now); err != nil {
+			return err
+		}
+
+		response, err = s.getOrder(ctx, query, order.ID)
+		return err
+	})
+	if err != nil {
+		err = translateError(err, "order")
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to place order", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.OrderResponse{}, err
+	}
+
+	span.SetAttributes(
+		attribute.Int64("orderId", response.Id),
+		attribute.String("total", response.Total.String()),
+		attribute.String("currency", response.Currency),
+		attribute.Int64Slice("appliedRuleIds", appliedRules),
+	)
+	OrderTransitions.WithLabelValues("none", model.OrderStatusPending).Inc()
+	OrdersByStatus.WithLabelValues(model.OrderStatusPending).Inc()
+	observeWarehouseMovements(ctx, s.repository.Query, movements...)
+
+	return response, nil
+}
+
+func (s *OrderService) GetOrder(ctx context.Context, id int64) (model.OrderResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.GetOrder")
+	defer span.End()
+
+	response, err := s.getOrder(ctx, s.repository.Query, id)
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("order %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to get order", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.OrderResponse{}, err
+	}
+
+	return response, nil
+}
+
+// ListOrders lists the orders, newest first, page by page using an opaque cursor.
+func (s *OrderService) ListOrders(ctx context.Context, request model.OrderListRequest) (model.OrderListResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.ListOrders", trace.WithAttributes(
+		attribute.String("status", request.Status),
+		attribute.Int64("limit", request.Limit),
+		attribute.Bool("hasCursor", request.After != nil),
+	))
+	defer span.End()
+
+	params := productrepository.ListOrdersParams{
+		Status:   sql.NullString{String: request.Status, Valid: request.Status != ""},
+		PageSize: request.Limit + 1,
+	}
+	if request.After != nil {
+		params.BeforeID = sql.NullInt64{Int64: request.After.Id, Valid: true}
+	}
+
+	data, err := s.repository.Query.ListOrders(ctx, params)
+	if err != nil {
+		err = translateError(err, "order")
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to list orders", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.OrderListResponse{}, err
+	}
+
+	paging := model.Paging{Limit: request.Limit}
+	if int64(len(data)) > request.Limit {
+		data = data[:request.Limit]
+
+		last := data[len(data)-1]
+		cursor, err := utility.EncodeCursor(utility.Cursor{SortBy: "id", SortOrder: "desc", Value: last.ID, Id: last.ID})
+		if err != nil {
+			err = translateError(err, "order")
+			utility.RecordSpanError(span, err)
+			zap.L().Error("failed to encode cursor", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+			return model.OrderListResponse{}, err
+		}
+
+		paging.NextCursor = cursor
+		paging.HasMore = true
+	}
+
+	orders := make([]model.OrderResponse, 0, len(data))
+	for _, order := range data {
+		orders = append(orders, orderResponse(order, nil, nil))
+	}
+
+	return model.OrderListResponse{Data: orders, Paging: paging}, nil
+}
+
+// PayOrder records the payment of a pending order.
+func (s *OrderService) PayOrder(ctx context.Context, id int64) (model.OrderResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.PayOrder")
+	defer span.End()
+
+	response, err := s.transition(ctx, span, id, model.OrderStatusPaid)
+	if err != nil {
+		zap.L().Error("failed to pay order", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.OrderResponse{}, err
+	}
+	return response, nil
+}
+
+// ShipOrder records the shipment of a paid order. The units already left the stock when the order was placed.
+func (s *OrderService) ShipOrder(ctx context.Context, id int64) (model.OrderResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.ShipOrder")
+	defer span.End()
+
+	response, err := s.transition(ctx, span, id, model.OrderStatusShipped)
+	if err != nil {
+		zap.L().Error("failed to ship order", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.OrderResponse{}, err
+	}
+	return response, nil
+}
+
+// CancelOrder cancels an order that has not been shipped and puts its units back in stock, into the warehouses
+// they were ordered from.
+func (s *OrderService) CancelOrder(ctx context.Context, id int64) (model.OrderResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.CancelOrder")
+	defer span.End()
+
+	response, err := s.transition(ctx, span, id, model.OrderStatusCancelled)
+	if err != nil {
+		zap.L().Error("failed to cancel order", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.OrderResponse{}, err
+	}
+	return response, nil
+}
+
+// RefreshMetrics sets the order gauges from the database, e.g. on startup.
+func (s *OrderService) RefreshMetrics(ctx context.Context) error {
+	ctx, span := s.trace.Start(ctx, "Service.RefreshOrderMetrics")
+	defer span.End()
+
+	counts, err := s.repository.Query.CountOrdersByStatus(ctx)
+	if err != nil {
+		err = translateError(err, "order")
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to refresh order metrics", zap.Error(err))
+		return err
+	}
+
+	for _, status := range []string{model.OrderStatusPending, model.OrderStatusPaid, model.OrderStatusShipped, model.OrderStatusCancelled} {
+		OrdersByStatus.WithLabelValues(status).Set(0)
+	}
+	for _, count := range counts {
+		OrdersByStatus.WithLabelValues(count.Status).Set(float64(count.Count))
+	}
+	return nil
+}
+
+// transition moves an order to status to, which the state machine must allow from its current status, and
+// records the change. The span of the caller gets the statuses and the error, if any.
+func (s *OrderService) transition(ctx context.Context, span trace.Span, id int64, to string) (model.OrderResponse, error) {
+	span.SetAttributes(attribute.Int64("orderId", id), attribute.String("toStatus", to))
+
+	now := time.Now().UTC()
+
+	var order productrepository.Order
+	var response model.OrderResponse
+	var movements []productrepository.StockMovement
+	err := s.repository.Transaction(ctx, func(tx *sql.Tx) error {
+		query := s.repository.Query.WithTx(tx)
+
+		var err error
+		order, err = query.GetOrder(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !model.CanTransitionOrder(order.Status, to) {
+			return apperror.New(apperror.Conflict, fmt.Sprintf("order %d is %s and cannot become %s", id, order.Status, to))
+		}
+
+		// Note : The update only matches while the order still has the status read above, so of two concurrent
+		// transitions only one is made.
+		moved, err := query.TransitionOrder(ctx, productrepository.TransitionOrderParams{
+			ToStatus:   to,
+			UpdatedAt:  sql.NullTime{Time: now, Valid: true},
+			ID:         id,
+			FromStatus: order.Status,
+		})
+		if err != nil {
+			return err
+		}
+		if moved == 0 {
+			return apperror.New(apperror.Conflict, fmt.Sprintf("order %d was changed meanwhile, try again", id))
+		}
+
+		if to == model.OrderStatusCancelled {
+			lines, err := query.ListOrderLines(ctx, id)
+			if err != nil {
+				return err
+			}
+			for _, line := range lines {
+				// Note : A product deleted since the order was placed has nothing to give the units back to. Any other
+				// line that cannot be given back, e.g. of a variant deleted meanwhile, rolls the cancellation back.
+				if _, err := query.GetProduct(ctx, line.ProductID); errors.Is(err, sql.ErrNoRows) {
+					continue
+				} else if err != nil {
+					return err
+				}
+
+				movement, err := moveOrderedStock(ctx, query, line, line.Quantity, model.StockReasonOrderCancel, now)
+				if errors.Is(err, sql.ErrNoRows) && line.VariantID.Valid {
+					return translateError(err, fmt.Sprintf("variant %d of product %d", line.VariantID.Int64, line.ProductID))
+				}
+				if err != nil {
+					return err
+				}
+				movements = append(movements, movement)
+			}
+		}
+
+		if err := recordOrderTransition(ctx, query, id, order.Status, to, now); err != nil {
+			return err
+		}
+
+		response, err = s.getOrder(ctx, query, id)
+		return err
+	})
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("order %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to transition order", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)), zap.Int64("orderId", id), zap.String("toStatus", to))
+		return model.OrderResponse{}, err
+	}
+
+	// Note : An order entered its previous status when it was last updated, or when it was placed.
+	enteredAt := order.CreatedAt
+	if order.UpdatedAt.Valid {
+		enteredAt = order.UpdatedAt.Time
+	}
+
+	span.SetAttributes(attribute.String("fromStatus", order.Status))
+	span.AddEvent("transition", trace.WithAttributes(
+		attribute.String("from", order.Status),
+		attribute.String("to", to),
+	))
+	OrderTransitions.WithLabelValues(order.Status, to).Inc()
+	OrderStatusDuration.WithLabelValues(order.Status).Observe(now.Sub(enteredAt).Seconds())
+	OrdersByStatus.WithLabelValues(order.Status).Dec()
+	OrdersByStatus.WithLabelValues(to).Inc()
+	observeWarehouseMovements(ctx, s.repository.Query, movements...)
+
+	return response, nil
+}
+
+func (s *OrderService) getOrder(ctx context.Context, query *productrepository.Queries, id int64) (model.OrderResponse, error) {
+	order, err := query.GetOrder(ctx, id)
+	if err != nil {
+		return model.OrderResponse{}, err
+	}
+
+	lines, err := query.ListOrderLines(ctx, id)
+	if err != nil {
+		return model.OrderResponse{}, err
+	}
+
+	transitions, err := query.ListOrderTransitions(ctx, id)
+	if err != nil {
+		return model.OrderResponse{}, err
+	}
+
+	return orderResponse(order, lines, transitions), nil
+}
+
+// orderLinePrice returns the unit price of an order line in minor units once the pricing rules running at now are
+// applied, its currency, and the rules that were.
+func orderLinePrice(ctx context.Context, query *productrepository.Queries, line model.OrderLineRequest, now time.Time) (int64, string, []model.AppliedPricingRuleResponse, error) {
+	basePrice, currency, err := listPrice(ctx, query, line.ProductId, line.VariantId)
+	if err != nil {
+		return 0, "", nil, err
+	}
+	unitPrice, applied, err := applyPricingRules(ctx, query, line.ProductId, basePrice, currency, line.Quantity, now)
+	if err != nil {
+		return 0, "", nil, err
+	}
+	return unitPrice, currency, applied, nil
+}
+
+// moveOrderedStock changes the quantity of an ordered line and records it in the stock ledger.
+func moveOrderedStock(ctx context.Context, query *productrepository.Queries, line productrepository.OrderLine, delta int64, reason string, now time.Time) (productrepository.StockMovement, error) {
+	_, movement, err := moveStock(ctx, query, productrepository.CreateStockMovementParams{
+		ProductID:   line.ProductID,
+		VariantID:   line.VariantID,
+		WarehouseID: line.WarehouseID,
+		Delta:       delta,
+		Reason:      reason,
+		Reference:   sql.NullString{String: fmt.Sprintf("order %d", line.OrderID), Valid: true},
+		RequestID:   requestIdOf(ctx),
+		CreatedAt:   now,
+	})
+	return movement, err
+}
+
+// recordOrderTransition adds a status change to the history of an order. from is empty for its placement.
+func recordOrderTransition(ctx context.Context, query *productrepository.Queries, orderId int64, from, to string, now time.Time) error {
+	return query.CreateOrderTransition(ctx, productrepository.CreateOrderTransitionParams{
+		OrderID:    orderId,
+		FromStatus: sql.NullString{String: from, Valid: from != ""},
+		ToStatus:   to,
+		RequestID:  requestIdOf(ctx),
+		CreatedAt:  now,
+	})
+}
+
+func orderResponse(order productrepository.Order, lines []productrepository.OrderLine, transitions []productrepository.OrderTransition) model.OrderResponse {
+	response := model.OrderResponse{
+		Id:        order.ID,
+		Status:    order.Status,
+		Reference: order.Reference.String,
+		Total:     model.NewMoney(order.TotalMinor, order.Currency),
+		Currency:  order.Currency,
+		CreatedAt: order.CreatedAt,
+	}
+	if order.UpdatedAt.Valid {
+		response.UpdatedAt = &order.UpdatedAt.Time
+	}
+
+	for _, line := range lines {
+		item := model.OrderLineResponse{
+			ProductId: line.ProductID,
+			Quantity:  line.Quantity,
+			UnitPrice: model.NewMoney(line.UnitPriceMinor, order.Currency),
+			Amount:    model.NewMoney(line.UnitPriceMinor*line.Quantity, order.Currency),
+		}
+		if line.VariantID.Valid {
+			item.VariantId = &line.VariantID.Int64
+		}
+		if line.WarehouseID.Valid {
+			item.WarehouseId = &line.WarehouseID.Int64
+		}
+		response.Lines = append(response.Lines, item)
+	}
+
+	for _, transition := range transitions {
+		response.Transitions = append(response.Transitions, model.OrderTransitionResponse{
+			From:      transition.FromStatus.String,
+			To:        transition.ToStatus,
+			RequestId: transition.RequestID.String,
+			CreatedAt: transition.CreatedAt,
+		})
+	}
+
+	return response
+}
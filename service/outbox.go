@@ -0,0 +1,312 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/repository"
+	productrepository "github.com/indrabrata/observability-playground/repository/product"
+	"github.com/indrabrata/observability-playground/utility"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	outboxRelayBatchSize = 100
+
+	// Note : A failed event is retried after outboxRetryDelay, doubled on every further failure up to outboxMaxRetryDelay.
+	// It is never given up on, the backlog metrics show an event that keeps failing.
+	outboxRetryDelay    = 5 * time.Second
+	outboxMaxRetryDelay = time.Hour
+)
+
+// recordEvent writes an event to the outbox through query, which must belong to the transaction that makes the
+// change, so the event is stored exactly when the change is committed. The trace context of ctx is stored with
+// it, which lets the relay link its dispatch back to the request that made the change.
+func recordEvent(ctx context.Context, query *productrepository.Queries, eventType, aggregateType string, aggregateId int64, payload any, occurredAt time.Time) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	// Note : The W3C propagator is used directly rather than the global one, so the stored context does not depend on the setup.
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+
+	return query.CreateOutboxEvent(ctx, productrepository.CreateOutboxEventParams{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateId,
+		EventType:     eventType,
+		Payload:       string(body),
+		RequestID:     requestIdOf(ctx),
+		TraceParent:   sql.NullString{String: carrier.Get("traceparent"), Valid: carrier.Get("traceparent") != ""},
+		TraceState:    sql.NullString{String: carrier.Get("tracestate"), Valid: carrier.Get("tracestate") != ""},
+		CreatedAt:     occurredAt.UTC(),
+	})
+}
+
+// recordProductEvent writes a product event whose payload is the product as the API returns it.
+func recordProductEvent(ctx context.Context, query *productrepository.Queries, eventType string, product productrepository.Product, occurredAt time.Time) error {
+	return recordEvent(ctx, query, eventType, model.AggregateProduct, product.ID, productResponse(product), occurredAt)
+}
+
+// productResponse returns product as the API returns it. Every response and event payload of a product is built
+// here, so a new column only has to be added once.
+func productResponse(product productrepository.Product) model.ProductResponse {
+	response := model.ProductResponse{
+		Id:           product.ID,
+		Name:         product.Name,
+		Quantity:     product.Quantity,
+		Price:        model.NewMoney(product.PriceMinor, product.Currency),
+		Currency:     product.Currency,
+		Sku:          product.Sku.String,
+		Barcode:      product.Barcode.String,
+		ReorderPoint: nullInt64Pointer(product.ReorderPoint),
+		Version:      product.Version,
+	}
+	if product.DeletedAt.Valid {
+		response.DeletedAt = &product.DeletedAt.Time
+	}
+	return response
+}
+
+// OutboxRelay hands the events written to the outbox to its sinks. An event is marked dispatched once every sink
+// accepted it; otherwise it is handed to all of them again later, so delivery is at least once.
+type OutboxRelay struct {
+	repository *repository.BaseRepository[*productrepository.Queries]
+	trace      trace.Tracer
+	sinks      []OutboxSink
+}
+
+func NewOutboxRelay(repository *repository.BaseRepository[*productrepository.Queries], trace trace.Tracer, sinks ...OutboxSink) *OutboxRelay {
+	return &OutboxRelay{
+		repository: repository,
+		trace:      trace,
+		sinks:      sinks,
+	}
+}
+
+// RelayOutbox dispatches every outbox event that is due, in the order they were written, and returns how many
+// were dispatched. A failed event is rescheduled and does not hold up the events after it.
+func (r *OutboxRelay) RelayOutbox(ctx context.Context) (int64, error) {
+	ctx, span := r.trace.Start(ctx, "Service.RelayOutbox")
+	defer span.End()
+
+	now := time.Now().UTC()
+
+	var dispatched, failed int64
+	for {
+		// Note : Dispatched events and rescheduled ones, which are due after now, drop out of the next page.
+		events, err := r.repository.Query.ListPendingOutboxEvents(ctx, productrepository.ListPendingOutboxEventsParams{
+			Now:      now,
+			PageSize: outboxRelayBatchSize,
+		})
+		if err != nil {
+			err = translateError(err, "outbox event")
+			utility.RecordSpanError(span, err)
+			zap.L().Error("failed to list pending outbox events", zap.Error(err))
+			return dispatched, err
+		}
+
+		for _, event := range events {
+			ok, err := r.dispatch(ctx, event)
+			if err != nil {
+				err = translateError(err, "outbox event")
+				utility.RecordSpanError(span, err)
+				zap.L().Error("failed to record outbox dispatch", zap.Error(err), zap.Int64("eventId", event.ID))
+				return dispatched, err
+			}
+			if ok {
+				dispatched++
+			} else {
+				failed++
+			}
+		}
+
+		if len(events) < outboxRelayBatchSize {
+			break
+		}
+	}
+
+	span.SetAttributes(
+		attribute.Int64("dispatchedCount", dispatched),
+		attribute.Int64("failedCount", failed),
+	)
+
+	if err := r.refreshMetrics(ctx); err != nil {
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to refresh outbox metrics", zap.Error(err))
+	}
+
+	return dispatched, nil
+}
+
+// Run calls RelayOutbox every interval until ctx is cancelled.
+func (r *OutboxRelay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.RelayOutbox(ctx)
+		}
+	}
+}
+
+// RunCleanup deletes events dispatched longer than retention ago every interval until ctx is cancelled.
+func (r *OutboxRelay) RunCleanup(ctx context.Context, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := r.repository.Query.DeleteDispatchedOutboxEvents(ctx, sql.NullTime{Time: time.Now().UTC().Add(-retention), Valid: true})
+			if err != nil {
+				zap.L().Error("failed to delete dispatched outbox events", zap.Error(err))
+			} else if deleted > 0 {
+				zap.L().Info("deleted dispatched outbox events", zap.Int64("deletedCount", deleted), zap.Duration("retention", retention))
+			}
+		}
+	}
+}
+
+// RefreshMetrics sets the backlog gauges from the outbox, e.g. on startup before the first relay.
+func (r *OutboxRelay) RefreshMetrics(ctx context.Context) error {
+	ctx, span := r.trace.Start(ctx, "Service.RefreshOutboxMetrics")
+	defer span.End()
+
+	if err := r.refreshMetrics(ctx); err != nil {
+		err = translateError(err, "outbox event")
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to refresh outbox metrics", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// dispatch hands an event to every sink and records the outcome. It reports whether all sinks accepted the event,
+// the error is only about recording the outcome.
+func (r *OutboxRelay) dispatch(ctx context.Context, event productrepository.OutboxEvent) (bool, error) {
+	domainEvent := domainEventOf(event)
+
+	// Note : The dispatch is part of the relay's trace, the request that wrote the event is linked rather than
+	// continued, since the relay runs long after that request ended.
+	options := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.Int64("eventId", event.ID),
+			attribute.String("eventType", event.EventType),
+			attribute.Int64("aggregateId", event.AggregateID),
+			attribute.Int64("attempt", event.Attempts+1),
+		),
+	}
+	origin := propagation.TraceContext{}.Extract(context.Background(), propagation.MapCarrier{
+		"traceparent": domainEvent.TraceParent,
+		"tracestate":  domainEvent.TraceState,
+	})
+	if link := trace.LinkFromContext(origin); link.SpanContext.IsValid() {
+		options = append(options, trace.WithLinks(link))
+	}
+	ctx, span := r.trace.Start(ctx, "Service.DispatchOutboxEvent", options...)
+	defer span.End()
+
+	var errs []error
+	for _, sink := range r.sinks {
+		if err := r.publish(ctx, sink, domainEvent); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	now := time.Now().UTC()
+	if err := errors.Join(errs...); err != nil {
+		utility.RecordSpanError(span, err)
+		nextAttemptAt := now.Add(retryBackoff(event.Attempts, outboxRetryDelay, outboxMaxRetryDelay))
+		zap.L().Warn("failed to dispatch outbox event", zap.Error(err), zap.Int64("eventId", event.ID), zap.Int64("attempts", event.Attempts+1), zap.Time("nextAttemptAt", nextAttemptAt))
+		return false, r.repository.Query.MarkOutboxEventFailed(ctx, productrepository.MarkOutboxEventFailedParams{
+			NextAttemptAt: nextAttemptAt,
+			LastError:     sql.NullString{String: err.Error(), Valid: true},
+			ID:            event.ID,
+		})
+	}
+
+	err := r.repository.Query.MarkOutboxEventDispatched(ctx, productrepository.MarkOutboxEventDispatchedParams{
+		DispatchedAt: sql.NullTime{Time: now, Valid: true},
+		ID:           event.ID,
+	})
+	if err != nil {
+		return false, err
+	}
+	OutboxDispatchLag.Observe(now.Sub(event.CreatedAt).Seconds())
+
+	return true, nil
+}
+
+func (r *OutboxRelay) publish(ctx context.Context, sink OutboxSink, event model.DomainEvent) error {
+	ctx, span := r.trace.Start(ctx, "Service.PublishOutboxEvent", trace.WithAttributes(
+		attribute.String("sink", sink.Name()),
+		attribute.Int64("eventId", event.Id),
+	))
+	defer span.End()
+
+	if err := sink.Publish(ctx, event); err != nil {
+		utility.RecordSpanError(span, err)
+		OutboxPublished.WithLabelValues(sink.Name(), "failed").Inc()
+		return err
+	}
+	OutboxPublished.WithLabelValues(sink.Name(), "published").Inc()
+	return nil
+}
+
+func (r *OutboxRelay) refreshMetrics(ctx context.Context) error {
+	pending, err := r.repository.Query.CountPendingOutboxEvents(ctx)
+	if err != nil {
+		return err
+	}
+
+	var age time.Duration
+	oldest, err := r.repository.Query.GetOldestPendingOutboxEvent(ctx)
+	if err == nil {
+		age = time.Since(oldest.CreatedAt)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	OutboxBacklog.Set(float64(pending))
+	OutboxOldestPendingAge.Set(age.Seconds())
+	return nil
+}
+
+// retryBackoff returns how long to wait before retrying something that failed after attempts earlier attempts:
+// delay, doubled for every earlier attempt, up to maxDelay.
+func retryBackoff(attempts int64, delay, maxDelay time.Duration) time.Duration {
+	for i := int64(0); i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxDelay)
+}
+
+func domainEventOf(event productrepository.OutboxEvent) model.DomainEvent {
+	return model.DomainEvent{
+		Id:            event.ID,
+		Type:          event.EventType,
+		AggregateType: event.AggregateType,
+		AggregateId:   event.AggregateID,
+		Payload:       json.RawMessage(event.Payload),
+		RequestId:     event.RequestID.String,
+		TraceParent:   event.TraceParent.String,
+		TraceState:    event.TraceState.String,
+		OccurredAt:    event.CreatedAt,
+	}
+}
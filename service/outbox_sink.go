@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+
+	"github.com/indrabrata/observability-playground/model"
+	"go.uber.org/zap"
+)
+
+// OutboxSink receives the events relayed from the outbox. Delivery is at least once: an event is handed to every
+// sink again when any of them failed, so a sink must tolerate events it has already seen, e.g. by their id.
+type OutboxSink interface {
+	// Name labels the sink in metrics and logs.
+	Name() string
+	Publish(ctx context.Context, event model.DomainEvent) error
+}
+
+// LogOutboxSink writes every event to the application log.
+type LogOutboxSink struct{}
+
+func (LogOutboxSink) Name() string {
+	return "log"
+}
+
+func (LogOutboxSink) Publish(ctx context.Context, event model.DomainEvent) error {
+	zap.L().Info("domain event",
+		zap.Int64("eventId", event.Id),
+		zap.String("type", event.Type),
+		zap.String("aggregateType", event.AggregateType),
+		zap.Int64("aggregateId", event.AggregateId),
+		zap.String("requestId", event.RequestId))
+	return nil
+}
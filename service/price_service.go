@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/model"
+	productrepository "github.com/indrabrata/observability-playground/repository/product"
+	"github.com/indrabrata/observability-playground/utility"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Note : The scheduler applies at most this many due prices per pass before looking for more.
+const priceSchedulerBatchSize = 100
+
+// GetPriceHistory returns every price a product has had, oldest first, together with the prices still scheduled for it.
+// The history itself is written by triggers on the products table, so every write path is covered.
+func (s *ProductService) GetPriceHistory(ctx context.Context, id int64) (model.PriceHistoryResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.GetPriceHistory")
+	defer span.End()
+
+	_, err := s.repository.Query.GetProduct(ctx, id)
+	var history []productrepository.PriceHistory
+	var scheduled []productrepository.ScheduledPrice
+	if err == nil {
+		history, err = s.repository.Query.ListPriceHistory(ctx, id)
+	}
+	if err == nil {
+		scheduled, err = s.repository.Query.ListPendingScheduledPrices(ctx, id)
+	}
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("product %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to get price history", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.PriceHistoryResponse{}, err
+	}
+
+	response := model.PriceHistoryResponse{
+		ProductId: id,
+		History:   make([]model.PriceChangeResponse, 0, len(history)),
+		Scheduled: make([]model.ScheduledPriceResponse, 0, len(scheduled)),
+	}
+	for _, change := range history {
+		entry := model.PriceChangeResponse{
+			Id:        change.ID,
+			NewPrice:  model.NewMoney(change.NewPriceMinor, change.NewCurrency),
+			Currency:  change.NewCurrency,
+			ChangedAt: change.ChangedAt,
+		}
+		if change.OldPriceMinor.Valid {
+			oldPrice := model.NewMoney(change.OldPriceMinor.Int64, change.OldCurrency.String)
+			entry.OldPrice = &oldPrice
+			entry.OldCurrency = change.OldCurrency.String
+		}
+		response.History = append(response.History, entry)
+	}
+	for _, price := range scheduled {
+		response.Scheduled = append(response.Scheduled, scheduledPriceResponse(price))
+	}
+
+	return response, nil
+}
+
+// SchedulePrice stores a price that the scheduler applies once its effective time has passed.
+func (s *ProductService) SchedulePrice(ctx context.Context, id int64, request model.ScheduledPriceRequest) (model.ScheduledPriceResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.SchedulePrice", trace.WithAttributes(attribute.String("effectiveAt", request.EffectiveAt.UTC().Format(time.RFC3339))))
+	defer span.End()
+
+	var scheduled productrepository.ScheduledPrice
+	product, err := s.repository.Query.GetProduct(ctx, id)
+	if err == nil {
+		currency := request.Currency
+		if currency == "" {
+			currency = product.Currency
+		}
+
+		var priceMinor int64
+		priceMinor, err = request.PriceMinorUnits(currency)
+		if err != nil {
+			err = apperror.Wrap(apperror.Validation, err, "request validation failed")
+		} else {
+			// Note : Scheduled times are stored in UTC so that the scheduler can compare them as plain text.
+			scheduled, err = s.repository.Query.CreateScheduledPrice(ctx, productrepository.CreateScheduledPriceParams{
+				ProductID:   id,
+				PriceMinor:  priceMinor,
+				Currency:    currency,
+				EffectiveAt: request.EffectiveAt.UTC(),
+				CreatedAt:   time.Now().UTC(),
+			})
+		}
+	}
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("product %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to schedule price", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.ScheduledPriceResponse{}, err
+	}
+
+	span.SetAttributes(attribute.Int64("scheduledPriceId", scheduled.ID))
+
+	return scheduledPriceResponse(scheduled), nil
+}
+
+// CancelScheduledPrice withdraws a scheduled price that has not been applied yet.
+func (s *ProductService) CancelScheduledPrice(ctx context.Context, id, scheduledPriceId int64) error {
+	ctx, span := s.trace.Start(ctx, "Service.CancelScheduledPrice", trace.WithAttributes(attribute.Int64("scheduledPriceId", scheduledPriceId)))
+	defer span.End()
+
+	cancelled, err := s.repository.Query.CancelScheduledPrice(ctx, productrepository.CancelScheduledPriceParams{
+		ID:        scheduledPriceId,
+		ProductID: id,
+	})
+	if err == nil && cancelled == 0 {
+		err = apperror.New(apperror.NotFound, fmt.Sprintf("pending scheduled price %d of product %d not found", scheduledPriceId, id))
+	}
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("scheduled price %d", scheduledPriceId))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to cancel scheduled price", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return err
+	}
+
+	return nil
+}
+
+// ApplyScheduledPrices applies every scheduled price whose effective time has passed and returns how many were handled.
+func (s *ProductService) ApplyScheduledPrices(ctx context.Context) (int64, error) {
+	ctx, span := s.trace.Start(ctx, "Service.ApplyScheduledPrices")
+	defer span.End()
+
+	var applied int64
+	for {
+		due, err := s.repository.Query.ListDueScheduledPrices(ctx, productrepository.ListDueScheduledPricesParams{
+			Now:      time.Now().UTC(),
+			PageSize: priceSchedulerBatchSize,
+		})
+		if err != nil {
+			err = translateError(err, "scheduled price")
+			utility.RecordSpanError(span, err)
+			zap.L().Error("failed to list due scheduled prices", zap.Error(err))
+			return applied, err
+		}
+
+		for _, price := range due {
+			if err := s.applyScheduledPrice(ctx, price); err != nil {
+				return applied, err
+			}
+			applied++
+		}
+
+		if len(due) < priceSchedulerBatchSize {
+			break
+		}
+	}
+
+	span.SetAttributes(attribute.Int64("appliedCount", applied))
+
+	return applied, nil
+}
+
+// RunPriceScheduler calls ApplyScheduledPrices every interval until ctx is cancelled.
+func (s *ProductService) RunPriceScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.ApplyScheduledPrices(ctx)
+		}
+	}
+}
+
+// applyScheduledPrice changes the product price and closes the scheduled price in one transaction.
+// Closing only succeeds while the scheduled price is still pending, so one cancelled in the meantime is left alone.
+func (s *ProductService) applyScheduledPrice(ctx context.Context, price productrepository.ScheduledPrice) error {
+	ctx, span := s.trace.Start(ctx, "Service.ApplyScheduledPrice", trace.WithAttributes(
+		attribute.Int64("scheduledPriceId", price.ID),
+		attribute.Int64("productId", price.ProductID),
+		attribute.String("price", model.NewMoney(price.PriceMinor, price.Currency).String()),
+		attribute.String("currency", price.Currency),
+	))
+	defer span.End()
+
+	// Note : The change runs in the background, the audit log records the price scheduler as its actor.
+	ctx = context.WithValue(ctx, "actor", model.AuditActorPriceScheduler)
+
+	now := time.Now().UTC()
+	status := model.ScheduledPriceStatusApplied
+
+	var oldPrice model.Money
+	var oldCurrency string
+	var finished int64
+	err := s.repository.Transaction(ctx, func(tx *sql.Tx) error {
+		query := s.repository.Query.WithTx(tx)
+
+		current, err := query.GetProduct(ctx, price.ProductID)
+		if errors.Is(err, sql.ErrNoRows) {
+			// Note : The product was deleted after the price was scheduled, there is nothing left to reprice.
+			status = model.ScheduledPriceStatusSkipped
+		} else if err != nil {
+			return err
+		}
+		oldPrice, oldCurrency = model.NewMoney(current.PriceMinor, current.Currency), current.Currency
+
+		finished, err = query.FinishScheduledPrice(ctx, productrepository.FinishScheduledPriceParams{
+			Status:    status,
+			AppliedAt: sql.NullTime{Time: now, Valid: status == model.ScheduledPriceStatusApplied},
+			ID:        price.ID,
+		})
+		if err != nil || finished == 0 || status != model.ScheduledPriceStatusApplied {
+			return err
+		}
+
+		product, err := query.ApplyProductPrice(ctx, productrepository.ApplyProductPriceParams{
+			PriceMinor: price.PriceMinor,
+			Currency:   price.Currency,
+			UpdatedAt:  sql.NullTime{Time: now, Valid: true},
+			ID:         price.ProductID,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := recordProductEvent(ctx, query, model.EventProductUpdated, product, now); err != nil {
+			return err
+		}
+		return recordProductAudit(ctx, query, model.AuditActionUpdate, &current, product, now)
+	})
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("scheduled price %d", price.ID))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to apply scheduled price", zap.Error(err), zap.Int64("scheduledPriceId", price.ID), zap.Int64("productId", price.ProductID))
+		return err
+	}
+	if finished == 0 {
+		// Note : The scheduled price was cancelled after it was listed as due.
+		return nil
+	}
+
+	span.SetAttributes(attribute.String("status", status))
+	AppliedScheduledPrices.WithLabelValues(status).Inc()
+
+	zap.L().Info("scheduled price handled",
+		zap.Int64("scheduledPriceId", price.ID),
+		zap.Int64("productId", price.ProductID),
+		zap.String("status", status),
+		zap.Stringer("oldPrice", oldPrice),
+		zap.String("oldCurrency", oldCurrency),
+		zap.Stringer("newPrice", model.NewMoney(price.PriceMinor, price.Currency)),
+		zap.String("currency", price.Currency),
+		zap.Time("effectiveAt", price.EffectiveAt),
+	)
+
+	return nil
+}
+
+func scheduledPriceResponse(price productrepository.ScheduledPrice) model.ScheduledPriceResponse {
+	response := model.ScheduledPriceResponse{
+		Id:          price.ID,
+		ProductId:   price.ProductID,
+		Price:       model.NewMoney(price.PriceMinor, price.Currency),
+		Currency:    price.Currency,
+		EffectiveAt: price.EffectiveAt,
+		Status:      price.Status,
+		CreatedAt:   price.CreatedAt,
+	}
+	if price.AppliedAt.Valid {
+		response.AppliedAt = &price.AppliedAt.Time
+	}
+	return response
+}
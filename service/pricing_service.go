@@ -0,0 +1,428 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/repository"
+	productrepository "github.com/indrabrata/observability-playground/repository/product"
+	"github.com/indrabrata/observability-playground/utility"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// PricingService manages the pricing rules and quotes the price of a product once they are applied. The price of
+// a product stays its list price, rules only change what a quantity of it costs at a given time.
+type PricingService struct {
+	repository *repository.BaseRepository[*productrepository.Queries]
+	trace      trace.Tracer
+}
+
+func NewPricingService(repository *repository.BaseRepository[*productrepository.Queries], trace trace.Tracer) *PricingService {
+	return &PricingService{
+		repository: repository,
+		trace:      trace,
+	}
+}
+
+func (s *PricingService) CreatePricingRule(ctx context.Context, request model.PricingRuleRequest) (model.PricingRuleResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.CreatePricingRule", trace.WithAttributes(attribute.String("ruleType", request.Type)))
+	defer span.End()
+
+	var response model.PricingRuleResponse
+	err := s.repository.Transaction(ctx, func(tx *sql.Tx) error {
+		query := s.repository.Query.WithTx(tx)
+
+		if err := checkPricingRuleScope(ctx, query, request); err != nil {
+			return err
+		}
+
+		start, end := pricingRuleWindow(request)
+		rule, err := query.CreatePricingRule(ctx, productrepository.CreatePricingRuleParams{
+			Name:       request.Name,
+			Type:       request.Type,
+			ProductID:  nullInt64(request.ProductId),
+			CategoryID: nullInt64(request.CategoryId),
+			Currency:   sql.NullString{String: request.Currency, Valid: request.Currency != ""},
+			StartsAt:   start,
+			EndsAt:     end,
+			CreatedAt:  time.Now().UTC(),
+		})
+		if err != nil {
+			return err
+		}
+
+		tiers, err := createPricingRuleTiers(ctx, query, rule.ID, request)
+		if err != nil {
+			return err
+		}
+
+		response = pricingRuleResponse(rule, tiers)
+		return nil
+	})
+	if err != nil {
+		err = translateError(err, "pricing rule")
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to create pricing rule", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.PricingRuleResponse{}, err
+	}
+
+	span.SetAttributes(attribute.Int64("ruleId", response.Id))
+
+	return response, nil
+}
+
+func (s *PricingService) GetPricingRule(ctx context.Context, id int64) (model.PricingRuleResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.GetPricingRule", trace.WithAttributes(attribute.Int64("ruleId", id)))
+	defer span.End()
+
+	rule, err := s.repository.Query.GetPricingRule(ctx, id)
+	var tiers []productrepository.PricingRuleTier
+	if err == nil {
+		tiers, err = s.repository.Query.ListPricingRuleTiers(ctx, id)
+	}
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("pricing rule %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to get pricing rule", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.PricingRuleResponse{}, err
+	}
+
+	return pricingRuleResponse(rule, tiers), nil
+}
+
+func (s *PricingService) ListPricingRules(ctx context.Context) (model.PricingRuleListResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.ListPricingRules")
+	defer span.End()
+
+	rules, err := s.repository.Query.ListPricingRules(ctx)
+	var tiers []productrepository.PricingRuleTier
+	if err == nil {
+		tiers, err = s.repository.Query.ListAllPricingRuleTiers(ctx)
+	}
+	if err != nil {
+		err = translateError(err, "pricing rule")
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to list pricing rules", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.PricingRuleListResponse{}, err
+	}
+
+	tiersByRule := make(map[int64][]productrepository.PricingRuleTier, len(rules))
+	for _, tier := range tiers {
+		tiersByRule[tier.RuleID] = append(tiersByRule[tier.RuleID], tier)
+	}
+
+	responses := make([]model.PricingRuleResponse, 0, len(rules))
+	for _, rule := range rules {
+		responses = append(responses, pricingRuleResponse(rule, tiersByRule[rule.ID]))
+	}
+
+	return model.PricingRuleListResponse{Data: responses}, nil
+}
+
+// UpdatePricingRule replaces a pricing rule, tiers included. Quotes and orders pick the change up straight away.
+func (s *PricingService) UpdatePricingRule(ctx context.Context, id int64, request model.PricingRuleRequest) (model.PricingRuleResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.UpdatePricingRule", trace.WithAttributes(attribute.Int64("ruleId", id)))
+	defer span.End()
+
+	var response model.PricingRuleResponse
+	err := s.repository.Transaction(ctx, func(tx *sql.Tx) error {
+		query := s.repository.Query.WithTx(tx)
+
+		if _, err := query.GetPricingRule(ctx, id); err != nil {
+			return err
+		}
+		if err := checkPricingRuleScope(ctx, query, request); err != nil {
+			return err
+		}
+
+		start, end := pricingRuleWindow(request)
+		rule, err := query.UpdatePricingRule(ctx, productrepository.UpdatePricingRuleParams{
+			Name:       request.Name,
+			Type:       request.Type,
+			ProductID:  nullInt64(request.ProductId),
+			CategoryID: nullInt64(request.CategoryId),
+			Currency:   sql.NullString{String: request.Currency, Valid: request.Currency != ""},
+			StartsAt:   start,
+			EndsAt:     end,
+			UpdatedAt:  sql.NullTime{Time: time.Now().UTC(), Valid: true},
+			ID:         id,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := query.DeletePricingRuleTiers(ctx, id); err != nil {
+			return err
+		}
+		tiers, err := createPricingRuleTiers(ctx, query, id, request)
+		if err != nil {
+			return err
+		}
+
+		response = pricingRuleResponse(rule, tiers)
+		return nil
+	})
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("pricing rule %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to update pricing rule", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.PricingRuleResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (s *PricingService) DeletePricingRule(ctx context.Context, id int64) error {
+	ctx, span := s.trace.Start(ctx, "Service.DeletePricingRule", trace.WithAttributes(attribute.Int64("ruleId", id)))
+	defer span.End()
+
+	err := s.repository.Transaction(ctx, func(tx *sql.Tx) error {
+		query := s.repository.Query.WithTx(tx)
+
+		if err := query.DeletePricingRuleTiers(ctx, id); err != nil {
+			return err
+		}
+
+		deleted, err := query.DeletePricingRule(ctx, id)
+		if err == nil && deleted == 0 {
+			err = sql.ErrNoRows
+		}
+		return err
+	})
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("pricing rule %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to delete pricing rule", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return err
+	}
+
+	return nil
+}
+
+// QuotePrice returns what request.Quantity units of a product, or of one of its variants, cost right now and which
+// pricing rules made up the difference with the list price.
+func (s *PricingService) QuotePrice(ctx context.Context, id int64, request model.PriceQuoteRequest) (model.PriceQuoteResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.QuotePrice", trace.WithAttributes(
+		attribute.Int64("productId", id),
+		attribute.Int64("quantity", request.Quantity),
+	))
+	defer span.End()
+
+	now := time.Now().UTC()
+
+	basePrice, currency, err := listPrice(ctx, s.repository.Query, id, request.VariantId)
+	var unitPrice, baseTotal, total int64
+	var applied []model.AppliedPricingRuleResponse
+	if err == nil {
+		unitPrice, applied, err = applyPricingRules(ctx, s.repository.Query, id, basePrice, currency, request.Quantity, now)
+	}
+	if err == nil {
+		// Note : The rules never raise the price, so the total fits whenever the list price total does.
+		baseTotal, err = lineAmount(basePrice, currency, request.Quantity)
+		total = unitPrice * request.Quantity
+	}
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("product %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to quote price", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.PriceQuoteResponse{}, err
+	}
+
+	span.SetAttributes(
+		attribute.Int64Slice("appliedRuleIds", appliedRuleIds(applied)),
+		attribute.String("unitPrice", model.NewMoney(unitPrice, currency).String()),
+		attribute.String("currency", currency),
+	)
+
+	return model.PriceQuoteResponse{
+		ProductId:    id,
+		VariantId:    request.VariantId,
+		Quantity:     request.Quantity,
+		Currency:     currency,
+		BasePrice:    model.NewMoney(basePrice, currency),
+		UnitPrice:    model.NewMoney(unitPrice, currency),
+		Discount:     model.NewMoney(baseTotal-total, currency),
+		Total:        model.NewMoney(total, currency),
+		AppliedRules: applied,
+		QuotedAt:     now,
+	}, nil
+}
+
+// applyPricingRules returns the unit price of quantity units of a product listed at basePrice, once the rules
+// running at now are applied, and the rules that were. Every rule applies the highest tier the quantity reaches.
+// Percentage rules go first, each on the price the ones before it left, then fixed rules take their amount off.
+// Fixed rules in another currency than the price are left out, and the price never drops below zero.
+func applyPricingRules(ctx context.Context, query *productrepository.Queries, productId, basePrice int64, currency string, quantity int64, now time.Time) (int64, []model.AppliedPricingRuleResponse, error) {
+	rules, err := query.ListApplicablePricingRules(ctx, productrepository.ListApplicablePricingRulesParams{
+		ProductID: productId,
+		Now:       sql.NullTime{Time: now, Valid: true},
+		Quantity:  quantity,
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	price := basePrice
+	applied := make([]model.AppliedPricingRuleResponse, 0, len(rules))
+	for _, ruleType := range []string{model.PricingRuleTypePercentage, model.PricingRuleTypeFixed} {
+		for _, rule := range rules {
+			if rule.Type != ruleType || (rule.Currency.Valid && rule.Currency.String != currency) {
+				continue
+			}
+
+			var discount int64
+			value := model.NewMoney(rule.Value, currency)
+			if rule.Type == model.PricingRuleTypePercentage {
+				// Note : Rounded half up to the minor unit, split so that price * basis points cannot overflow.
+				discount = price/10000*rule.Value + (price%10000*rule.Value+5000)/10000
+				value = model.NewPercentage(rule.Value)
+			} else {
+				discount = rule.Value
+			}
+			discount = min(discount, price)
+			price -= discount
+
+			response := model.AppliedPricingRuleResponse{
+				RuleId:       rule.ID,
+				Name:         rule.Name,
+				Type:         rule.Type,
+				Scope:        model.PricingRuleScopeProduct,
+				MinQuantity:  rule.MinQuantity,
+				Value:        value,
+				UnitDiscount: model.NewMoney(discount, currency),
+			}
+			if rule.CategoryID.Valid {
+				response.Scope = model.PricingRuleScopeCategory
+				response.CategoryId = &rule.CategoryID.Int64
+			}
+			applied = append(applied, response)
+		}
+	}
+
+	return price, applied, nil
+}
+
+// listPrice returns the list price of one unit in minor units, and its currency: the price of the variant when it
+// overrides the price of its product, the price of the product otherwise.
+func listPrice(ctx context.Context, query *productrepository.Queries, productId int64, variantId *int64) (int64, string, error) {
+	product, err := query.GetProduct(ctx, productId)
+	if err != nil {
+		return 0, "", translateError(err, fmt.Sprintf("product %d", productId))
+	}
+	if variantId == nil {
+		return product.PriceMinor, product.Currency, nil
+	}
+
+	variant, err := query.GetVariant(ctx, productrepository.GetVariantParams{ID: *variantId, ProductID: productId})
+	if err != nil {
+		return 0, "", translateError(err, fmt.Sprintf("variant %d of product %d", *variantId, productId))
+	}
+	if !variant.PriceMinor.Valid {
+		return product.PriceMinor, product.Currency, nil
+	}
+	if variant.Currency.Valid {
+		return variant.PriceMinor.Int64, variant.Currency.String, nil
+	}
+	return variant.PriceMinor.Int64, product.Currency, nil
+}
+
+// lineAmount returns what quantity units priced at unitPrice minor units cost, and rejects a line whose amount does
+// not fit in int64 minor units.
+func lineAmount(unitPrice int64, currency string, quantity int64) (int64, error) {
+	if quantity > 0 && unitPrice > math.MaxInt64/quantity {
+		return 0, apperror.New(apperror.Unprocessable, fmt.Sprintf("%d units at %s %s is more than a price can hold", quantity, model.NewMoney(unitPrice, currency), currency))
+	}
+	return unitPrice * quantity, nil
+}
+
+// checkPricingRuleScope makes sure the product or category a rule applies to exists.
+func checkPricingRuleScope(ctx context.Context, query *productrepository.Queries, request model.PricingRuleRequest) error {
+	if request.ProductId != nil {
+		if _, err := query.GetProduct(ctx, *request.ProductId); err != nil {
+			return translateError(err, fmt.Sprintf("product %d", *request.ProductId))
+		}
+		return nil
+	}
+	if _, err := query.GetCategory(ctx, *request.CategoryId); err != nil {
+		return translateError(err, fmt.Sprintf("category %d", *request.CategoryId))
+	}
+	return nil
+}
+
+// pricingRuleWindow returns when a rule starts and ends. Times are stored in UTC so that quotes can compare them as plain text.
+func pricingRuleWindow(request model.PricingRuleRequest) (sql.NullTime, sql.NullTime) {
+	var start, end sql.NullTime
+	if request.StartsAt != nil {
+		start = sql.NullTime{Time: request.StartsAt.UTC(), Valid: true}
+	}
+	if request.EndsAt != nil {
+		end = sql.NullTime{Time: request.EndsAt.UTC(), Valid: true}
+	}
+	return start, end
+}
+
+func createPricingRuleTiers(ctx context.Context, query *productrepository.Queries, ruleId int64, request model.PricingRuleRequest) ([]productrepository.PricingRuleTier, error) {
+	tiers := make([]productrepository.PricingRuleTier, 0, len(request.Tiers))
+	for _, tier := range request.Tiers {
+		value, err := request.TierValue(tier)
+		if err != nil {
+			return nil, apperror.Wrap(apperror.Validation, err, "request validation failed")
+		}
+
+		params := productrepository.CreatePricingRuleTierParams{
+			RuleID:      ruleId,
+			MinQuantity: tier.MinQuantity,
+			Value:       value,
+		}
+		if err := query.CreatePricingRuleTier(ctx, params); err != nil {
+			return nil, err
+		}
+		tiers = append(tiers, productrepository.PricingRuleTier(params))
+	}
+	return tiers, nil
+}
+
+func appliedRuleIds(applied []model.AppliedPricingRuleResponse) []int64 {
+	ids := make([]int64, 0, len(applied))
+	for _, rule := range applied {
+		ids = append(ids, rule.RuleId)
+	}
+	return ids
+}
+
+func pricingRuleResponse(rule productrepository.PricingRule, tiers []productrepository.PricingRuleTier) model.PricingRuleResponse {
+	response := model.PricingRuleResponse{
+		Id:         rule.ID,
+		Name:       rule.Name,
+		Type:       rule.Type,
+		ProductId:  nullInt64Pointer(rule.ProductID),
+		CategoryId: nullInt64Pointer(rule.CategoryID),
+		Currency:   rule.Currency.String,
+		Tiers:      make([]model.PricingTierResponse, 0, len(tiers)),
+		CreatedAt:  rule.CreatedAt,
+	}
+	for _, tier := range tiers {
+		value := model.NewPercentage(tier.Value)
+		if rule.Type == model.PricingRuleTypeFixed {
+			value = model.NewMoney(tier.Value, rule.Currency.String)
+		}
+		response.Tiers = append(response.Tiers, model.PricingTierResponse{MinQuantity: tier.MinQuantity, Value: value})
+	}
+	if rule.StartsAt.Valid {
+		response.StartsAt = &rule.StartsAt.Time
+	}
+	if rule.EndsAt.Valid {
+		response.EndsAt = &rule.EndsAt.Time
+	}
+	if rule.UpdatedAt.Valid {
+		response.UpdatedAt = &rule.UpdatedAt.Time
+	}
+	return response
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/repository"
+	productrepository "github.com/indrabrata/observability-playground/repository/product"
+	"github.com/indrabrata/observability-playground/utility"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	productEventPageSize = 100
+
+	// Note : productEventRetry is how long a browser waits before it reconnects after the stream broke.
+	productEventRetry = 3 * time.Second
+)
+
+// ProductEventStream streams the product events of the outbox to its subscribers as server-sent events. The
+// outbox is the change log: a subscriber reads the events after the last one it saw straight from it, so it can
+// resume from any event still kept. The stream is an outbox sink only to learn that new events were written.
+type ProductEventStream struct {
+	repository *repository.BaseRepository[*productrepository.Queries]
+	trace      trace.Tracer
+	heartbeat  time.Duration
+
+	// Note : changed is closed and replaced whenever product events were relayed, which wakes every subscriber
+	// at once without the relay ever waiting for one of them.
+	mu      sync.Mutex
+	changed chan struct{}
+}
+
+func NewProductEventStream(repository *repository.BaseRepository[*productrepository.Queries], trace trace.Tracer, heartbeat time.Duration) *ProductEventStream {
+	return &ProductEventStream{
+		repository: repository,
+		trace:      trace,
+		heartbeat:  heartbeat,
+		changed:    make(chan struct{}),
+	}
+}
+
+func (s *ProductEventStream) Name() string {
+	return "product-events"
+}
+
+// Publish wakes the subscribers, which read the event from the outbox themselves.
+func (s *ProductEventStream) Publish(ctx context.Context, event model.DomainEvent) error {
+	if event.AggregateType != model.AggregateProduct {
+		return nil
+	}
+
+	s.mu.Lock()
+	close(s.changed)
+	s.changed = make(chan struct{})
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *ProductEventStream) wait() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.changed
+}
+
+// StreamProductEvents writes the product events to w as server-sent events until ctx is cancelled or a write
+// fails. A subscriber that gives lastEventId gets every event after it first, otherwise it only gets new events.
+// When the events after lastEventId are no longer kept a reset event is sent instead of them.
+//
+// Events are read a page at a time and the next page is only read once flush returned, so a slow subscriber is
+// sent events at its own pace and never holds up the others. Comment lines are sent every heartbeat while there
+// are no events, which keeps proxies from closing an idle connection. It returns the number of bytes written,
+// which tells the caller whether a response has already started.
+func (s *ProductEventStream) StreamProductEvents(ctx context.Context, lastEventId *int64, w io.Writer, flush func() error) (int64, error) {
+	ctx, span := s.trace.Start(ctx, "Service.StreamProductEvents")
+	defer span.End()
+
+	eventRange, err := s.repository.Query.GetOutboxEventIdRange(ctx)
+	if err != nil {
+		err = translateError(err, "product events")
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to get product event range", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return 0, err
+	}
+
+	counter := &countingWriter{w: w}
+	buffered := bufio.NewWriter(counter)
+
+	ProductEventSubscribers.Inc()
+	defer ProductEventSubscribers.Dec()
+
+	var sent int64
+	err = func() error {
+		if _, err := fmt.Fprintf(buffered, "retry: %d\n\n", productEventRetry.Milliseconds()); err != nil {
+			return err
+		}
+
+		after := eventRange.LatestID
+		switch {
+		case lastEventId == nil:
+		case *lastEventId > eventRange.LatestID || *lastEventId < eventRange.OldestID-1:
+			// Note : The missed events were deleted by the outbox cleanup, or the id is not from this change log.
+			span.AddEvent("reset", trace.WithAttributes(attribute.Int64("lastEventId", *lastEventId)))
+			data, err := json.Marshal(model.EventStreamResetData{LastEventId: after})
+			if err != nil {
+				return err
+			}
+			if err := writeServerSentEvent(buffered, after, model.EventStreamReset, data); err != nil {
+				return err
+			}
+		default:
+			after = *lastEventId
+		}
+		span.SetAttributes(attribute.Int64("afterEventId", after))
+
+		if err := buffered.Flush(); err != nil {
+			return err
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+
+		heartbeat := time.NewTicker(s.heartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			// Note : The channel is taken before reading, so events relayed while reading still wake the loop.
+			changed := s.wait()
+
+			for {
+				count, last, err := s.sendProductEvents(ctx, after, buffered, flush)
+				if err != nil {
+					return err
+				}
+				sent += count
+				after = last
+				if count < productEventPageSize {
+					break
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-changed:
+			case <-heartbeat.C:
+				if _, err := io.WriteString(buffered, ": heartbeat\n\n"); err != nil {
+					return err
+				}
+				if err := buffered.Flush(); err != nil {
+					return err
+				}
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+	}()
+
+	span.SetAttributes(
+		attribute.Int64("eventCount", sent),
+		attribute.Int64("byteCount", counter.count),
+	)
+
+	if err != nil && ctx.Err() == nil {
+		utility.RecordSpanError(span, err)
+		return counter.count, err
+	}
+	return counter.count, nil
+}
+
+// sendProductEvents writes a page of the product events after afterId and flushes it. It returns how many
+// events were sent and the id of the last one, which is afterId when there were none.
+func (s *ProductEventStream) sendProductEvents(ctx context.Context, afterId int64, w *bufio.Writer, flush func() error) (int64, int64, error) {
+	ctx, span := s.trace.Start(ctx, "Service.SendProductEvents", trace.WithAttributes(attribute.Int64("afterEventId", afterId)))
+	defer span.End()
+
+	events, err := s.repository.Query.ListOutboxEventsAfter(ctx, productrepository.ListOutboxEventsAfterParams{
+		AggregateType: model.AggregateProduct,
+		AfterID:       afterId,
+		PageSize:      productEventPageSize,
+	})
+	if err != nil {
+		err = translateError(err, "product events")
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to list product events", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return 0, afterId, err
+	}
+	if len(events) == 0 {
+		return 0, afterId, nil
+	}
+
+	for _, event := range events {
+		data, err := json.Marshal(domainEventOf(event))
+		if err != nil {
+			utility.RecordSpanError(span, err)
+			return 0, afterId, err
+		}
+		if err := writeServerSentEvent(w, event.ID, event.EventType, data); err != nil {
+			utility.RecordSpanError(span, err)
+			return 0, afterId, err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		utility.RecordSpanError(span, err)
+		return 0, afterId, err
+	}
+	if err := flush(); err != nil {
+		utility.RecordSpanError(span, err)
+		return 0, afterId, err
+	}
+
+	last := events[len(events)-1].ID
+	span.SetAttributes(
+		attribute.Int("eventCount", len(events)),
+		attribute.Int64("lastEventId", last),
+	)
+	ProductEventsStreamed.Add(float64(len(events)))
+
+	return int64(len(events)), last, nil
+}
+
+// writeServerSentEvent writes one event in the text/event-stream format. data must not contain a newline, which
+// holds for JSON produced by encoding/json.
+func writeServerSentEvent(w io.Writer, id int64, event string, data []byte) error {
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, event, data)
+	return err
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/indrabrata/observability-playground/model"
+	productrepository "github.com/indrabrata/observability-playground/repository/product"
+	"github.com/indrabrata/observability-playground/utility"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// exportPageTimeout bounds the read of one chunk of an export. The export as a whole runs as long as the client
+// keeps reading.
+const exportPageTimeout = 5 * time.Second
+
+// countingWriter counts the bytes that reached the underlying writer.
+type countingWriter struct {
+	w     io.Writer
+	count int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.count += int64(n)
+	return n, err
+}
+
+// ExportProducts streams every product to w in the given format without loading the catalog in memory. Products
+// are read one chunk at a time in id order, and flush is called after every chunk so the client receives data while
+// the export runs. It returns the number of bytes written, which tells the caller whether a response has already
+// started.
+func (s *ProductService) ExportProducts(ctx context.Context, format string, w io.Writer, flush func() error) (int64, error) {
+	ctx, span := s.trace.Start(ctx, "Service.ExportProducts", trace.WithAttributes(attribute.String("format", format)))
+	defer span.End()
+
+	counter := &countingWriter{w: w}
+	var rowCount, chunkRows, chunkStart int64
+
+	err := func() error {
+		buffered := bufio.NewWriter(counter)
+		csvWriter := csv.NewWriter(buffered)
+		encoder := json.NewEncoder(buffered)
+
+		writeRow := func(row model.ProductExportRow) error {
+			return encoder.Encode(row)
+		}
+		flushChunk := func() error {
+			return buffered.Flush()
+		}
+		if format == model.ExportFormatCSV {
+			writeRow = func(row model.ProductExportRow) error {
+				return csvWriter.Write(row.CSVRecord())
+			}
+			flushChunk = func() error {
+				csvWriter.Flush()
+				if err := csvWriter.Error(); err != nil {
+					return err
+				}
+				return buffered.Flush()
+			}
+			if err := csvWriter.Write(model.ProductExportColumns); err != nil {
+				return err
+			}
+		}
+
+		endChunk := func() error {
+			if err := flushChunk(); err != nil {
+				return err
+			}
+			if err := flush(); err != nil {
+				return err
+			}
+
+			span.AddEvent("chunk", trace.WithAttributes(
+				attribute.Int64("rows", chunkRows),
+				attribute.Int64("bytes", counter.count-chunkStart),
+			))
+			ExportedRows.WithLabelValues(format).Add(float64(chunkRows))
+			ExportedBytes.WithLabelValues(format).Add(float64(counter.count - chunkStart))
+			chunkRows, chunkStart = 0, counter.count
+			return nil
+		}
+
+		var afterId int64
+		for {
+			// Note : Every chunk is its own bounded read keyed on the last id, so a slow client never holds a
+			// read transaction open for the whole export.
+			pageCtx, cancel := context.WithTimeout(ctx, exportPageTimeout)
+			products, err := s.repository.Query.ExportProducts(pageCtx, productrepository.ExportProductsParams{
+				AfterID: afterId,
+				Limit:   model.ProductExportChunkSize,
+			})
+			cancel()
+			if err != nil {
+				return err
+			}
+
+			for _, product := range products {
+				if err := writeRow(exportRow(product)); err != nil {
+					return err
+				}
+				rowCount++
+				chunkRows++
+			}
+			if len(products) < model.ProductExportChunkSize {
+				break
+			}
+
+			afterId = products[len(products)-1].ID
+			if err := endChunk(); err != nil {
+				return err
+			}
+		}
+
+		return endChunk()
+	}()
+
+	span.SetAttributes(attribute.Int64("rowCount", rowCount), attribute.Int64("byteCount", counter.count))
+
+	if err != nil {
+		err = translateError(err, "product")
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to export products", zap.Error(err), zap.Int64("rowCount", rowCount), zap.String("requestId", ctx.Value("requestId").(string)))
+		return counter.count, err
+	}
+
+	return counter.count, nil
+}
+
+func exportRow(product productrepository.Product) model.ProductExportRow {
+	row := model.ProductExportRow{
+		Id:           product.ID,
+		Name:         product.Name,
+		Quantity:     product.Quantity,
+		Price:        model.NewMoney(product.PriceMinor, product.Currency),
+		Currency:     product.Currency,
+		Sku:          product.Sku.String,
+		Barcode:      product.Barcode.String,
+		ReorderPoint: nullInt64Pointer(product.ReorderPoint),
+		Version:      product.Version,
+		CreatedAt:    product.CreatedAt,
+	}
+	if product.UpdatedAt.Valid {
+		row.UpdatedAt = &product.UpdatedAt.Time
+	}
+	return row
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/model"
+	productrepository "github.com/indrabrata/observability-playground/repository/product"
+	"github.com/indrabrata/observability-playground/utility"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// ImportProducts inserts every valid row read from rows. In partial mode valid rows are committed in
+// batches and invalid rows are only reported; in all-or-nothing mode a single invalid row rolls back the
+// whole import, but the remaining rows are still read so that every error is reported at once.
+func (s *ProductService) ImportProducts(ctx context.Context, rows model.ProductRowReader, mode string) (model.ProductImportResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.ImportProducts", trace.WithAttributes(attribute.String("mode", mode)))
+	defer span.End()
+
+	response := model.ProductImportResponse{Mode: mode, Errors: make([]model.ProductImportError, 0)}
+
+	var tx *sql.Tx
+	var pending int64
+	defer func() {
+		if tx != nil {
+			tx.Rollback()
+		}
+	}()
+
+	fail := func(err error) (model.ProductImportResponse, error) {
+		err = translateError(err, "product")
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to import products", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.ProductImportResponse{}, err
+	}
+
+	for {
+		line, request, err := rows.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err == nil {
+			err = request.Validate()
+		}
+
+		var validationErrors model.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			response.Errors = append(response.Errors, model.ProductImportError{Line: line, Errors: validationErrors})
+			response.Failed++
+			continue
+		}
+		if err != nil {
+			return fail(apperror.Wrap(apperror.Validation, err, "import body could not be read"))
+		}
+
+		// Note : Once an all-or-nothing import has failed nothing will be committed, rows are only validated from then on.
+		if mode == model.ImportModeAllOrNothing && response.Failed > 0 {
+			if tx != nil {
+				tx.Rollback()
+				tx, pending = nil, 0
+			}
+			continue
+		}
+
+		if tx == nil {
+			tx, err = s.repository.DB.BeginTx(ctx, nil)
+			if err != nil {
+				return fail(err)
+			}
+		}
+
+		// Note : The row passed Validate, so its price always fits its currency.
+		priceMinor, _ := request.PriceMinorUnits()
+
+		query := s.repository.Query.WithTx(tx)
+		created, err := query.CreateProduct(ctx, productrepository.CreateProductParams{
+			Name:         request.Name,
+			Quantity:     request.Quantity,
+			PriceMinor:   priceMinor,
+			Currency:     request.PriceCurrency(),
+			Sku:          sql.NullString{String: request.Sku, Valid: request.Sku != ""},
+			Barcode:      sql.NullString{String: request.Barcode, Valid: request.Barcode != ""},
+			ReorderPoint: nullInt64(request.ReorderPoint),
+			CreatedAt:    time.Now(),
+		})
+		if err == nil {
+			_, err = query.CreateStockMovement(ctx, productrepository.CreateStockMovementParams{
+				ProductID:     created.ID,
+				Delta:         created.Quantity,
+				QuantityAfter: created.Quantity,
+				Reason:        model.StockReasonInitial,
+				Reference:     sql.NullString{String: "import line " + strconv.Itoa(line), Valid: true},
+				RequestID:     requestIdOf(ctx),
+				CreatedAt:     created.CreatedAt,
+			})
+		}
+		if err == nil {
+			err = recordProductEvent(ctx, query, model.EventProductCreated, created, created.CreatedAt)
+		}
+		if err == nil {
+			err = recordProductAudit(ctx, query, model.AuditActionCreate, nil, created, created.CreatedAt)
+		}
+		if err != nil {
+			err = translateError(err, "product")
+			if apperror.KindOf(err) != apperror.Conflict {
+				return fail(err)
+			}
+			response.Errors = append(response.Errors, model.ProductImportError{Line: line, Errors: model.ValidationErrors{{Field: "row", Message: apperror.MessageOf(err)}}})
+			response.Failed++
+			continue
+		}
+		pending++
+
+		if mode == model.ImportModePartial && pending == model.ProductImportBatchSize {
+			if err := tx.Commit(); err != nil {
+				tx = nil
+				return fail(err)
+			}
+			response.Created += pending
+			tx, pending = nil, 0
+		}
+	}
+
+	if tx != nil && (mode == model.ImportModePartial || response.Failed == 0) {
+		err := tx.Commit()
+		tx = nil
+		if err != nil {
+			return fail(err)
+		}
+		response.Created += pending
+	}
+
+	span.SetAttributes(attribute.Int64("createdCount", response.Created), attribute.Int64("failedCount", response.Failed))
+
+	s.lowStock.StockChanged()
+
+	return response, nil
+}
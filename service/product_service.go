@@ -3,11 +3,18 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
 	"time"
 
+	"github.com/indrabrata/observability-playground/apperror"
 	"github.com/indrabrata/observability-playground/model"
 	"github.com/indrabrata/observability-playground/repository"
 	productrepository "github.com/indrabrata/observability-playground/repository/product"
+	"github.com/indrabrata/observability-playground/utility"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
@@ -15,6 +22,7 @@ import (
 type ProductService struct {
 	repository *repository.BaseRepository[*productrepository.Queries]
 	trace      trace.Tracer
+	lowStock   *LowStockService
 }
 
 func New(repository *repository.BaseRepository[*productrepository.Queries], trace trace.Tracer) *ProductService {
@@ -24,62 +32,239 @@ func New(repository *repository.BaseRepository[*productrepository.Queries], trac
 	}
 }
 
+// SetLowStockService makes every stock change of the service ask lowStock for an evaluation.
+func (s *ProductService) SetLowStockService(lowStock *LowStockService) {
+	s.lowStock = lowStock
+}
+
 func (s *ProductService) CreateProduct(ctx context.Context, request model.ProductRequest) (model.ProductResponse, error) {
 	ctx, span := s.trace.Start(ctx, "Service.CreateProduct")
 	defer span.End()
 
+	priceMinor, err := request.PriceMinorUnits()
+	if err != nil {
+		err = apperror.Wrap(apperror.Validation, err, "request validation failed")
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to create product", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.ProductResponse{}, err
+	}
+
 	product := productrepository.CreateProductParams{
-		Name:      request.Name,
-		Quantity:  request.Quantity,
-		Price:     request.Price,
-		CreatedAt: time.Now(),
+		Name:         request.Name,
+		Quantity:     request.Quantity,
+		PriceMinor:   priceMinor,
+		Currency:     request.PriceCurrency(),
+		Sku:          sql.NullString{String: request.Sku, Valid: request.Sku != ""},
+		Barcode:      sql.NullString{String: request.Barcode, Valid: request.Barcode != ""},
+		ReorderPoint: nullInt64(request.ReorderPoint),
+		CreatedAt:    time.Now(),
 	}
 
 	zap.L().Debug("create product payload", zap.String("requestId", ctx.Value("requestId").(string)),
 		zap.Dict("product",
 			zap.String("name", product.Name),
 			zap.Int64("quantity", product.Quantity),
-			zap.Float64("price", product.Price)))
+			zap.Stringer("price", request.Price),
+			zap.String("currency", product.Currency),
+			zap.String("sku", request.Sku)))
 
-	data, err := s.repository.Query.CreateProduct(ctx, product)
+	var data productrepository.Product
+	err = s.repository.Transaction(ctx, func(tx *sql.Tx) error {
+		query := s.repository.Query.WithTx(tx)
+
+		var err error
+		data, err = query.CreateProduct(ctx, product)
+		if err != nil {
+			return err
+		}
+
+		// Note : The opening stock is the first ledger entry, so the movements of a product always add up to its quantity.
+		_, err = query.CreateStockMovement(ctx, productrepository.CreateStockMovementParams{
+			ProductID:     data.ID,
+			Delta:         data.Quantity,
+			QuantityAfter: data.Quantity,
+			Reason:        model.StockReasonInitial,
+			RequestID:     requestIdOf(ctx),
+			CreatedAt:     data.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := recordProductEvent(ctx, query, model.EventProductCreated, data, data.CreatedAt); err != nil {
+			return err
+		}
+		return recordProductAudit(ctx, query, model.AuditActionCreate, nil, data, data.CreatedAt)
+	})
 	if err != nil {
+		err = translateError(err, "product")
+		utility.RecordSpanError(span, err)
 		zap.L().Error("failed to create product", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
 		return model.ProductResponse{}, err
 	}
 
-	response := model.ProductResponse{
-		Id:       data.ID,
-		Name:     data.Name,
-		Quantity: data.Quantity,
-		Price:    data.Price,
-	}
+	response := productResponse(data)
+
+	s.lowStock.StockChanged()
 
 	return response, nil
 }
 
-func (s *ProductService) GetProducts(ctx context.Context) ([]model.ProductResponse, error) {
-	ctx, span := s.trace.Start(ctx, "Service.GetProducts")
+func (s *ProductService) GetProducts(ctx context.Context, request model.ProductListRequest) (model.ProductListResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.GetProducts", trace.WithAttributes(
+		attribute.String("sortBy", request.SortBy),
+		attribute.String("sortOrder", request.SortOrder),
+		attribute.Int64("limit", request.Limit),
+		attribute.Bool("hasCursor", request.After != nil),
+		attribute.Bool("includeDeleted", request.IncludeDeleted),
+		attribute.Int("tagCount", len(request.Tags)),
+	))
 	defer span.End()
+	defer observeQueryLatency("list", time.Now())
 
-	data, err := s.repository.Query.GetProducts(ctx)
-	if err != nil {
-		zap.L().Error("failed to get products", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
-		return nil, err
+	// Note : One extra row is fetched to know whether there is a next page without running a COUNT query.
+	params := productrepository.ListProductsAscendingParams{
+		IncludeDeleted: request.IncludeDeleted,
+		SortBy:         request.SortBy,
+		NameContains:   sql.NullString{String: request.NameContains, Valid: request.NameContains != ""},
+		PageSize:       request.Limit + 1,
+	}
+	if request.Currency != "" {
+		params.Currency = sql.NullString{String: request.Currency, Valid: true}
+	}
+	if request.MinPriceMinor != nil {
+		params.MinPriceMinor = sql.NullInt64{Int64: *request.MinPriceMinor, Valid: true}
+	}
+	if request.MaxPriceMinor != nil {
+		params.MaxPriceMinor = sql.NullInt64{Int64: *request.MaxPriceMinor, Valid: true}
+	}
+	if request.InStock != nil {
+		params.InStock = sql.NullBool{Bool: *request.InStock, Valid: true}
+	}
+	if len(request.Tags) > 0 {
+		params.Tags = sql.NullString{String: strings.Join(request.Tags, ","), Valid: true}
+		params.TagCount = int64(len(request.Tags))
+	}
+	if request.After != nil {
+		params.CursorValue = request.After.Value
+		params.CursorID = request.After.Id
+	}
+
+	var data []productrepository.ListProductsAscendingRow
+	if request.SortOrder == "desc" {
+		rows, err := s.repository.Query.ListProductsDescending(ctx, productrepository.ListProductsDescendingParams(params))
+		if err != nil {
+			err = translateError(err, "product")
+			utility.RecordSpanError(span, err)
+			zap.L().Error("failed to get products", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+			return model.ProductListResponse{}, err
+		}
+		for _, row := range rows {
+			data = append(data, productrepository.ListProductsAscendingRow(row))
+		}
+	} else {
+		rows, err := s.repository.Query.ListProductsAscending(ctx, params)
+		if err != nil {
+			err = translateError(err, "product")
+			utility.RecordSpanError(span, err)
+			zap.L().Error("failed to get products", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+			return model.ProductListResponse{}, err
+		}
+		data = rows
+	}
+
+	paging := model.Paging{Limit: request.Limit}
+	if int64(len(data)) > request.Limit {
+		data = data[:request.Limit]
+		last := data[len(data)-1]
+
+		cursor, err := utility.EncodeCursor(utility.Cursor{
+			SortBy:    request.SortBy,
+			SortOrder: request.SortOrder,
+			Value:     last.SortKey,
+			Id:        last.Product.ID,
+		})
+		if err != nil {
+			err = translateError(err, "product")
+			utility.RecordSpanError(span, err)
+			zap.L().Error("failed to encode cursor", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+			return model.ProductListResponse{}, err
+		}
+
+		paging.NextCursor = cursor
+		paging.HasMore = true
 	}
 
 	responses := make([]model.ProductResponse, 0)
-	for _, product := range data {
-		response := model.ProductResponse{
-			Id:       product.ID,
-			Name:     product.Name,
-			Quantity: product.Quantity,
-			Price:    product.Price,
+	for _, row := range data {
+		responses = append(responses, productResponse(row.Product))
+	}
+
+	return model.ProductListResponse{Data: responses, Paging: paging}, nil
+}
+
+func (s *ProductService) SearchProducts(ctx context.Context, request model.ProductSearchRequest) (model.ProductSearchResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.SearchProducts", trace.WithAttributes(
+		attribute.String("query", request.Query),
+		attribute.Int64("limit", request.Limit),
+	))
+	defer span.End()
+	defer observeQueryLatency("search", time.Now())
+
+	data, err := s.repository.Query.SearchProducts(ctx, productrepository.SearchProductsParams{
+		Query:    buildSearchQuery(request.Query),
+		PageSize: request.Limit,
+	})
+	if err != nil {
+		err = translateError(err, "product")
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to search products", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.ProductSearchResponse{}, err
+	}
+
+	span.SetAttributes(attribute.Int("resultCount", len(data)))
+
+	results := make([]model.ProductSearchResult, 0)
+	for _, row := range data {
+		result := model.ProductSearchResult{
+			ProductResponse: productResponse(row.Product),
+			Highlight:       highlightHTML(row.Highlight),
+			// Note : FTS5 bm25() is lower-is-better, negate it so clients can treat a higher score as more relevant.
+			Score: -row.Score,
 		}
 
-		responses = append(responses, response)
+		results = append(results, result)
 	}
 
-	return responses, nil
+	return model.ProductSearchResponse{Data: results}, nil
+}
+
+// Note : The search snippet marks matches with these control characters rather than with HTML, so that the name
+// around them can be escaped before the marks are turned into <mark> tags.
+const (
+	highlightStart = "\x02"
+	highlightEnd   = "\x03"
+)
+
+// highlightHTML turns a search snippet into HTML: the product name is escaped and only the matches are wrapped in
+// <mark>, so a name such as "<script>" is shown as text by clients that render the highlight.
+func highlightHTML(snippet string) string {
+	escaped := html.EscapeString(snippet)
+	return strings.NewReplacer(highlightStart, "<mark>", highlightEnd, "</mark>").Replace(escaped)
+}
+
+// buildSearchQuery turns free text into an FTS5 query: every term is quoted so that
+// operators typed by users are not interpreted, and the last term matches as a prefix.
+func buildSearchQuery(text string) string {
+	terms := strings.Fields(text)
+	for i, term := range terms {
+		terms[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+	}
+	if len(terms) > 0 {
+		terms[len(terms)-1] += "*"
+	}
+
+	return strings.Join(terms, " ")
 }
 
 func (s *ProductService) GetProduct(ctx context.Context, id int64) (model.ProductResponse, error) {
@@ -87,64 +272,522 @@ func (s *ProductService) GetProduct(ctx context.Context, id int64) (model.Produc
 	defer span.End()
 
 	data, err := s.repository.Query.GetProduct(ctx, id)
+	var stock *model.ProductStockResponse
+	if err == nil {
+		stock, err = productStock(ctx, s.repository.Query, data)
+	}
 	if err != nil {
+		err = translateError(err, fmt.Sprintf("product %d", id))
+		utility.RecordSpanError(span, err)
 		zap.L().Error("failed to get product", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
 		return model.ProductResponse{}, err
 	}
 
-	response := model.ProductResponse{
-		Id:       data.ID,
-		Name:     data.Name,
-		Quantity: data.Quantity,
-		Price:    data.Price,
+	response := productResponse(data)
+	response.Stock = stock
+
+	return response, nil
+}
+
+// GetProductBySku finds a product by its SKU, ignoring case.
+func (s *ProductService) GetProductBySku(ctx context.Context, sku string) (model.ProductResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.GetProductBySku", trace.WithAttributes(attribute.String("sku", sku)))
+	defer span.End()
+
+	data, err := s.repository.Query.GetProductBySku(ctx, sql.NullString{String: sku, Valid: true})
+	var stock *model.ProductStockResponse
+	if err == nil {
+		stock, err = productStock(ctx, s.repository.Query, data)
+	}
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("product with sku %q", sku))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to get product by sku", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.ProductResponse{}, err
 	}
 
+	span.SetAttributes(attribute.Int64("productId", data.ID))
+
+	response := productResponse(data)
+	response.Stock = stock
+
 	return response, nil
 }
 
-func (s *ProductService) UpdateProduct(ctx context.Context, id int64, request model.ProductRequest) (model.ProductResponse, error) {
-	ctx, span := s.trace.Start(ctx, "Service.UpdateProduct")
+// GetProductByBarcode finds a product by its GTIN barcode.
+func (s *ProductService) GetProductByBarcode(ctx context.Context, barcode string) (model.ProductResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.GetProductByBarcode", trace.WithAttributes(attribute.String("barcode", barcode)))
 	defer span.End()
 
+	data, err := s.repository.Query.GetProductByBarcode(ctx, sql.NullString{String: barcode, Valid: true})
+	var stock *model.ProductStockResponse
+	if err == nil {
+		stock, err = productStock(ctx, s.repository.Query, data)
+	}
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("product with barcode %s", barcode))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to get product by barcode", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.ProductResponse{}, err
+	}
+
+	span.SetAttributes(attribute.Int64("productId", data.ID))
+
+	response := productResponse(data)
+	response.Stock = stock
+
+	return response, nil
+}
+
+func (s *ProductService) UpdateProduct(ctx context.Context, id int64, request model.ProductRequest, precondition model.Precondition) (model.ProductResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.UpdateProduct", trace.WithAttributes(attribute.Bool("ifMatch", precondition.IfMatch)))
+	defer span.End()
+
+	expectedVersion, err := s.expectedVersion(ctx, id, precondition)
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("product %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to check product version", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.ProductResponse{}, err
+	}
+
+	priceMinor, err := request.PriceMinorUnits()
+	if err != nil {
+		err = apperror.Wrap(apperror.Validation, err, "request validation failed")
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to update product", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.ProductResponse{}, err
+	}
+
 	product := productrepository.UpdateProductParams{
-		ID:        id,
-		Name:      request.Name,
-		Quantity:  request.Quantity,
-		Price:     request.Price,
-		UpdatedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		ID:              id,
+		Name:            request.Name,
+		Quantity:        request.Quantity,
+		PriceMinor:      priceMinor,
+		Currency:        request.PriceCurrency(),
+		Sku:             sql.NullString{String: request.Sku, Valid: request.Sku != ""},
+		Barcode:         sql.NullString{String: request.Barcode, Valid: request.Barcode != ""},
+		ReorderPoint:    nullInt64(request.ReorderPoint),
+		UpdatedAt:       sql.NullTime{Time: time.Now(), Valid: true},
+		ExpectedVersion: expectedVersion,
 	}
 
 	zap.L().Debug("update product payload", zap.String("requestId", ctx.Value("requestId").(string)),
 		zap.Dict("product",
 			zap.String("name", product.Name),
 			zap.Int64("quantity", product.Quantity),
-			zap.Float64("price", product.Price)))
+			zap.Stringer("price", request.Price),
+			zap.String("currency", product.Currency),
+			zap.String("sku", request.Sku)))
+
+	var data productrepository.Product
+	err = s.repository.Transaction(ctx, func(tx *sql.Tx) error {
+		query := s.repository.Query.WithTx(tx)
 
-	err := s.repository.Query.UpdateProduct(ctx, product)
+		before, err := query.GetProduct(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := checkQuantityChange(ctx, query, before, sql.NullInt64{Int64: product.Quantity, Valid: true}); err != nil {
+			return err
+		}
+
+		err = query.RecordQuantityChange(ctx, productrepository.RecordQuantityChangeParams{
+			Quantity:  sql.NullInt64{Int64: product.Quantity, Valid: true},
+			Reason:    model.StockReasonUpdate,
+			RequestID: requestIdOf(ctx),
+			CreatedAt: product.UpdatedAt.Time,
+			ProductID: id,
+		})
+		if err != nil {
+			return err
+		}
+
+		data, err = query.UpdateProduct(ctx, product)
+		if err != nil {
+			return err
+		}
+
+		if err := recordProductEvent(ctx, query, model.EventProductUpdated, data, product.UpdatedAt.Time); err != nil {
+			return err
+		}
+		return recordProductAudit(ctx, query, model.AuditActionUpdate, &before, data, product.UpdatedAt.Time)
+	})
+	if errors.Is(err, sql.ErrNoRows) && expectedVersion.Valid {
+		err = s.staleVersionError(ctx, id)
+	}
 	if err != nil {
+		err = translateError(err, fmt.Sprintf("product %d", id))
+		utility.RecordSpanError(span, err)
 		zap.L().Error("failed to update product", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
 		return model.ProductResponse{}, err
 	}
 
-	response := model.ProductResponse{
-		Id:       product.ID,
-		Name:     product.Name,
-		Quantity: product.Quantity,
-		Price:    product.Price,
+	response := productResponse(data)
+
+	s.lowStock.StockChanged()
+
+	return response, nil
+}
+
+func (s *ProductService) PatchProduct(ctx context.Context, id int64, request model.ProductPatchRequest, precondition model.Precondition) (model.ProductResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.PatchProduct", trace.WithAttributes(
+		attribute.Bool("ifMatch", precondition.IfMatch),
+		attribute.Bool("patch.name", request.Name != nil),
+		attribute.Bool("patch.quantity", request.Quantity != nil),
+		attribute.Bool("patch.price", request.Price != nil),
+		attribute.Bool("patch.currency", request.Currency != nil),
+		attribute.Bool("patch.clear", request.ClearSku || request.ClearBarcode || request.ClearReorderPoint),
+		attribute.Int("patch.tests", len(request.Tests)),
+	))
+	defer span.End()
+
+	expectedVersion, err := s.expectedVersion(ctx, id, precondition)
+	if err == nil && len(request.Tests) > 0 {
+		expectedVersion, err = s.checkPatchTests(ctx, id, request.Tests, expectedVersion)
+	}
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("product %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to check product patch preconditions", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.ProductResponse{}, err
 	}
 
+	// Note : Only supplied fields are sent, the query keeps the stored value for the others so concurrent changes to them survive.
+	product := productrepository.PatchProductParams{
+		ID:                id,
+		ClearSku:          request.ClearSku,
+		ClearBarcode:      request.ClearBarcode,
+		ClearReorderPoint: request.ClearReorderPoint,
+		UpdatedAt:         sql.NullTime{Time: time.Now(), Valid: true},
+		ExpectedVersion:   expectedVersion,
+	}
+	if request.Name != nil {
+		product.Name = sql.NullString{String: *request.Name, Valid: true}
+	}
+	if request.Quantity != nil {
+		product.Quantity = sql.NullInt64{Int64: *request.Quantity, Valid: true}
+	}
+	if request.Currency != nil {
+		product.Currency = sql.NullString{String: *request.Currency, Valid: true}
+	}
+	if request.Sku != nil {
+		product.Sku = sql.NullString{String: *request.Sku, Valid: true}
+	}
+	if request.Barcode != nil {
+		product.Barcode = sql.NullString{String: *request.Barcode, Valid: true}
+	}
+	product.ReorderPoint = nullInt64(request.ReorderPoint)
+
+	var data productrepository.Product
+	err = s.repository.Transaction(ctx, func(tx *sql.Tx) error {
+		query := s.repository.Query.WithTx(tx)
+
+		before, err := query.GetProduct(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		// Note : A price without a currency is in the product's current currency, which is read in the same
+		// transaction so that the amount cannot be checked against a currency that is changed meanwhile.
+		if request.Price != nil {
+			currency := product.Currency.String
+			if !product.Currency.Valid {
+				currency = before.Currency
+			}
+
+			priceMinor, err := request.PriceMinorUnits(currency)
+			if err != nil {
+				return apperror.Wrap(apperror.Validation, err, "request validation failed")
+			}
+			product.PriceMinor = sql.NullInt64{Int64: priceMinor, Valid: true}
+		}
+
+		if err := checkQuantityChange(ctx, query, before, product.Quantity); err != nil {
+			return err
+		}
+
+		// Note : Without a quantity in the patch no movement matches, since quantity <> NULL is never true.
+		err = query.RecordQuantityChange(ctx, productrepository.RecordQuantityChangeParams{
+			Quantity:  product.Quantity,
+			Reason:    model.StockReasonUpdate,
+			RequestID: requestIdOf(ctx),
+			CreatedAt: product.UpdatedAt.Time,
+			ProductID: id,
+		})
+		if err != nil {
+			return err
+		}
+
+		data, err = query.PatchProduct(ctx, product)
+		if err != nil {
+			return err
+		}
+
+		if err := recordProductEvent(ctx, query, model.EventProductUpdated, data, product.UpdatedAt.Time); err != nil {
+			return err
+		}
+		return recordProductAudit(ctx, query, model.AuditActionUpdate, &before, data, product.UpdatedAt.Time)
+	})
+	if errors.Is(err, sql.ErrNoRows) && expectedVersion.Valid {
+		err = s.staleVersionError(ctx, id)
+	}
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("product %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to patch product", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.ProductResponse{}, err
+	}
+
+	response := productResponse(data)
+
+	s.lowStock.StockChanged()
+
 	return response, nil
 }
 
-func (s *ProductService) DeleteProduct(ctx context.Context, id int64) error {
-	ctx, span := s.trace.Start(ctx, "Service.DeleteProduct")
+func (s *ProductService) DeleteProduct(ctx context.Context, id int64, precondition model.Precondition) error {
+	ctx, span := s.trace.Start(ctx, "Service.DeleteProduct", trace.WithAttributes(attribute.Bool("ifMatch", precondition.IfMatch)))
 	defer span.End()
 
-	err := s.repository.Query.DeleteProduct(ctx, id)
+	expectedVersion, err := s.expectedVersion(ctx, id, precondition)
+	if err == nil {
+		// Note : Deletion times are stored in UTC so that the purge can compare them as plain text.
+		deletedAt := time.Now().UTC()
+
+		var affected int64
+		err = s.repository.Transaction(ctx, func(tx *sql.Tx) error {
+			query := s.repository.Query.WithTx(tx)
+
+			before, err := query.GetProduct(ctx, id)
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			affected, err = query.DeleteProduct(ctx, productrepository.DeleteProductParams{
+				DeletedAt:       sql.NullTime{Time: deletedAt, Valid: true},
+				ID:              id,
+				ExpectedVersion: expectedVersion,
+			})
+			if err != nil || affected == 0 {
+				return err
+			}
+
+			if err := recordEvent(ctx, query, model.EventProductDeleted, model.AggregateProduct, id, model.ProductDeletedPayload{Id: id, DeletedAt: deletedAt}, deletedAt); err != nil {
+				return err
+			}
+
+			// Note : The delete only sets deleted_at and bumps the version, so the row is not read back.
+			after := before
+			after.DeletedAt = sql.NullTime{Time: deletedAt, Valid: true}
+			after.Version++
+			return recordProductAudit(ctx, query, model.AuditActionDelete, &before, after, deletedAt)
+		})
+		if err == nil && affected == 0 {
+			if expectedVersion.Valid {
+				err = s.staleVersionError(ctx, id)
+			} else {
+				err = apperror.New(apperror.NotFound, fmt.Sprintf("product %d not found", id))
+			}
+		}
+	}
 	if err != nil {
+		err = translateError(err, fmt.Sprintf("product %d", id))
+		utility.RecordSpanError(span, err)
 		zap.L().Error("failed to delete product", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
 		return err
 	}
 
+	s.lowStock.StockChanged()
+
 	return nil
 }
+
+func (s *ProductService) RestoreProduct(ctx context.Context, id int64) (model.ProductResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.RestoreProduct")
+	defer span.End()
+
+	now := time.Now()
+
+	var data productrepository.Product
+	err := s.repository.Transaction(ctx, func(tx *sql.Tx) error {
+		query := s.repository.Query.WithTx(tx)
+
+		before, err := query.GetProductIncludingDeleted(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		data, err = query.RestoreProduct(ctx, productrepository.RestoreProductParams{
+			UpdatedAt: sql.NullTime{Time: now, Valid: true},
+			ID:        id,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := recordProductEvent(ctx, query, model.EventProductRestored, data, now); err != nil {
+			return err
+		}
+		return recordProductAudit(ctx, query, model.AuditActionRestore, &before, data, now)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// Note : No row was restored, either the product does not exist (or was purged) or it is not deleted.
+		if _, getErr := s.repository.Query.GetProduct(ctx, id); getErr == nil {
+			err = apperror.New(apperror.Conflict, fmt.Sprintf("product %d is not deleted", id))
+		}
+	}
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("product %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to restore product", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.ProductResponse{}, err
+	}
+
+	response := productResponse(data)
+
+	s.lowStock.StockChanged()
+
+	return response, nil
+}
+
+// PurgeDeletedProducts permanently removes products that were soft deleted longer than retention ago, together
+// with their ledger, prices, variants and pricing rules. Products that orders, reservations or transfers reference
+// are left soft deleted.
+func (s *ProductService) PurgeDeletedProducts(ctx context.Context, retention time.Duration) (int64, error) {
+	ctx, span := s.trace.Start(ctx, "Service.PurgeDeletedProducts", trace.WithAttributes(attribute.String("retention", retention.String())))
+	defer span.End()
+
+	deletedBefore := sql.NullTime{Time: time.Now().UTC().Add(-retention), Valid: true}
+
+	var purged int64
+	err := s.repository.Transaction(ctx, func(tx *sql.Tx) error {
+		query := s.repository.Query.WithTx(tx)
+
+		if err := query.DeletePurgeableStockMovements(ctx, deletedBefore); err != nil {
+			return err
+		}
+		if err := query.DeletePurgeablePriceHistory(ctx, deletedBefore); err != nil {
+			return err
+		}
+		if err := query.DeletePurgeableScheduledPrices(ctx, deletedBefore); err != nil {
+			return err
+		}
+		if err := query.DeletePurgeableProductCategories(ctx, deletedBefore); err != nil {
+			return err
+		}
+		if err := query.DeletePurgeableProductTags(ctx, deletedBefore); err != nil {
+			return err
+		}
+		if err := query.DeletePurgeableWarehouseStock(ctx, deletedBefore); err != nil {
+			return err
+		}
+		if err := query.DeletePurgeableLowStockAlerts(ctx, deletedBefore); err != nil {
+			return err
+		}
+		if err := query.DeletePurgeableVariants(ctx, deletedBefore); err != nil {
+			return err
+		}
+		if err := query.DeletePurgeablePricingRuleTiers(ctx, deletedBefore); err != nil {
+			return err
+		}
+		if err := query.DeletePurgeablePricingRules(ctx, deletedBefore); err != nil {
+			return err
+		}
+
+		var err error
+		purged, err = query.PurgeDeletedProducts(ctx, deletedBefore)
+		return err
+	})
+	if err != nil {
+		err = translateError(err, "product")
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to purge deleted products", zap.Error(err))
+		return 0, err
+	}
+
+	span.SetAttributes(attribute.Int64("purgedCount", purged))
+	PurgedProducts.Add(float64(purged))
+
+	return purged, nil
+}
+
+// RunPurge calls PurgeDeletedProducts every interval until ctx is cancelled.
+func (s *ProductService) RunPurge(ctx context.Context, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.PurgeDeletedProducts(ctx, retention)
+			if err == nil && purged > 0 {
+				zap.L().Info("purged deleted products", zap.Int64("purgedCount", purged), zap.Duration("retention", retention))
+			}
+		}
+	}
+}
+
+// expectedVersion resolves an If-Match precondition to the single version the write must see.
+// With several acceptable versions the current one is read first; the write itself still compares
+// the version, so a concurrent update between the read and the write is detected.
+func (s *ProductService) expectedVersion(ctx context.Context, id int64, precondition model.Precondition) (sql.NullInt64, error) {
+	if !precondition.IfMatch {
+		return sql.NullInt64{}, nil
+	}
+	if len(precondition.Versions) == 1 {
+		return sql.NullInt64{Int64: precondition.Versions[0], Valid: true}, nil
+	}
+
+	current, err := s.repository.Query.GetProduct(ctx, id)
+	if err != nil {
+		return sql.NullInt64{}, err
+	}
+	if !precondition.Matches(current.Version) {
+		return sql.NullInt64{}, apperror.New(apperror.PreconditionFailed, fmt.Sprintf("product %d has been modified, fetch it again and retry", id))
+	}
+
+	return sql.NullInt64{Int64: current.Version, Valid: true}, nil
+}
+
+// staleVersionError explains why a versioned write matched no row: either the product is gone or its version moved on.
+func (s *ProductService) staleVersionError(ctx context.Context, id int64) error {
+	_, err := s.repository.Query.GetProduct(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return apperror.New(apperror.PreconditionFailed, fmt.Sprintf("product %d has been modified, fetch it again and retry", id))
+}
+
+// checkPatchTests evaluates JSON Patch test operations against the current product and pins the
+// write to the version they were evaluated on, so the patch is not applied on top of a newer state.
+func (s *ProductService) checkPatchTests(ctx context.Context, id int64, tests []model.PatchTest, expectedVersion sql.NullInt64) (sql.NullInt64, error) {
+	current, err := s.GetProduct(ctx, id)
+	if err != nil {
+		return sql.NullInt64{}, err
+	}
+	if expectedVersion.Valid && expectedVersion.Int64 != current.Version {
+		return sql.NullInt64{}, apperror.New(apperror.PreconditionFailed, fmt.Sprintf("product %d has been modified, fetch it again and retry", id))
+	}
+
+	for _, test := range tests {
+		if !test.Matches(current) {
+			return sql.NullInt64{}, apperror.New(apperror.Conflict, fmt.Sprintf("test operation on /%s failed", test.Field))
+		}
+	}
+
+	return sql.NullInt64{Int64: current.Version, Valid: true}, nil
+}
+
+// requestIdOf returns the request ID for ledger rows, which are also written by background jobs without one.
+func requestIdOf(ctx context.Context) sql.NullString {
+	requestId, _ := ctx.Value("requestId").(string)
+	return sql.NullString{String: requestId, Valid: requestId != ""}
+}
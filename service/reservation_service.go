@@ -0,0 +1,390 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/repository"
+	productrepository "github.com/indrabrata/observability-playground/repository/product"
+	"github.com/indrabrata/observability-playground/utility"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Note : The sweeper releases at most this many expired reservations per transaction so it never holds the write lock for long.
+const reservationSweepBatchSize = 100
+
+// ReservationService holds stock for checkouts. Reserved units are taken out of products.quantity through
+// the stock ledger, so everything that reads the quantity already sees them as unavailable.
+type ReservationService struct {
+	repository *repository.BaseRepository[*productrepository.Queries]
+	trace      trace.Tracer
+	defaultTTL time.Duration
+}
+
+func NewReservationService(repository *repository.BaseRepository[*productrepository.Queries], trace trace.Tracer, defaultTTL time.Duration) *ReservationService {
+	return &ReservationService{
+		repository: repository,
+		trace:      trace,
+		defaultTTL: defaultTTL,
+	}
+}
+
+// CreateReservation holds every requested quantity or none of them.
+func (s *ReservationService) CreateReservation(ctx context.Context, request model.ReservationRequest) (model.ReservationResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.CreateReservation", trace.WithAttributes(attribute.Int("itemCount", len(request.Items))))
+	defer span.End()
+
+	ttl := s.defaultTTL
+	if request.TTLSeconds > 0 {
+		ttl = time.Duration(request.TTLSeconds) * time.Second
+	}
+	// Note : Reservation times are stored in UTC so that the sweeper can compare them as plain text.
+	now := time.Now().UTC()
+
+	var reservation productrepository.Reservation
+	var movements []productrepository.StockMovement
+	err := s.repository.Transaction(ctx, func(tx *sql.Tx) error {
+		query := s.repository.Query.WithTx(tx)
+
+		var err error
+		reservation, err = query.CreateReservation(ctx, productrepository.CreateReservationParams{
+			Reference: sql.NullString{String: request.Reference, Valid: request.Reference != ""},
+			ExpiresAt: now.Add(ttl),
+			CreatedAt: now,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, item := range request.Items {
+			row := productrepository.ReservationItem{
+				ReservationID: reservation.ID,
+				ProductID:     item.ProductId,
+				VariantID:     nullInt64(item.VariantId),
+				WarehouseID:   nullInt64(item.WarehouseId),
+				Quantity:      item.Quantity,
+			}
+			// Note : Stock is taken before the item is written, so an unknown product is reported as not found
+			// rather than failing the item's foreign key.
+			movement, err := moveReservedStock(ctx, query, row, -item.Quantity, model.StockReasonReserve, now)
+			if errors.Is(err, sql.ErrNoRows) {
+				// Note : No row was updated, either the product or variant does not exist or it has too little stock.
+				return stockShortageError(ctx, query, item.ProductId, nullInt64(item.VariantId), "reserve", item.Quantity)
+			}
+			if err != nil {
+				return err
+			}
+
+			err = query.CreateReservationItem(ctx, productrepository.CreateReservationItemParams{
+				ReservationID: row.ReservationID,
+				ProductID:     row.ProductID,
+				VariantID:     row.VariantID,
+				WarehouseID:   row.WarehouseID,
+				Quantity:      row.Quantity,
+			})
+			if err != nil {
+				return err
+			}
+			movements = append(movements, movement)
+		}
+		return nil
+	})
+	if err != nil {
+		err = translateError(err, "reservation")
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to create reservation", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.ReservationResponse{}, err
+	}
+
+	span.SetAttributes(attribute.Int64("reservationId", reservation.ID))
+	ActiveReservations.Inc()
+	observeWarehouseMovements(ctx, s.repository.Query, movements...)
+
+	items := make([]model.ReservationItemResponse, 0, len(request.Items))
+	for _, item := range request.Items {
+		items = append(items, model.ReservationItemResponse{ProductId: item.ProductId, VariantId: item.VariantId, WarehouseId: item.WarehouseId, Quantity: item.Quantity})
+	}
+
+	return reservationResponse(reservation, items), nil
+}
+
+func (s *ReservationService) GetReservation(ctx context.Context, id int64) (model.ReservationResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.GetReservation")
+	defer span.End()
+
+	response, err := s.getReservation(ctx, s.repository.Query, id)
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("reservation %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to get reservation", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.ReservationResponse{}, err
+	}
+
+	return response, nil
+}
+
+// ConfirmReservation turns held stock into sold stock. The units were already taken out of the quantity
+// when the reservation was made, so confirming only closes the reservation.
+func (s *ReservationService) ConfirmReservation(ctx context.Context, id int64) (model.ReservationResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.ConfirmReservation")
+	defer span.End()
+
+	var response model.ReservationResponse
+	err := s.repository.Transaction(ctx, func(tx *sql.Tx) error {
+		query := s.repository.Query.WithTx(tx)
+
+		confirmed, err := query.ConfirmReservation(ctx, productrepository.ConfirmReservationParams{
+			Now: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+			ID:  id,
+		})
+		if err != nil {
+			return err
+		}
+
+		response, err = s.getReservation(ctx, query, id)
+		if err != nil {
+			return err
+		}
+		if confirmed == 0 {
+			return inactiveReservationError(response)
+		}
+		return nil
+	})
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("reservation %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to confirm reservation", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.ReservationResponse{}, err
+	}
+
+	ActiveReservations.Dec()
+	ConfirmedReservations.Inc()
+
+	return response, nil
+}
+
+// CancelReservation gives the held stock back.
+func (s *ReservationService) CancelReservation(ctx context.Context, id int64) (model.ReservationResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.CancelReservation")
+	defer span.End()
+
+	var response model.ReservationResponse
+	var movements []productrepository.StockMovement
+	err := s.repository.Transaction(ctx, func(tx *sql.Tx) error {
+		query := s.repository.Query.WithTx(tx)
+
+		var err error
+		response, movements, err = s.release(ctx, query, id, model.ReservationStatusCancelled)
+		return err
+	})
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("reservation %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to cancel reservation", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.ReservationResponse{}, err
+	}
+
+	ActiveReservations.Dec()
+	CancelledReservations.Inc()
+	observeWarehouseMovements(ctx, s.repository.Query, movements...)
+
+	return response, nil
+}
+
+// ExpireReservations releases every active reservation whose TTL has passed and returns how many were released.
+func (s *ReservationService) ExpireReservations(ctx context.Context) (int64, error) {
+	ctx, span := s.trace.Start(ctx, "Service.ExpireReservations")
+	defer span.End()
+
+	var expired int64
+	for {
+		var ids []int64
+		var movements []productrepository.StockMovement
+		err := s.repository.Transaction(ctx, func(tx *sql.Tx) error {
+			query := s.repository.Query.WithTx(tx)
+
+			var err error
+			ids, err = query.ListExpiredReservations(ctx, productrepository.ListExpiredReservationsParams{
+				Now:      time.Now().UTC(),
+				PageSize: reservationSweepBatchSize,
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, id := range ids {
+				_, released, err := s.release(ctx, query, id, model.ReservationStatusExpired)
+				if err != nil {
+					return err
+				}
+				movements = append(movements, released...)
+			}
+			return nil
+		})
+		if err != nil {
+			err = translateError(err, "reservation")
+			utility.RecordSpanError(span, err)
+			zap.L().Error("failed to expire reservations", zap.Error(err))
+			return expired, err
+		}
+
+		expired += int64(len(ids))
+		ExpiredReservations.Add(float64(len(ids)))
+		observeWarehouseMovements(ctx, s.repository.Query, movements...)
+		if len(ids) < reservationSweepBatchSize {
+			break
+		}
+	}
+
+	s.RefreshMetrics(ctx)
+
+	span.SetAttributes(attribute.Int64("expiredCount", expired))
+
+	return expired, nil
+}
+
+// RefreshMetrics sets the active reservations gauge from the database, so it starts right after a restart and
+// does not drift from what the sweeper changed.
+func (s *ReservationService) RefreshMetrics(ctx context.Context) error {
+	ctx, span := s.trace.Start(ctx, "Service.RefreshReservationMetrics")
+	defer span.End()
+
+	active, err := s.repository.Query.CountActiveReservations(ctx)
+	if err != nil {
+		err = translateError(err, "reservation")
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to refresh reservation metrics", zap.Error(err))
+		return err
+	}
+
+	ActiveReservations.Set(float64(active))
+	return nil
+}
+
+// RunExpiry calls ExpireReservations every interval until ctx is cancelled.
+func (s *ReservationService) RunExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := s.ExpireReservations(ctx)
+			if err == nil && expired > 0 {
+				zap.L().Info("expired reservations", zap.Int64("expiredCount", expired))
+			}
+		}
+	}
+}
+
+// release closes an active reservation with status and puts its units back in stock, into the warehouses they were
+// reserved from. It returns the stock movements it recorded.
+func (s *ReservationService) release(ctx context.Context, query *productrepository.Queries, id int64, status string) (model.ReservationResponse, []productrepository.StockMovement, error) {
+	now := time.Now().UTC()
+
+	released, err := query.ReleaseReservation(ctx, productrepository.ReleaseReservationParams{
+		Status:    status,
+		UpdatedAt: sql.NullTime{Time: now, Valid: true},
+		ID:        id,
+	})
+	if err != nil {
+		return model.ReservationResponse{}, nil, err
+	}
+
+	response, err := s.getReservation(ctx, query, id)
+	if err != nil {
+		return model.ReservationResponse{}, nil, err
+	}
+	if released == 0 {
+		return model.ReservationResponse{}, nil, inactiveReservationError(response)
+	}
+
+	var movements []productrepository.StockMovement
+	for _, item := range response.Items {
+		row := productrepository.ReservationItem{
+			ReservationID: id,
+			ProductID:     item.ProductId,
+			VariantID:     nullInt64(item.VariantId),
+			WarehouseID:   nullInt64(item.WarehouseId),
+			Quantity:      item.Quantity,
+		}
+		movement, err := moveReservedStock(ctx, query, row, item.Quantity, model.StockReasonRelease, now)
+		// Note : A product deleted while reserved has nothing to give the units back to.
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return model.ReservationResponse{}, nil, err
+		}
+		if err == nil {
+			movements = append(movements, movement)
+		}
+	}
+
+	return response, movements, nil
+}
+
+func (s *ReservationService) getReservation(ctx context.Context, query *productrepository.Queries, id int64) (model.ReservationResponse, error) {
+	reservation, err := query.GetReservation(ctx, id)
+	if err != nil {
+		return model.ReservationResponse{}, err
+	}
+
+	rows, err := query.ListReservationItems(ctx, id)
+	if err != nil {
+		return model.ReservationResponse{}, err
+	}
+
+	items := make([]model.ReservationItemResponse, 0, len(rows))
+	for _, row := range rows {
+		item := model.ReservationItemResponse{ProductId: row.ProductID, Quantity: row.Quantity}
+		if row.VariantID.Valid {
+			item.VariantId = &row.VariantID.Int64
+		}
+		if row.WarehouseID.Valid {
+			item.WarehouseId = &row.WarehouseID.Int64
+		}
+		items = append(items, item)
+	}
+
+	return reservationResponse(reservation, items), nil
+}
+
+// moveReservedStock changes the quantity of a reserved item and records it in the stock ledger.
+func moveReservedStock(ctx context.Context, query *productrepository.Queries, item productrepository.ReservationItem, delta int64, reason string, now time.Time) (productrepository.StockMovement, error) {
+	_, movement, err := moveStock(ctx, query, productrepository.CreateStockMovementParams{
+		ProductID:   item.ProductID,
+		VariantID:   item.VariantID,
+		WarehouseID: item.WarehouseID,
+		Delta:       delta,
+		Reason:      reason,
+		Reference:   sql.NullString{String: fmt.Sprintf("reservation %d", item.ReservationID), Valid: true},
+		RequestID:   requestIdOf(ctx),
+		CreatedAt:   now,
+	})
+	return movement, err
+}
+
+func inactiveReservationError(reservation model.ReservationResponse) error {
+	if reservation.Status == model.ReservationStatusActive {
+		return apperror.New(apperror.Conflict, fmt.Sprintf("reservation %d has expired", reservation.Id))
+	}
+	return apperror.New(apperror.Conflict, fmt.Sprintf("reservation %d is already %s", reservation.Id, reservation.Status))
+}
+
+func reservationResponse(reservation productrepository.Reservation, items []model.ReservationItemResponse) model.ReservationResponse {
+	return model.ReservationResponse{
+		Id:        reservation.ID,
+		Status:    reservation.Status,
+		Reference: reservation.Reference.String,
+		ExpiresAt: reservation.ExpiresAt,
+		CreatedAt: reservation.CreatedAt,
+		Items:     items,
+	}
+}
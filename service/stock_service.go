@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/model"
+	productrepository "github.com/indrabrata/observability-playground/repository/product"
+	"github.com/indrabrata/observability-playground/utility"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// AdjustStock changes the quantity of a product, or of one of its variants, and records the movement in the
+// same transaction. The quantity is never allowed to go below zero.
+func (s *ProductService) AdjustStock(ctx context.Context, id int64, request model.StockAdjustRequest) (model.StockAdjustResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.AdjustStock", trace.WithAttributes(
+		attribute.Int64("delta", request.Delta),
+		attribute.String("reason", request.Reason),
+		attribute.Bool("hasVariant", request.VariantId != nil),
+		attribute.Bool("hasWarehouse", request.WarehouseId != nil),
+	))
+	defer span.End()
+
+	var product productrepository.Product
+	var movement productrepository.StockMovement
+	err := s.repository.Transaction(ctx, func(tx *sql.Tx) error {
+		query := s.repository.Query.WithTx(tx)
+
+		var err error
+		product, movement, err = moveStock(ctx, query, productrepository.CreateStockMovementParams{
+			ProductID:   id,
+			VariantID:   nullInt64(request.VariantId),
+			WarehouseID: nullInt64(request.WarehouseId),
+			Delta:       request.Delta,
+			Reason:      request.Reason,
+			Reference:   sql.NullString{String: request.Reference, Valid: request.Reference != ""},
+			Actor:       sql.NullString{String: request.Actor, Valid: request.Actor != ""},
+			RequestID:   requestIdOf(ctx),
+			CreatedAt:   time.Now(),
+		})
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) && request.Delta < 0 {
+		// Note : No row was updated, either the product or variant does not exist or the stock would go negative.
+		err = stockShortageError(ctx, s.repository.Query, id, nullInt64(request.VariantId), "remove", -request.Delta)
+	} else if errors.Is(err, sql.ErrNoRows) && request.VariantId != nil {
+		// Note : Adding stock never goes negative, so the product or variant is gone.
+		err = translateError(err, fmt.Sprintf("variant %d of product %d", *request.VariantId, id))
+	}
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("product %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to adjust stock", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.StockAdjustResponse{}, err
+	}
+
+	span.SetAttributes(attribute.Int64("quantityAfter", product.Quantity))
+	observeWarehouseMovements(ctx, s.repository.Query, movement)
+
+	response := model.StockAdjustResponse{
+		Product:  productResponse(product),
+		Movement: stockMovementResponse(movement),
+	}
+
+	s.lowStock.StockChanged()
+
+	return response, nil
+}
+
+func (s *ProductService) GetStockHistory(ctx context.Context, id int64, request model.StockHistoryRequest) (model.StockHistoryResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.GetStockHistory", trace.WithAttributes(
+		attribute.Int64("limit", request.Limit),
+		attribute.Bool("hasCursor", request.After != nil),
+	))
+	defer span.End()
+
+	params := productrepository.ListStockMovementsParams{
+		ProductID: id,
+		PageSize:  request.Limit + 1,
+	}
+	if request.After != nil {
+		params.BeforeID = sql.NullInt64{Int64: request.After.Id, Valid: true}
+	}
+
+	_, err := s.repository.Query.GetProduct(ctx, id)
+	var data []productrepository.StockMovement
+	if err == nil {
+		data, err = s.repository.Query.ListStockMovements(ctx, params)
+	}
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("product %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to get stock history", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.StockHistoryResponse{}, err
+	}
+
+	paging := model.Paging{Limit: request.Limit}
+	if int64(len(data)) > request.Limit {
+		data = data[:request.Limit]
+
+		last := data[len(data)-1]
+		cursor, err := utility.EncodeCursor(utility.Cursor{SortBy: "id", SortOrder: "desc", Value: last.ID, Id: last.ID})
+		if err != nil {
+			err = translateError(err, "stock movement")
+			utility.RecordSpanError(span, err)
+			zap.L().Error("failed to encode cursor", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+			return model.StockHistoryResponse{}, err
+		}
+
+		paging.NextCursor = cursor
+		paging.HasMore = true
+	}
+
+	movements := make([]model.StockMovementResponse, 0, len(data))
+	for _, movement := range data {
+		movements = append(movements, stockMovementResponse(movement))
+	}
+
+	return model.StockHistoryResponse{Data: movements, Paging: paging}, nil
+}
+
+// moveStock changes the quantity of a product by params.Delta and records the movement in the stock ledger.
+// The quantity of a product with variants is the sum of theirs, so its stock only moves through one of its
+// variants, named by params.VariantID; the ledger then records the variant's quantity as quantity_after.
+// params.WarehouseID names the warehouse the units enter or leave, without it they are unallocated.
+// The change is written to the outbox in the same transaction. Like AdjustProductQuantity it returns
+// sql.ErrNoRows when the stock would go below zero.
+func moveStock(ctx context.Context, query *productrepository.Queries, params productrepository.CreateStockMovementParams) (productrepository.Product, productrepository.StockMovement, error) {
+	updatedAt := sql.NullTime{Time: params.CreatedAt, Valid: true}
+
+	if !params.VariantID.Valid {
+		variants, err := query.CountProductVariants(ctx, params.ProductID)
+		if err != nil {
+			return productrepository.Product{}, productrepository.StockMovement{}, err
+		}
+		if variants > 0 {
+			return productrepository.Product{}, productrepository.StockMovement{}, apperror.New(apperror.Conflict, fmt.Sprintf("product %d has variants, name one with variant_id", params.ProductID))
+		}
+	}
+
+	// Note : The product goes first so that a deleted product fails before any of its variants is touched.
+	product, err := query.AdjustProductQuantity(ctx, productrepository.AdjustProductQuantityParams{
+		Delta:     params.Delta,
+		UpdatedAt: updatedAt,
+		ID:        params.ProductID,
+	})
+	if err != nil {
+		return productrepository.Product{}, productrepository.StockMovement{}, err
+	}
+	params.QuantityAfter = product.Quantity
+
+	if params.VariantID.Valid {
+		variant, err := query.AdjustVariantQuantity(ctx, productrepository.AdjustVariantQuantityParams{
+			Delta:     params.Delta,
+			UpdatedAt: updatedAt,
+			ID:        params.VariantID.Int64,
+			ProductID: params.ProductID,
+		})
+		if err != nil {
+			return productrepository.Product{}, productrepository.StockMovement{}, err
+		}
+		params.QuantityAfter = variant.Quantity
+	}
+
+	if params.WarehouseID.Valid {
+		err = moveWarehouseStock(ctx, query, params.WarehouseID.Int64, params.ProductID, params.VariantID, params.Delta)
+	} else if params.Delta < 0 {
+		err = checkUnallocatedStock(ctx, query, params.ProductID, params.VariantID, params.QuantityAfter, -params.Delta)
+	}
+	if err != nil {
+		return productrepository.Product{}, productrepository.StockMovement{}, err
+	}
+
+	movement, err := query.CreateStockMovement(ctx, params)
+	if err != nil {
+		return productrepository.Product{}, productrepository.StockMovement{}, err
+	}
+
+	return product, movement, recordQuantityChange(ctx, query, product, params.Delta, params.CreatedAt)
+}
+
+// recordQuantityChange writes the product.updated event and the audit entry for product, as returned by
+// AdjustProductQuantity after moving its stock by delta, through query, which must belong to the transaction that
+// moved the stock. Every path that calls AdjustProductQuantity calls it too, so subscribers and the audit log see
+// every stock change and version bump.
+func recordQuantityChange(ctx context.Context, query *productrepository.Queries, product productrepository.Product, delta int64, at time.Time) error {
+	if err := recordProductEvent(ctx, query, model.EventProductUpdated, product, at); err != nil {
+		return err
+	}
+
+	// Note : AdjustProductQuantity only changes the quantity and bumps the version, so the product it read is
+	// rebuilt rather than read again.
+	before := product
+	before.Quantity -= delta
+	before.Version--
+	return recordProductAudit(ctx, query, model.AuditActionUpdate, &before, product, at)
+}
+
+// checkQuantityChange checks a quantity set directly through an update of current, the product as read in the
+// same transaction. The quantity of a product with variants is the sum of theirs and cannot be set; setting it to
+// the value it already has is allowed, so a full update can send back what it read. A lower quantity must still
+// cover what the warehouses hold.
+func checkQuantityChange(ctx context.Context, query *productrepository.Queries, current productrepository.Product, quantity sql.NullInt64) error {
+	if !quantity.Valid || current.Quantity == quantity.Int64 {
+		return nil
+	}
+
+	id := current.ID
+	variants, err := query.CountProductVariants(ctx, id)
+	if err != nil {
+		return err
+	}
+	if variants > 0 {
+		return apperror.New(apperror.Conflict, fmt.Sprintf("quantity of product %d is the sum of its %d variants and cannot be set directly", id, variants))
+	}
+
+	if quantity.Int64 < current.Quantity {
+		return checkUnallocatedStock(ctx, query, id, sql.NullInt64{}, quantity.Int64, current.Quantity-quantity.Int64)
+	}
+	return nil
+}
+
+// stockShortageError explains why moving quantity units of a product, or of one of its variants, updated no row.
+func stockShortageError(ctx context.Context, query *productrepository.Queries, productId int64, variantId sql.NullInt64, verb string, quantity int64) error {
+	product, err := query.GetProduct(ctx, productId)
+	if err != nil {
+		return translateError(err, fmt.Sprintf("product %d", productId))
+	}
+	if !variantId.Valid {
+		return apperror.New(apperror.Conflict, fmt.Sprintf("product %d has %d in stock, cannot %s %d", productId, product.Quantity, verb, quantity))
+	}
+
+	variant, err := query.GetVariant(ctx, productrepository.GetVariantParams{ID: variantId.Int64, ProductID: productId})
+	if err != nil {
+		return translateError(err, fmt.Sprintf("variant %d of product %d", variantId.Int64, productId))
+	}
+	return apperror.New(apperror.Conflict, fmt.Sprintf("variant %d of product %d has %d in stock, cannot %s %d", variantId.Int64, productId, variant.Quantity, verb, quantity))
+}
+
+func stockMovementResponse(movement productrepository.StockMovement) model.StockMovementResponse {
+	var variantId, warehouseId *int64
+	if movement.VariantID.Valid {
+		variantId = &movement.VariantID.Int64
+	}
+	if movement.WarehouseID.Valid {
+		warehouseId = &movement.WarehouseID.Int64
+	}
+
+	return model.StockMovementResponse{
+		Id:            movement.ID,
+		ProductId:     movement.ProductID,
+		VariantId:     variantId,
+		WarehouseId:   warehouseId,
+		Delta:         movement.Delta,
+		QuantityAfter: movement.QuantityAfter,
+		Reason:        movement.Reason,
+		Reference:     movement.Reference.String,
+		Actor:         movement.Actor.String,
+		RequestId:     movement.RequestID.String,
+		CreatedAt:     movement.CreatedAt,
+	}
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/indrabrata/observability-playground/model"
+	productrepository "github.com/indrabrata/observability-playground/repository/product"
+	"github.com/indrabrata/observability-playground/utility"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+func (s *ProductService) GetProductCategories(ctx context.Context, id int64) (model.ProductCategoriesResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.GetProductCategories")
+	defer span.End()
+
+	_, err := s.repository.Query.GetProduct(ctx, id)
+	var categories []productrepository.Category
+	if err == nil {
+		categories, err = s.repository.Query.ListProductCategories(ctx, id)
+	}
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("product %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to get product categories", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.ProductCategoriesResponse{}, err
+	}
+
+	return model.ProductCategoriesResponse{ProductId: id, Categories: categoryResponses(categories)}, nil
+}
+
+// SetProductCategories replaces the categories of a product with the requested ones.
+func (s *ProductService) SetProductCategories(ctx context.Context, id int64, request model.ProductCategoriesRequest) (model.ProductCategoriesResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.SetProductCategories", trace.WithAttributes(attribute.Int("categoryCount", len(request.CategoryIds))))
+	defer span.End()
+
+	var categories []productrepository.Category
+	err := s.repository.Transaction(ctx, func(tx *sql.Tx) error {
+		query := s.repository.Query.WithTx(tx)
+
+		if _, err := query.GetProduct(ctx, id); err != nil {
+			return translateError(err, fmt.Sprintf("product %d", id))
+		}
+		if err := query.DeleteProductCategories(ctx, id); err != nil {
+			return err
+		}
+
+		for _, categoryId := range request.CategoryIds {
+			if _, err := query.GetCategory(ctx, categoryId); err != nil {
+				return translateError(err, fmt.Sprintf("category %d", categoryId))
+			}
+			err := query.AddProductCategory(ctx, productrepository.AddProductCategoryParams{
+				ProductID:  id,
+				CategoryID: categoryId,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		var err error
+		categories, err = query.ListProductCategories(ctx, id)
+		return err
+	})
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("product %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to set product categories", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.ProductCategoriesResponse{}, err
+	}
+
+	return model.ProductCategoriesResponse{ProductId: id, Categories: categoryResponses(categories)}, nil
+}
+
+func (s *ProductService) GetProductTags(ctx context.Context, id int64) (model.ProductTagsResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.GetProductTags")
+	defer span.End()
+
+	_, err := s.repository.Query.GetProduct(ctx, id)
+	var tags []string
+	if err == nil {
+		tags, err = s.repository.Query.ListProductTags(ctx, id)
+	}
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("product %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to get product tags", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.ProductTagsResponse{}, err
+	}
+
+	return model.ProductTagsResponse{ProductId: id, Tags: append([]string{}, tags...)}, nil
+}
+
+// SetProductTags replaces the tags of a product. Tags are free-form, a tag that is not known yet is created.
+func (s *ProductService) SetProductTags(ctx context.Context, id int64, request model.ProductTagsRequest) (model.ProductTagsResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.SetProductTags", trace.WithAttributes(attribute.Int("tagCount", len(request.Tags))))
+	defer span.End()
+
+	var tags []string
+	err := s.repository.Transaction(ctx, func(tx *sql.Tx) error {
+		query := s.repository.Query.WithTx(tx)
+
+		if _, err := query.GetProduct(ctx, id); err != nil {
+			return err
+		}
+		if err := query.DeleteProductTags(ctx, id); err != nil {
+			return err
+		}
+
+		for _, tag := range request.Tags {
+			tagId, err := query.UpsertTag(ctx, tag)
+			if err != nil {
+				return err
+			}
+			err = query.AddProductTag(ctx, productrepository.AddProductTagParams{
+				ProductID: id,
+				TagID:     tagId,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		var err error
+		tags, err = query.ListProductTags(ctx, id)
+		return err
+	})
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("product %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to set product tags", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.ProductTagsResponse{}, err
+	}
+
+	return model.ProductTagsResponse{ProductId: id, Tags: append([]string{}, tags...)}, nil
+}
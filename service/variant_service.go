@@ -0,0 +1,352 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/model"
+	productrepository "github.com/indrabrata/observability-playground/repository/product"
+	"github.com/indrabrata/observability-playground/utility"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Note : The quantity of a product with variants is the sum of the quantities of its variants. Every change of a
+// variant's quantity moves the product's quantity by the same delta in the same transaction, so listings, filters
+// and exports that read products.quantity keep showing the aggregate.
+
+func (s *ProductService) ListVariants(ctx context.Context, id int64) (model.VariantListResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.ListVariants", trace.WithAttributes(attribute.Int64("productId", id)))
+	defer span.End()
+
+	_, err := s.repository.Query.GetProduct(ctx, id)
+	var variants []productrepository.ProductVariant
+	if err == nil {
+		variants, err = s.repository.Query.ListVariants(ctx, id)
+	}
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("product %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to list variants", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.VariantListResponse{}, err
+	}
+
+	responses := make([]model.VariantResponse, 0, len(variants))
+	for _, variant := range variants {
+		responses = append(responses, variantResponse(variant))
+	}
+
+	return model.VariantListResponse{ProductId: id, Data: responses}, nil
+}
+
+func (s *ProductService) GetVariant(ctx context.Context, id, variantId int64) (model.VariantResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.GetVariant", trace.WithAttributes(
+		attribute.Int64("productId", id),
+		attribute.Int64("variantId", variantId),
+	))
+	defer span.End()
+
+	var variant productrepository.ProductVariant
+	_, err := s.repository.Query.GetProduct(ctx, id)
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("product %d", id))
+	} else {
+		variant, err = s.repository.Query.GetVariant(ctx, productrepository.GetVariantParams{ID: variantId, ProductID: id})
+	}
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("variant %d of product %d", variantId, id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to get variant", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.VariantResponse{}, err
+	}
+
+	return variantResponse(variant), nil
+}
+
+// CreateVariant adds a variant to a product. Once a product has variants its stock belongs to them, so the first
+// variant is refused while the product still holds stock or reservations of its own.
+func (s *ProductService) CreateVariant(ctx context.Context, id int64, request model.VariantRequest) (model.VariantResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.CreateVariant", trace.WithAttributes(
+		attribute.Int64("productId", id),
+		attribute.Int64("quantity", request.Quantity),
+	))
+	defer span.End()
+
+	now := time.Now()
+
+	var variant productrepository.ProductVariant
+	err := s.repository.Transaction(ctx, func(tx *sql.Tx) error {
+		query := s.repository.Query.WithTx(tx)
+
+		product, err := query.GetProduct(ctx, id)
+		if err != nil {
+			return translateError(err, fmt.Sprintf("product %d", id))
+		}
+
+		variants, err := query.CountProductVariants(ctx, id)
+		if err != nil {
+			return err
+		}
+		if variants >= model.MaxVariantsPerProduct {
+			return apperror.New(apperror.Conflict, fmt.Sprintf("product %d already has the maximum of %d variants", id, model.MaxVariantsPerProduct))
+		}
+		if variants == 0 {
+			if product.Quantity > 0 {
+				return apperror.New(apperror.Conflict, fmt.Sprintf("product %d has %d in stock that belongs to no variant, adjust it to 0 before adding variants", id, product.Quantity))
+			}
+			reservations, err := query.CountActiveItemReservations(ctx, productrepository.CountActiveItemReservationsParams{ProductID: id})
+			if err != nil {
+				return err
+			}
+			if reservations > 0 {
+				return apperror.New(apperror.Conflict, fmt.Sprintf("product %d has %d active reservations, they must be closed before adding variants", id, reservations))
+			}
+		}
+
+		columns, err := newVariantColumns(request, product)
+		if err != nil {
+			return err
+		}
+
+		variant, err = query.CreateVariant(ctx, productrepository.CreateVariantParams{
+			ProductID:  id,
+			Sku:        request.Sku,
+			Options:    columns.Options,
+			PriceMinor: columns.PriceMinor,
+			Currency:   columns.Currency,
+			Quantity:   request.Quantity,
+			CreatedAt:  now,
+		})
+		if err != nil || request.Quantity == 0 {
+			return err
+		}
+
+		product, err = query.AdjustProductQuantity(ctx, productrepository.AdjustProductQuantityParams{
+			Delta:     request.Quantity,
+			UpdatedAt: sql.NullTime{Time: now, Valid: true},
+			ID:        id,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = query.CreateStockMovement(ctx, productrepository.CreateStockMovementParams{
+			ProductID:     id,
+			VariantID:     sql.NullInt64{Int64: variant.ID, Valid: true},
+			Delta:         request.Quantity,
+			QuantityAfter: request.Quantity,
+			Reason:        model.StockReasonInitial,
+			RequestID:     requestIdOf(ctx),
+			CreatedAt:     now,
+		})
+		if err != nil {
+			return err
+		}
+		return recordQuantityChange(ctx, query, product, request.Quantity, now)
+	})
+	if err != nil {
+		err = translateError(err, "variant")
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to create variant", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.VariantResponse{}, err
+	}
+
+	span.SetAttributes(attribute.Int64("variantId", variant.ID))
+
+	s.lowStock.StockChanged()
+
+	return variantResponse(variant), nil
+}
+
+// UpdateVariant replaces a variant. A changed quantity is recorded in the stock ledger like a product update.
+func (s *ProductService) UpdateVariant(ctx context.Context, id, variantId int64, request model.VariantRequest) (model.VariantResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.UpdateVariant", trace.WithAttributes(
+		attribute.Int64("productId", id),
+		attribute.Int64("variantId", variantId),
+	))
+	defer span.End()
+
+	now := time.Now()
+
+	var variant productrepository.ProductVariant
+	err := s.repository.Transaction(ctx, func(tx *sql.Tx) error {
+		query := s.repository.Query.WithTx(tx)
+
+		product, err := query.GetProduct(ctx, id)
+		if err != nil {
+			return translateError(err, fmt.Sprintf("product %d", id))
+		}
+		current, err := query.GetVariant(ctx, productrepository.GetVariantParams{ID: variantId, ProductID: id})
+		if err != nil {
+			return err
+		}
+
+		columns, err := newVariantColumns(request, product)
+		if err != nil {
+			return err
+		}
+
+		if delta := request.Quantity - current.Quantity; delta != 0 {
+			_, _, err := moveStock(ctx, query, productrepository.CreateStockMovementParams{
+				ProductID: id,
+				VariantID: sql.NullInt64{Int64: variantId, Valid: true},
+				Delta:     delta,
+				Reason:    model.StockReasonUpdate,
+				RequestID: requestIdOf(ctx),
+				CreatedAt: now,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		variant, err = query.UpdateVariant(ctx, productrepository.UpdateVariantParams{
+			Sku:        request.Sku,
+			Options:    columns.Options,
+			PriceMinor: columns.PriceMinor,
+			Currency:   columns.Currency,
+			UpdatedAt:  sql.NullTime{Time: now, Valid: true},
+			ID:         variantId,
+			ProductID:  id,
+		})
+		return err
+	})
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("variant %d of product %d", variantId, id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to update variant", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.VariantResponse{}, err
+	}
+
+	s.lowStock.StockChanged()
+
+	return variantResponse(variant), nil
+}
+
+// DeleteVariant removes a variant without stock, active reservations or open orders. Its stock movements are kept.
+func (s *ProductService) DeleteVariant(ctx context.Context, id, variantId int64) error {
+	ctx, span := s.trace.Start(ctx, "Service.DeleteVariant", trace.WithAttributes(
+		attribute.Int64("productId", id),
+		attribute.Int64("variantId", variantId),
+	))
+	defer span.End()
+
+	err := s.repository.Transaction(ctx, func(tx *sql.Tx) error {
+		query := s.repository.Query.WithTx(tx)
+
+		if _, err := query.GetProduct(ctx, id); err != nil {
+			return translateError(err, fmt.Sprintf("product %d", id))
+		}
+		variant, err := query.GetVariant(ctx, productrepository.GetVariantParams{ID: variantId, ProductID: id})
+		if err != nil {
+			return err
+		}
+		if variant.Quantity > 0 {
+			return apperror.New(apperror.Conflict, fmt.Sprintf("variant %d of product %d still has %d in stock, adjust it to 0 first", variantId, id, variant.Quantity))
+		}
+
+		reservations, err := query.CountActiveItemReservations(ctx, productrepository.CountActiveItemReservationsParams{
+			ProductID: id,
+			VariantID: sql.NullInt64{Int64: variantId, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		if reservations > 0 {
+			return apperror.New(apperror.Conflict, fmt.Sprintf("variant %d of product %d has %d active reservations", variantId, id, reservations))
+		}
+
+		// Note : Cancelling an open order gives its units back to the variant, so the variant must outlive it.
+		orders, err := query.CountOpenOrderLines(ctx, productrepository.CountOpenOrderLinesParams{
+			VariantID: sql.NullInt64{Int64: variantId, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		if orders > 0 {
+			return apperror.New(apperror.Conflict, fmt.Sprintf("variant %d of product %d is on %d open order lines", variantId, id, orders))
+		}
+
+		// Note : A variant without stock can only have empty rows left in the warehouses.
+		err = query.DeleteVariantWarehouseStock(ctx, productrepository.DeleteVariantWarehouseStockParams{
+			ProductID: id,
+			VariantID: sql.NullInt64{Int64: variantId, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		// Note : The variant is only marked deleted, so the ledger, transfers and orders that name it still point at a row.
+		_, err = query.DeleteVariant(ctx, productrepository.DeleteVariantParams{
+			DeletedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+			ID:        variantId,
+			ProductID: id,
+		})
+		return err
+	})
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("variant %d of product %d", variantId, id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to delete variant", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return err
+	}
+
+	return nil
+}
+
+type variantColumns struct {
+	Options    string
+	PriceMinor sql.NullInt64
+	Currency   sql.NullString
+}
+
+// variantParams converts the options and price override of a request to their stored form. Options are stored
+// as JSON, whose keys encoding/json sorts, so that equal options are equal text for the unique index.
+func newVariantColumns(request model.VariantRequest, product productrepository.Product) (variantColumns, error) {
+	options, err := json.Marshal(request.Options)
+	if err != nil {
+		return variantColumns{}, err
+	}
+
+	columns := variantColumns{Options: string(options)}
+	if request.Price != nil {
+		currency := request.Currency
+		if currency == "" {
+			currency = product.Currency
+		}
+
+		priceMinor, err := request.PriceMinorUnits(currency)
+		if err != nil {
+			return variantColumns{}, apperror.Wrap(apperror.Validation, err, "request validation failed")
+		}
+		columns.PriceMinor = sql.NullInt64{Int64: priceMinor, Valid: true}
+		columns.Currency = sql.NullString{String: currency, Valid: true}
+	}
+	return columns, nil
+}
+
+func variantResponse(variant productrepository.ProductVariant) model.VariantResponse {
+	response := model.VariantResponse{
+		Id:        variant.ID,
+		ProductId: variant.ProductID,
+		Sku:       variant.Sku,
+		Quantity:  variant.Quantity,
+		CreatedAt: variant.CreatedAt,
+	}
+	// Note : Options are only ever written by variantParams, so they always decode.
+	_ = json.Unmarshal([]byte(variant.Options), &response.Options)
+	if variant.PriceMinor.Valid {
+		price := model.NewMoney(variant.PriceMinor.Int64, variant.Currency.String)
+		response.Price = &price
+		response.Currency = variant.Currency.String
+	}
+	if variant.UpdatedAt.Valid {
+		response.UpdatedAt = &variant.UpdatedAt.Time
+	}
+	return response
+}
@@ -0,0 +1,532 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/repository"
+	productrepository "github.com/indrabrata/observability-playground/repository/product"
+	"github.com/indrabrata/observability-playground/utility"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// WarehouseService manages warehouses and moves stock between them. The quantity of a product stays its total
+// across all locations; warehouse_stock records which part of it sits where, and the rest is unallocated.
+type WarehouseService struct {
+	repository *repository.BaseRepository[*productrepository.Queries]
+	trace      trace.Tracer
+}
+
+func NewWarehouseService(repository *repository.BaseRepository[*productrepository.Queries], trace trace.Tracer) *WarehouseService {
+	return &WarehouseService{
+		repository: repository,
+		trace:      trace,
+	}
+}
+
+func (s *WarehouseService) CreateWarehouse(ctx context.Context, request model.WarehouseRequest) (model.WarehouseResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.CreateWarehouse", trace.WithAttributes(attribute.String("warehouseCode", request.Code)))
+	defer span.End()
+
+	warehouse, err := s.repository.Query.CreateWarehouse(ctx, productrepository.CreateWarehouseParams{
+		Code:      request.Code,
+		Name:      request.Name,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		err = translateError(err, "warehouse")
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to create warehouse", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.WarehouseResponse{}, err
+	}
+
+	span.SetAttributes(attribute.Int64("warehouseId", warehouse.ID))
+	s.refreshMetrics(ctx)
+
+	return warehouseResponse(warehouse), nil
+}
+
+func (s *WarehouseService) GetWarehouse(ctx context.Context, id int64) (model.WarehouseResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.GetWarehouse", trace.WithAttributes(attribute.Int64("warehouseId", id)))
+	defer span.End()
+
+	warehouse, err := s.repository.Query.GetWarehouse(ctx, id)
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("warehouse %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to get warehouse", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.WarehouseResponse{}, err
+	}
+
+	return warehouseResponse(warehouse), nil
+}
+
+func (s *WarehouseService) ListWarehouses(ctx context.Context) (model.WarehouseListResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.ListWarehouses")
+	defer span.End()
+
+	warehouses, err := s.repository.Query.ListWarehouses(ctx)
+	if err != nil {
+		err = translateError(err, "warehouse")
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to list warehouses", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.WarehouseListResponse{}, err
+	}
+
+	responses := make([]model.WarehouseResponse, 0, len(warehouses))
+	for _, warehouse := range warehouses {
+		responses = append(responses, warehouseResponse(warehouse))
+	}
+
+	return model.WarehouseListResponse{Data: responses}, nil
+}
+
+// UpdateWarehouse renames a warehouse. A new code also renames the warehouse label of its metrics.
+func (s *WarehouseService) UpdateWarehouse(ctx context.Context, id int64, request model.WarehouseRequest) (model.WarehouseResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.UpdateWarehouse", trace.WithAttributes(attribute.Int64("warehouseId", id)))
+	defer span.End()
+
+	warehouse, err := s.repository.Query.UpdateWarehouse(ctx, productrepository.UpdateWarehouseParams{
+		Code:      request.Code,
+		Name:      request.Name,
+		UpdatedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		ID:        id,
+	})
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("warehouse %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to update warehouse", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.WarehouseResponse{}, err
+	}
+
+	s.refreshMetrics(ctx)
+
+	return warehouseResponse(warehouse), nil
+}
+
+// DeleteWarehouse removes a warehouse that holds no stock, active reservations or open orders. Its stock movements and
+// transfers keep pointing at its id.
+func (s *WarehouseService) DeleteWarehouse(ctx context.Context, id int64) error {
+	ctx, span := s.trace.Start(ctx, "Service.DeleteWarehouse", trace.WithAttributes(attribute.Int64("warehouseId", id)))
+	defer span.End()
+
+	err := s.repository.Transaction(ctx, func(tx *sql.Tx) error {
+		query := s.repository.Query.WithTx(tx)
+
+		if _, err := query.GetWarehouse(ctx, id); err != nil {
+			return err
+		}
+
+		held, err := query.CountWarehouseStock(ctx, id)
+		if err != nil {
+			return err
+		}
+		if held > 0 {
+			return apperror.New(apperror.Conflict, fmt.Sprintf("warehouse %d still holds %d units, transfer them first", id, held))
+		}
+
+		reservations, err := query.CountActiveWarehouseReservations(ctx, sql.NullInt64{Int64: id, Valid: true})
+		if err != nil {
+			return err
+		}
+		if reservations > 0 {
+			return apperror.New(apperror.Conflict, fmt.Sprintf("warehouse %d has %d active reservations", id, reservations))
+		}
+
+		// Note : Cancelling an open order gives its units back to the warehouse, so the warehouse must outlive it.
+		orders, err := query.CountOpenOrderLines(ctx, productrepository.CountOpenOrderLinesParams{
+			WarehouseID: sql.NullInt64{Int64: id, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		if orders > 0 {
+			return apperror.New(apperror.Conflict, fmt.Sprintf("warehouse %d is on %d open order lines", id, orders))
+		}
+
+		if err := query.DeleteEmptyWarehouseStock(ctx, id); err != nil {
+			return err
+		}
+		// Note : The warehouse is only marked deleted, so the ledger, transfers and orders that name it still point at a row.
+		_, err = query.DeleteWarehouse(ctx, productrepository.DeleteWarehouseParams{
+			DeletedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+			ID:        id,
+		})
+		return err
+	})
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("warehouse %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to delete warehouse", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return err
+	}
+
+	s.refreshMetrics(ctx)
+
+	return nil
+}
+
+// CreateTransfer moves every item from one location to the other in one transaction, or none of them. Totals do not
+// change; the ledger records each item as a transfer_out and a transfer_in movement that cancel out.
+func (s *WarehouseService) CreateTransfer(ctx context.Context, request model.TransferRequest) (model.TransferResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.CreateTransfer", trace.WithAttributes(
+		attribute.Bool("fromUnallocated", request.FromWarehouseId == nil),
+		attribute.Bool("toUnallocated", request.ToWarehouseId == nil),
+		attribute.Int("itemCount", len(request.Items)),
+	))
+	defer span.End()
+
+	now := time.Now()
+	from := nullInt64(request.FromWarehouseId)
+	to := nullInt64(request.ToWarehouseId)
+
+	var transfer productrepository.StockTransfer
+	var movements []productrepository.StockMovement
+	err := s.repository.Transaction(ctx, func(tx *sql.Tx) error {
+		query := s.repository.Query.WithTx(tx)
+
+		for _, warehouseId := range []sql.NullInt64{from, to} {
+			if !warehouseId.Valid {
+				continue
+			}
+			if _, err := query.GetWarehouse(ctx, warehouseId.Int64); err != nil {
+				return translateError(err, fmt.Sprintf("warehouse %d", warehouseId.Int64))
+			}
+		}
+
+		var err error
+		transfer, err = query.CreateStockTransfer(ctx, productrepository.CreateStockTransferParams{
+			FromWarehouseID: from,
+			ToWarehouseID:   to,
+			Reference:       sql.NullString{String: request.Reference, Valid: request.Reference != ""},
+			Actor:           sql.NullString{String: request.Actor, Valid: request.Actor != ""},
+			CreatedAt:       now,
+		})
+		if err != nil {
+			return err
+		}
+
+		bumped := make(map[int64]bool, len(request.Items))
+		for _, item := range request.Items {
+			// Note : A transfer leaves the quantity alone but changes the stock breakdown the product is read with,
+			// so its version is bumped and written to the outbox once, however many of its variants move.
+			if !bumped[item.ProductId] {
+				product, err := query.BumpProductVersion(ctx, productrepository.BumpProductVersionParams{
+					UpdatedAt: sql.NullTime{Time: now, Valid: true},
+					ID:        item.ProductId,
+				})
+				if err != nil {
+					return translateError(err, fmt.Sprintf("product %d", item.ProductId))
+				}
+				if err := recordProductEvent(ctx, query, model.EventProductUpdated, product, now); err != nil {
+					return err
+				}
+				bumped[item.ProductId] = true
+			}
+
+			variantId := nullInt64(item.VariantId)
+			err := query.CreateStockTransferItem(ctx, productrepository.CreateStockTransferItemParams{
+				TransferID: transfer.ID,
+				ProductID:  item.ProductId,
+				VariantID:  variantId,
+				Quantity:   item.Quantity,
+			})
+			if err != nil {
+				return err
+			}
+
+			total, err := transferTotal(ctx, query, item.ProductId, variantId)
+			if err != nil {
+				return err
+			}
+
+			if from.Valid {
+				err = moveWarehouseStock(ctx, query, from.Int64, item.ProductId, variantId, -item.Quantity)
+			}
+			if err == nil && to.Valid {
+				err = moveWarehouseStock(ctx, query, to.Int64, item.ProductId, variantId, item.Quantity)
+			}
+			if err == nil && !from.Valid {
+				err = checkUnallocatedStock(ctx, query, item.ProductId, variantId, total, item.Quantity)
+			}
+			if err != nil {
+				return err
+			}
+
+			for _, leg := range []struct {
+				warehouseId sql.NullInt64
+				delta       int64
+				reason      string
+			}{
+				{from, -item.Quantity, model.StockReasonTransferOut},
+				{to, item.Quantity, model.StockReasonTransferIn},
+			} {
+				movement, err := query.CreateStockMovement(ctx, productrepository.CreateStockMovementParams{
+					ProductID:     item.ProductId,
+					VariantID:     variantId,
+					WarehouseID:   leg.warehouseId,
+					Delta:         leg.delta,
+					QuantityAfter: total,
+					Reason:        leg.reason,
+					Reference:     sql.NullString{String: fmt.Sprintf("transfer %d", transfer.ID), Valid: true},
+					Actor:         sql.NullString{String: request.Actor, Valid: request.Actor != ""},
+					RequestID:     requestIdOf(ctx),
+					CreatedAt:     now,
+				})
+				if err != nil {
+					return err
+				}
+				movements = append(movements, movement)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		err = translateError(err, "transfer")
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to create transfer", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.TransferResponse{}, err
+	}
+
+	span.SetAttributes(attribute.Int64("transferId", transfer.ID))
+	observeWarehouseMovements(ctx, s.repository.Query, movements...)
+
+	items := make([]model.TransferItemResponse, 0, len(request.Items))
+	for _, item := range request.Items {
+		items = append(items, model.TransferItemResponse{ProductId: item.ProductId, VariantId: item.VariantId, Quantity: item.Quantity})
+	}
+
+	return transferResponse(transfer, items), nil
+}
+
+func (s *WarehouseService) GetTransfer(ctx context.Context, id int64) (model.TransferResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.GetTransfer", trace.WithAttributes(attribute.Int64("transferId", id)))
+	defer span.End()
+
+	transfer, err := s.repository.Query.GetStockTransfer(ctx, id)
+	var rows []productrepository.StockTransferItem
+	if err == nil {
+		rows, err = s.repository.Query.ListStockTransferItems(ctx, id)
+	}
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("transfer %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to get transfer", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.TransferResponse{}, err
+	}
+
+	items := make([]model.TransferItemResponse, 0, len(rows))
+	for _, row := range rows {
+		item := model.TransferItemResponse{ProductId: row.ProductID, Quantity: row.Quantity}
+		if row.VariantID.Valid {
+			item.VariantId = &row.VariantID.Int64
+		}
+		items = append(items, item)
+	}
+
+	return transferResponse(transfer, items), nil
+}
+
+// RefreshMetrics sets the warehouse stock gauges from the database, e.g. on startup.
+func (s *WarehouseService) RefreshMetrics(ctx context.Context) error {
+	_, err := refreshWarehouseStock(ctx, s.repository.Query)
+	return err
+}
+
+func (s *WarehouseService) refreshMetrics(ctx context.Context) {
+	if _, err := refreshWarehouseStock(ctx, s.repository.Query); err != nil {
+		zap.L().Warn("failed to refresh warehouse metrics", zap.Error(err))
+	}
+}
+
+// transferTotal returns the quantity of the product or variant being transferred.
+func transferTotal(ctx context.Context, query *productrepository.Queries, productId int64, variantId sql.NullInt64) (int64, error) {
+	if !variantId.Valid {
+		variants, err := query.CountProductVariants(ctx, productId)
+		if err != nil {
+			return 0, err
+		}
+		if variants > 0 {
+			return 0, apperror.New(apperror.Conflict, fmt.Sprintf("product %d has variants, name one with variant_id", productId))
+		}
+		product, err := query.GetProduct(ctx, productId)
+		if err != nil {
+			return 0, translateError(err, fmt.Sprintf("product %d", productId))
+		}
+		return product.Quantity, nil
+	}
+
+	variant, err := query.GetVariant(ctx, productrepository.GetVariantParams{ID: variantId.Int64, ProductID: productId})
+	if err != nil {
+		return 0, translateError(err, fmt.Sprintf("variant %d of product %d", variantId.Int64, productId))
+	}
+	return variant.Quantity, nil
+}
+
+// moveWarehouseStock moves delta units of a product, or of one of its variants, into or out of a warehouse.
+// A warehouse never holds less than nothing.
+func moveWarehouseStock(ctx context.Context, query *productrepository.Queries, warehouseId, productId int64, variantId sql.NullInt64, delta int64) error {
+	warehouse, err := query.GetWarehouse(ctx, warehouseId)
+	if err != nil {
+		return translateError(err, fmt.Sprintf("warehouse %d", warehouseId))
+	}
+
+	if delta > 0 {
+		_, err := query.AddWarehouseStock(ctx, productrepository.AddWarehouseStockParams{
+			WarehouseID: warehouseId,
+			ProductID:   productId,
+			VariantID:   variantId,
+			Quantity:    delta,
+		})
+		return err
+	}
+
+	// Note : variant_key is the variant id, or 0 for stock of a product without variants.
+	_, err = query.RemoveWarehouseStock(ctx, productrepository.RemoveWarehouseStockParams{
+		Quantity:    -delta,
+		WarehouseID: warehouseId,
+		ProductID:   productId,
+		VariantKey:  variantId.Int64,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		held, err := query.GetWarehouseStockQuantity(ctx, productrepository.GetWarehouseStockQuantityParams{
+			WarehouseID: warehouseId,
+			ProductID:   productId,
+			VariantKey:  variantId.Int64,
+		})
+		if err != nil {
+			return err
+		}
+		return apperror.New(apperror.Conflict, fmt.Sprintf("warehouse %s has %d of %s, cannot remove %d", warehouse.Code, held, stockItemName(productId, variantId), -delta))
+	}
+	return err
+}
+
+// checkUnallocatedStock checks that, with total units of a product or variant in stock, the warehouses do not hold
+// more than that. removed is how many unallocated units the change took, for the error message.
+func checkUnallocatedStock(ctx context.Context, query *productrepository.Queries, productId int64, variantId sql.NullInt64, total, removed int64) error {
+	allocated, err := query.CountAllocatedStock(ctx, productrepository.CountAllocatedStockParams{
+		ProductID:  productId,
+		VariantKey: variantId.Int64,
+	})
+	if err != nil {
+		return err
+	}
+	if total < allocated {
+		return apperror.New(apperror.Conflict, fmt.Sprintf("%s has %d units outside warehouses, cannot remove %d, name a warehouse_id", stockItemName(productId, variantId), total+removed-allocated, removed))
+	}
+	return nil
+}
+
+// productStock breaks the quantity of a product down by warehouse.
+func productStock(ctx context.Context, query *productrepository.Queries, product productrepository.Product) (*model.ProductStockResponse, error) {
+	rows, err := query.ListProductStockLocations(ctx, product.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	stock := &model.ProductStockResponse{
+		Total:       product.Quantity,
+		Unallocated: product.Quantity,
+		Locations:   make([]model.StockLocationResponse, 0, len(rows)),
+	}
+	for _, row := range rows {
+		stock.Unallocated -= row.Quantity
+		stock.Locations = append(stock.Locations, model.StockLocationResponse{
+			WarehouseId:   row.ID,
+			WarehouseCode: row.Code,
+			Quantity:      row.Quantity,
+		})
+	}
+	return stock, nil
+}
+
+// observeWarehouseMovements updates the warehouse metrics once movements are committed. Stock levels are read back
+// rather than tracked, so they cannot drift from the database.
+func observeWarehouseMovements(ctx context.Context, query *productrepository.Queries, movements ...productrepository.StockMovement) {
+	located := false
+	for _, movement := range movements {
+		located = located || movement.WarehouseID.Valid
+	}
+	if !located {
+		return
+	}
+
+	codes, err := refreshWarehouseStock(ctx, query)
+	if err != nil {
+		zap.L().Warn("failed to refresh warehouse metrics", zap.Error(err))
+		return
+	}
+
+	for _, movement := range movements {
+		if !movement.WarehouseID.Valid {
+			continue
+		}
+		if movement.Delta < 0 {
+			WarehouseMovedUnits.WithLabelValues(codes[movement.WarehouseID.Int64], "out").Add(float64(-movement.Delta))
+		} else {
+			WarehouseMovedUnits.WithLabelValues(codes[movement.WarehouseID.Int64], "in").Add(float64(movement.Delta))
+		}
+	}
+}
+
+// refreshWarehouseStock sets the stock gauge of every warehouse and returns the warehouse codes by id.
+func refreshWarehouseStock(ctx context.Context, query *productrepository.Queries) (map[int64]string, error) {
+	levels, err := query.ListWarehouseStockLevels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Note : Reset drops the gauges of deleted or renamed warehouses.
+	WarehouseStock.Reset()
+	codes := make(map[int64]string, len(levels))
+	for _, level := range levels {
+		codes[level.ID] = level.Code
+		WarehouseStock.WithLabelValues(level.Code).Set(float64(level.Quantity))
+	}
+	return codes, nil
+}
+
+func stockItemName(productId int64, variantId sql.NullInt64) string {
+	if variantId.Valid {
+		return fmt.Sprintf("variant %d of product %d", variantId.Int64, productId)
+	}
+	return fmt.Sprintf("product %d", productId)
+}
+
+func warehouseResponse(warehouse productrepository.Warehouse) model.WarehouseResponse {
+	response := model.WarehouseResponse{
+		Id:        warehouse.ID,
+		Code:      warehouse.Code,
+		Name:      warehouse.Name,
+		CreatedAt: warehouse.CreatedAt,
+	}
+	if warehouse.UpdatedAt.Valid {
+		response.UpdatedAt = &warehouse.UpdatedAt.Time
+	}
+	return response
+}
+
+func transferResponse(transfer productrepository.StockTransfer, items []model.TransferItemResponse) model.TransferResponse {
+	response := model.TransferResponse{
+		Id:        transfer.ID,
+		Reference: transfer.Reference.String,
+		Actor:     transfer.Actor.String,
+		CreatedAt: transfer.CreatedAt,
+		Items:     items,
+	}
+	if transfer.FromWarehouseID.Valid {
+		response.FromWarehouseId = &transfer.FromWarehouseID.Int64
+	}
+	if transfer.ToWarehouseID.Valid {
+		response.ToWarehouseId = &transfer.ToWarehouseID.Int64
+	}
+	return response
+}
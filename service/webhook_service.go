@@ -0,0 +1,578 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/repository"
+	productrepository "github.com/indrabrata/observability-playground/repository/product"
+	"github.com/indrabrata/observability-playground/utility"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	webhookDeliveryBatchSize = 50
+
+	// Note : A failed delivery is retried after webhookRetryDelay, doubled on every further failure up to
+	// webhookMaxRetryDelay. After webhookMaxAttempts attempts, about half an hour, the delivery is dead.
+	webhookMaxAttempts    = 8
+	webhookRetryDelay     = 10 * time.Second
+	webhookMaxRetryDelay  = time.Hour
+	webhookMaxErrorLength = 500
+
+	WebhookIdHeader        = "X-Webhook-Id"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// WebhookService manages webhook subscriptions and delivers events to them. It is an OutboxSink: a relayed event
+// becomes one pending delivery per subscription that wants it, and Run posts the pending deliveries, each with its
+// own retries, so a slow or failing partner never holds up the outbox or the other partners.
+type WebhookService struct {
+	repository *repository.BaseRepository[*productrepository.Queries]
+	trace      trace.Tracer
+	client     *http.Client
+
+	// Note : queued holds at most one pending wake up, so a burst of events is delivered in one run.
+	queued chan struct{}
+}
+
+// NewWebhookService returns a service whose deliveries give up on a partner after timeout. The HTTP client is
+// instrumented, so every delivery is a client span that sends its trace context along.
+func NewWebhookService(repository *repository.BaseRepository[*productrepository.Queries], trace trace.Tracer, timeout time.Duration) *WebhookService {
+	return &WebhookService{
+		repository: repository,
+		trace:      trace,
+		client:     &http.Client{Timeout: timeout, Transport: otelhttp.NewTransport(http.DefaultTransport)},
+		queued:     make(chan struct{}, 1),
+	}
+}
+
+func (s *WebhookService) CreateWebhook(ctx context.Context, request model.WebhookRequest) (model.WebhookResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.CreateWebhook")
+	defer span.End()
+
+	secret := request.Secret
+	eventTypes, err := json.Marshal(request.EventTypes)
+	if err == nil && secret == "" {
+		secret, err = newWebhookSecret()
+	}
+	var webhook productrepository.WebhookSubscription
+	if err == nil {
+		webhook, err = s.repository.Query.CreateWebhookSubscription(ctx, productrepository.CreateWebhookSubscriptionParams{
+			Url:        request.Url,
+			EventTypes: string(eventTypes),
+			Secret:     secret,
+			CreatedAt:  time.Now().UTC(),
+		})
+	}
+	if err != nil {
+		err = translateError(err, "webhook")
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to create webhook", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.WebhookResponse{}, err
+	}
+
+	span.SetAttributes(attribute.Int64("webhookId", webhook.ID))
+
+	response := webhookResponse(webhook)
+	response.Secret = webhook.Secret
+	return response, nil
+}
+
+func (s *WebhookService) GetWebhook(ctx context.Context, id int64) (model.WebhookResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.GetWebhook", trace.WithAttributes(attribute.Int64("webhookId", id)))
+	defer span.End()
+
+	webhook, err := s.repository.Query.GetWebhookSubscription(ctx, id)
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("webhook %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to get webhook", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.WebhookResponse{}, err
+	}
+
+	return webhookResponse(webhook), nil
+}
+
+func (s *WebhookService) ListWebhooks(ctx context.Context) (model.WebhookListResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.ListWebhooks")
+	defer span.End()
+
+	webhooks, err := s.repository.Query.ListWebhookSubscriptions(ctx)
+	if err != nil {
+		err = translateError(err, "webhook")
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to list webhooks", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.WebhookListResponse{}, err
+	}
+
+	responses := make([]model.WebhookResponse, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		responses = append(responses, webhookResponse(webhook))
+	}
+
+	return model.WebhookListResponse{Data: responses}, nil
+}
+
+// UpdateWebhook replaces a subscription. Without a secret in the request the current one is kept. Pending
+// deliveries go to the new URL with the new secret.
+func (s *WebhookService) UpdateWebhook(ctx context.Context, id int64, request model.WebhookRequest) (model.WebhookResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.UpdateWebhook", trace.WithAttributes(
+		attribute.Int64("webhookId", id),
+		attribute.Bool("secretChanged", request.Secret != ""),
+	))
+	defer span.End()
+
+	var webhook productrepository.WebhookSubscription
+	err := s.repository.Transaction(ctx, func(tx *sql.Tx) error {
+		query := s.repository.Query.WithTx(tx)
+
+		current, err := query.GetWebhookSubscription(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		secret := request.Secret
+		if secret == "" {
+			secret = current.Secret
+		}
+		eventTypes, err := json.Marshal(request.EventTypes)
+		if err != nil {
+			return err
+		}
+
+		webhook, err = query.UpdateWebhookSubscription(ctx, productrepository.UpdateWebhookSubscriptionParams{
+			Url:        request.Url,
+			EventTypes: string(eventTypes),
+			Secret:     secret,
+			UpdatedAt:  sql.NullTime{Time: time.Now().UTC(), Valid: true},
+			ID:         id,
+		})
+		return err
+	})
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("webhook %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to update webhook", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.WebhookResponse{}, err
+	}
+
+	response := webhookResponse(webhook)
+	response.Secret = request.Secret
+	return response, nil
+}
+
+// DeleteWebhook removes a subscription together with its delivery log, including deliveries still pending.
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id int64) error {
+	ctx, span := s.trace.Start(ctx, "Service.DeleteWebhook", trace.WithAttributes(attribute.Int64("webhookId", id)))
+	defer span.End()
+
+	err := s.repository.Transaction(ctx, func(tx *sql.Tx) error {
+		query := s.repository.Query.WithTx(tx)
+
+		if err := query.DeleteWebhookDeliveries(ctx, id); err != nil {
+			return err
+		}
+
+		affected, err := query.DeleteWebhookSubscription(ctx, id)
+		if err == nil && affected == 0 {
+			err = sql.ErrNoRows
+		}
+		return err
+	})
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("webhook %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to delete webhook", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return err
+	}
+
+	s.refreshMetrics(ctx)
+
+	return nil
+}
+
+func (s *WebhookService) ListWebhookDeliveries(ctx context.Context, id int64, request model.WebhookDeliveryListRequest) (model.WebhookDeliveryListResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.ListWebhookDeliveries", trace.WithAttributes(
+		attribute.Int64("webhookId", id),
+		attribute.String("status", request.Status),
+		attribute.Int64("limit", request.Limit),
+		attribute.Bool("hasCursor", request.After != nil),
+	))
+	defer span.End()
+
+	params := productrepository.ListWebhookDeliveriesParams{
+		SubscriptionID: id,
+		Status:         sql.NullString{String: request.Status, Valid: request.Status != ""},
+		PageSize:       request.Limit + 1,
+	}
+	if request.After != nil {
+		params.BeforeID = sql.NullInt64{Int64: request.After.Id, Valid: true}
+	}
+
+	_, err := s.repository.Query.GetWebhookSubscription(ctx, id)
+	var data []productrepository.WebhookDelivery
+	if err == nil {
+		data, err = s.repository.Query.ListWebhookDeliveries(ctx, params)
+	}
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("webhook %d", id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to list webhook deliveries", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.WebhookDeliveryListResponse{}, err
+	}
+
+	paging := model.Paging{Limit: request.Limit}
+	if int64(len(data)) > request.Limit {
+		data = data[:request.Limit]
+
+		last := data[len(data)-1]
+		cursor, err := utility.EncodeCursor(utility.Cursor{SortBy: "id", SortOrder: "desc", Value: last.ID, Id: last.ID})
+		if err != nil {
+			err = translateError(err, "webhook delivery")
+			utility.RecordSpanError(span, err)
+			zap.L().Error("failed to encode cursor", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+			return model.WebhookDeliveryListResponse{}, err
+		}
+
+		paging.NextCursor = cursor
+		paging.HasMore = true
+	}
+
+	deliveries := make([]model.WebhookDeliveryResponse, 0, len(data))
+	for _, delivery := range data {
+		deliveries = append(deliveries, webhookDeliveryResponse(delivery))
+	}
+
+	return model.WebhookDeliveryListResponse{Data: deliveries, Paging: paging}, nil
+}
+
+// RetryWebhookDelivery moves a dead delivery back to pending with a fresh set of attempts.
+func (s *WebhookService) RetryWebhookDelivery(ctx context.Context, id, deliveryId int64) (model.WebhookDeliveryResponse, error) {
+	ctx, span := s.trace.Start(ctx, "Service.RetryWebhookDelivery", trace.WithAttributes(
+		attribute.Int64("webhookId", id),
+		attribute.Int64("deliveryId", deliveryId),
+	))
+	defer span.End()
+
+	delivery, err := s.repository.Query.RetryWebhookDelivery(ctx, productrepository.RetryWebhookDeliveryParams{
+		NextAttemptAt:  time.Now().UTC(),
+		ID:             deliveryId,
+		SubscriptionID: id,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// Note : No row was retried, either the delivery does not exist or it is not dead.
+		if current, getErr := s.repository.Query.GetWebhookDelivery(ctx, productrepository.GetWebhookDeliveryParams{ID: deliveryId, SubscriptionID: id}); getErr == nil {
+			err = apperror.New(apperror.Conflict, fmt.Sprintf("delivery %d of webhook %d is %s, only dead deliveries can be retried", deliveryId, id, current.Status))
+		}
+	}
+	if err != nil {
+		err = translateError(err, fmt.Sprintf("delivery %d of webhook %d", deliveryId, id))
+		utility.RecordSpanError(span, err)
+		zap.L().Error("failed to retry webhook delivery", zap.Error(err), zap.String("requestId", ctx.Value("requestId").(string)))
+		return model.WebhookDeliveryResponse{}, err
+	}
+
+	s.wake()
+	s.refreshMetrics(ctx)
+
+	return webhookDeliveryResponse(delivery), nil
+}
+
+func (s *WebhookService) Name() string {
+	return "webhook"
+}
+
+// Publish queues a delivery of event for every subscription that wants its type. An event that is relayed again
+// is not queued twice for the same subscription.
+func (s *WebhookService) Publish(ctx context.Context, event model.DomainEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	queued, err := s.repository.Query.CreateWebhookDeliveries(ctx, productrepository.CreateWebhookDeliveriesParams{
+		EventID:     event.Id,
+		EventType:   event.Type,
+		Payload:     string(body),
+		TraceParent: sql.NullString{String: event.TraceParent, Valid: event.TraceParent != ""},
+		TraceState:  sql.NullString{String: event.TraceState, Valid: event.TraceState != ""},
+		CreatedAt:   time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	if queued > 0 {
+		s.wake()
+	}
+	return nil
+}
+
+// DeliverWebhooks posts every pending delivery that is due and returns how many were delivered.
+func (s *WebhookService) DeliverWebhooks(ctx context.Context) (int64, error) {
+	ctx, span := s.trace.Start(ctx, "Service.DeliverWebhooks")
+	defer span.End()
+
+	now := time.Now().UTC()
+
+	var delivered, failed int64
+	for {
+		// Note : Finished deliveries and rescheduled ones, which are due after now, drop out of the next page.
+		deliveries, err := s.repository.Query.ListDueWebhookDeliveries(ctx, productrepository.ListDueWebhookDeliveriesParams{
+			Now:      now,
+			PageSize: webhookDeliveryBatchSize,
+		})
+		if err != nil {
+			err = translateError(err, "webhook delivery")
+			utility.RecordSpanError(span, err)
+			zap.L().Error("failed to list due webhook deliveries", zap.Error(err))
+			return delivered, err
+		}
+
+		for _, delivery := range deliveries {
+			ok, err := s.deliver(ctx, delivery)
+			if err != nil {
+				err = translateError(err, "webhook delivery")
+				utility.RecordSpanError(span, err)
+				zap.L().Error("failed to record webhook delivery", zap.Error(err), zap.Int64("deliveryId", delivery.ID))
+				return delivered, err
+			}
+			if ok {
+				delivered++
+			} else {
+				failed++
+			}
+		}
+
+		if len(deliveries) < webhookDeliveryBatchSize {
+			break
+		}
+	}
+
+	span.SetAttributes(
+		attribute.Int64("deliveredCount", delivered),
+		attribute.Int64("failedCount", failed),
+	)
+	s.refreshMetrics(ctx)
+
+	return delivered, nil
+}
+
+// Run delivers webhooks as soon as Publish queued a delivery, and every interval for the retries, until ctx is cancelled.
+func (s *WebhookService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.queued:
+		case <-ticker.C:
+		}
+
+		s.DeliverWebhooks(ctx)
+	}
+}
+
+// RefreshMetrics sets the delivery gauges from the delivery log, e.g. on startup before the first run.
+func (s *WebhookService) RefreshMetrics(ctx context.Context) error {
+	return refreshWebhookDeliveries(ctx, s.repository.Query)
+}
+
+// deliver posts a delivery and records the outcome. It reports whether the partner accepted it, the error is only
+// about recording the outcome.
+func (s *WebhookService) deliver(ctx context.Context, delivery productrepository.ListDueWebhookDeliveriesRow) (bool, error) {
+	// Note : Unlike the outbox dispatch, a delivery continues the trace of the request that changed the product,
+	// so the partner's spans show up under that request. The run that picked the delivery up is linked instead.
+	batch := trace.LinkFromContext(ctx)
+	parent := propagation.TraceContext{}.Extract(ctx, propagation.MapCarrier{
+		"traceparent": delivery.TraceParent.String,
+		"tracestate":  delivery.TraceState.String,
+	})
+	ctx, span := s.trace.Start(parent, "Service.DeliverWebhook", trace.WithLinks(batch), trace.WithAttributes(
+		attribute.Int64("webhookId", delivery.SubscriptionID),
+		attribute.Int64("deliveryId", delivery.ID),
+		attribute.Int64("eventId", delivery.EventID),
+		attribute.String("eventType", delivery.EventType),
+		attribute.Int64("attempt", delivery.Attempts+1),
+	))
+	defer span.End()
+
+	start := time.Now()
+	status, err := s.post(ctx, delivery, start)
+	WebhookDeliveryDuration.Observe(time.Since(start).Seconds())
+
+	now := time.Now().UTC()
+	params := productrepository.FinishWebhookDeliveryParams{
+		Status:         model.WebhookDeliveryStatusDelivered,
+		NextAttemptAt:  now,
+		ResponseStatus: sql.NullInt64{Int64: int64(status), Valid: status != 0},
+		DeliveredAt:    sql.NullTime{Time: now, Valid: true},
+		ID:             delivery.ID,
+	}
+	if err != nil {
+		utility.RecordSpanError(span, err)
+
+		message := err.Error()
+		if len(message) > webhookMaxErrorLength {
+			message = message[:webhookMaxErrorLength]
+		}
+		params.LastError = sql.NullString{String: message, Valid: true}
+		params.DeliveredAt = sql.NullTime{}
+
+		if delivery.Attempts+1 >= webhookMaxAttempts {
+			params.Status = model.WebhookDeliveryStatusDead
+			zap.L().Error("webhook delivery is dead", zap.Error(err), zap.Int64("webhookId", delivery.SubscriptionID), zap.Int64("deliveryId", delivery.ID), zap.Int64("attempts", delivery.Attempts+1))
+		} else {
+			params.Status = model.WebhookDeliveryStatusPending
+			params.NextAttemptAt = now.Add(retryBackoff(delivery.Attempts, webhookRetryDelay, webhookMaxRetryDelay))
+			zap.L().Warn("failed to deliver webhook", zap.Error(err), zap.Int64("webhookId", delivery.SubscriptionID), zap.Int64("deliveryId", delivery.ID), zap.Time("nextAttemptAt", params.NextAttemptAt))
+		}
+	}
+	span.SetAttributes(attribute.String("status", params.Status))
+
+	if err := s.repository.Query.FinishWebhookDelivery(ctx, params); err != nil {
+		return false, err
+	}
+
+	outcome := params.Status
+	if outcome == model.WebhookDeliveryStatusPending {
+		outcome = "failed"
+	}
+	WebhookDeliveries.WithLabelValues(outcome).Inc()
+
+	return err == nil, nil
+}
+
+// post sends a delivery signed with the subscription's secret and returns the response status, 0 without one.
+// The signature is the hex HMAC-SHA256 of "<timestamp>.<body>", so a receiver can also reject replayed requests.
+func (s *WebhookService) post(ctx context.Context, delivery productrepository.ListDueWebhookDeliveriesRow, now time.Time) (int, error) {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader([]byte(delivery.Payload)))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookIdHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhook(delivery.Secret, timestamp, []byte(delivery.Payload)))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	// Note : The body is drained so the connection can be reused, a partner's answer is never read otherwise.
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode, fmt.Errorf("webhook answered %s", res.Status)
+	}
+	return res.StatusCode, nil
+}
+
+func (s *WebhookService) wake() {
+	select {
+	case s.queued <- struct{}{}:
+	default:
+	}
+}
+
+func (s *WebhookService) refreshMetrics(ctx context.Context) {
+	if err := refreshWebhookDeliveries(ctx, s.repository.Query); err != nil {
+		zap.L().Warn("failed to refresh webhook metrics", zap.Error(err))
+	}
+}
+
+// refreshWebhookDeliveries sets the delivery gauges from the delivery log.
+func refreshWebhookDeliveries(ctx context.Context, query *productrepository.Queries) error {
+	counts, err := query.CountWebhookDeliveriesByStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Note : Statuses without deliveries are not returned, so every gauge is reset first.
+	for _, status := range []string{model.WebhookDeliveryStatusPending, model.WebhookDeliveryStatusDelivered, model.WebhookDeliveryStatusDead} {
+		WebhookDeliveriesByStatus.WithLabelValues(status).Set(0)
+	}
+	for _, count := range counts {
+		WebhookDeliveriesByStatus.WithLabelValues(count.Status).Set(float64(count.Deliveries))
+	}
+	return nil
+}
+
+// SignWebhook returns the hex HMAC-SHA256 signature of a webhook body sent at timestamp.
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+func webhookResponse(webhook productrepository.WebhookSubscription) model.WebhookResponse {
+	response := model.WebhookResponse{
+		Id:        webhook.ID,
+		Url:       webhook.Url,
+		CreatedAt: webhook.CreatedAt,
+	}
+	// Note : Event types are only ever written from a validated request, so they always decode.
+	_ = json.Unmarshal([]byte(webhook.EventTypes), &response.EventTypes)
+	if webhook.UpdatedAt.Valid {
+		response.UpdatedAt = &webhook.UpdatedAt.Time
+	}
+	return response
+}
+
+func webhookDeliveryResponse(delivery productrepository.WebhookDelivery) model.WebhookDeliveryResponse {
+	response := model.WebhookDeliveryResponse{
+		Id:             delivery.ID,
+		WebhookId:      delivery.SubscriptionID,
+		EventId:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		ResponseStatus: nullInt64Pointer(delivery.ResponseStatus),
+		LastError:      delivery.LastError.String,
+		CreatedAt:      delivery.CreatedAt,
+	}
+	if delivery.Status == model.WebhookDeliveryStatusPending {
+		response.NextAttemptAt = &delivery.NextAttemptAt
+	}
+	if delivery.DeliveredAt.Valid {
+		response.DeliveredAt = &delivery.DeliveredAt.Time
+	}
+	return response
+}
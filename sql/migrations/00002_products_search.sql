@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE VIRTUAL TABLE products_search USING fts5(
+    name,
+    content = 'products',
+    content_rowid = 'id',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER products_search_after_insert AFTER INSERT ON products BEGIN
+    INSERT INTO products_search (rowid, name) VALUES (new.id, new.name);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER products_search_after_delete AFTER DELETE ON products BEGIN
+    INSERT INTO products_search (products_search, rowid, name) VALUES ('delete', old.id, old.name);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER products_search_after_update AFTER UPDATE ON products BEGIN
+    INSERT INTO products_search (products_search, rowid, name) VALUES ('delete', old.id, old.name);
+    INSERT INTO products_search (rowid, name) VALUES (new.id, new.name);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+INSERT INTO products_search (products_search) VALUES ('rebuild');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER products_search_after_update;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TRIGGER products_search_after_delete;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TRIGGER products_search_after_insert;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE products_search;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE products ADD COLUMN deleted_at DATETIME;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_products_deleted_at ON products (deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_products_deleted_at;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE products DROP COLUMN deleted_at;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE stock_movements (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    product_id INTEGER NOT NULL REFERENCES products (id),
+    delta INTEGER NOT NULL,
+    quantity_after INTEGER NOT NULL,
+    reason TEXT NOT NULL,
+    reference TEXT,
+    actor TEXT,
+    request_id TEXT,
+    created_at DATETIME NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_stock_movements_product_id ON stock_movements (product_id, id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+INSERT INTO stock_movements (product_id, delta, quantity_after, reason, created_at)
+SELECT id, quantity, quantity, 'initial', created_at FROM products;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE stock_movements;
+-- +goose StatementEnd
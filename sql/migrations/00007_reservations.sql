@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE reservations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    status TEXT NOT NULL,
+    reference TEXT,
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_reservations_status_expires_at ON reservations (status, expires_at);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE reservation_items (
+    reservation_id INTEGER NOT NULL REFERENCES reservations (id),
+    product_id INTEGER NOT NULL REFERENCES products (id),
+    quantity INTEGER NOT NULL,
+    PRIMARY KEY (reservation_id, product_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE reservation_items;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE reservations;
+-- +goose StatementEnd
@@ -0,0 +1,64 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE price_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    product_id INTEGER NOT NULL REFERENCES products (id),
+    old_price REAL,
+    new_price REAL NOT NULL,
+    changed_at DATETIME NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_price_history_product_id ON price_history (product_id, id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+INSERT INTO price_history (product_id, old_price, new_price, changed_at)
+SELECT id, NULL, price, created_at FROM products;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER price_history_after_insert AFTER INSERT ON products BEGIN
+    INSERT INTO price_history (product_id, old_price, new_price, changed_at) VALUES (new.id, NULL, new.price, new.created_at);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER price_history_after_update AFTER UPDATE OF price ON products WHEN old.price IS NOT new.price BEGIN
+    INSERT INTO price_history (product_id, old_price, new_price, changed_at) VALUES (new.id, old.price, new.price, COALESCE(new.updated_at, new.created_at));
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE scheduled_prices (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    product_id INTEGER NOT NULL REFERENCES products (id),
+    price REAL NOT NULL,
+    effective_at DATETIME NOT NULL,
+    status TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    applied_at DATETIME
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_scheduled_prices_status_effective_at ON scheduled_prices (status, effective_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE scheduled_prices;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TRIGGER price_history_after_update;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TRIGGER price_history_after_insert;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE price_history;
+-- +goose StatementEnd
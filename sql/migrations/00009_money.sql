@@ -0,0 +1,200 @@
+-- +goose Up
+-- Note : Existing prices are all taken to be USD. A REAL price that is not a whole number of cents is rounded to the
+-- nearest cent instead of failing the migration, and recorded in money_migration_roundings so it can be reviewed.
+-- +goose StatementBegin
+CREATE TABLE money_migration_roundings (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    table_name TEXT NOT NULL,
+    row_id INTEGER NOT NULL,
+    column_name TEXT NOT NULL,
+    original_price REAL NOT NULL,
+    rounded_price_minor INTEGER NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+INSERT INTO money_migration_roundings (table_name, row_id, column_name, original_price, rounded_price_minor)
+SELECT 'products', id, 'price', price, CAST(ROUND(price * 100) AS INTEGER) FROM products
+WHERE ABS(price * 100 - ROUND(price * 100)) >= 0.000001
+UNION ALL
+SELECT 'price_history', id, 'new_price', new_price, CAST(ROUND(new_price * 100) AS INTEGER) FROM price_history
+WHERE ABS(new_price * 100 - ROUND(new_price * 100)) >= 0.000001
+UNION ALL
+SELECT 'price_history', id, 'old_price', old_price, CAST(ROUND(old_price * 100) AS INTEGER) FROM price_history
+WHERE old_price IS NOT NULL AND ABS(old_price * 100 - ROUND(old_price * 100)) >= 0.000001
+UNION ALL
+SELECT 'scheduled_prices', id, 'price', price, CAST(ROUND(price * 100) AS INTEGER) FROM scheduled_prices
+WHERE ABS(price * 100 - ROUND(price * 100)) >= 0.000001;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TRIGGER price_history_after_update;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TRIGGER price_history_after_insert;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE products ADD COLUMN price_minor INTEGER NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE products ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE products SET price_minor = CAST(ROUND(price * 100) AS INTEGER);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE products DROP COLUMN price;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE price_history ADD COLUMN old_price_minor INTEGER;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE price_history ADD COLUMN old_currency TEXT;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE price_history ADD COLUMN new_price_minor INTEGER NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE price_history ADD COLUMN new_currency TEXT NOT NULL DEFAULT 'USD';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE price_history
+SET old_price_minor = CAST(ROUND(old_price * 100) AS INTEGER),
+    old_currency = CASE WHEN old_price IS NULL THEN NULL ELSE 'USD' END,
+    new_price_minor = CAST(ROUND(new_price * 100) AS INTEGER);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE price_history DROP COLUMN old_price;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE price_history DROP COLUMN new_price;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE scheduled_prices ADD COLUMN price_minor INTEGER NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE scheduled_prices ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE scheduled_prices SET price_minor = CAST(ROUND(price * 100) AS INTEGER);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE scheduled_prices DROP COLUMN price;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER price_history_after_insert AFTER INSERT ON products BEGIN
+    INSERT INTO price_history (product_id, old_price_minor, old_currency, new_price_minor, new_currency, changed_at)
+    VALUES (new.id, NULL, NULL, new.price_minor, new.currency, new.created_at);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER price_history_after_update AFTER UPDATE OF price_minor, currency ON products
+WHEN old.price_minor IS NOT new.price_minor OR old.currency IS NOT new.currency BEGIN
+    INSERT INTO price_history (product_id, old_price_minor, old_currency, new_price_minor, new_currency, changed_at)
+    VALUES (new.id, old.price_minor, old.currency, new.price_minor, new.currency, COALESCE(new.updated_at, new.created_at));
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- Note : Going back to REAL prices assumes two decimal places, amounts in other currencies are not converted correctly.
+-- +goose StatementBegin
+DROP TRIGGER price_history_after_update;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TRIGGER price_history_after_insert;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE scheduled_prices ADD COLUMN price REAL NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE scheduled_prices SET price = price_minor / 100.0;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE scheduled_prices DROP COLUMN currency;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE scheduled_prices DROP COLUMN price_minor;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE price_history ADD COLUMN old_price REAL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE price_history ADD COLUMN new_price REAL NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE price_history SET old_price = old_price_minor / 100.0, new_price = new_price_minor / 100.0;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE price_history DROP COLUMN new_currency;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE price_history DROP COLUMN new_price_minor;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE price_history DROP COLUMN old_currency;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE price_history DROP COLUMN old_price_minor;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE products ADD COLUMN price REAL NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE products SET price = price_minor / 100.0;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE products DROP COLUMN currency;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE products DROP COLUMN price_minor;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER price_history_after_insert AFTER INSERT ON products BEGIN
+    INSERT INTO price_history (product_id, old_price, new_price, changed_at) VALUES (new.id, NULL, new.price, new.created_at);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER price_history_after_update AFTER UPDATE OF price ON products WHEN old.price IS NOT new.price BEGIN
+    INSERT INTO price_history (product_id, old_price, new_price, changed_at) VALUES (new.id, old.price, new.price, COALESCE(new.updated_at, new.created_at));
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE money_migration_roundings;
+-- +goose StatementEnd
@@ -0,0 +1,66 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE categories (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    parent_id INTEGER REFERENCES categories (id),
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX idx_categories_parent_name ON categories (COALESCE(parent_id, 0), name COLLATE NOCASE);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_categories_parent_id ON categories (parent_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE product_categories (
+    product_id INTEGER NOT NULL REFERENCES products (id),
+    category_id INTEGER NOT NULL REFERENCES categories (id),
+    PRIMARY KEY (product_id, category_id)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_product_categories_category_id ON product_categories (category_id, product_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE tags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE product_tags (
+    product_id INTEGER NOT NULL REFERENCES products (id),
+    tag_id INTEGER NOT NULL REFERENCES tags (id),
+    PRIMARY KEY (product_id, tag_id)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_product_tags_tag_id ON product_tags (tag_id, product_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE product_tags;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE tags;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE product_categories;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE categories;
+-- +goose StatementEnd
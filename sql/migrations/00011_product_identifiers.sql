@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE products ADD COLUMN sku TEXT;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE products ADD COLUMN barcode TEXT;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX idx_products_sku ON products (sku COLLATE NOCASE);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX idx_products_barcode ON products (barcode);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_products_barcode;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP INDEX idx_products_sku;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE products DROP COLUMN barcode;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE products DROP COLUMN sku;
+-- +goose StatementEnd
@@ -0,0 +1,89 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE product_variants (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    product_id INTEGER NOT NULL REFERENCES products (id),
+    sku TEXT NOT NULL,
+    options TEXT NOT NULL,
+    price_minor INTEGER,
+    currency TEXT,
+    quantity INTEGER NOT NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX idx_product_variants_sku ON product_variants (sku COLLATE NOCASE);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX idx_product_variants_options ON product_variants (product_id, options);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE stock_movements ADD COLUMN variant_id INTEGER REFERENCES product_variants (id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Note : A reservation may hold several variants of one product, so items are no longer keyed by product alone.
+CREATE TABLE reservation_items_new (
+    reservation_id INTEGER NOT NULL REFERENCES reservations (id),
+    product_id INTEGER NOT NULL REFERENCES products (id),
+    variant_id INTEGER REFERENCES product_variants (id),
+    quantity INTEGER NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+INSERT INTO reservation_items_new (reservation_id, product_id, quantity)
+SELECT reservation_id, product_id, quantity FROM reservation_items;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE reservation_items;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE reservation_items_new RENAME TO reservation_items;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX idx_reservation_items_item ON reservation_items (reservation_id, product_id, COALESCE(variant_id, 0));
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_reservation_items_product_id ON reservation_items (product_id, variant_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE TABLE reservation_items_old (
+    reservation_id INTEGER NOT NULL REFERENCES reservations (id),
+    product_id INTEGER NOT NULL REFERENCES products (id),
+    quantity INTEGER NOT NULL,
+    PRIMARY KEY (reservation_id, product_id)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+INSERT INTO reservation_items_old (reservation_id, product_id, quantity)
+SELECT reservation_id, product_id, SUM(quantity) FROM reservation_items
+GROUP BY reservation_id, product_id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE reservation_items;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE reservation_items_old RENAME TO reservation_items;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE stock_movements DROP COLUMN variant_id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE product_variants;
+-- +goose StatementEnd
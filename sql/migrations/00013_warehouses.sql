@@ -0,0 +1,89 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE warehouses (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    code TEXT NOT NULL,
+    name TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX idx_warehouses_code ON warehouses (code COLLATE NOCASE);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Note : Stock that is in no warehouse is unallocated: the product or variant quantity minus what the warehouses hold.
+CREATE TABLE warehouse_stock (
+    warehouse_id INTEGER NOT NULL REFERENCES warehouses (id),
+    product_id INTEGER NOT NULL REFERENCES products (id),
+    variant_id INTEGER REFERENCES product_variants (id),
+    quantity INTEGER NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX idx_warehouse_stock_item ON warehouse_stock (warehouse_id, product_id, COALESCE(variant_id, 0));
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_warehouse_stock_product_id ON warehouse_stock (product_id, variant_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE stock_transfers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    from_warehouse_id INTEGER REFERENCES warehouses (id),
+    to_warehouse_id INTEGER REFERENCES warehouses (id),
+    reference TEXT,
+    actor TEXT,
+    created_at DATETIME NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE stock_transfer_items (
+    transfer_id INTEGER NOT NULL REFERENCES stock_transfers (id),
+    product_id INTEGER NOT NULL REFERENCES products (id),
+    variant_id INTEGER REFERENCES product_variants (id),
+    quantity INTEGER NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_stock_transfer_items_transfer_id ON stock_transfer_items (transfer_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE stock_movements ADD COLUMN warehouse_id INTEGER REFERENCES warehouses (id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE reservation_items ADD COLUMN warehouse_id INTEGER REFERENCES warehouses (id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE reservation_items DROP COLUMN warehouse_id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE stock_movements DROP COLUMN warehouse_id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE stock_transfer_items;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE stock_transfers;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE warehouse_stock;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE warehouses;
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE products ADD COLUMN reorder_point INTEGER;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE low_stock_alerts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    product_id INTEGER NOT NULL REFERENCES products (id),
+    quantity INTEGER NOT NULL,
+    reorder_point INTEGER NOT NULL,
+    triggered_at DATETIME NOT NULL,
+    resolved_at DATETIME
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Note : A product has at most one open alert, so a crossing is only ever recorded once.
+CREATE UNIQUE INDEX idx_low_stock_alerts_open ON low_stock_alerts (product_id) WHERE resolved_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_low_stock_alerts_open;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE low_stock_alerts;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE products DROP COLUMN reorder_point;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE outbox_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    aggregate_type TEXT NOT NULL,
+    aggregate_id INTEGER NOT NULL,
+    event_type TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    request_id TEXT,
+    trace_parent TEXT,
+    trace_state TEXT,
+    created_at DATETIME NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME NOT NULL,
+    last_error TEXT,
+    dispatched_at DATETIME
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Note : The relay only ever reads events that are still waiting, so the index leaves dispatched ones out.
+CREATE INDEX idx_outbox_events_pending ON outbox_events (next_attempt_at, id) WHERE dispatched_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_outbox_events_pending;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE outbox_events;
+-- +goose StatementEnd
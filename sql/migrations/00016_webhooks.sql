@@ -0,0 +1,57 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Note : event_types is a JSON array of the event types the subscription receives.
+CREATE TABLE webhook_subscriptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    url TEXT NOT NULL,
+    event_types TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE webhook_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    subscription_id INTEGER NOT NULL REFERENCES webhook_subscriptions (id),
+    event_id INTEGER NOT NULL,
+    event_type TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    trace_parent TEXT,
+    trace_state TEXT,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME NOT NULL,
+    response_status INTEGER,
+    last_error TEXT,
+    created_at DATETIME NOT NULL,
+    delivered_at DATETIME
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Note : An outbox event that is relayed again must not be delivered to a subscription twice.
+CREATE UNIQUE INDEX idx_webhook_deliveries_event ON webhook_deliveries (subscription_id, event_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_webhook_deliveries_pending ON webhook_deliveries (next_attempt_at, id) WHERE status = 'pending';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_webhook_deliveries_pending;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP INDEX idx_webhook_deliveries_event;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE webhook_deliveries;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE webhook_subscriptions;
+-- +goose StatementEnd
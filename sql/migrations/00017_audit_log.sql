@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Note : before and after hold only the fields the change touched, as JSON objects. before is NULL for a created
+-- entity.
+CREATE TABLE audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    entity_type TEXT NOT NULL,
+    entity_id INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    actor TEXT,
+    before TEXT,
+    after TEXT,
+    request_id TEXT,
+    trace_id TEXT,
+    client_ip TEXT,
+    created_at DATETIME NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_audit_log_entity ON audit_log (entity_type, entity_id, id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_audit_log_created_at ON audit_log (entity_type, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_audit_log_created_at;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP INDEX idx_audit_log_entity;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE audit_log;
+-- +goose StatementEnd
@@ -0,0 +1,67 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE orders (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    status TEXT NOT NULL,
+    reference TEXT,
+    total_minor INTEGER NOT NULL,
+    currency TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_orders_status ON orders (status, id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Note : The unit price is the price of the product, or of its variant, when the order was placed.
+CREATE TABLE order_lines (
+    order_id INTEGER NOT NULL REFERENCES orders (id),
+    line INTEGER NOT NULL,
+    product_id INTEGER NOT NULL REFERENCES products (id),
+    variant_id INTEGER REFERENCES product_variants (id),
+    warehouse_id INTEGER REFERENCES warehouses (id),
+    quantity INTEGER NOT NULL,
+    unit_price_minor INTEGER NOT NULL,
+    PRIMARY KEY (order_id, line)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- from_status is NULL for the placement of the order.
+CREATE TABLE order_transitions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    order_id INTEGER NOT NULL REFERENCES orders (id),
+    from_status TEXT,
+    to_status TEXT NOT NULL,
+    request_id TEXT,
+    created_at DATETIME NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_order_transitions_order ON order_transitions (order_id, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_order_transitions_order;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE order_transitions;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE order_lines;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP INDEX idx_orders_status;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE orders;
+-- +goose StatementEnd
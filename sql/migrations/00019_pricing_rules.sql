@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Note : A rule applies to one product, or to every product of one category and of its descendants. currency is
+-- only set for fixed discounts, which only apply to prices in that currency.
+CREATE TABLE pricing_rules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    type TEXT NOT NULL,
+    product_id INTEGER REFERENCES products (id),
+    category_id INTEGER REFERENCES categories (id),
+    currency TEXT,
+    starts_at DATETIME,
+    ends_at DATETIME,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_pricing_rules_product_id ON pricing_rules (product_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_pricing_rules_category_id ON pricing_rules (category_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Note : value is in basis points for percentage discounts and in minor units of the rule currency for fixed ones.
+CREATE TABLE pricing_rule_tiers (
+    rule_id INTEGER NOT NULL REFERENCES pricing_rules (id),
+    min_quantity INTEGER NOT NULL,
+    value INTEGER NOT NULL,
+    PRIMARY KEY (rule_id, min_quantity)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE pricing_rule_tiers;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE pricing_rules;
+-- +goose StatementEnd
@@ -0,0 +1,68 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Note : Variants and warehouses are soft deleted so that the ledger, transfers, reservations and orders that
+-- reference them keep pointing at a row now that foreign keys are enforced.
+ALTER TABLE product_variants ADD COLUMN deleted_at DATETIME;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE warehouses ADD COLUMN deleted_at DATETIME;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Note : A deleted variant or warehouse gives up its sku, options or code for new ones.
+DROP INDEX idx_product_variants_sku;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX idx_product_variants_sku ON product_variants (sku COLLATE NOCASE) WHERE deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP INDEX idx_product_variants_options;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX idx_product_variants_options ON product_variants (product_id, options) WHERE deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP INDEX idx_warehouses_code;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX idx_warehouses_code ON warehouses (code COLLATE NOCASE) WHERE deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_warehouses_code;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX idx_warehouses_code ON warehouses (code COLLATE NOCASE);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP INDEX idx_product_variants_options;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX idx_product_variants_options ON product_variants (product_id, options);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP INDEX idx_product_variants_sku;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX idx_product_variants_sku ON product_variants (sku COLLATE NOCASE);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE warehouses DROP COLUMN deleted_at;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE product_variants DROP COLUMN deleted_at;
+-- +goose StatementEnd
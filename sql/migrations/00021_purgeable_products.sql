@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Note : Orders, reservations and transfers are kept whole, so a deleted product they reference is never purged and
+-- stays soft deleted. Every purge query reads its products from here.
+CREATE VIEW purgeable_products AS
+SELECT id, deleted_at FROM products
+WHERE deleted_at IS NOT NULL
+    AND id NOT IN (SELECT product_id FROM order_lines)
+    AND id NOT IN (SELECT product_id FROM reservation_items)
+    AND id NOT IN (SELECT product_id FROM stock_transfer_items);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP VIEW purgeable_products;
+-- +goose StatementEnd
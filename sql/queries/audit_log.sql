@@ -0,0 +1,16 @@
+-- name: CreateAuditLogEntry :exec
+INSERT INTO audit_log (
+  entity_type, entity_id, action, actor, before, after, request_id, trace_id, client_ip, created_at
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+);
+
+-- name: ListAuditLogEntries :many
+SELECT * FROM audit_log
+WHERE entity_type = sqlc.arg(entity_type)
+  AND (CAST(sqlc.narg(entity_id) AS INTEGER) IS NULL OR entity_id = sqlc.narg(entity_id))
+  AND created_at >= sqlc.arg(created_from)
+  AND created_at < sqlc.arg(created_to)
+  AND (CAST(sqlc.narg(before_id) AS INTEGER) IS NULL OR id < sqlc.narg(before_id))
+ORDER BY id DESC
+LIMIT sqlc.arg(page_size);
@@ -0,0 +1,114 @@
+-- name: CreateCategory :one
+INSERT INTO categories (
+  name, parent_id, created_at
+) VALUES (
+  ?, ?, ?
+) RETURNING *;
+
+-- name: GetCategory :one
+SELECT * FROM categories
+WHERE id = ? LIMIT 1;
+
+-- name: ListCategories :many
+SELECT * FROM categories
+ORDER BY COALESCE(parent_id, 0), name COLLATE NOCASE, id;
+
+-- name: UpdateCategory :one
+UPDATE categories
+set name = sqlc.arg(name),
+parent_id = sqlc.narg(parent_id),
+updated_at = sqlc.arg(updated_at)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: DeleteCategory :execrows
+DELETE FROM categories
+WHERE id = ?;
+
+-- name: CountChildCategories :one
+SELECT COUNT(*) FROM categories
+WHERE parent_id = ?;
+
+-- name: CountCategoryDescendant :one
+WITH RECURSIVE descendants AS (
+  SELECT CAST(sqlc.arg(ancestor_id) AS INTEGER) AS id
+  UNION
+  SELECT categories.id FROM categories
+  JOIN descendants ON categories.parent_id = descendants.id
+)
+SELECT COUNT(*) FROM descendants
+WHERE id = sqlc.arg(category_id);
+
+-- name: ListCategoryProducts :many
+WITH RECURSIVE descendants AS (
+  SELECT CAST(sqlc.arg(category_id) AS INTEGER) AS id
+  UNION
+  SELECT categories.id FROM categories
+  JOIN descendants ON categories.parent_id = descendants.id
+)
+SELECT products.* FROM products
+WHERE products.deleted_at IS NULL
+  AND products.id IN (
+    SELECT product_categories.product_id FROM product_categories
+    JOIN descendants ON product_categories.category_id = descendants.id
+  )
+  AND products.id > sqlc.arg(after_id)
+ORDER BY products.id
+LIMIT sqlc.arg(page_size);
+
+-- name: DeleteCategoryProducts :exec
+DELETE FROM product_categories
+WHERE category_id = ?;
+
+-- name: ListProductCategories :many
+SELECT categories.* FROM categories
+JOIN product_categories ON product_categories.category_id = categories.id
+WHERE product_categories.product_id = ?
+ORDER BY categories.name COLLATE NOCASE, categories.id;
+
+-- name: DeleteProductCategories :exec
+DELETE FROM product_categories
+WHERE product_id = ?;
+
+-- name: AddProductCategory :exec
+INSERT INTO product_categories (
+  product_id, category_id
+) VALUES (
+  ?, ?
+);
+
+-- name: UpsertTag :one
+INSERT INTO tags (name) VALUES (?)
+ON CONFLICT (name) DO UPDATE SET name = excluded.name
+RETURNING id;
+
+-- name: ListProductTags :many
+SELECT tags.name FROM tags
+JOIN product_tags ON product_tags.tag_id = tags.id
+WHERE product_tags.product_id = ?
+ORDER BY tags.name;
+
+-- name: DeleteProductTags :exec
+DELETE FROM product_tags
+WHERE product_id = ?;
+
+-- name: AddProductTag :exec
+INSERT INTO product_tags (
+  product_id, tag_id
+) VALUES (
+  ?, ?
+);
+
+-- name: DeletePurgeableProductCategories :exec
+DELETE FROM product_categories
+WHERE product_id IN (
+  SELECT id FROM purgeable_products
+  WHERE purgeable_products.deleted_at < sqlc.arg(deleted_before)
+);
+
+-- name: DeletePurgeableProductTags :exec
+DELETE FROM product_tags
+WHERE product_id IN (
+  SELECT id FROM purgeable_products
+  WHERE purgeable_products.deleted_at < sqlc.arg(deleted_before)
+);
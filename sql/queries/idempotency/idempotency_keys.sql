@@ -0,0 +1,33 @@
+-- name: ClaimIdempotencyKey :execrows
+INSERT INTO idempotency_keys (
+  key, request_hash, created_at, expires_at
+) VALUES (
+  sqlc.arg(key), sqlc.arg(request_hash), sqlc.arg(created_at), sqlc.arg(expires_at)
+)
+ON CONFLICT (key) DO UPDATE
+set request_hash = excluded.request_hash,
+status_code = NULL,
+response_headers = NULL,
+response_body = NULL,
+created_at = excluded.created_at,
+expires_at = excluded.expires_at
+WHERE idempotency_keys.expires_at < excluded.created_at;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE key = ? LIMIT 1;
+
+-- name: CompleteIdempotencyKey :exec
+UPDATE idempotency_keys
+set status_code = sqlc.arg(status_code),
+response_headers = sqlc.arg(response_headers),
+response_body = sqlc.arg(response_body)
+WHERE key = sqlc.arg(key);
+
+-- name: ReleaseIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE key = ? AND status_code IS NULL;
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_keys
+WHERE expires_at < sqlc.arg(now);
@@ -0,0 +1,35 @@
+-- name: OpenLowStockAlerts :many
+INSERT INTO low_stock_alerts (
+  product_id, quantity, reorder_point, triggered_at
+)
+SELECT id, quantity, reorder_point, sqlc.arg(triggered_at)
+FROM products
+WHERE deleted_at IS NULL
+  AND reorder_point IS NOT NULL
+  AND quantity < reorder_point
+ON CONFLICT DO NOTHING
+RETURNING *;
+
+-- name: ResolveLowStockAlerts :execrows
+UPDATE low_stock_alerts
+set resolved_at = sqlc.arg(resolved_at)
+WHERE resolved_at IS NULL
+  AND product_id NOT IN (
+    SELECT id FROM products
+    WHERE deleted_at IS NULL
+      AND reorder_point IS NOT NULL
+      AND quantity < reorder_point);
+
+-- name: ListOpenLowStockAlerts :many
+SELECT low_stock_alerts.id, low_stock_alerts.product_id, products.name, products.sku,
+  products.quantity, CAST(products.reorder_point AS INTEGER) AS reorder_point, low_stock_alerts.triggered_at
+FROM low_stock_alerts
+JOIN products ON products.id = low_stock_alerts.product_id
+WHERE low_stock_alerts.resolved_at IS NULL
+ORDER BY low_stock_alerts.triggered_at, low_stock_alerts.id;
+
+-- name: DeletePurgeableLowStockAlerts :exec
+DELETE FROM low_stock_alerts
+WHERE product_id IN (
+  SELECT id FROM purgeable_products
+  WHERE purgeable_products.deleted_at < sqlc.arg(deleted_before));
@@ -0,0 +1,59 @@
+-- name: CreateOrder :one
+INSERT INTO orders (
+  status, reference, total_minor, currency, created_at
+) VALUES (
+  'pending', ?, ?, ?, ?
+) RETURNING *;
+
+-- name: CreateOrderLine :exec
+INSERT INTO order_lines (
+  order_id, line, product_id, variant_id, warehouse_id, quantity, unit_price_minor
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?
+);
+
+-- name: GetOrder :one
+SELECT * FROM orders
+WHERE id = ? LIMIT 1;
+
+-- name: ListOrders :many
+SELECT * FROM orders
+WHERE (CAST(sqlc.narg(status) AS TEXT) IS NULL OR status = sqlc.narg(status))
+  AND (CAST(sqlc.narg(before_id) AS INTEGER) IS NULL OR id < sqlc.narg(before_id))
+ORDER BY id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: ListOrderLines :many
+SELECT * FROM order_lines
+WHERE order_id = ?
+ORDER BY line;
+
+-- name: TransitionOrder :execrows
+UPDATE orders
+set status = sqlc.arg(to_status),
+updated_at = sqlc.arg(updated_at)
+WHERE id = sqlc.arg(id)
+  AND status = sqlc.arg(from_status);
+
+-- name: CreateOrderTransition :exec
+INSERT INTO order_transitions (
+  order_id, from_status, to_status, request_id, created_at
+) VALUES (
+  ?, ?, ?, ?, ?
+);
+
+-- name: ListOrderTransitions :many
+SELECT * FROM order_transitions
+WHERE order_id = ?
+ORDER BY id;
+
+-- name: CountOrdersByStatus :many
+SELECT status, COUNT(*) AS count FROM orders
+GROUP BY status;
+
+-- name: CountOpenOrderLines :one
+SELECT COUNT(*) FROM order_lines
+JOIN orders ON orders.id = order_lines.order_id
+WHERE orders.status IN ('pending', 'paid')
+  AND (CAST(sqlc.narg(variant_id) AS INTEGER) IS NULL OR order_lines.variant_id = sqlc.narg(variant_id))
+  AND (CAST(sqlc.narg(warehouse_id) AS INTEGER) IS NULL OR order_lines.warehouse_id = sqlc.narg(warehouse_id));
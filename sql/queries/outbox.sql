@@ -0,0 +1,53 @@
+-- name: CreateOutboxEvent :exec
+INSERT INTO outbox_events (
+  aggregate_type, aggregate_id, event_type, payload, request_id, trace_parent, trace_state, created_at, next_attempt_at
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, sqlc.arg(created_at), sqlc.arg(created_at)
+);
+
+-- name: ListPendingOutboxEvents :many
+SELECT * FROM outbox_events
+WHERE dispatched_at IS NULL
+  AND next_attempt_at <= sqlc.arg(now)
+ORDER BY id
+LIMIT sqlc.arg(page_size);
+
+-- name: MarkOutboxEventDispatched :exec
+UPDATE outbox_events
+set dispatched_at = sqlc.arg(dispatched_at),
+  attempts = attempts + 1,
+  last_error = NULL
+WHERE id = sqlc.arg(id);
+
+-- name: MarkOutboxEventFailed :exec
+UPDATE outbox_events
+set attempts = attempts + 1,
+  next_attempt_at = sqlc.arg(next_attempt_at),
+  last_error = sqlc.arg(last_error)
+WHERE id = sqlc.arg(id);
+
+-- name: CountPendingOutboxEvents :one
+SELECT COUNT(*) FROM outbox_events
+WHERE dispatched_at IS NULL;
+
+-- name: GetOldestPendingOutboxEvent :one
+SELECT * FROM outbox_events
+WHERE dispatched_at IS NULL
+ORDER BY id
+LIMIT 1;
+
+-- name: DeleteDispatchedOutboxEvents :execrows
+DELETE FROM outbox_events
+WHERE dispatched_at IS NOT NULL
+  AND dispatched_at < sqlc.arg(dispatched_before);
+
+-- name: GetOutboxEventIdRange :one
+SELECT CAST(COALESCE(MIN(id), 0) AS INTEGER) AS oldest_id, CAST(COALESCE(MAX(id), 0) AS INTEGER) AS latest_id
+FROM outbox_events;
+
+-- name: ListOutboxEventsAfter :many
+SELECT * FROM outbox_events
+WHERE aggregate_type = sqlc.arg(aggregate_type)
+  AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(page_size);
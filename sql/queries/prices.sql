@@ -0,0 +1,62 @@
+-- name: ListPriceHistory :many
+SELECT * FROM price_history
+WHERE product_id = ?
+ORDER BY id;
+
+-- name: CreateScheduledPrice :one
+INSERT INTO scheduled_prices (
+  product_id, price_minor, currency, effective_at, status, created_at
+) VALUES (
+  ?, ?, ?, ?, 'pending', ?
+) RETURNING *;
+
+-- name: ListPendingScheduledPrices :many
+SELECT * FROM scheduled_prices
+WHERE product_id = ?
+  AND status = 'pending'
+ORDER BY effective_at, id;
+
+-- name: CancelScheduledPrice :execrows
+UPDATE scheduled_prices
+set status = 'cancelled'
+WHERE id = sqlc.arg(id)
+  AND product_id = sqlc.arg(product_id)
+  AND status = 'pending';
+
+-- name: ListDueScheduledPrices :many
+SELECT * FROM scheduled_prices
+WHERE status = 'pending'
+  AND effective_at <= sqlc.arg(now)
+ORDER BY effective_at, id
+LIMIT sqlc.arg(page_size);
+
+-- name: ApplyProductPrice :one
+UPDATE products
+set price_minor = sqlc.arg(price_minor),
+currency = sqlc.arg(currency),
+updated_at = sqlc.arg(updated_at),
+version = version + 1
+WHERE id = sqlc.arg(id)
+  AND deleted_at IS NULL
+RETURNING *;
+
+-- name: FinishScheduledPrice :execrows
+UPDATE scheduled_prices
+set status = sqlc.arg(status),
+applied_at = sqlc.arg(applied_at)
+WHERE id = sqlc.arg(id)
+  AND status = 'pending';
+
+-- name: DeletePurgeablePriceHistory :exec
+DELETE FROM price_history
+WHERE product_id IN (
+  SELECT id FROM purgeable_products
+  WHERE purgeable_products.deleted_at < sqlc.arg(deleted_before)
+);
+
+-- name: DeletePurgeableScheduledPrices :exec
+DELETE FROM scheduled_prices
+WHERE product_id IN (
+  SELECT id FROM purgeable_products
+  WHERE purgeable_products.deleted_at < sqlc.arg(deleted_before)
+);
@@ -0,0 +1,94 @@
+-- name: CreatePricingRule :one
+INSERT INTO pricing_rules (
+  name, type, product_id, category_id, currency, starts_at, ends_at, created_at
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?
+) RETURNING *;
+
+-- name: GetPricingRule :one
+SELECT * FROM pricing_rules
+WHERE id = ? LIMIT 1;
+
+-- name: ListPricingRules :many
+SELECT * FROM pricing_rules
+ORDER BY id;
+
+-- name: UpdatePricingRule :one
+UPDATE pricing_rules
+set name = ?,
+type = ?,
+product_id = ?,
+category_id = ?,
+currency = ?,
+starts_at = ?,
+ends_at = ?,
+updated_at = ?
+WHERE id = ?
+RETURNING *;
+
+-- name: DeletePricingRule :execrows
+DELETE FROM pricing_rules
+WHERE id = ?;
+
+-- name: CreatePricingRuleTier :exec
+INSERT INTO pricing_rule_tiers (
+  rule_id, min_quantity, value
+) VALUES (
+  ?, ?, ?
+);
+
+-- name: ListPricingRuleTiers :many
+SELECT * FROM pricing_rule_tiers
+WHERE rule_id = ?
+ORDER BY min_quantity;
+
+-- name: ListAllPricingRuleTiers :many
+SELECT * FROM pricing_rule_tiers
+ORDER BY rule_id, min_quantity;
+
+-- name: DeletePricingRuleTiers :exec
+DELETE FROM pricing_rule_tiers
+WHERE rule_id = ?;
+
+-- name: CountCategoryPricingRules :one
+SELECT COUNT(*) FROM pricing_rules
+WHERE category_id = ?;
+
+-- name: ListApplicablePricingRules :many
+-- Note : Returns the rules of a product, and of its categories and their ancestors, that are running at now
+-- together with the highest tier the quantity reaches. Rules whose lowest tier is not reached are left out.
+WITH RECURSIVE ancestors AS (
+  SELECT product_categories.category_id AS id FROM product_categories
+  WHERE product_categories.product_id = CAST(sqlc.arg(product_id) AS INTEGER)
+  UNION
+  SELECT categories.parent_id FROM categories
+  JOIN ancestors ON categories.id = ancestors.id
+  WHERE categories.parent_id IS NOT NULL
+)
+SELECT pricing_rules.*, pricing_rule_tiers.min_quantity, pricing_rule_tiers.value FROM pricing_rules
+JOIN pricing_rule_tiers ON pricing_rule_tiers.rule_id = pricing_rules.id
+WHERE (pricing_rules.product_id = CAST(sqlc.arg(product_id) AS INTEGER) OR pricing_rules.category_id IN (SELECT id FROM ancestors))
+  AND (pricing_rules.starts_at IS NULL OR pricing_rules.starts_at <= sqlc.arg(now))
+  AND (pricing_rules.ends_at IS NULL OR pricing_rules.ends_at > sqlc.arg(now))
+  AND pricing_rule_tiers.min_quantity = (
+    SELECT MAX(tiers.min_quantity) FROM pricing_rule_tiers AS tiers
+    WHERE tiers.rule_id = pricing_rules.id AND tiers.min_quantity <= sqlc.arg(quantity)
+  )
+ORDER BY pricing_rules.id;
+
+-- name: DeletePurgeablePricingRuleTiers :exec
+DELETE FROM pricing_rule_tiers
+WHERE rule_id IN (
+  SELECT id FROM pricing_rules
+  WHERE product_id IN (
+    SELECT id FROM purgeable_products
+    WHERE purgeable_products.deleted_at < sqlc.arg(deleted_before)
+  )
+);
+
+-- name: DeletePurgeablePricingRules :exec
+DELETE FROM pricing_rules
+WHERE product_id IN (
+  SELECT id FROM purgeable_products
+  WHERE purgeable_products.deleted_at < sqlc.arg(deleted_before)
+);
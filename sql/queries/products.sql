@@ -1,26 +1,158 @@
 -- name: GetProduct :one
 SELECT * FROM products
-WHERE id = ? LIMIT 1;
+WHERE id = ? AND deleted_at IS NULL LIMIT 1;
+
+-- name: GetProductBySku :one
+SELECT * FROM products
+WHERE sku = ? COLLATE NOCASE AND deleted_at IS NULL LIMIT 1;
 
--- name: GetProducts :many
+-- name: GetProductByBarcode :one
 SELECT * FROM products
-ORDER BY name;
+WHERE barcode = ? AND deleted_at IS NULL LIMIT 1;
+
+-- name: ListProductsAscending :many
+SELECT sqlc.embed(products),
+  CASE CAST(sqlc.arg(sort_by) AS TEXT)
+    WHEN 'price' THEN price_minor
+    WHEN 'quantity' THEN quantity
+    WHEN 'created_at' THEN created_at
+    ELSE name
+  END AS sort_key
+FROM products
+WHERE (CAST(sqlc.arg(include_deleted) AS BOOLEAN) OR deleted_at IS NULL)
+  AND (CAST(sqlc.narg(name_contains) AS TEXT) IS NULL OR name LIKE '%' || sqlc.narg(name_contains) || '%')
+  AND (CAST(sqlc.narg(currency) AS TEXT) IS NULL OR currency = sqlc.narg(currency))
+  AND (CAST(sqlc.narg(min_price_minor) AS INTEGER) IS NULL OR price_minor >= sqlc.narg(min_price_minor))
+  AND (CAST(sqlc.narg(max_price_minor) AS INTEGER) IS NULL OR price_minor <= sqlc.narg(max_price_minor))
+  AND (CAST(sqlc.narg(in_stock) AS BOOLEAN) IS NULL OR (quantity > 0) = sqlc.narg(in_stock))
+  AND (CAST(sqlc.narg(tags) AS TEXT) IS NULL OR id IN (
+    SELECT product_tags.product_id FROM product_tags
+    JOIN tags ON tags.id = product_tags.tag_id
+    WHERE instr(',' || sqlc.narg(tags) || ',', ',' || tags.name || ',') > 0
+    GROUP BY product_tags.product_id
+    HAVING COUNT(*) = CAST(sqlc.arg(tag_count) AS INTEGER)))
+  AND (sqlc.narg(cursor_value) IS NULL OR (
+    CASE sqlc.arg(sort_by)
+      WHEN 'price' THEN price_minor
+      WHEN 'quantity' THEN quantity
+      WHEN 'created_at' THEN created_at
+      ELSE name
+    END, id) > (sqlc.narg(cursor_value), CAST(sqlc.arg(cursor_id) AS INTEGER)))
+ORDER BY sort_key ASC, id ASC
+LIMIT sqlc.arg(page_size);
+
+-- name: ListProductsDescending :many
+SELECT sqlc.embed(products),
+  CASE CAST(sqlc.arg(sort_by) AS TEXT)
+    WHEN 'price' THEN price_minor
+    WHEN 'quantity' THEN quantity
+    WHEN 'created_at' THEN created_at
+    ELSE name
+  END AS sort_key
+FROM products
+WHERE (CAST(sqlc.arg(include_deleted) AS BOOLEAN) OR deleted_at IS NULL)
+  AND (CAST(sqlc.narg(name_contains) AS TEXT) IS NULL OR name LIKE '%' || sqlc.narg(name_contains) || '%')
+  AND (CAST(sqlc.narg(currency) AS TEXT) IS NULL OR currency = sqlc.narg(currency))
+  AND (CAST(sqlc.narg(min_price_minor) AS INTEGER) IS NULL OR price_minor >= sqlc.narg(min_price_minor))
+  AND (CAST(sqlc.narg(max_price_minor) AS INTEGER) IS NULL OR price_minor <= sqlc.narg(max_price_minor))
+  AND (CAST(sqlc.narg(in_stock) AS BOOLEAN) IS NULL OR (quantity > 0) = sqlc.narg(in_stock))
+  AND (CAST(sqlc.narg(tags) AS TEXT) IS NULL OR id IN (
+    SELECT product_tags.product_id FROM product_tags
+    JOIN tags ON tags.id = product_tags.tag_id
+    WHERE instr(',' || sqlc.narg(tags) || ',', ',' || tags.name || ',') > 0
+    GROUP BY product_tags.product_id
+    HAVING COUNT(*) = CAST(sqlc.arg(tag_count) AS INTEGER)))
+  AND (sqlc.narg(cursor_value) IS NULL OR (
+    CASE sqlc.arg(sort_by)
+      WHEN 'price' THEN price_minor
+      WHEN 'quantity' THEN quantity
+      WHEN 'created_at' THEN created_at
+      ELSE name
+    END, id) < (sqlc.narg(cursor_value), CAST(sqlc.arg(cursor_id) AS INTEGER)))
+ORDER BY sort_key DESC, id DESC
+LIMIT sqlc.arg(page_size);
 
 -- name: CreateProduct :one
 INSERT INTO products (
-  name, quantity, price, created_at
+  name, quantity, price_minor, currency, sku, barcode, reorder_point, created_at
 ) VALUES (
-  ?, ?, ?, ?
-) RETURNING id, name, quantity, price, created_at, updated_at;
+  ?, ?, ?, ?, ?, ?, ?, ?
+) RETURNING *;
+
+-- name: UpdateProduct :one
+UPDATE products
+set name = sqlc.arg(name),
+quantity = sqlc.arg(quantity),
+price_minor = sqlc.arg(price_minor),
+currency = sqlc.arg(currency),
+sku = sqlc.narg(sku),
+barcode = sqlc.narg(barcode),
+reorder_point = sqlc.narg(reorder_point),
+updated_at = sqlc.arg(updated_at),
+version = version + 1
+WHERE id = sqlc.arg(id)
+  AND deleted_at IS NULL
+  AND (CAST(sqlc.narg(expected_version) AS INTEGER) IS NULL OR version = sqlc.narg(expected_version))
+RETURNING *;
+
+-- name: PatchProduct :one
+UPDATE products
+set name = COALESCE(CAST(sqlc.narg(name) AS TEXT), name),
+quantity = COALESCE(CAST(sqlc.narg(quantity) AS INTEGER), quantity),
+price_minor = COALESCE(CAST(sqlc.narg(price_minor) AS INTEGER), price_minor),
+currency = COALESCE(CAST(sqlc.narg(currency) AS TEXT), currency),
+sku = CASE WHEN CAST(sqlc.arg(clear_sku) AS BOOLEAN) THEN NULL ELSE COALESCE(CAST(sqlc.narg(sku) AS TEXT), sku) END,
+barcode = CASE WHEN CAST(sqlc.arg(clear_barcode) AS BOOLEAN) THEN NULL ELSE COALESCE(CAST(sqlc.narg(barcode) AS TEXT), barcode) END,
+reorder_point = CASE WHEN CAST(sqlc.arg(clear_reorder_point) AS BOOLEAN) THEN NULL ELSE COALESCE(CAST(sqlc.narg(reorder_point) AS INTEGER), reorder_point) END,
+updated_at = sqlc.arg(updated_at),
+version = version + 1
+WHERE id = sqlc.arg(id)
+  AND deleted_at IS NULL
+  AND (CAST(sqlc.narg(expected_version) AS INTEGER) IS NULL OR version = sqlc.narg(expected_version))
+RETURNING *;
 
--- name: UpdateProduct :exec
+-- name: DeleteProduct :execrows
 UPDATE products
-set name = ?,
-quantity = ?,
-price = ?,
-updated_at = ?
-WHERE id = ?;
+set deleted_at = sqlc.arg(deleted_at),
+version = version + 1
+WHERE id = sqlc.arg(id)
+  AND deleted_at IS NULL
+  AND (CAST(sqlc.narg(expected_version) AS INTEGER) IS NULL OR version = sqlc.narg(expected_version));
 
--- name: DeleteProduct :exec
+-- name: RestoreProduct :one
+UPDATE products
+set deleted_at = NULL,
+updated_at = sqlc.arg(updated_at),
+version = version + 1
+WHERE id = sqlc.arg(id)
+  AND deleted_at IS NOT NULL
+RETURNING *;
+
+-- name: PurgeDeletedProducts :execrows
 DELETE FROM products
-WHERE id = ?;
+WHERE id IN (
+  SELECT id FROM purgeable_products
+  WHERE purgeable_products.deleted_at < sqlc.arg(deleted_before)
+);
+
+-- name: SearchProducts :many
+SELECT sqlc.embed(products),
+  snippet(products_search, 0, char(2), char(3), '...', 16) AS highlight,
+  bm25(products_search) AS score
+FROM products_search
+JOIN products ON products.id = products_search.rowid
+WHERE products_search.name MATCH sqlc.arg(query)
+  AND products.deleted_at IS NULL
+ORDER BY score
+LIMIT sqlc.arg(page_size);
+
+-- name: ExportProducts :many
+SELECT * FROM products
+WHERE deleted_at IS NULL
+  AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(limit);
+
+-- name: GetProductIncludingDeleted :one
+SELECT * FROM products
+WHERE id = ? LIMIT 1;
@@ -0,0 +1,55 @@
+-- name: CreateReservation :one
+INSERT INTO reservations (
+  status, reference, expires_at, created_at
+) VALUES (
+  'active', ?, ?, ?
+) RETURNING *;
+
+-- name: CreateReservationItem :exec
+INSERT INTO reservation_items (
+  reservation_id, product_id, variant_id, warehouse_id, quantity
+) VALUES (
+  ?, ?, ?, ?, ?
+);
+
+-- name: GetReservation :one
+SELECT * FROM reservations
+WHERE id = ? LIMIT 1;
+
+-- name: ListReservationItems :many
+SELECT * FROM reservation_items
+WHERE reservation_id = ?
+ORDER BY product_id, variant_id;
+
+-- name: ConfirmReservation :execrows
+UPDATE reservations
+set status = 'confirmed',
+updated_at = sqlc.arg(now)
+WHERE id = sqlc.arg(id)
+  AND status = 'active'
+  AND expires_at > sqlc.arg(now);
+
+-- name: ReleaseReservation :execrows
+UPDATE reservations
+set status = sqlc.arg(status),
+updated_at = sqlc.arg(updated_at)
+WHERE id = sqlc.arg(id)
+  AND status = 'active';
+
+-- name: ListExpiredReservations :many
+SELECT id FROM reservations
+WHERE status = 'active'
+  AND expires_at <= sqlc.arg(now)
+ORDER BY expires_at
+LIMIT sqlc.arg(page_size);
+
+-- name: CountActiveReservations :one
+SELECT COUNT(*) FROM reservations
+WHERE status = 'active';
+
+-- name: CountActiveItemReservations :one
+SELECT COUNT(*) FROM reservation_items
+JOIN reservations ON reservations.id = reservation_items.reservation_id
+WHERE reservations.status = 'active'
+  AND reservation_items.product_id = sqlc.arg(product_id)
+  AND (CAST(sqlc.narg(variant_id) AS INTEGER) IS NULL OR reservation_items.variant_id = sqlc.narg(variant_id));
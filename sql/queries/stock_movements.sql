@@ -0,0 +1,48 @@
+-- name: CreateStockMovement :one
+INSERT INTO stock_movements (
+  product_id, variant_id, warehouse_id, delta, quantity_after, reason, reference, actor, request_id, created_at
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+) RETURNING *;
+
+-- name: RecordQuantityChange :exec
+INSERT INTO stock_movements (
+  product_id, delta, quantity_after, reason, reference, actor, request_id, created_at
+)
+SELECT products.id, CAST(sqlc.narg(quantity) AS INTEGER) - products.quantity, sqlc.narg(quantity), sqlc.arg(reason), NULL, NULL, sqlc.arg(request_id), sqlc.arg(created_at)
+FROM products
+WHERE products.id = sqlc.arg(product_id)
+  AND products.deleted_at IS NULL
+  AND products.quantity <> sqlc.narg(quantity);
+
+-- name: AdjustProductQuantity :one
+UPDATE products
+set quantity = quantity + sqlc.arg(delta),
+updated_at = sqlc.arg(updated_at),
+version = version + 1
+WHERE id = sqlc.arg(id)
+  AND deleted_at IS NULL
+  AND quantity + sqlc.arg(delta) >= 0
+RETURNING *;
+
+-- name: BumpProductVersion :one
+UPDATE products
+set updated_at = sqlc.arg(updated_at),
+version = version + 1
+WHERE id = sqlc.arg(id)
+  AND deleted_at IS NULL
+RETURNING *;
+
+-- name: ListStockMovements :many
+SELECT * FROM stock_movements
+WHERE product_id = sqlc.arg(product_id)
+  AND (CAST(sqlc.narg(before_id) AS INTEGER) IS NULL OR id < sqlc.narg(before_id))
+ORDER BY id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: DeletePurgeableStockMovements :exec
+DELETE FROM stock_movements
+WHERE product_id IN (
+  SELECT id FROM purgeable_products
+  WHERE purgeable_products.deleted_at < sqlc.arg(deleted_before)
+);
@@ -0,0 +1,51 @@
+-- name: CreateVariant :one
+INSERT INTO product_variants (
+  product_id, sku, options, price_minor, currency, quantity, created_at
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?
+) RETURNING *;
+
+-- name: GetVariant :one
+SELECT * FROM product_variants
+WHERE id = ? AND product_id = ? AND deleted_at IS NULL LIMIT 1;
+
+-- name: ListVariants :many
+SELECT * FROM product_variants
+WHERE product_id = ? AND deleted_at IS NULL
+ORDER BY id;
+
+-- name: CountProductVariants :one
+SELECT COUNT(*) FROM product_variants
+WHERE product_id = ? AND deleted_at IS NULL;
+
+-- name: UpdateVariant :one
+UPDATE product_variants
+set sku = ?,
+options = ?,
+price_minor = ?,
+currency = ?,
+updated_at = ?
+WHERE id = ? AND product_id = ? AND deleted_at IS NULL
+RETURNING *;
+
+-- name: AdjustVariantQuantity :one
+UPDATE product_variants
+set quantity = quantity + sqlc.arg(delta),
+updated_at = sqlc.arg(updated_at)
+WHERE id = sqlc.arg(id)
+  AND product_id = sqlc.arg(product_id)
+  AND deleted_at IS NULL
+  AND quantity + sqlc.arg(delta) >= 0
+RETURNING *;
+
+-- name: DeleteVariant :execrows
+UPDATE product_variants
+set deleted_at = ?
+WHERE id = ? AND product_id = ? AND deleted_at IS NULL;
+
+-- name: DeletePurgeableVariants :exec
+DELETE FROM product_variants
+WHERE product_id IN (
+  SELECT id FROM purgeable_products
+  WHERE purgeable_products.deleted_at < sqlc.arg(deleted_before)
+);
@@ -0,0 +1,122 @@
+-- name: CreateWarehouse :one
+INSERT INTO warehouses (
+  code, name, created_at
+) VALUES (
+  ?, ?, ?
+) RETURNING *;
+
+-- name: GetWarehouse :one
+SELECT * FROM warehouses
+WHERE id = ? AND deleted_at IS NULL LIMIT 1;
+
+-- name: ListWarehouses :many
+SELECT * FROM warehouses
+WHERE deleted_at IS NULL
+ORDER BY code;
+
+-- name: UpdateWarehouse :one
+UPDATE warehouses
+set code = ?,
+name = ?,
+updated_at = ?
+WHERE id = ? AND deleted_at IS NULL
+RETURNING *;
+
+-- name: DeleteWarehouse :execrows
+UPDATE warehouses
+set deleted_at = ?
+WHERE id = ? AND deleted_at IS NULL;
+
+-- name: CountWarehouseStock :one
+SELECT CAST(COALESCE(SUM(quantity), 0) AS INTEGER) FROM warehouse_stock
+WHERE warehouse_id = ?;
+
+-- name: CountActiveWarehouseReservations :one
+SELECT COUNT(*) FROM reservation_items
+JOIN reservations ON reservations.id = reservation_items.reservation_id
+WHERE reservations.status = 'active'
+  AND reservation_items.warehouse_id = ?;
+
+-- name: DeleteEmptyWarehouseStock :exec
+DELETE FROM warehouse_stock
+WHERE warehouse_id = ? AND quantity = 0;
+
+-- name: AddWarehouseStock :one
+INSERT INTO warehouse_stock (
+  warehouse_id, product_id, variant_id, quantity
+) VALUES (
+  ?, ?, ?, ?
+)
+ON CONFLICT (warehouse_id, product_id, COALESCE(variant_id, 0)) DO UPDATE SET quantity = quantity + excluded.quantity
+RETURNING *;
+
+-- name: RemoveWarehouseStock :one
+UPDATE warehouse_stock
+set quantity = quantity - sqlc.arg(quantity)
+WHERE warehouse_id = sqlc.arg(warehouse_id)
+  AND product_id = sqlc.arg(product_id)
+  AND COALESCE(variant_id, 0) = CAST(sqlc.arg(variant_key) AS INTEGER)
+  AND quantity >= sqlc.arg(quantity)
+RETURNING *;
+
+-- name: GetWarehouseStockQuantity :one
+SELECT CAST(COALESCE(SUM(quantity), 0) AS INTEGER) FROM warehouse_stock
+WHERE warehouse_id = sqlc.arg(warehouse_id)
+  AND product_id = sqlc.arg(product_id)
+  AND COALESCE(variant_id, 0) = CAST(sqlc.arg(variant_key) AS INTEGER);
+
+-- name: CountAllocatedStock :one
+SELECT CAST(COALESCE(SUM(quantity), 0) AS INTEGER) FROM warehouse_stock
+WHERE product_id = sqlc.arg(product_id)
+  AND COALESCE(variant_id, 0) = CAST(sqlc.arg(variant_key) AS INTEGER);
+
+-- name: ListProductStockLocations :many
+SELECT warehouses.id, warehouses.code, CAST(SUM(warehouse_stock.quantity) AS INTEGER) AS quantity
+FROM warehouse_stock
+JOIN warehouses ON warehouses.id = warehouse_stock.warehouse_id
+WHERE warehouse_stock.product_id = ?
+GROUP BY warehouses.id, warehouses.code
+HAVING SUM(warehouse_stock.quantity) > 0
+ORDER BY warehouses.code;
+
+-- name: ListWarehouseStockLevels :many
+SELECT warehouses.id, warehouses.code, CAST(COALESCE(SUM(warehouse_stock.quantity), 0) AS INTEGER) AS quantity
+FROM warehouses
+LEFT JOIN warehouse_stock ON warehouse_stock.warehouse_id = warehouses.id
+WHERE warehouses.deleted_at IS NULL
+GROUP BY warehouses.id, warehouses.code
+ORDER BY warehouses.code;
+
+-- name: DeleteVariantWarehouseStock :exec
+DELETE FROM warehouse_stock
+WHERE product_id = ? AND variant_id = ?;
+
+-- name: DeletePurgeableWarehouseStock :exec
+DELETE FROM warehouse_stock
+WHERE product_id IN (
+  SELECT id FROM purgeable_products
+  WHERE purgeable_products.deleted_at < sqlc.arg(deleted_before)
+);
+
+-- name: CreateStockTransfer :one
+INSERT INTO stock_transfers (
+  from_warehouse_id, to_warehouse_id, reference, actor, created_at
+) VALUES (
+  ?, ?, ?, ?, ?
+) RETURNING *;
+
+-- name: CreateStockTransferItem :exec
+INSERT INTO stock_transfer_items (
+  transfer_id, product_id, variant_id, quantity
+) VALUES (
+  ?, ?, ?, ?
+);
+
+-- name: GetStockTransfer :one
+SELECT * FROM stock_transfers
+WHERE id = ? LIMIT 1;
+
+-- name: ListStockTransferItems :many
+SELECT * FROM stock_transfer_items
+WHERE transfer_id = ?
+ORDER BY product_id, variant_id;
@@ -0,0 +1,94 @@
+-- name: CreateWebhookSubscription :one
+INSERT INTO webhook_subscriptions (
+  url, event_types, secret, created_at
+) VALUES (
+  ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: GetWebhookSubscription :one
+SELECT * FROM webhook_subscriptions
+WHERE id = ? LIMIT 1;
+
+-- name: ListWebhookSubscriptions :many
+SELECT * FROM webhook_subscriptions
+ORDER BY id;
+
+-- name: UpdateWebhookSubscription :one
+UPDATE webhook_subscriptions
+set url = sqlc.arg(url),
+event_types = sqlc.arg(event_types),
+secret = sqlc.arg(secret),
+updated_at = sqlc.arg(updated_at)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: DeleteWebhookSubscription :execrows
+DELETE FROM webhook_subscriptions
+WHERE id = ?;
+
+-- name: DeleteWebhookDeliveries :exec
+DELETE FROM webhook_deliveries
+WHERE subscription_id = ?;
+
+-- name: CreateWebhookDeliveries :execrows
+INSERT INTO webhook_deliveries (
+  subscription_id, event_id, event_type, payload, trace_parent, trace_state, next_attempt_at, created_at
+)
+SELECT webhook_subscriptions.id, sqlc.arg(event_id), sqlc.arg(event_type), sqlc.arg(payload),
+  sqlc.arg(trace_parent), sqlc.arg(trace_state), sqlc.arg(created_at), sqlc.arg(created_at)
+FROM webhook_subscriptions
+WHERE EXISTS (
+  SELECT 1 FROM json_each(webhook_subscriptions.event_types)
+  WHERE json_each.value = sqlc.arg(event_type))
+ON CONFLICT DO NOTHING;
+
+-- name: ListDueWebhookDeliveries :many
+SELECT webhook_deliveries.id, webhook_deliveries.subscription_id, webhook_deliveries.event_id,
+  webhook_deliveries.event_type, webhook_deliveries.payload, webhook_deliveries.trace_parent,
+  webhook_deliveries.trace_state, webhook_deliveries.attempts, webhook_subscriptions.url, webhook_subscriptions.secret
+FROM webhook_deliveries
+JOIN webhook_subscriptions ON webhook_subscriptions.id = webhook_deliveries.subscription_id
+WHERE webhook_deliveries.status = 'pending'
+  AND webhook_deliveries.next_attempt_at <= sqlc.arg(now)
+ORDER BY webhook_deliveries.id
+LIMIT sqlc.arg(page_size);
+
+-- name: FinishWebhookDelivery :exec
+UPDATE webhook_deliveries
+set status = sqlc.arg(status),
+attempts = attempts + 1,
+next_attempt_at = sqlc.arg(next_attempt_at),
+response_status = sqlc.arg(response_status),
+last_error = sqlc.arg(last_error),
+delivered_at = sqlc.arg(delivered_at)
+WHERE id = sqlc.arg(id);
+
+-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries
+WHERE id = sqlc.arg(id)
+  AND subscription_id = sqlc.arg(subscription_id)
+LIMIT 1;
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE subscription_id = sqlc.arg(subscription_id)
+  AND (CAST(sqlc.narg(status) AS TEXT) IS NULL OR status = sqlc.narg(status))
+  AND (CAST(sqlc.narg(before_id) AS INTEGER) IS NULL OR id < sqlc.narg(before_id))
+ORDER BY id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: RetryWebhookDelivery :one
+UPDATE webhook_deliveries
+set status = 'pending',
+attempts = 0,
+next_attempt_at = sqlc.arg(next_attempt_at)
+WHERE id = sqlc.arg(id)
+  AND subscription_id = sqlc.arg(subscription_id)
+  AND status = 'dead'
+RETURNING *;
+
+-- name: CountWebhookDeliveriesByStatus :many
+SELECT status, COUNT(*) AS deliveries
+FROM webhook_deliveries
+GROUP BY status;
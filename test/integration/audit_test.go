@@ -0,0 +1,118 @@
+//go:build sqlite_fts5
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/repository"
+	productrepository "github.com/indrabrata/observability-playground/repository/product"
+	"github.com/indrabrata/observability-playground/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestAuditLog(t *testing.T) {
+	productService, db := newProductService(t)
+	auditService := service.NewAuditService(repository.NewBaseRepository(db, productrepository.New(db)), noop.NewTracerProvider().Tracer("test"))
+
+	traceId, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanId, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(newContext(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceId, SpanID: spanId}))
+	ctx = context.WithValue(ctx, "actor", "jane.doe")
+	ctx = context.WithValue(ctx, "clientIp", "203.0.113.7")
+
+	list := func(request model.AuditLogRequest) model.AuditLogResponse {
+		t.Helper()
+		request.Entity = model.AuditEntityProduct
+		require.NoError(t, request.Validate())
+		entries, err := auditService.ListAuditLog(newContext(), request)
+		require.NoError(t, err)
+		return entries
+	}
+
+	start := time.Now()
+	lamp, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Lamp", Quantity: 4, Price: model.MustParseMoney("20")})
+	require.NoError(t, err)
+	desk, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Desk", Quantity: 1, Price: model.MustParseMoney("120")})
+	require.NoError(t, err)
+
+	name := "Desk Lamp"
+	_, err = productService.PatchProduct(ctx, lamp.Id, model.ProductPatchRequest{Name: &name}, model.Precondition{})
+	require.NoError(t, err)
+	require.NoError(t, productService.DeleteProduct(ctx, lamp.Id, model.Precondition{}))
+	_, err = productService.RestoreProduct(ctx, lamp.Id)
+	require.NoError(t, err)
+
+	id := lamp.Id
+	entries := list(model.AuditLogRequest{EntityId: &id})
+	require.Len(t, entries.Data, 4)
+	actions := make([]string, 0, len(entries.Data))
+	for _, entry := range entries.Data {
+		actions = append(actions, entry.Action)
+	}
+	assert.Equal(t, []string{model.AuditActionRestore, model.AuditActionDelete, model.AuditActionUpdate, model.AuditActionCreate}, actions, "newest first")
+
+	update := entries.Data[2]
+	assert.Equal(t, "jane.doe", update.Actor)
+	assert.Equal(t, "203.0.113.7", update.ClientIp)
+	assert.Equal(t, "test-123", update.RequestId)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", update.TraceId)
+	assert.JSONEq(t, `{"name": "Lamp", "version": 1}`, string(update.Before), "only the changed fields are kept")
+	assert.JSONEq(t, `{"name": "Desk Lamp", "version": 2}`, string(update.After))
+
+	deleted := entries.Data[1]
+	assert.JSONEq(t, `{"deleted_at": null, "version": 2}`, string(deleted.Before))
+	assert.Contains(t, string(deleted.After), `"deleted_at"`)
+
+	created := entries.Data[3]
+	assert.Nil(t, created.Before)
+	assert.Contains(t, string(created.After), `"name":"Lamp"`)
+
+	// Every product is listed without an id, page by page.
+	page := list(model.AuditLogRequest{Limit: 3})
+	require.Len(t, page.Data, 3)
+	require.True(t, page.Paging.HasMore)
+	page = list(model.AuditLogRequest{Limit: 3, Cursor: page.Paging.NextCursor})
+	require.Len(t, page.Data, 2)
+	assert.False(t, page.Paging.HasMore)
+	assert.Equal(t, desk.Id, page.Data[0].EntityId)
+
+	// The time range keeps the entries at or after from and before to.
+	future := time.Now().Add(time.Hour)
+	assert.Empty(t, list(model.AuditLogRequest{From: &future}).Data)
+	assert.Len(t, list(model.AuditLogRequest{From: &start, To: &future}).Data, 5)
+	assert.Empty(t, list(model.AuditLogRequest{To: &start}).Data)
+
+	request := model.AuditLogRequest{Entity: "order"}
+	assert.Error(t, request.Validate())
+}
+
+func TestAuditLogRecordsStockChanges(t *testing.T) {
+	productService, db := newProductService(t)
+	auditService := service.NewAuditService(repository.NewBaseRepository(db, productrepository.New(db)), noop.NewTracerProvider().Tracer("test"))
+	ctx := context.WithValue(newContext(), "actor", "jane.doe")
+
+	lamp, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Lamp", Quantity: 4, Price: model.MustParseMoney("20")})
+	require.NoError(t, err)
+	_, err = productService.AdjustStock(ctx, lamp.Id, model.StockAdjustRequest{Delta: -3, Reason: "sold"})
+	require.NoError(t, err)
+
+	id := lamp.Id
+	request := model.AuditLogRequest{Entity: model.AuditEntityProduct, EntityId: &id}
+	require.NoError(t, request.Validate())
+	entries, err := auditService.ListAuditLog(ctx, request)
+	require.NoError(t, err)
+	require.Len(t, entries.Data, 2)
+
+	adjusted := entries.Data[0]
+	assert.Equal(t, model.AuditActionUpdate, adjusted.Action)
+	assert.Equal(t, "jane.doe", adjusted.Actor)
+	assert.JSONEq(t, `{"quantity": 4, "version": 1}`, string(adjusted.Before))
+	assert.JSONEq(t, `{"quantity": 1, "version": 2}`, string(adjusted.After))
+}
@@ -0,0 +1,151 @@
+//go:build sqlite_fts5
+
+package integration
+
+import (
+	"testing"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/repository"
+	productrepository "github.com/indrabrata/observability-playground/repository/product"
+	"github.com/indrabrata/observability-playground/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestCategoryTree(t *testing.T) {
+	productService, db := newProductService(t)
+	categoryService := service.NewCategoryService(repository.NewBaseRepository(db, productrepository.New(db)), noop.NewTracerProvider().Tracer("test"))
+	ctx := newContext()
+
+	clothing, err := categoryService.CreateCategory(ctx, model.CategoryRequest{Name: "Clothing"})
+	require.NoError(t, err)
+	shoes, err := categoryService.CreateCategory(ctx, model.CategoryRequest{Name: "Shoes", ParentId: &clothing.Id})
+	require.NoError(t, err)
+	running, err := categoryService.CreateCategory(ctx, model.CategoryRequest{Name: "Running", ParentId: &shoes.Id})
+	require.NoError(t, err)
+	garden, err := categoryService.CreateCategory(ctx, model.CategoryRequest{Name: "Garden"})
+	require.NoError(t, err)
+
+	_, err = categoryService.CreateCategory(ctx, model.CategoryRequest{Name: "shoes", ParentId: &clothing.Id})
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err), "sibling names are unique ignoring case")
+	missing := int64(999)
+	_, err = categoryService.CreateCategory(ctx, model.CategoryRequest{Name: "Hats", ParentId: &missing})
+	assert.Equal(t, apperror.NotFound, apperror.KindOf(err))
+
+	// Moving a category below its own descendant would detach the subtree.
+	_, err = categoryService.UpdateCategory(ctx, clothing.Id, model.CategoryRequest{Name: "Clothing", ParentId: &running.Id})
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err))
+	_, err = categoryService.UpdateCategory(ctx, shoes.Id, model.CategoryRequest{Name: "Shoes", ParentId: &shoes.Id})
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err))
+
+	sneaker, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Sneaker", Quantity: 5, Price: model.MustParseMoney("80")})
+	require.NoError(t, err)
+	boot, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Boot", Quantity: 2, Price: model.MustParseMoney("120")})
+	require.NoError(t, err)
+	shirt, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Shirt", Quantity: 9, Price: model.MustParseMoney("25")})
+	require.NoError(t, err)
+	rake, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Rake", Quantity: 1, Price: model.MustParseMoney("15")})
+	require.NoError(t, err)
+
+	for productId, categoryIds := range map[int64][]int64{
+		sneaker.Id: {running.Id},
+		boot.Id:    {shoes.Id},
+		shirt.Id:   {clothing.Id},
+		rake.Id:    {garden.Id},
+	} {
+		_, err := productService.SetProductCategories(ctx, productId, model.ProductCategoriesRequest{CategoryIds: categoryIds})
+		require.NoError(t, err)
+	}
+	_, err = productService.SetProductCategories(ctx, rake.Id, model.ProductCategoriesRequest{CategoryIds: []int64{missing}})
+	assert.Equal(t, apperror.NotFound, apperror.KindOf(err))
+	categories, err := productService.GetProductCategories(ctx, rake.Id)
+	require.NoError(t, err)
+	require.Len(t, categories.Categories, 1, "a failed replace keeps the previous categories")
+	assert.Equal(t, garden.Id, categories.Categories[0].Id)
+
+	// Soft deleted products drop out of the category listing.
+	require.NoError(t, productService.DeleteProduct(ctx, boot.Id, model.Precondition{}))
+
+	listed := listCategoryProducts(t, categoryService, clothing.Id)
+	assert.Equal(t, []int64{sneaker.Id, shirt.Id}, listed)
+	assert.Equal(t, []int64{sneaker.Id}, listCategoryProducts(t, categoryService, shoes.Id))
+
+	// Moving Shoes to the top level takes its products out of Clothing.
+	_, err = categoryService.UpdateCategory(ctx, shoes.Id, model.CategoryRequest{Name: "Shoes"})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{shirt.Id}, listCategoryProducts(t, categoryService, clothing.Id))
+
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(categoryService.DeleteCategory(ctx, shoes.Id)), "a category with subcategories cannot be deleted")
+	require.NoError(t, categoryService.DeleteCategory(ctx, running.Id))
+	categories, err = productService.GetProductCategories(ctx, sneaker.Id)
+	require.NoError(t, err)
+	assert.Empty(t, categories.Categories)
+	assert.Equal(t, apperror.NotFound, apperror.KindOf(categoryService.DeleteCategory(ctx, running.Id)))
+}
+
+func listCategoryProducts(t *testing.T, categoryService *service.CategoryService, id int64) []int64 {
+	t.Helper()
+
+	var ids []int64
+	request := model.CategoryProductsRequest{Limit: 1}
+	for {
+		require.NoError(t, request.Validate())
+		page, err := categoryService.ListCategoryProducts(newContext(), id, request)
+		require.NoError(t, err)
+		for _, product := range page.Data {
+			ids = append(ids, product.Id)
+		}
+		if !page.Paging.HasMore {
+			return ids
+		}
+		request = model.CategoryProductsRequest{Limit: 1, Cursor: page.Paging.NextCursor}
+	}
+}
+
+func TestProductTagFilter(t *testing.T) {
+	productService, _ := newProductService(t)
+	ctx := newContext()
+
+	tent, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Tent", Quantity: 3, Price: model.MustParseMoney("200")})
+	require.NoError(t, err)
+	jacket, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Jacket", Quantity: 4, Price: model.MustParseMoney("90")})
+	require.NoError(t, err)
+	_, err = productService.CreateProduct(ctx, model.ProductRequest{Name: "Mug", Quantity: 8, Price: model.MustParseMoney("8")})
+	require.NoError(t, err)
+
+	request := model.ProductTagsRequest{Tags: []string{" Outdoor", "waterproof", "OUTDOOR"}}
+	require.NoError(t, request.Validate())
+	tags, err := productService.SetProductTags(ctx, tent.Id, request)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"outdoor", "waterproof"}, tags.Tags)
+
+	_, err = productService.SetProductTags(ctx, jacket.Id, model.ProductTagsRequest{Tags: []string{"outdoor", "sale"}})
+	require.NoError(t, err)
+
+	listNames := func(tags ...string) []string {
+		request := model.ProductListRequest{Tags: tags}
+		require.NoError(t, request.Validate())
+		products, err := productService.GetProducts(ctx, request)
+		require.NoError(t, err)
+		names := make([]string, 0, len(products.Data))
+		for _, product := range products.Data {
+			names = append(names, product.Name)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"Jacket", "Mug", "Tent"}, listNames())
+	assert.Equal(t, []string{"Jacket", "Tent"}, listNames("outdoor"))
+	assert.Equal(t, []string{"Tent"}, listNames("Outdoor", "waterproof"))
+	assert.Empty(t, listNames("waterproof", "sale"))
+	// A tag that is a prefix of another must not match it.
+	assert.Empty(t, listNames("out"))
+
+	tags, err = productService.SetProductTags(ctx, tent.Id, model.ProductTagsRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, tags.Tags)
+	assert.Equal(t, []string{"Jacket"}, listNames("outdoor"))
+}
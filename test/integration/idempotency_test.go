@@ -0,0 +1,116 @@
+//go:build sqlite_fts5
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/indrabrata/observability-playground/handler"
+	"github.com/indrabrata/observability-playground/middleware"
+	"github.com/indrabrata/observability-playground/model"
+	idempotencyrepository "github.com/indrabrata/observability-playground/repository/idempotency"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestIdempotentCreateProduct(t *testing.T) {
+	productService, db := newProductService(t)
+	productHandler := handler.New(productService, noop.NewTracerProvider().Tracer("test"))
+	idempotency := middleware.NewIdempotency(idempotencyrepository.New(db), time.Hour)
+
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), "requestId", "test-123")))
+		})
+	})
+	router.With(idempotency.Middleware).Post("/products", productHandler.CreateProduct)
+
+	post := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(body))
+		req.Header.Set("Idempotency-Key", key)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := post("order-1", `{"name":"Kettle","quantity":2,"price":30}`)
+	require.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get("Idempotent-Replayed"))
+
+	retry := post("order-1", `{"name":"Kettle","quantity":2,"price":30}`)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, first.Header().Get("ETag"), retry.Header().Get("ETag"))
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+
+	mismatch := post("order-1", `{"name":"Kettle","quantity":3,"price":30}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, mismatch.Code)
+
+	second := post("order-2", `{"name":"Kettle","quantity":2,"price":30}`)
+	assert.Equal(t, http.StatusCreated, second.Code)
+
+	request := model.ProductListRequest{}
+	require.NoError(t, request.Validate())
+	page, err := productService.GetProducts(newContext(), request)
+	require.NoError(t, err)
+	assert.Len(t, page.Data, 2)
+
+	// An expired key is claimed again as if it were new.
+	_, err = db.Exec(`UPDATE idempotency_keys SET expires_at = ? WHERE key = 'order-1'`, time.Now().UTC().Add(-time.Minute))
+	require.NoError(t, err)
+	expired := post("order-1", `{"name":"Kettle","quantity":3,"price":30}`)
+	assert.Equal(t, http.StatusCreated, expired.Code)
+
+	var created model.ProductResponse
+	require.NoError(t, json.NewDecoder(expired.Body).Decode(&created))
+	assert.Equal(t, int64(3), created.Quantity)
+}
+
+func TestIdempotencyKeyReleasedOnPanic(t *testing.T) {
+	_, db := newProductService(t)
+	idempotency := middleware.NewIdempotency(idempotencyrepository.New(db), time.Hour)
+
+	panics := true
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				if recover() != nil {
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+			}()
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), "requestId", "test-123")))
+		})
+	})
+	router.With(idempotency.Middleware).Post("/orders", func(w http.ResponseWriter, r *http.Request) {
+		if panics {
+			panic("boom")
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{}`))
+		req.Header.Set("Idempotency-Key", "order-1")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusInternalServerError, post().Code)
+
+	// The retry runs the request again instead of finding the key still being processed.
+	panics = false
+	retry := post()
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Empty(t, retry.Header().Get("Idempotent-Replayed"))
+}
@@ -0,0 +1,53 @@
+//go:build sqlite_fts5
+
+package integration
+
+import (
+	"testing"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProductIdentifiers(t *testing.T) {
+	productService, _ := newProductService(t)
+	ctx := newContext()
+
+	request := model.ProductRequest{Name: "Shirt", Quantity: 3, Price: model.MustParseMoney("20"), Sku: " SHIRT-RED-M ", Barcode: "4006381333931"}
+	require.NoError(t, request.Validate())
+	shirt, err := productService.CreateProduct(ctx, request)
+	require.NoError(t, err)
+	assert.Equal(t, "SHIRT-RED-M", shirt.Sku)
+
+	found, err := productService.GetProductBySku(ctx, "shirt-red-m")
+	require.NoError(t, err)
+	assert.Equal(t, shirt.Id, found.Id)
+	found, err = productService.GetProductByBarcode(ctx, "4006381333931")
+	require.NoError(t, err)
+	assert.Equal(t, shirt.Id, found.Id)
+
+	// SKUs are unique ignoring case, and the conflict names the field.
+	_, err = productService.CreateProduct(ctx, model.ProductRequest{Name: "Other shirt", Quantity: 1, Price: model.MustParseMoney("20"), Sku: "shirt-red-m"})
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err))
+	assert.Equal(t, "sku is already used by another product", apperror.MessageOf(err))
+	_, err = productService.CreateProduct(ctx, model.ProductRequest{Name: "Other shirt", Quantity: 1, Price: model.MustParseMoney("20"), Barcode: "4006381333931"})
+	assert.Equal(t, "barcode is already used by another product", apperror.MessageOf(err))
+
+	// Products without identifiers do not collide with each other.
+	for range 2 {
+		_, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Plain", Quantity: 1, Price: model.MustParseMoney("1")})
+		require.NoError(t, err)
+	}
+
+	mug, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Mug", Quantity: 1, Price: model.MustParseMoney("5"), Sku: "MUG"})
+	require.NoError(t, err)
+	sku := "Shirt-Red-M"
+	_, err = productService.PatchProduct(ctx, mug.Id, model.ProductPatchRequest{Sku: &sku}, model.Precondition{})
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err))
+
+	require.NoError(t, productService.DeleteProduct(ctx, shirt.Id, model.Precondition{}))
+	_, err = productService.GetProductBySku(ctx, "SHIRT-RED-M")
+	assert.Equal(t, apperror.NotFound, apperror.KindOf(err), "soft deleted products are not found by sku")
+}
@@ -0,0 +1,133 @@
+//go:build sqlite_fts5
+
+package integration
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/repository"
+	productrepository "github.com/indrabrata/observability-playground/repository/product"
+	"github.com/indrabrata/observability-playground/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+type recordingNotifier struct {
+	mu     sync.Mutex
+	alerts []model.LowStockAlertResponse
+}
+
+func (n *recordingNotifier) Name() string {
+	return "recording"
+}
+
+func (n *recordingNotifier) NotifyLowStock(ctx context.Context, alert model.LowStockAlertResponse) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.alerts = append(n.alerts, alert)
+	return nil
+}
+
+func (n *recordingNotifier) count() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.alerts)
+}
+
+func TestLowStockAlerts(t *testing.T) {
+	productService, db := newProductService(t)
+	notifier := &recordingNotifier{}
+	lowStockService := service.NewLowStockService(repository.NewBaseRepository(db, productrepository.New(db)), noop.NewTracerProvider().Tracer("test"), notifier)
+	ctx := newContext()
+
+	reorderPoint := int64(5)
+	lamp, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Lamp", Quantity: 10, Price: model.MustParseMoney("20"), ReorderPoint: &reorderPoint})
+	require.NoError(t, err)
+	require.NotNil(t, lamp.ReorderPoint)
+	_, err = productService.CreateProduct(ctx, model.ProductRequest{Name: "Rug", Quantity: 1, Price: model.MustParseMoney("80")})
+	require.NoError(t, err)
+
+	opened, err := lowStockService.EvaluateLowStock(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), opened, "a product without a reorder point is never alerted on")
+
+	_, err = productService.AdjustStock(ctx, lamp.Id, model.StockAdjustRequest{Delta: -5, Reason: model.StockReasonShip})
+	require.NoError(t, err)
+	opened, err = lowStockService.EvaluateLowStock(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), opened, "a quantity equal to the reorder point is not below it")
+
+	_, err = productService.AdjustStock(ctx, lamp.Id, model.StockAdjustRequest{Delta: -1, Reason: model.StockReasonShip})
+	require.NoError(t, err)
+	opened, err = lowStockService.EvaluateLowStock(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), opened)
+	require.Equal(t, 1, notifier.count())
+	assert.Equal(t, lamp.Id, notifier.alerts[0].ProductId)
+	assert.Equal(t, int64(4), notifier.alerts[0].Quantity)
+
+	// Staying below the reorder point is the same crossing, it is not notified again.
+	_, err = productService.AdjustStock(ctx, lamp.Id, model.StockAdjustRequest{Delta: -2, Reason: model.StockReasonShip})
+	require.NoError(t, err)
+	opened, err = lowStockService.EvaluateLowStock(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), opened)
+	assert.Equal(t, 1, notifier.count())
+
+	alerts, err := lowStockService.ListLowStockAlerts(ctx)
+	require.NoError(t, err)
+	require.Len(t, alerts.Data, 1)
+	assert.Equal(t, "Lamp", alerts.Data[0].Name)
+	assert.Equal(t, int64(2), alerts.Data[0].Quantity, "the list shows the current quantity")
+
+	// Recovering resolves the alert, and the next crossing is notified again.
+	_, err = productService.AdjustStock(ctx, lamp.Id, model.StockAdjustRequest{Delta: 8, Reason: model.StockReasonReceive})
+	require.NoError(t, err)
+	_, err = lowStockService.EvaluateLowStock(ctx)
+	require.NoError(t, err)
+	alerts, err = lowStockService.ListLowStockAlerts(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, alerts.Data)
+
+	higher := int64(20)
+	_, err = productService.PatchProduct(ctx, lamp.Id, model.ProductPatchRequest{ReorderPoint: &higher}, model.Precondition{})
+	require.NoError(t, err)
+	opened, err = lowStockService.EvaluateLowStock(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), opened, "raising the reorder point above the quantity is a crossing too")
+	assert.Equal(t, 2, notifier.count())
+
+	// A deleted product is no longer low on stock.
+	require.NoError(t, productService.DeleteProduct(ctx, lamp.Id, model.Precondition{}))
+	_, err = lowStockService.EvaluateLowStock(ctx)
+	require.NoError(t, err)
+	alerts, err = lowStockService.ListLowStockAlerts(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, alerts.Data)
+}
+
+func TestLowStockEvaluatedAfterStockChange(t *testing.T) {
+	productService, db := newProductService(t)
+	notifier := &recordingNotifier{}
+	lowStockService := service.NewLowStockService(repository.NewBaseRepository(db, productrepository.New(db)), noop.NewTracerProvider().Tracer("test"), notifier)
+	productService.SetLowStockService(lowStockService)
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go lowStockService.Run(runCtx, time.Hour)
+
+	ctx := newContext()
+	reorderPoint := int64(3)
+	desk, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Desk", Quantity: 5, Price: model.MustParseMoney("120"), ReorderPoint: &reorderPoint})
+	require.NoError(t, err)
+	_, err = productService.AdjustStock(ctx, desk.Id, model.StockAdjustRequest{Delta: -4, Reason: model.StockReasonShip})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool { return notifier.count() == 1 }, 5*time.Second, 10*time.Millisecond,
+		"the evaluator runs after a stock change without waiting for its interval")
+}
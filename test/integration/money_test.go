@@ -0,0 +1,128 @@
+//go:build sqlite_fts5
+
+package integration
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// migrateWithRealPrices migrates a fresh database up to the last REAL price schema and inserts prices.
+func migrateWithRealPrices(t *testing.T, prices ...float64) *sql.DB {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db")+"?_foreign_keys=on")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	goose.SetBaseFS(os.DirFS("../.."))
+	require.NoError(t, goose.SetDialect("sqlite3"))
+	require.NoError(t, goose.UpTo(db, "sql/migrations", 8))
+
+	for _, price := range prices {
+		_, err := db.Exec(`INSERT INTO products (name, quantity, price, created_at) VALUES ('Item', 1, ?, ?)`, price, time.Now())
+		require.NoError(t, err)
+	}
+	return db
+}
+
+func TestMoneyMigrationIsLossless(t *testing.T) {
+	db := migrateWithRealPrices(t, 10.99, 0.1+0.2, 19.9, 1234567.89)
+	_, err := db.Exec(`UPDATE products SET price = 11.01, updated_at = ? WHERE id = 1`, time.Now())
+	require.NoError(t, err)
+
+	require.NoError(t, goose.Up(db, "sql/migrations"))
+
+	rows, err := db.Query(`SELECT price_minor, currency FROM products ORDER BY id`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var prices []int64
+	for rows.Next() {
+		var price int64
+		var currency string
+		require.NoError(t, rows.Scan(&price, &currency))
+		assert.Equal(t, "USD", currency)
+		prices = append(prices, price)
+	}
+	assert.Equal(t, []int64{1101, 30, 1990, 123456789}, prices)
+
+	var oldPrice, newPrice int64
+	require.NoError(t, db.QueryRow(`SELECT old_price_minor, new_price_minor FROM price_history WHERE product_id = 1 AND old_price_minor IS NOT NULL`).Scan(&oldPrice, &newPrice))
+	assert.Equal(t, int64(1099), oldPrice)
+	assert.Equal(t, int64(1101), newPrice)
+}
+
+func TestMoneyMigrationRoundsFractionalCents(t *testing.T) {
+	db := migrateWithRealPrices(t, 10.99, 10.996)
+
+	require.NoError(t, goose.Up(db, "sql/migrations"))
+
+	var price int64
+	require.NoError(t, db.QueryRow(`SELECT price_minor FROM products WHERE id = 2`).Scan(&price))
+	assert.Equal(t, int64(1100), price)
+
+	// Note : The product and its first price history row were both rounded, the whole prices were not.
+	rows, err := db.Query(`SELECT table_name, row_id, column_name, original_price, rounded_price_minor FROM money_migration_roundings ORDER BY id`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var rounded []string
+	for rows.Next() {
+		var table, column string
+		var rowId, minor int64
+		var original float64
+		require.NoError(t, rows.Scan(&table, &rowId, &column, &original, &minor))
+		assert.Equal(t, 10.996, original)
+		assert.Equal(t, int64(1100), minor)
+		rounded = append(rounded, table+"."+column)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"products.price", "price_history.new_price"}, rounded)
+}
+
+func TestProductCurrencies(t *testing.T) {
+	productService, _ := newProductService(t)
+	ctx := newContext()
+
+	tea, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Tea", Quantity: 5, Price: model.MustParseMoney("1200"), Currency: "JPY"})
+	require.NoError(t, err)
+	assert.Equal(t, "1200", tea.Price.String())
+	coffee, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Coffee", Quantity: 5, Price: model.MustParseMoney("8.5")})
+	require.NoError(t, err)
+	assert.Equal(t, "8.50", coffee.Price.String())
+	assert.Equal(t, model.DefaultCurrency, coffee.Currency)
+
+	minPrice := model.MustParseMoney("1000")
+	request := model.ProductListRequest{MinPrice: &minPrice, Currency: "JPY"}
+	require.NoError(t, request.Validate())
+	page, err := productService.GetProducts(ctx, request)
+	require.NoError(t, err)
+	require.Len(t, page.Data, 1)
+	assert.Equal(t, tea.Id, page.Data[0].Id)
+
+	// A price without a currency is checked against the currency the product already has.
+	price := model.MustParseMoney("1199.5")
+	_, err = productService.PatchProduct(ctx, tea.Id, model.ProductPatchRequest{Price: &price}, model.Precondition{})
+	assert.Equal(t, apperror.Validation, apperror.KindOf(err))
+
+	price, currency := model.MustParseMoney("9.95"), "EUR"
+	patched, err := productService.PatchProduct(ctx, tea.Id, model.ProductPatchRequest{Price: &price, Currency: &currency}, model.Precondition{})
+	require.NoError(t, err)
+	assert.Equal(t, "9.95", patched.Price.String())
+	assert.Equal(t, "EUR", patched.Currency)
+
+	history, err := productService.GetPriceHistory(ctx, tea.Id)
+	require.NoError(t, err)
+	require.Len(t, history.History, 2)
+	assert.Equal(t, "1200", history.History[1].OldPrice.String())
+	assert.Equal(t, "JPY", history.History[1].OldCurrency)
+	assert.Equal(t, "EUR", history.History[1].Currency)
+}
@@ -0,0 +1,255 @@
+//go:build sqlite_fts5
+
+package integration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/repository"
+	productrepository "github.com/indrabrata/observability-playground/repository/product"
+	"github.com/indrabrata/observability-playground/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestOrders(t *testing.T) {
+	productService, db := newProductService(t)
+	orderService := service.NewOrderService(repository.NewBaseRepository(db, productrepository.New(db)), noop.NewTracerProvider().Tracer("test"))
+	ctx := newContext()
+
+	quantity := func(id int64) int64 {
+		t.Helper()
+		product, err := productService.GetProduct(ctx, id)
+		require.NoError(t, err)
+		return product.Quantity
+	}
+
+	mug, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Mug", Quantity: 10, Price: model.MustParseMoney("8.50"), Currency: "USD"})
+	require.NoError(t, err)
+	kettle, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Kettle", Quantity: 2, Price: model.MustParseMoney("40"), Currency: "USD"})
+	require.NoError(t, err)
+	teapot, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Teapot", Quantity: 5, Price: model.MustParseMoney("30"), Currency: "EUR"})
+	require.NoError(t, err)
+
+	// One line short of stock places nothing.
+	_, err = orderService.PlaceOrder(ctx, model.OrderRequest{Lines: []model.OrderLineRequest{
+		{ProductId: mug.Id, Quantity: 2},
+		{ProductId: kettle.Id, Quantity: 3},
+	}})
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err))
+	assert.Equal(t, int64(10), quantity(mug.Id))
+
+	_, err = orderService.PlaceOrder(ctx, model.OrderRequest{Lines: []model.OrderLineRequest{
+		{ProductId: mug.Id, Quantity: 1},
+		{ProductId: teapot.Id, Quantity: 1},
+	}})
+	assert.Equal(t, apperror.Unprocessable, apperror.KindOf(err))
+	assert.Equal(t, int64(10), quantity(mug.Id))
+
+	_, err = orderService.PlaceOrder(ctx, model.OrderRequest{Lines: []model.OrderLineRequest{{ProductId: 999, Quantity: 1}}})
+	assert.Equal(t, apperror.NotFound, apperror.KindOf(err))
+
+	shipped, err := orderService.PlaceOrder(ctx, model.OrderRequest{Lines: []model.OrderLineRequest{
+		{ProductId: mug.Id, Quantity: 2},
+		{ProductId: kettle.Id, Quantity: 1},
+	}, Reference: "web-1"})
+	require.NoError(t, err)
+	assert.Equal(t, model.OrderStatusPending, shipped.Status)
+	assert.Equal(t, "57.00", shipped.Total.String())
+	assert.Equal(t, "USD", shipped.Currency)
+	require.Len(t, shipped.Lines, 2)
+	assert.Equal(t, "17.00", shipped.Lines[0].Amount.String())
+	assert.Equal(t, int64(8), quantity(mug.Id))
+	assert.Equal(t, int64(1), quantity(kettle.Id))
+
+	// Prices are kept as they were when the order was placed.
+	price := model.MustParseMoney("9")
+	_, err = productService.PatchProduct(ctx, mug.Id, model.ProductPatchRequest{Price: &price}, model.Precondition{})
+	require.NoError(t, err)
+
+	_, err = orderService.ShipOrder(ctx, shipped.Id)
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err), "a pending order is not shipped")
+	shipped, err = orderService.PayOrder(ctx, shipped.Id)
+	require.NoError(t, err)
+	assert.Equal(t, model.OrderStatusPaid, shipped.Status)
+	shipped, err = orderService.ShipOrder(ctx, shipped.Id)
+	require.NoError(t, err)
+	assert.Equal(t, model.OrderStatusShipped, shipped.Status)
+	assert.Equal(t, "8.50", shipped.Lines[0].UnitPrice.String())
+
+	_, err = orderService.CancelOrder(ctx, shipped.Id)
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err), "a shipped order is not cancelled")
+	_, err = orderService.PayOrder(ctx, shipped.Id)
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err))
+	assert.Equal(t, int64(8), quantity(mug.Id))
+
+	statuses := make([]string, 0, len(shipped.Transitions))
+	for _, transition := range shipped.Transitions {
+		statuses = append(statuses, transition.From+">"+transition.To)
+		assert.Equal(t, "test-123", transition.RequestId)
+	}
+	assert.Equal(t, []string{">pending", "pending>paid", "paid>shipped"}, statuses)
+
+	cancelled, err := orderService.PlaceOrder(ctx, model.OrderRequest{Lines: []model.OrderLineRequest{{ProductId: mug.Id, Quantity: 3}}})
+	require.NoError(t, err)
+	assert.Equal(t, "27.00", cancelled.Total.String())
+	assert.Equal(t, int64(5), quantity(mug.Id))
+	cancelled, err = orderService.PayOrder(ctx, cancelled.Id)
+	require.NoError(t, err)
+	cancelled, err = orderService.CancelOrder(ctx, cancelled.Id)
+	require.NoError(t, err)
+	assert.Equal(t, model.OrderStatusCancelled, cancelled.Status)
+	assert.Equal(t, int64(8), quantity(mug.Id), "cancelling puts the units back")
+
+	_, err = orderService.GetOrder(ctx, 999)
+	assert.Equal(t, apperror.NotFound, apperror.KindOf(err))
+
+	list := func(request model.OrderListRequest) model.OrderListResponse {
+		t.Helper()
+		require.NoError(t, request.Validate())
+		orders, err := orderService.ListOrders(ctx, request)
+		require.NoError(t, err)
+		return orders
+	}
+
+	_, err = orderService.PlaceOrder(ctx, model.OrderRequest{Lines: []model.OrderLineRequest{{ProductId: mug.Id, Quantity: 1}}})
+	require.NoError(t, err)
+
+	page := list(model.OrderListRequest{Limit: 2})
+	require.Len(t, page.Data, 2)
+	require.True(t, page.Paging.HasMore)
+	assert.Empty(t, page.Data[0].Lines, "lists leave the lines out")
+	page = list(model.OrderListRequest{Limit: 2, Cursor: page.Paging.NextCursor})
+	require.Len(t, page.Data, 1)
+	assert.False(t, page.Paging.HasMore)
+	assert.Equal(t, shipped.Id, page.Data[0].Id)
+
+	page = list(model.OrderListRequest{Status: model.OrderStatusCancelled})
+	require.Len(t, page.Data, 1)
+	assert.Equal(t, cancelled.Id, page.Data[0].Id)
+}
+
+func TestCancelOrderOfDeletedStock(t *testing.T) {
+	productService, db := newProductService(t)
+	orderService := service.NewOrderService(repository.NewBaseRepository(db, productrepository.New(db)), noop.NewTracerProvider().Tracer("test"))
+	ctx := newContext()
+
+	shirt, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Shirt", Quantity: 0, Price: model.MustParseMoney("20"), Currency: "USD"})
+	require.NoError(t, err)
+	red, err := productService.CreateVariant(ctx, shirt.Id, model.VariantRequest{Sku: "SHIRT-RED", Options: map[string]string{"colour": "red"}, Quantity: 3})
+	require.NoError(t, err)
+	order, err := orderService.PlaceOrder(ctx, model.OrderRequest{Lines: []model.OrderLineRequest{{ProductId: shirt.Id, VariantId: &red.Id, Quantity: 2}}})
+	require.NoError(t, err)
+
+	// A variant that is gone cannot take its units back, so nothing of the cancellation is kept.
+	_, err = db.Exec(`UPDATE product_variants SET deleted_at = ? WHERE id = ?`, time.Now().UTC(), red.Id)
+	require.NoError(t, err)
+	_, err = orderService.CancelOrder(ctx, order.Id)
+	assert.Equal(t, apperror.NotFound, apperror.KindOf(err))
+	product, err := productService.GetProduct(ctx, shirt.Id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), product.Quantity)
+	order, err = orderService.GetOrder(ctx, order.Id)
+	require.NoError(t, err)
+	assert.Equal(t, model.OrderStatusPending, order.Status)
+
+	// A product that is gone has nothing to give the units back to, its lines are left out.
+	lamp, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Lamp", Quantity: 2, Price: model.MustParseMoney("15"), Currency: "USD"})
+	require.NoError(t, err)
+	order, err = orderService.PlaceOrder(ctx, model.OrderRequest{Lines: []model.OrderLineRequest{{ProductId: lamp.Id, Quantity: 1}}})
+	require.NoError(t, err)
+	require.NoError(t, productService.DeleteProduct(ctx, lamp.Id, model.Precondition{}))
+	order, err = orderService.CancelOrder(ctx, order.Id)
+	require.NoError(t, err)
+	assert.Equal(t, model.OrderStatusCancelled, order.Status)
+}
+
+func TestDeleteStockOfOpenOrder(t *testing.T) {
+	productService, db := newProductService(t)
+	tracer := noop.NewTracerProvider().Tracer("test")
+	orderService := service.NewOrderService(repository.NewBaseRepository(db, productrepository.New(db)), tracer)
+	warehouseService := service.NewWarehouseService(repository.NewBaseRepository(db, productrepository.New(db)), tracer)
+	ctx := newContext()
+
+	amsterdam, err := warehouseService.CreateWarehouse(ctx, model.WarehouseRequest{Code: "AMS-1", Name: "Amsterdam"})
+	require.NoError(t, err)
+	shirt, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Shirt", Quantity: 0, Price: model.MustParseMoney("20"), Currency: "USD"})
+	require.NoError(t, err)
+	red, err := productService.CreateVariant(ctx, shirt.Id, model.VariantRequest{Sku: "SHIRT-RED", Options: map[string]string{"colour": "red"}, Quantity: 0})
+	require.NoError(t, err)
+	_, err = productService.AdjustStock(ctx, shirt.Id, model.StockAdjustRequest{VariantId: &red.Id, WarehouseId: &amsterdam.Id, Delta: 2, Reason: model.StockReasonReceive})
+	require.NoError(t, err)
+
+	// The order empties the variant and the warehouse, which are still needed to cancel it.
+	order, err := orderService.PlaceOrder(ctx, model.OrderRequest{Lines: []model.OrderLineRequest{
+		{ProductId: shirt.Id, VariantId: &red.Id, WarehouseId: &amsterdam.Id, Quantity: 2},
+	}})
+	require.NoError(t, err)
+	err = productService.DeleteVariant(ctx, shirt.Id, red.Id)
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err), "a variant on a pending order cannot be deleted")
+	_, err = orderService.PayOrder(ctx, order.Id)
+	require.NoError(t, err)
+	err = warehouseService.DeleteWarehouse(ctx, amsterdam.Id)
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err), "a warehouse on a paid order cannot be deleted")
+
+	// Once shipped the order is no longer open.
+	_, err = orderService.ShipOrder(ctx, order.Id)
+	require.NoError(t, err)
+	require.NoError(t, productService.DeleteVariant(ctx, shirt.Id, red.Id))
+	require.NoError(t, warehouseService.DeleteWarehouse(ctx, amsterdam.Id))
+}
+
+func TestOrderPricing(t *testing.T) {
+	productService, db := newProductService(t)
+	tracer := noop.NewTracerProvider().Tracer("test")
+	orderService := service.NewOrderService(repository.NewBaseRepository(db, productrepository.New(db)), tracer)
+	pricingService := service.NewPricingService(repository.NewBaseRepository(db, productrepository.New(db)), tracer)
+	ctx := newContext()
+
+	mug, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Mug", Quantity: 10, Price: model.MustParseMoney("8.50"), Currency: "USD"})
+	require.NoError(t, err)
+	kettle, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Kettle", Quantity: 2, Price: model.MustParseMoney("40"), Currency: "USD"})
+	require.NoError(t, err)
+	rule := model.PricingRuleRequest{Name: "Bulk mugs", Type: model.PricingRuleTypePercentage, ProductId: &mug.Id,
+		Tiers: []model.PricingTierRequest{{MinQuantity: 3, Value: model.MustParseMoney("20")}}}
+	require.NoError(t, rule.Validate())
+	_, err = pricingService.CreatePricingRule(ctx, rule)
+	require.NoError(t, err)
+
+	// Only the line that reaches the tier is discounted, and the total adds up the discounted amount.
+	order, err := orderService.PlaceOrder(ctx, model.OrderRequest{Lines: []model.OrderLineRequest{
+		{ProductId: mug.Id, Quantity: 3},
+		{ProductId: kettle.Id, Quantity: 1},
+	}})
+	require.NoError(t, err)
+	require.Len(t, order.Lines, 2)
+	assert.Equal(t, "6.80", order.Lines[0].UnitPrice.String())
+	assert.Equal(t, "20.40", order.Lines[0].Amount.String())
+	assert.Equal(t, "40.00", order.Lines[1].UnitPrice.String())
+	assert.Equal(t, "60.40", order.Total.String())
+
+	// Amounts that do not fit in minor units are refused rather than wrapped around.
+	vault, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Vault", Quantity: 100, Price: model.MustParseMoney("9000000000000000.00"), Currency: "USD"})
+	require.NoError(t, err)
+	_, err = orderService.PlaceOrder(ctx, model.OrderRequest{Lines: []model.OrderLineRequest{{ProductId: vault.Id, Quantity: 11}}})
+	assert.Equal(t, apperror.Unprocessable, apperror.KindOf(err))
+	_, err = orderService.PlaceOrder(ctx, model.OrderRequest{Lines: []model.OrderLineRequest{
+		{ProductId: vault.Id, Quantity: 5},
+		{ProductId: vault.Id, Quantity: 6},
+	}})
+	assert.Equal(t, apperror.Unprocessable, apperror.KindOf(err))
+	_, err = pricingService.QuotePrice(ctx, vault.Id, model.PriceQuoteRequest{Quantity: 11})
+	assert.Equal(t, apperror.Unprocessable, apperror.KindOf(err))
+
+	quote, err := pricingService.QuotePrice(ctx, vault.Id, model.PriceQuoteRequest{Quantity: 10})
+	require.NoError(t, err)
+	assert.Equal(t, "90000000000000000.00", quote.Total.String())
+
+	product, err := productService.GetProduct(ctx, vault.Id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), product.Quantity)
+}
@@ -0,0 +1,199 @@
+//go:build sqlite_fts5
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/repository"
+	productrepository "github.com/indrabrata/observability-playground/repository/product"
+	"github.com/indrabrata/observability-playground/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+type recordingSink struct {
+	mu     sync.Mutex
+	fail   bool
+	events []model.DomainEvent
+}
+
+func (s *recordingSink) Name() string {
+	return "recording"
+}
+
+func (s *recordingSink) Publish(ctx context.Context, event model.DomainEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail {
+		return errors.New("sink unavailable")
+	}
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *recordingSink) setFail(fail bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fail = fail
+}
+
+func (s *recordingSink) types() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	types := make([]string, 0, len(s.events))
+	for _, event := range s.events {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+func TestOutboxRelay(t *testing.T) {
+	productService, db := newProductService(t)
+	sink := &recordingSink{}
+	relay := service.NewOutboxRelay(repository.NewBaseRepository(db, productrepository.New(db)), noop.NewTracerProvider().Tracer("test"), sink)
+
+	// The event keeps the trace of the request that made the change.
+	traceId, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanId, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(newContext(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceId,
+		SpanID:     spanId,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	lamp, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Lamp", Quantity: 4, Price: model.MustParseMoney("20")})
+	require.NoError(t, err)
+	name := "Desk Lamp"
+	_, err = productService.PatchProduct(ctx, lamp.Id, model.ProductPatchRequest{Name: &name}, model.Precondition{})
+	require.NoError(t, err)
+	require.NoError(t, productService.DeleteProduct(ctx, lamp.Id, model.Precondition{}))
+	_, err = productService.RestoreProduct(ctx, lamp.Id)
+	require.NoError(t, err)
+
+	// A change that is rolled back leaves no event behind.
+	_, err = productService.UpdateProduct(ctx, lamp.Id, model.ProductRequest{Name: "Lamp", Quantity: 4, Price: model.MustParseMoney("20")}, model.Precondition{IfMatch: true, Versions: []int64{1}})
+	require.Error(t, err)
+
+	// A failing sink keeps every event pending.
+	sink.setFail(true)
+	dispatched, err := relay.RelayOutbox(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), dispatched)
+
+	var attempts int64
+	var lastError string
+	require.NoError(t, db.QueryRow("SELECT attempts, last_error FROM outbox_events ORDER BY id LIMIT 1").Scan(&attempts, &lastError))
+	assert.Equal(t, int64(1), attempts)
+	assert.Equal(t, "sink unavailable", lastError)
+
+	dispatched, err = relay.RelayOutbox(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), dispatched, "a failed event waits for its retry")
+
+	// Once the retry is due the events are delivered in the order they were written.
+	sink.setFail(false)
+	_, err = db.Exec("UPDATE outbox_events SET next_attempt_at = ?", time.Now().UTC().Add(-time.Second))
+	require.NoError(t, err)
+	dispatched, err = relay.RelayOutbox(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), dispatched)
+	assert.Equal(t, []string{model.EventProductCreated, model.EventProductUpdated, model.EventProductDeleted, model.EventProductRestored}, sink.types())
+
+	dispatched, err = relay.RelayOutbox(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), dispatched, "dispatched events are not sent again")
+
+	created := sink.events[0]
+	assert.Equal(t, model.AggregateProduct, created.AggregateType)
+	assert.Equal(t, lamp.Id, created.AggregateId)
+	assert.Equal(t, "test-123", created.RequestId)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", created.TraceParent)
+
+	var payload model.ProductResponse
+	require.NoError(t, json.Unmarshal(sink.events[1].Payload, &payload))
+	assert.Equal(t, "Desk Lamp", payload.Name)
+	assert.Equal(t, int64(2), payload.Version)
+
+	var deleted model.ProductDeletedPayload
+	require.NoError(t, json.Unmarshal(sink.events[2].Payload, &deleted))
+	assert.Equal(t, lamp.Id, deleted.Id)
+}
+
+func TestStockChangesWriteOutboxEvents(t *testing.T) {
+	productService, db := newProductService(t)
+	sink := &recordingSink{}
+	relay := service.NewOutboxRelay(repository.NewBaseRepository(db, productrepository.New(db)), noop.NewTracerProvider().Tracer("test"), sink)
+	ctx := newContext()
+
+	lamp, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Lamp", Quantity: 4, Price: model.MustParseMoney("20")})
+	require.NoError(t, err)
+	_, err = productService.AdjustStock(ctx, lamp.Id, model.StockAdjustRequest{Delta: -3, Reason: "sold"})
+	require.NoError(t, err)
+
+	// Stock that is not there leaves no event behind.
+	_, err = productService.AdjustStock(ctx, lamp.Id, model.StockAdjustRequest{Delta: -2, Reason: "sold"})
+	require.Error(t, err)
+
+	dispatched, err := relay.RelayOutbox(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), dispatched)
+	assert.Equal(t, []string{model.EventProductCreated, model.EventProductUpdated}, sink.types())
+
+	var payload model.ProductResponse
+	require.NoError(t, json.Unmarshal(sink.events[1].Payload, &payload))
+	assert.Equal(t, int64(1), payload.Quantity)
+	assert.Equal(t, int64(2), payload.Version)
+	assert.Equal(t, "test-123", sink.events[1].RequestId)
+}
+
+func TestTransferWritesOneEventPerProduct(t *testing.T) {
+	productService, db := newProductService(t)
+	tracer := noop.NewTracerProvider().Tracer("test")
+	warehouseService := service.NewWarehouseService(repository.NewBaseRepository(db, productrepository.New(db)), tracer)
+	sink := &recordingSink{}
+	relay := service.NewOutboxRelay(repository.NewBaseRepository(db, productrepository.New(db)), tracer, sink)
+	ctx := newContext()
+
+	amsterdam, err := warehouseService.CreateWarehouse(ctx, model.WarehouseRequest{Code: "AMS-1", Name: "Amsterdam"})
+	require.NoError(t, err)
+	shirt, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Shirt", Quantity: 0, Price: model.MustParseMoney("20")})
+	require.NoError(t, err)
+	red, err := productService.CreateVariant(ctx, shirt.Id, model.VariantRequest{Sku: "SHIRT-RED", Options: map[string]string{"colour": "red"}, Quantity: 2})
+	require.NoError(t, err)
+	blue, err := productService.CreateVariant(ctx, shirt.Id, model.VariantRequest{Sku: "SHIRT-BLUE", Options: map[string]string{"colour": "blue"}, Quantity: 3})
+	require.NoError(t, err)
+	before, err := productService.GetProduct(ctx, shirt.Id)
+	require.NoError(t, err)
+	_, err = relay.RelayOutbox(ctx)
+	require.NoError(t, err)
+	sink.events = nil
+
+	// Both variants move in one transfer, the product is bumped once.
+	_, err = warehouseService.CreateTransfer(ctx, model.TransferRequest{
+		ToWarehouseId: &amsterdam.Id,
+		Items: []model.TransferItemRequest{
+			{ProductId: shirt.Id, VariantId: &red.Id, Quantity: 2},
+			{ProductId: shirt.Id, VariantId: &blue.Id, Quantity: 1},
+		},
+	})
+	require.NoError(t, err)
+
+	dispatched, err := relay.RelayOutbox(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), dispatched)
+	assert.Equal(t, []string{model.EventProductUpdated}, sink.types())
+
+	var payload model.ProductResponse
+	require.NoError(t, json.Unmarshal(sink.events[0].Payload, &payload))
+	assert.Equal(t, before.Version+1, payload.Version)
+	assert.Equal(t, int64(5), payload.Quantity)
+}
@@ -0,0 +1,84 @@
+//go:build sqlite_fts5
+
+package integration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriceHistory(t *testing.T) {
+	productService, _ := newProductService(t)
+	ctx := newContext()
+
+	kettle, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Kettle", Quantity: 4, Price: model.MustParseMoney("30")})
+	require.NoError(t, err)
+
+	// A quantity only update leaves the price timeline alone.
+	_, err = productService.UpdateProduct(ctx, kettle.Id, model.ProductRequest{Name: "Kettle", Quantity: 6, Price: model.MustParseMoney("30")}, model.Precondition{})
+	require.NoError(t, err)
+	_, err = productService.UpdateProduct(ctx, kettle.Id, model.ProductRequest{Name: "Kettle", Quantity: 6, Price: model.MustParseMoney("35")}, model.Precondition{})
+	require.NoError(t, err)
+	price := model.MustParseMoney("32.5")
+	_, err = productService.PatchProduct(ctx, kettle.Id, model.ProductPatchRequest{Price: &price}, model.Precondition{})
+	require.NoError(t, err)
+
+	future, err := productService.SchedulePrice(ctx, kettle.Id, model.ScheduledPriceRequest{Price: model.MustParseMoney("25"), EffectiveAt: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	due, err := productService.SchedulePrice(ctx, kettle.Id, model.ScheduledPriceRequest{Price: model.MustParseMoney("28"), EffectiveAt: time.Now().Add(-time.Second)})
+	require.NoError(t, err)
+	withdrawn, err := productService.SchedulePrice(ctx, kettle.Id, model.ScheduledPriceRequest{Price: model.MustParseMoney("1"), EffectiveAt: time.Now().Add(-time.Second)})
+	require.NoError(t, err)
+	require.NoError(t, productService.CancelScheduledPrice(ctx, kettle.Id, withdrawn.Id))
+	assert.Equal(t, apperror.NotFound, apperror.KindOf(productService.CancelScheduledPrice(ctx, kettle.Id, withdrawn.Id)))
+
+	applied, err := productService.ApplyScheduledPrices(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), applied)
+
+	current, err := productService.GetProduct(ctx, kettle.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "28.00", current.Price.String())
+
+	history, err := productService.GetPriceHistory(ctx, kettle.Id)
+	require.NoError(t, err)
+	require.Len(t, history.History, 4)
+	assert.Nil(t, history.History[0].OldPrice)
+	newPrices := make([]string, 0, len(history.History))
+	for _, change := range history.History {
+		newPrices = append(newPrices, change.NewPrice.String())
+	}
+	assert.Equal(t, []string{"30.00", "35.00", "32.50", "28.00"}, newPrices)
+	assert.Equal(t, "32.50", history.History[3].OldPrice.String())
+
+	require.Len(t, history.Scheduled, 1)
+	assert.Equal(t, future.Id, history.Scheduled[0].Id)
+	assert.NotEqual(t, due.Id, history.Scheduled[0].Id)
+
+	// A price that becomes due after its product was deleted is skipped instead of applied.
+	toaster, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Toaster", Quantity: 2, Price: model.MustParseMoney("45")})
+	require.NoError(t, err)
+	_, err = productService.SchedulePrice(ctx, toaster.Id, model.ScheduledPriceRequest{Price: model.MustParseMoney("40"), EffectiveAt: time.Now().Add(-time.Second)})
+	require.NoError(t, err)
+	require.NoError(t, productService.DeleteProduct(ctx, toaster.Id, model.Precondition{}))
+
+	_, err = productService.SchedulePrice(ctx, toaster.Id, model.ScheduledPriceRequest{Price: model.MustParseMoney("20"), EffectiveAt: time.Now().Add(time.Hour)})
+	assert.Equal(t, apperror.NotFound, apperror.KindOf(err))
+
+	applied, err = productService.ApplyScheduledPrices(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), applied)
+
+	restored, err := productService.RestoreProduct(ctx, toaster.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "45.00", restored.Price.String())
+	history, err = productService.GetPriceHistory(ctx, toaster.Id)
+	require.NoError(t, err)
+	assert.Len(t, history.History, 1)
+	assert.Empty(t, history.Scheduled)
+}
@@ -0,0 +1,146 @@
+//go:build sqlite_fts5
+
+package integration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/repository"
+	productrepository "github.com/indrabrata/observability-playground/repository/product"
+	"github.com/indrabrata/observability-playground/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestPricingRules(t *testing.T) {
+	productService, db := newProductService(t)
+	tracer := noop.NewTracerProvider().Tracer("test")
+	pricingService := service.NewPricingService(repository.NewBaseRepository(db, productrepository.New(db)), tracer)
+	categoryService := service.NewCategoryService(repository.NewBaseRepository(db, productrepository.New(db)), tracer)
+	orderService := service.NewOrderService(repository.NewBaseRepository(db, productrepository.New(db)), tracer)
+	ctx := newContext()
+
+	kitchen, err := categoryService.CreateCategory(ctx, model.CategoryRequest{Name: "Kitchen"})
+	require.NoError(t, err)
+	mugs, err := categoryService.CreateCategory(ctx, model.CategoryRequest{Name: "Mugs", ParentId: &kitchen.Id})
+	require.NoError(t, err)
+
+	mug, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Mug", Quantity: 100, Price: model.MustParseMoney("10"), Currency: "USD"})
+	require.NoError(t, err)
+	_, err = productService.SetProductCategories(ctx, mug.Id, model.ProductCategoriesRequest{CategoryIds: []int64{mugs.Id}})
+	require.NoError(t, err)
+	kettle, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Kettle", Quantity: 5, Price: model.MustParseMoney("33.33"), Currency: "USD"})
+	require.NoError(t, err)
+
+	create := func(request model.PricingRuleRequest) model.PricingRuleResponse {
+		t.Helper()
+		require.NoError(t, request.Validate())
+		rule, err := pricingService.CreatePricingRule(ctx, request)
+		require.NoError(t, err)
+		return rule
+	}
+	quote := func(productId, quantity int64) model.PriceQuoteResponse {
+		t.Helper()
+		quote, err := pricingService.QuotePrice(ctx, productId, model.PriceQuoteRequest{Quantity: quantity})
+		require.NoError(t, err)
+		return quote
+	}
+	ruleIds := func(quote model.PriceQuoteResponse) []int64 {
+		ids := []int64{}
+		for _, rule := range quote.AppliedRules {
+			ids = append(ids, rule.RuleId)
+		}
+		return ids
+	}
+
+	now := time.Now()
+	starts, ends := now.Add(-time.Hour), now.Add(time.Hour)
+	later := now.Add(24 * time.Hour)
+
+	bulk := create(model.PricingRuleRequest{Name: "Bulk mugs", Type: model.PricingRuleTypePercentage, ProductId: &mug.Id, Tiers: []model.PricingTierRequest{
+		{MinQuantity: 50, Value: model.MustParseMoney("20")},
+		{MinQuantity: 10, Value: model.MustParseMoney("10")},
+	}})
+	assert.Equal(t, int64(10), bulk.Tiers[0].MinQuantity, "tiers are sorted by quantity")
+	assert.Equal(t, "10.00", bulk.Tiers[0].Value.String())
+
+	promotion := create(model.PricingRuleRequest{Name: "Kitchen week", Type: model.PricingRuleTypeFixed, CategoryId: &kitchen.Id, Currency: "USD",
+		Tiers: []model.PricingTierRequest{{MinQuantity: 1, Value: model.MustParseMoney("0.50")}}, StartsAt: &starts, EndsAt: &ends})
+	create(model.PricingRuleRequest{Name: "Next week", Type: model.PricingRuleTypePercentage, CategoryId: &kitchen.Id,
+		Tiers: []model.PricingTierRequest{{MinQuantity: 1, Value: model.MustParseMoney("5")}}, StartsAt: &later})
+	create(model.PricingRuleRequest{Name: "Euro kitchen", Type: model.PricingRuleTypeFixed, CategoryId: &kitchen.Id, Currency: "EUR",
+		Tiers: []model.PricingTierRequest{{MinQuantity: 1, Value: model.MustParseMoney("1")}}})
+
+	// Below the first bulk tier only the running promotion of the parent category applies.
+	single := quote(mug.Id, 1)
+	assert.Equal(t, "10.00", single.BasePrice.String())
+	assert.Equal(t, "9.50", single.UnitPrice.String())
+	assert.Equal(t, []int64{promotion.Id}, ruleIds(single))
+	assert.Equal(t, model.PricingRuleScopeCategory, single.AppliedRules[0].Scope)
+	assert.Equal(t, kitchen.Id, *single.AppliedRules[0].CategoryId)
+
+	// Percentages apply before fixed amounts.
+	ten := quote(mug.Id, 10)
+	assert.Equal(t, "8.50", ten.UnitPrice.String())
+	assert.Equal(t, "85.00", ten.Total.String())
+	assert.Equal(t, "15.00", ten.Discount.String())
+	assert.Equal(t, []int64{bulk.Id, promotion.Id}, ruleIds(ten))
+	assert.Equal(t, "1.00", ten.AppliedRules[0].UnitDiscount.String())
+	assert.Equal(t, int64(10), ten.AppliedRules[0].MinQuantity)
+
+	fifty := quote(mug.Id, 50)
+	assert.Equal(t, "7.50", fifty.UnitPrice.String())
+	assert.Equal(t, "20.00", fifty.AppliedRules[0].Value.String())
+
+	assert.Empty(t, quote(kettle.Id, 1).AppliedRules)
+	create(model.PricingRuleRequest{Name: "Kettle deal", Type: model.PricingRuleTypePercentage, ProductId: &kettle.Id,
+		Tiers: []model.PricingTierRequest{{MinQuantity: 1, Value: model.MustParseMoney("12.5")}}})
+	assert.Equal(t, "29.16", quote(kettle.Id, 1).UnitPrice.String(), "4.16625 off is rounded to 4.17")
+
+	// Orders are placed at the quoted price.
+	order, err := orderService.PlaceOrder(ctx, model.OrderRequest{Lines: []model.OrderLineRequest{{ProductId: mug.Id, Quantity: 10}}})
+	require.NoError(t, err)
+	assert.Equal(t, "85.00", order.Total.String())
+	assert.Equal(t, "8.50", order.Lines[0].UnitPrice.String())
+
+	// A rule can be rescoped and retiered, and is gone once deleted.
+	updated, err := pricingService.UpdatePricingRule(ctx, bulk.Id, model.PricingRuleRequest{Name: "Bulk mugs", Type: model.PricingRuleTypeFixed, ProductId: &mug.Id, Currency: "USD",
+		Tiers: []model.PricingTierRequest{{MinQuantity: 5, Value: model.MustParseMoney("2")}}})
+	require.NoError(t, err)
+	require.Len(t, updated.Tiers, 1)
+	assert.Equal(t, "7.50", quote(mug.Id, 5).UnitPrice.String(), "two fixed amounts off")
+
+	require.NoError(t, pricingService.DeletePricingRule(ctx, promotion.Id))
+	assert.Equal(t, "10.00", quote(mug.Id, 1).UnitPrice.String())
+	assert.Equal(t, apperror.NotFound, apperror.KindOf(pricingService.DeletePricingRule(ctx, promotion.Id)))
+
+	rules, err := pricingService.ListPricingRules(ctx)
+	require.NoError(t, err)
+	require.Len(t, rules.Data, 4)
+	assert.Equal(t, "2.00", rules.Data[0].Tiers[0].Value.String())
+
+	missing := int64(999)
+	_, err = pricingService.CreatePricingRule(ctx, model.PricingRuleRequest{Name: "Missing", Type: model.PricingRuleTypePercentage, ProductId: &missing,
+		Tiers: []model.PricingTierRequest{{MinQuantity: 1, Value: model.MustParseMoney("5")}}})
+	assert.Equal(t, apperror.NotFound, apperror.KindOf(err))
+	_, err = pricingService.QuotePrice(ctx, missing, model.PriceQuoteRequest{Quantity: 1})
+	assert.Equal(t, apperror.NotFound, apperror.KindOf(err))
+
+	// A category is only deleted once its pricing rules are.
+	err = categoryService.DeleteCategory(ctx, kitchen.Id)
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err))
+
+	for _, request := range []model.PricingRuleRequest{
+		{Name: "Both", Type: model.PricingRuleTypePercentage, ProductId: &mug.Id, CategoryId: &kitchen.Id, Tiers: []model.PricingTierRequest{{MinQuantity: 1, Value: model.MustParseMoney("5")}}},
+		{Name: "Too much", Type: model.PricingRuleTypePercentage, ProductId: &mug.Id, Tiers: []model.PricingTierRequest{{MinQuantity: 1, Value: model.MustParseMoney("150")}}},
+		{Name: "No currency", Type: model.PricingRuleTypeFixed, ProductId: &mug.Id, Tiers: []model.PricingTierRequest{{MinQuantity: 1, Value: model.MustParseMoney("1")}}},
+		{Name: "Repeated", Type: model.PricingRuleTypePercentage, ProductId: &mug.Id, Tiers: []model.PricingTierRequest{{MinQuantity: 2, Value: model.MustParseMoney("5")}, {MinQuantity: 2, Value: model.MustParseMoney("6")}}},
+		{Name: "Backwards", Type: model.PricingRuleTypePercentage, ProductId: &mug.Id, Tiers: []model.PricingTierRequest{{MinQuantity: 1, Value: model.MustParseMoney("5")}}, StartsAt: &ends, EndsAt: &starts},
+	} {
+		assert.Error(t, request.Validate(), request.Name)
+	}
+}
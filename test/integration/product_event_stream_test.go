@@ -0,0 +1,154 @@
+//go:build sqlite_fts5
+
+package integration
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/indrabrata/observability-playground/handler"
+	"github.com/indrabrata/observability-playground/middleware"
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/repository"
+	productrepository "github.com/indrabrata/observability-playground/repository/product"
+	"github.com/indrabrata/observability-playground/service"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// readServerSentEvents sends every frame of an event stream, as its fields by name, until the body is closed.
+// Comment lines are collected under the empty name.
+func readServerSentEvents(body io.Reader) <-chan map[string]string {
+	frames := make(chan map[string]string, 16)
+	go func() {
+		defer close(frames)
+		scanner := bufio.NewScanner(body)
+		frame := map[string]string{}
+		for scanner.Scan() {
+			line := scanner.Text()
+			if line == "" {
+				frames <- frame
+				frame = map[string]string{}
+				continue
+			}
+			name, value, _ := strings.Cut(line, ":")
+			frame[name] = strings.TrimPrefix(value, " ")
+		}
+	}()
+	return frames
+}
+
+func nextFrame(t *testing.T, frames <-chan map[string]string) map[string]string {
+	t.Helper()
+	select {
+	case frame, ok := <-frames:
+		require.True(t, ok, "the stream ended")
+		return frame
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no event was streamed")
+		return nil
+	}
+}
+
+// nextEvent skips heartbeats and returns the next frame that is an event.
+func nextEvent(t *testing.T, frames <-chan map[string]string) map[string]string {
+	t.Helper()
+	for {
+		if frame := nextFrame(t, frames); frame["event"] != "" {
+			return frame
+		}
+	}
+}
+
+func TestProductEventStream(t *testing.T) {
+	productService, db := newProductService(t)
+	tracer := noop.NewTracerProvider().Tracer("test")
+	stream := service.NewProductEventStream(repository.NewBaseRepository(db, productrepository.New(db)), tracer, 50*time.Millisecond)
+	relay := service.NewOutboxRelay(repository.NewBaseRepository(db, productrepository.New(db)), tracer, stream)
+	ctx := newContext()
+
+	router := chi.NewRouter()
+	router.Use(middleware.RequestIdMiddleware)
+	router.Get("/products/events", handler.NewProductEventHandler(stream, tracer, time.Second).StreamProductEvents)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	connect := func(lastEventId string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/products/events", nil)
+		require.NoError(t, err)
+		if lastEventId != "" {
+			req.Header.Set("Last-Event-ID", lastEventId)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	desk, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Desk", Quantity: 3, Price: model.MustParseMoney("120")})
+	require.NoError(t, err)
+	_, err = relay.RelayOutbox(ctx)
+	require.NoError(t, err)
+
+	// A new subscriber only gets the changes made after it connected.
+	resp := connect("")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	frames := readServerSentEvents(resp.Body)
+	assert.Equal(t, "3000", nextFrame(t, frames)["retry"])
+	assert.Equal(t, float64(1), testutil.ToFloat64(service.ProductEventSubscribers))
+
+	assert.Contains(t, nextFrame(t, frames), "", "a heartbeat is sent while there are no changes")
+
+	chair, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Chair", Quantity: 10, Price: model.MustParseMoney("45.5")})
+	require.NoError(t, err)
+	require.NoError(t, productService.DeleteProduct(ctx, desk.Id, model.Precondition{}))
+	_, err = relay.RelayOutbox(ctx)
+	require.NoError(t, err)
+
+	frame := nextEvent(t, frames)
+	assert.Equal(t, "2", frame["id"])
+	assert.Equal(t, model.EventProductCreated, frame["event"])
+	var event model.DomainEvent
+	require.NoError(t, json.Unmarshal([]byte(frame["data"]), &event))
+	assert.Equal(t, chair.Id, event.AggregateId)
+
+	frame = nextEvent(t, frames)
+	assert.Equal(t, "3", frame["id"])
+	assert.Equal(t, model.EventProductDeleted, frame["event"])
+
+	resp.Body.Close()
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(service.ProductEventSubscribers) == 0
+	}, 5*time.Second, 10*time.Millisecond, "a disconnected subscriber is no longer counted")
+
+	// A subscriber that reconnects gets every change after the last one it saw, in order.
+	resp = connect("1")
+	frames = readServerSentEvents(resp.Body)
+	assert.Equal(t, "2", nextEvent(t, frames)["id"])
+	assert.Equal(t, "3", nextEvent(t, frames)["id"])
+	resp.Body.Close()
+
+	// Once the missed changes are deleted, the subscriber is told to reload instead.
+	_, err = db.Exec("DELETE FROM outbox_events WHERE id <= 2")
+	require.NoError(t, err)
+	resp = connect("1")
+	frames = readServerSentEvents(resp.Body)
+	frame = nextEvent(t, frames)
+	assert.Equal(t, model.EventStreamReset, frame["event"])
+	assert.Equal(t, "3", frame["id"])
+	assert.JSONEq(t, `{"last_event_id": 3}`, frame["data"])
+	resp.Body.Close()
+
+	resp = connect("latest")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+}
@@ -0,0 +1,602 @@
+//go:build sqlite_fts5
+
+package integration
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/infrastructure"
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/repository"
+	productrepository "github.com/indrabrata/observability-playground/repository/product"
+	"github.com/indrabrata/observability-playground/service"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func newProductService(t *testing.T) (*service.ProductService, *sql.DB) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db")+"?_foreign_keys=on")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	infrastructure.RunMigrations(db, os.DirFS("../.."))
+
+	tracer := noop.NewTracerProvider().Tracer("test")
+	productService := service.New(repository.NewBaseRepository(db, productrepository.New(db)), tracer)
+
+	return productService, db
+}
+
+func newContext() context.Context {
+	return context.WithValue(context.Background(), "requestId", "test-123")
+}
+
+func TestGetProductsPagination(t *testing.T) {
+	productService, _ := newProductService(t)
+	ctx := newContext()
+
+	for _, request := range []model.ProductRequest{
+		{Name: "Desk", Quantity: 3, Price: model.MustParseMoney("120")},
+		{Name: "Chair", Quantity: 10, Price: model.MustParseMoney("45.5")},
+		{Name: "Lamp", Quantity: 1, Price: model.MustParseMoney("19.99")},
+		{Name: "Shelf", Quantity: 7, Price: model.MustParseMoney("45.5")},
+		{Name: "Rug", Quantity: 2, Price: model.MustParseMoney("80")},
+	} {
+		_, err := productService.CreateProduct(ctx, request)
+		require.NoError(t, err)
+	}
+
+	testCases := []struct {
+		name     string
+		request  model.ProductListRequest
+		expected []string
+	}{
+		{
+			name:     "default sort by name",
+			request:  model.ProductListRequest{Limit: 2},
+			expected: []string{"Chair", "Desk", "Lamp", "Rug", "Shelf"},
+		},
+		{
+			name:     "price descending with ties",
+			request:  model.ProductListRequest{Limit: 2, SortBy: "price", SortOrder: "desc"},
+			expected: []string{"Desk", "Rug", "Shelf", "Chair", "Lamp"},
+		},
+		{
+			name:     "quantity ascending",
+			request:  model.ProductListRequest{Limit: 3, SortBy: "quantity"},
+			expected: []string{"Lamp", "Rug", "Desk", "Shelf", "Chair"},
+		},
+		{
+			name:     "created at descending",
+			request:  model.ProductListRequest{Limit: 4, SortBy: "created_at", SortOrder: "desc"},
+			expected: []string{"Rug", "Shelf", "Lamp", "Chair", "Desk"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			request := tc.request
+			names := make([]string, 0)
+
+			for {
+				require.NoError(t, request.Validate())
+
+				page, err := productService.GetProducts(ctx, request)
+				require.NoError(t, err)
+				assert.LessOrEqual(t, int64(len(page.Data)), request.Limit)
+
+				for _, product := range page.Data {
+					names = append(names, product.Name)
+				}
+
+				if !page.Paging.HasMore {
+					assert.Empty(t, page.Paging.NextCursor)
+					break
+				}
+				request.Cursor = page.Paging.NextCursor
+			}
+
+			assert.Equal(t, tc.expected, names)
+		})
+	}
+}
+
+func TestGetProductsFilters(t *testing.T) {
+	productService, _ := newProductService(t)
+	ctx := newContext()
+
+	for _, request := range []model.ProductRequest{
+		{Name: "Oak Desk", Quantity: 3, Price: model.MustParseMoney("120")},
+		{Name: "Oak Chair", Quantity: 10, Price: model.MustParseMoney("45.5")},
+		{Name: "Steel Lamp", Quantity: 1, Price: model.MustParseMoney("19.99")},
+	} {
+		_, err := productService.CreateProduct(ctx, request)
+		require.NoError(t, err)
+	}
+	_, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Oak Shelf", Quantity: 1, Price: model.MustParseMoney("60")})
+	require.NoError(t, err)
+
+	minPrice, maxPrice := model.MustParseMoney("40"), model.MustParseMoney("100")
+	request := model.ProductListRequest{NameContains: "oak", MinPrice: &minPrice, MaxPrice: &maxPrice}
+	require.NoError(t, request.Validate())
+
+	page, err := productService.GetProducts(ctx, request)
+	require.NoError(t, err)
+
+	names := make([]string, 0)
+	for _, product := range page.Data {
+		names = append(names, product.Name)
+	}
+	assert.Equal(t, []string{"Oak Chair", "Oak Shelf"}, names)
+	assert.False(t, page.Paging.HasMore)
+}
+
+func TestProductListRequestRejectsForeignCursor(t *testing.T) {
+	productService, _ := newProductService(t)
+	ctx := newContext()
+
+	for _, name := range []string{"A", "B"} {
+		_, err := productService.CreateProduct(ctx, model.ProductRequest{Name: name, Quantity: 1, Price: model.MustParseMoney("1")})
+		require.NoError(t, err)
+	}
+
+	request := model.ProductListRequest{Limit: 1}
+	require.NoError(t, request.Validate())
+	page, err := productService.GetProducts(ctx, request)
+	require.NoError(t, err)
+	require.True(t, page.Paging.HasMore)
+
+	other := model.ProductListRequest{Limit: 1, SortBy: "price", Cursor: page.Paging.NextCursor}
+	assert.Error(t, other.Validate())
+
+	garbage := model.ProductListRequest{Cursor: "not-a-cursor"}
+	assert.Error(t, garbage.Validate())
+}
+
+func TestGetProductsPaginationKeepsLargeSortKeys(t *testing.T) {
+	productService, _ := newProductService(t)
+	ctx := newContext()
+
+	// Sort keys above 2^53 keep their exact value in the cursor, so no page repeats or skips a product.
+	for _, quantity := range []int64{1<<53 + 1, 1<<53 + 2} {
+		_, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Bulk", Quantity: quantity, Price: model.MustParseMoney("1")})
+		require.NoError(t, err)
+	}
+	var quantities []int64
+	cursor := ""
+	// The loop is bounded, a cursor that drifts back would otherwise page forever.
+	for range 3 {
+		request := model.ProductListRequest{Limit: 1, SortBy: "quantity", Cursor: cursor}
+		require.NoError(t, request.Validate())
+		page, err := productService.GetProducts(ctx, request)
+		require.NoError(t, err)
+		for _, product := range page.Data {
+			quantities = append(quantities, product.Quantity)
+		}
+		if !page.Paging.HasMore {
+			break
+		}
+		cursor = page.Paging.NextCursor
+	}
+	assert.Equal(t, []int64{1<<53 + 1, 1<<53 + 2}, quantities)
+}
+
+func TestSearchProducts(t *testing.T) {
+	productService, _ := newProductService(t)
+	ctx := newContext()
+
+	var lampId int64
+	for _, request := range []model.ProductRequest{
+		{Name: "Walnut Desk Lamp", Quantity: 3, Price: model.MustParseMoney("59")},
+		{Name: "Walnut Desk", Quantity: 2, Price: model.MustParseMoney("350")},
+		{Name: "Steel Chair", Quantity: 8, Price: model.MustParseMoney("75")},
+	} {
+		product, err := productService.CreateProduct(ctx, request)
+		require.NoError(t, err)
+		if request.Name == "Walnut Desk Lamp" {
+			lampId = product.Id
+		}
+	}
+
+	request := model.ProductSearchRequest{Query: "walnut de"}
+	require.NoError(t, request.Validate())
+
+	results, err := productService.SearchProducts(ctx, request)
+	require.NoError(t, err)
+	require.Len(t, results.Data, 2)
+	assert.Equal(t, "Walnut Desk", results.Data[0].Name)
+	assert.Contains(t, results.Data[0].Highlight, "<mark>Walnut</mark>")
+	assert.GreaterOrEqual(t, results.Data[0].Score, results.Data[1].Score)
+
+	_, err = productService.UpdateProduct(ctx, lampId, model.ProductRequest{Name: "Brass Floor Lamp", Quantity: 3, Price: model.MustParseMoney("59")}, model.Precondition{})
+	require.NoError(t, err)
+
+	results, err = productService.SearchProducts(ctx, model.ProductSearchRequest{Query: "walnut", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, results.Data, 1)
+	assert.Equal(t, "Walnut Desk", results.Data[0].Name)
+
+	// Names are escaped in the highlight, only the matches are markup.
+	_, err = productService.CreateProduct(ctx, model.ProductRequest{Name: "Walnut <script>alert(1)</script>", Quantity: 1, Price: model.MustParseMoney("5")})
+	require.NoError(t, err)
+	results, err = productService.SearchProducts(ctx, model.ProductSearchRequest{Query: "alert", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, results.Data, 1)
+	assert.Equal(t, "Walnut &lt;script&gt;<mark>alert</mark>(1)&lt;/script&gt;", results.Data[0].Highlight)
+
+	// FTS5 operators typed by users must not break the query syntax.
+	results, err = productService.SearchProducts(ctx, model.ProductSearchRequest{Query: `"brass`, Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, results.Data, 1)
+
+	results, err = productService.SearchProducts(ctx, model.ProductSearchRequest{Query: "brass", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, results.Data, 1)
+	assert.Equal(t, lampId, results.Data[0].Id)
+
+	require.NoError(t, productService.DeleteProduct(ctx, lampId, model.Precondition{}))
+
+	results, err = productService.SearchProducts(ctx, model.ProductSearchRequest{Query: "brass", Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, results.Data)
+}
+
+func TestUpdateOutOfStockProduct(t *testing.T) {
+	productService, _ := newProductService(t)
+	ctx := newContext()
+
+	created, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Lamp", Quantity: 2, Price: model.MustParseMoney("30")})
+	require.NoError(t, err)
+	_, err = productService.AdjustStock(ctx, created.Id, model.StockAdjustRequest{Delta: -2, Reason: "sold out"})
+	require.NoError(t, err)
+
+	// A full update sends back the 0 it read.
+	request := model.ProductRequest{Name: "Desk Lamp", Quantity: 0, Price: model.MustParseMoney("30")}
+	require.NoError(t, request.Validate())
+	updated, err := productService.UpdateProduct(ctx, created.Id, request, model.Precondition{})
+	require.NoError(t, err)
+	assert.Equal(t, "Desk Lamp", updated.Name)
+	assert.Equal(t, int64(0), updated.Quantity)
+
+	zero, negative := int64(0), int64(-1)
+	patch := model.ProductPatchRequest{Quantity: &zero}
+	require.NoError(t, patch.Validate())
+	patch = model.ProductPatchRequest{Quantity: &negative}
+	assert.Error(t, patch.Validate())
+	request.Quantity = -1
+	assert.Error(t, request.Validate())
+}
+
+func TestUpdateProductOptimisticConcurrency(t *testing.T) {
+	productService, _ := newProductService(t)
+	ctx := newContext()
+
+	created, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Desk", Quantity: 3, Price: model.MustParseMoney("120")})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), created.Version)
+
+	first, err := productService.UpdateProduct(ctx, created.Id, model.ProductRequest{Name: "Desk", Quantity: 2, Price: model.MustParseMoney("120")},
+		model.Precondition{IfMatch: true, Versions: []int64{created.Version}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), first.Version)
+
+	// A second writer still holding version 1 must not overwrite the first update.
+	_, err = productService.UpdateProduct(ctx, created.Id, model.ProductRequest{Name: "Desk", Quantity: 9, Price: model.MustParseMoney("99")},
+		model.Precondition{IfMatch: true, Versions: []int64{created.Version}})
+	assert.Equal(t, apperror.PreconditionFailed, apperror.KindOf(err))
+
+	current, err := productService.GetProduct(ctx, created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), current.Quantity)
+
+	second, err := productService.UpdateProduct(ctx, created.Id, model.ProductRequest{Name: "Desk", Quantity: 5, Price: model.MustParseMoney("120")},
+		model.Precondition{IfMatch: true, Versions: []int64{1, 2}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), second.Version)
+
+	_, err = productService.UpdateProduct(ctx, 999, model.ProductRequest{Name: "Desk", Quantity: 5, Price: model.MustParseMoney("120")},
+		model.Precondition{IfMatch: true, Versions: []int64{1}})
+	assert.Equal(t, apperror.NotFound, apperror.KindOf(err))
+
+	err = productService.DeleteProduct(ctx, created.Id, model.Precondition{IfMatch: true, Versions: []int64{2}})
+	assert.Equal(t, apperror.PreconditionFailed, apperror.KindOf(err))
+
+	err = productService.DeleteProduct(ctx, created.Id, model.Precondition{IfMatch: true, Versions: []int64{3}})
+	assert.NoError(t, err)
+}
+
+func TestPatchProduct(t *testing.T) {
+	productService, _ := newProductService(t)
+	ctx := newContext()
+
+	created, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Lamp", Quantity: 7, Price: model.MustParseMoney("30")})
+	require.NoError(t, err)
+
+	patch, err := model.ParseMergePatch([]byte(`{"price":25.5}`))
+	require.NoError(t, err)
+	patched, err := productService.PatchProduct(ctx, created.Id, patch, model.Precondition{})
+	require.NoError(t, err)
+	assert.Equal(t, "25.50", patched.Price.String())
+	assert.Equal(t, int64(7), patched.Quantity)
+	assert.Equal(t, "Lamp", patched.Name)
+	assert.Equal(t, int64(2), patched.Version)
+
+	// A failed test operation must leave the product untouched.
+	patch, err = model.ParseJSONPatch([]byte(`[{"op":"test","path":"/quantity","value":8},{"op":"replace","path":"/quantity","value":1}]`))
+	require.NoError(t, err)
+	_, err = productService.PatchProduct(ctx, created.Id, patch, model.Precondition{})
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err))
+
+	patch, err = model.ParseJSONPatch([]byte(`[{"op":"test","path":"/quantity","value":7},{"op":"replace","path":"/quantity","value":1}]`))
+	require.NoError(t, err)
+	patched, err = productService.PatchProduct(ctx, created.Id, patch, model.Precondition{IfMatch: true, Versions: []int64{2}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), patched.Quantity)
+	assert.Equal(t, "25.50", patched.Price.String())
+
+	_, err = productService.PatchProduct(ctx, created.Id, patch, model.Precondition{IfMatch: true, Versions: []int64{2}})
+	assert.Equal(t, apperror.PreconditionFailed, apperror.KindOf(err))
+
+	// A null in a merge patch, or a remove operation, clears an optional field and leaves the others alone.
+	patch, err = model.ParseMergePatch([]byte(`{"sku":"LAMP-1","barcode":"4006381333931","reorder_point":2}`))
+	require.NoError(t, err)
+	require.NoError(t, patch.Validate())
+	_, err = productService.PatchProduct(ctx, created.Id, patch, model.Precondition{})
+	require.NoError(t, err)
+
+	patch, err = model.ParseMergePatch([]byte(`{"sku":null,"reorder_point":null}`))
+	require.NoError(t, err)
+	require.NoError(t, patch.Validate())
+	patched, err = productService.PatchProduct(ctx, created.Id, patch, model.Precondition{})
+	require.NoError(t, err)
+	assert.Empty(t, patched.Sku)
+	assert.Nil(t, patched.ReorderPoint)
+	assert.Equal(t, "4006381333931", patched.Barcode)
+
+	patch, err = model.ParseJSONPatch([]byte(`[{"op":"remove","path":"/barcode"}]`))
+	require.NoError(t, err)
+	patched, err = productService.PatchProduct(ctx, created.Id, patch, model.Precondition{})
+	require.NoError(t, err)
+	assert.Empty(t, patched.Barcode)
+
+	_, err = model.ParseMergePatch([]byte(`{"name":null}`))
+	assert.Error(t, err, "required fields cannot be removed")
+}
+
+func TestSoftDeleteRestoreAndPurge(t *testing.T) {
+	productService, db := newProductService(t)
+	tracer := noop.NewTracerProvider().Tracer("test")
+	pricingService := service.NewPricingService(repository.NewBaseRepository(db, productrepository.New(db)), tracer)
+	orderService := service.NewOrderService(repository.NewBaseRepository(db, productrepository.New(db)), tracer)
+	ctx := newContext()
+
+	kept, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Chair", Quantity: 4, Price: model.MustParseMoney("45")})
+	require.NoError(t, err)
+	removed, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Chair Cushion", Quantity: 2, Price: model.MustParseMoney("15")})
+	require.NoError(t, err)
+
+	require.NoError(t, productService.DeleteProduct(ctx, removed.Id, model.Precondition{}))
+
+	_, err = productService.GetProduct(ctx, removed.Id)
+	assert.Equal(t, apperror.NotFound, apperror.KindOf(err))
+
+	err = productService.DeleteProduct(ctx, removed.Id, model.Precondition{})
+	assert.Equal(t, apperror.NotFound, apperror.KindOf(err))
+
+	results, err := productService.SearchProducts(ctx, model.ProductSearchRequest{Query: "chair", Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, results.Data, 1)
+
+	request := model.ProductListRequest{}
+	require.NoError(t, request.Validate())
+	page, err := productService.GetProducts(ctx, request)
+	require.NoError(t, err)
+	require.Len(t, page.Data, 1)
+	assert.Equal(t, kept.Id, page.Data[0].Id)
+
+	request = model.ProductListRequest{IncludeDeleted: true}
+	require.NoError(t, request.Validate())
+	page, err = productService.GetProducts(ctx, request)
+	require.NoError(t, err)
+	require.Len(t, page.Data, 2)
+	assert.Nil(t, page.Data[0].DeletedAt)
+	assert.NotNil(t, page.Data[1].DeletedAt)
+
+	_, err = productService.RestoreProduct(ctx, kept.Id)
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err))
+
+	restored, err := productService.RestoreProduct(ctx, removed.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "Chair Cushion", restored.Name)
+	assert.Equal(t, int64(3), restored.Version)
+
+	_, err = pricingService.CreatePricingRule(ctx, model.PricingRuleRequest{Name: "Cushion deal", Type: model.PricingRuleTypePercentage, ProductId: &removed.Id,
+		Tiers: []model.PricingTierRequest{{MinQuantity: 1, Value: model.MustParseMoney("10")}}})
+	require.NoError(t, err)
+	require.NoError(t, productService.DeleteProduct(ctx, removed.Id, model.Precondition{}))
+
+	// A product that was ordered is never purged, so the order keeps its lines.
+	ordered, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Footstool", Quantity: 3, Price: model.MustParseMoney("25")})
+	require.NoError(t, err)
+	order, err := orderService.PlaceOrder(ctx, model.OrderRequest{Lines: []model.OrderLineRequest{{ProductId: ordered.Id, Quantity: 1}}})
+	require.NoError(t, err)
+	require.NoError(t, productService.DeleteProduct(ctx, ordered.Id, model.Precondition{}))
+
+	purged, err := productService.PurgeDeletedProducts(ctx, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), purged)
+
+	purged, err = productService.PurgeDeletedProducts(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	_, err = productService.RestoreProduct(ctx, removed.Id)
+	assert.Equal(t, apperror.NotFound, apperror.KindOf(err))
+	rules, err := pricingService.ListPricingRules(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, rules.Data, "the purged product's pricing rule goes with it")
+
+	_, err = orderService.GetOrder(ctx, order.Id)
+	require.NoError(t, err)
+	_, err = productService.RestoreProduct(ctx, ordered.Id)
+	require.NoError(t, err)
+}
+
+func TestImportProducts(t *testing.T) {
+	productService, _ := newProductService(t)
+	ctx := newContext()
+
+	csv := "name,price,quantity\nMug,4.5,10\n,3,2\nPlate,abc,5\nBowl,6,8\n"
+
+	rows, err := model.NewCSVProductRowReader(strings.NewReader(csv))
+	require.NoError(t, err)
+	report, err := productService.ImportProducts(ctx, rows, model.ImportModeAllOrNothing)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), report.Created)
+	assert.Equal(t, int64(2), report.Failed)
+	assert.Equal(t, []model.ProductImportError{
+		{Line: 3, Errors: []model.FieldError{{Field: "name", Message: "name is required"}}},
+		{Line: 4, Errors: []model.FieldError{{Field: "price", Message: "price must be a decimal number"}}},
+	}, report.Errors)
+
+	request := model.ProductListRequest{}
+	require.NoError(t, request.Validate())
+	page, err := productService.GetProducts(ctx, request)
+	require.NoError(t, err)
+	assert.Empty(t, page.Data)
+
+	rows, err = model.NewCSVProductRowReader(strings.NewReader(csv))
+	require.NoError(t, err)
+	report, err = productService.ImportProducts(ctx, rows, model.ImportModePartial)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), report.Created)
+	assert.Equal(t, int64(2), report.Failed)
+
+	ndjson := `{"name":"Spoon","quantity":30,"price":1.25}` + "\n\n" + `{"name":"Fork","quantity":-1,"price":1.25}` + "\n" + `not json` + "\n"
+	report, err = productService.ImportProducts(ctx, model.NewNDJSONProductRowReader(strings.NewReader(ndjson)), model.ImportModePartial)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), report.Created)
+	assert.Equal(t, []model.ProductImportError{
+		{Line: 3, Errors: []model.FieldError{{Field: "quantity", Message: "quantity must not be negative"}}},
+		{Line: 4, Errors: []model.FieldError{{Field: "row", Message: "row is not valid JSON"}}},
+	}, report.Errors)
+
+	page, err = productService.GetProducts(ctx, request)
+	require.NoError(t, err)
+	assert.Len(t, page.Data, 3)
+
+	_, err = model.NewCSVProductRowReader(strings.NewReader("name,price\nMug,4.5\n"))
+	assert.Equal(t, model.ValidationErrors{{Field: "body", Message: "csv header is missing the quantity column"}}, err)
+}
+
+func TestExportProducts(t *testing.T) {
+	productService, _ := newProductService(t)
+	ctx := newContext()
+
+	var ndjson strings.Builder
+	for i := 1; i <= model.ProductExportChunkSize+1; i++ {
+		fmt.Fprintf(&ndjson, `{"name":"Item %04d","quantity":%d,"price":2.5}`+"\n", i, i)
+	}
+	report, err := productService.ImportProducts(ctx, model.NewNDJSONProductRowReader(strings.NewReader(ndjson.String())), model.ImportModePartial)
+	require.NoError(t, err)
+	require.Equal(t, int64(model.ProductExportChunkSize+1), report.Created)
+	require.NoError(t, productService.DeleteProduct(ctx, 2, model.Precondition{}))
+
+	var csvOutput bytes.Buffer
+	flushes := 0
+	written, err := productService.ExportProducts(ctx, model.ExportFormatCSV, &csvOutput, func() error { flushes++; return nil })
+	require.NoError(t, err)
+	assert.Equal(t, int64(csvOutput.Len()), written)
+	assert.Equal(t, 2, flushes)
+
+	lines := strings.Split(strings.TrimSpace(csvOutput.String()), "\n")
+	assert.Len(t, lines, model.ProductExportChunkSize+1)
+	assert.Equal(t, "id,name,quantity,price,currency,sku,barcode,reorder_point,version,created_at,updated_at", lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "1,Item 0001,1,2.50,USD,,,,1,"))
+	assert.True(t, strings.HasPrefix(lines[2], "3,Item 0003,3,2.50,USD,,,,1,"))
+
+	var ndjsonOutput bytes.Buffer
+	_, err = productService.ExportProducts(ctx, model.ExportFormatNDJSON, &ndjsonOutput, func() error { return nil })
+	require.NoError(t, err)
+
+	var first model.ProductExportRow
+	require.NoError(t, json.NewDecoder(&ndjsonOutput).Decode(&first))
+	assert.Equal(t, "Item 0001", first.Name)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = productService.ExportProducts(cancelled, model.ExportFormatCSV, io.Discard, func() error { return nil })
+	assert.Equal(t, apperror.Unavailable, apperror.KindOf(err))
+}
+
+func TestStockLedger(t *testing.T) {
+	productService, _ := newProductService(t)
+	ctx := newContext()
+
+	created, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Pallet", Quantity: 100, Price: model.MustParseMoney("12")})
+	require.NoError(t, err)
+
+	received, err := productService.AdjustStock(ctx, created.Id, model.StockAdjustRequest{Delta: 20, Reason: model.StockReasonReceive, Reference: "PO-1"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(120), received.Product.Quantity)
+	assert.Equal(t, int64(120), received.Movement.QuantityAfter)
+	assert.Equal(t, "test-123", received.Movement.RequestId)
+
+	_, err = productService.AdjustStock(ctx, created.Id, model.StockAdjustRequest{Delta: -121, Reason: model.StockReasonShip})
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err))
+
+	_, err = productService.AdjustStock(ctx, 999, model.StockAdjustRequest{Delta: 1, Reason: "count"})
+	assert.Equal(t, apperror.NotFound, apperror.KindOf(err))
+
+	shipped, err := productService.AdjustStock(ctx, created.Id, model.StockAdjustRequest{Delta: -40, Reason: model.StockReasonShip, Actor: "jane"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(80), shipped.Product.Quantity)
+
+	// Quantity changes made through PUT and PATCH are recorded too, so the ledger always adds up.
+	_, err = productService.UpdateProduct(ctx, created.Id, model.ProductRequest{Name: "Pallet", Quantity: 75, Price: model.MustParseMoney("12")}, model.Precondition{})
+	require.NoError(t, err)
+	price := model.MustParseMoney("13")
+	_, err = productService.PatchProduct(ctx, created.Id, model.ProductPatchRequest{Price: &price}, model.Precondition{})
+	require.NoError(t, err)
+
+	request := model.StockHistoryRequest{Limit: 2}
+	require.NoError(t, request.Validate())
+	page, err := productService.GetStockHistory(ctx, created.Id, request)
+	require.NoError(t, err)
+	require.Len(t, page.Data, 2)
+	assert.True(t, page.Paging.HasMore)
+	assert.Equal(t, model.StockReasonUpdate, page.Data[0].Reason)
+	assert.Equal(t, int64(-5), page.Data[0].Delta)
+	assert.Equal(t, "jane", page.Data[1].Actor)
+
+	request = model.StockHistoryRequest{Limit: 2, Cursor: page.Paging.NextCursor}
+	require.NoError(t, request.Validate())
+	page, err = productService.GetStockHistory(ctx, created.Id, request)
+	require.NoError(t, err)
+	require.Len(t, page.Data, 2)
+	assert.False(t, page.Paging.HasMore)
+	assert.Equal(t, model.StockReasonInitial, page.Data[1].Reason)
+
+	current, err := productService.GetProduct(ctx, created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100+20-40-5), current.Quantity)
+
+	require.NoError(t, productService.DeleteProduct(ctx, created.Id, model.Precondition{}))
+	_, err = productService.PurgeDeletedProducts(ctx, 0)
+	require.NoError(t, err)
+	_, err = productService.GetStockHistory(ctx, created.Id, request)
+	assert.Equal(t, apperror.NotFound, apperror.KindOf(err))
+}
@@ -0,0 +1,85 @@
+//go:build sqlite_fts5
+
+package integration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/repository"
+	productrepository "github.com/indrabrata/observability-playground/repository/product"
+	"github.com/indrabrata/observability-playground/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestReservations(t *testing.T) {
+	productService, db := newProductService(t)
+	reservationService := service.NewReservationService(repository.NewBaseRepository(db, productrepository.New(db)), noop.NewTracerProvider().Tracer("test"), time.Minute)
+	ctx := newContext()
+
+	phone, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Phone", Quantity: 5, Price: model.MustParseMoney("500")})
+	require.NoError(t, err)
+	phoneCase, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Phone Case", Quantity: 1, Price: model.MustParseMoney("20")})
+	require.NoError(t, err)
+
+	// One item short of stock rolls back the whole reservation.
+	_, err = reservationService.CreateReservation(ctx, model.ReservationRequest{Items: []model.ReservationItemRequest{
+		{ProductId: phone.Id, Quantity: 2},
+		{ProductId: phoneCase.Id, Quantity: 2},
+	}})
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err))
+	current, err := productService.GetProduct(ctx, phone.Id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), current.Quantity)
+
+	_, err = reservationService.CreateReservation(ctx, model.ReservationRequest{Items: []model.ReservationItemRequest{{ProductId: 999, Quantity: 1}}})
+	assert.Equal(t, apperror.NotFound, apperror.KindOf(err))
+
+	confirmed, err := reservationService.CreateReservation(ctx, model.ReservationRequest{Items: []model.ReservationItemRequest{
+		{ProductId: phone.Id, Quantity: 2},
+		{ProductId: phoneCase.Id, Quantity: 1},
+	}, Reference: "checkout-1"})
+	require.NoError(t, err)
+	assert.Equal(t, model.ReservationStatusActive, confirmed.Status)
+	current, err = productService.GetProduct(ctx, phone.Id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), current.Quantity)
+
+	confirmed, err = reservationService.ConfirmReservation(ctx, confirmed.Id)
+	require.NoError(t, err)
+	assert.Equal(t, model.ReservationStatusConfirmed, confirmed.Status)
+	_, err = reservationService.CancelReservation(ctx, confirmed.Id)
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err))
+
+	cancelled, err := reservationService.CreateReservation(ctx, model.ReservationRequest{Items: []model.ReservationItemRequest{{ProductId: phone.Id, Quantity: 1}}})
+	require.NoError(t, err)
+	cancelled, err = reservationService.CancelReservation(ctx, cancelled.Id)
+	require.NoError(t, err)
+	assert.Equal(t, model.ReservationStatusCancelled, cancelled.Status)
+	current, err = productService.GetProduct(ctx, phone.Id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), current.Quantity)
+
+	expiring, err := reservationService.CreateReservation(ctx, model.ReservationRequest{Items: []model.ReservationItemRequest{{ProductId: phone.Id, Quantity: 3}}, TTLSeconds: 1})
+	require.NoError(t, err)
+	_, err = db.Exec(`UPDATE reservations SET expires_at = ? WHERE id = ?`, time.Now().UTC().Add(-time.Second), expiring.Id)
+	require.NoError(t, err)
+
+	_, err = reservationService.ConfirmReservation(ctx, expiring.Id)
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err))
+
+	expired, err := reservationService.ExpireReservations(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), expired)
+
+	expiring, err = reservationService.GetReservation(ctx, expiring.Id)
+	require.NoError(t, err)
+	assert.Equal(t, model.ReservationStatusExpired, expiring.Status)
+	current, err = productService.GetProduct(ctx, phone.Id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), current.Quantity)
+}
@@ -0,0 +1,150 @@
+//go:build sqlite_fts5
+
+package integration
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/repository"
+	productrepository "github.com/indrabrata/observability-playground/repository/product"
+	"github.com/indrabrata/observability-playground/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestProductVariants(t *testing.T) {
+	productService, db := newProductService(t)
+	reservationService := service.NewReservationService(repository.NewBaseRepository(db, productrepository.New(db)), noop.NewTracerProvider().Tracer("test"), time.Minute)
+	ctx := newContext()
+
+	shirt, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "T-Shirt", Quantity: 3, Price: model.MustParseMoney("20")})
+	require.NoError(t, err)
+
+	newVariant := func(sku string, options map[string]string, quantity int64) model.VariantRequest {
+		request := model.VariantRequest{Sku: sku, Options: options, Quantity: quantity}
+		require.NoError(t, request.Validate())
+		return request
+	}
+
+	// Stock that belongs to no variant must be gone before the first variant is added.
+	_, err = productService.CreateVariant(ctx, shirt.Id, newVariant("TS-RED-M", map[string]string{"size": "M", "colour": "red"}, 4))
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err))
+	_, err = productService.AdjustStock(ctx, shirt.Id, model.StockAdjustRequest{Delta: -3, Reason: "moved to variants"})
+	require.NoError(t, err)
+
+	redM, err := productService.CreateVariant(ctx, shirt.Id, newVariant("TS-RED-M", map[string]string{"size": "M", "colour": "red"}, 4))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"size": "M", "colour": "red"}, redM.Options)
+	assert.Nil(t, redM.Price, "a variant without a price override uses the product price")
+
+	request := newVariant("TS-RED-XL", map[string]string{" Colour ": "red", "SIZE": "XL"}, 2)
+	price := model.MustParseMoney("22.50")
+	request.Price = &price
+	redXL, err := productService.CreateVariant(ctx, shirt.Id, request)
+	require.NoError(t, err)
+	require.NotNil(t, redXL.Price)
+	assert.Equal(t, "22.50", redXL.Price.String())
+	assert.Equal(t, "USD", redXL.Currency)
+
+	_, err = productService.CreateVariant(ctx, shirt.Id, newVariant("ts-red-m", map[string]string{"size": "L"}, 0))
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err), "variant SKUs are unique ignoring case")
+	_, err = productService.CreateVariant(ctx, shirt.Id, newVariant("TS-RED-M2", map[string]string{"colour": "red", "size": "M"}, 0))
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err), "two variants cannot have the same options")
+
+	product, err := productService.GetProduct(ctx, shirt.Id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), product.Quantity, "the product quantity is the sum of its variants")
+
+	// Stock moves per variant; the product quantity follows.
+	_, err = productService.AdjustStock(ctx, shirt.Id, model.StockAdjustRequest{Delta: 5, Reason: "delivery"})
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err), "a product with variants needs a variant_id")
+	_, err = productService.AdjustStock(ctx, shirt.Id, model.StockAdjustRequest{VariantId: &redM.Id, Delta: -5, Reason: "damaged"})
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err), "a variant cannot go below zero even when the product has enough")
+	missing := int64(999)
+	_, err = productService.AdjustStock(ctx, shirt.Id, model.StockAdjustRequest{VariantId: &missing, Delta: 1, Reason: "delivery"})
+	assert.Equal(t, apperror.NotFound, apperror.KindOf(err))
+	assert.Equal(t, fmt.Sprintf("variant 999 of product %d not found", shirt.Id), apperror.MessageOf(err), "adding stock is never a shortage")
+
+	adjusted, err := productService.AdjustStock(ctx, shirt.Id, model.StockAdjustRequest{VariantId: &redM.Id, Delta: 5, Reason: "delivery"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), adjusted.Product.Quantity)
+	assert.Equal(t, int64(9), adjusted.Movement.QuantityAfter, "the ledger records the quantity of the variant")
+	require.NotNil(t, adjusted.Movement.VariantId)
+	assert.Equal(t, redM.Id, *adjusted.Movement.VariantId)
+
+	// The quantity of a product with variants cannot be set directly.
+	_, err = productService.UpdateProduct(ctx, shirt.Id, model.ProductRequest{Name: "T-Shirt", Quantity: 20, Price: model.MustParseMoney("20")}, model.Precondition{})
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err))
+	_, err = productService.UpdateProduct(ctx, shirt.Id, model.ProductRequest{Name: "Tee", Quantity: 11, Price: model.MustParseMoney("20")}, model.Precondition{})
+	require.NoError(t, err)
+
+	// Reservations hold stock of a variant and give it back to the same variant.
+	_, err = reservationService.CreateReservation(ctx, model.ReservationRequest{Items: []model.ReservationItemRequest{{ProductId: shirt.Id, Quantity: 1}}})
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err))
+	_, err = reservationService.CreateReservation(ctx, model.ReservationRequest{Items: []model.ReservationItemRequest{{ProductId: shirt.Id, VariantId: &redXL.Id, Quantity: 3}}})
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err))
+
+	reservation, err := reservationService.CreateReservation(ctx, model.ReservationRequest{Items: []model.ReservationItemRequest{
+		{ProductId: shirt.Id, VariantId: &redM.Id, Quantity: 2},
+		{ProductId: shirt.Id, VariantId: &redXL.Id, Quantity: 2},
+	}})
+	require.NoError(t, err)
+	variant, err := productService.GetVariant(ctx, shirt.Id, redXL.Id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), variant.Quantity)
+	product, err = productService.GetProduct(ctx, shirt.Id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), product.Quantity)
+
+	err = productService.DeleteVariant(ctx, shirt.Id, redXL.Id)
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err), "a variant with active reservations cannot be deleted")
+
+	reservation, err = reservationService.CancelReservation(ctx, reservation.Id)
+	require.NoError(t, err)
+	require.Len(t, reservation.Items, 2)
+	assert.Equal(t, redM.Id, *reservation.Items[0].VariantId)
+	variant, err = productService.GetVariant(ctx, shirt.Id, redXL.Id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), variant.Quantity)
+
+	// Updating a variant moves the product quantity by the difference and records it.
+	update := newVariant("TS-RED-XL", map[string]string{"size": "XL", "colour": "red"}, 0)
+	variant, err = productService.UpdateVariant(ctx, shirt.Id, redXL.Id, update)
+	require.NoError(t, err)
+	assert.Nil(t, variant.Price, "a replaced variant without a price drops its override")
+	product, err = productService.GetProduct(ctx, shirt.Id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(9), product.Quantity)
+
+	err = productService.DeleteVariant(ctx, shirt.Id, redM.Id)
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err), "a variant with stock cannot be deleted")
+	require.NoError(t, productService.DeleteVariant(ctx, shirt.Id, redXL.Id))
+	_, err = productService.GetVariant(ctx, shirt.Id, redXL.Id)
+	assert.Equal(t, apperror.NotFound, apperror.KindOf(err))
+
+	variants, err := productService.ListVariants(ctx, shirt.Id)
+	require.NoError(t, err)
+	require.Len(t, variants.Data, 1)
+	assert.Equal(t, redM.Id, variants.Data[0].Id)
+
+	// A deleted variant keeps its ledger and gives up its sku and options.
+	historyRequest := model.StockHistoryRequest{Limit: 100}
+	require.NoError(t, historyRequest.Validate())
+	history, err := productService.GetStockHistory(ctx, shirt.Id, historyRequest)
+	require.NoError(t, err)
+	variantIds := []int64{}
+	for _, movement := range history.Data {
+		if movement.VariantId != nil {
+			variantIds = append(variantIds, *movement.VariantId)
+		}
+	}
+	assert.Contains(t, variantIds, redXL.Id)
+	recreated, err := productService.CreateVariant(ctx, shirt.Id, newVariant("TS-RED-XL", map[string]string{"size": "XL", "colour": "red"}, 0))
+	require.NoError(t, err)
+	assert.NotEqual(t, redXL.Id, recreated.Id)
+}
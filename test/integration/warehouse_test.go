@@ -0,0 +1,143 @@
+//go:build sqlite_fts5
+
+package integration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/repository"
+	productrepository "github.com/indrabrata/observability-playground/repository/product"
+	"github.com/indrabrata/observability-playground/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestWarehouseStock(t *testing.T) {
+	productService, db := newProductService(t)
+	tracer := noop.NewTracerProvider().Tracer("test")
+	warehouseService := service.NewWarehouseService(repository.NewBaseRepository(db, productrepository.New(db)), tracer)
+	reservationService := service.NewReservationService(repository.NewBaseRepository(db, productrepository.New(db)), tracer, time.Minute)
+	ctx := newContext()
+
+	amsterdam, err := warehouseService.CreateWarehouse(ctx, model.WarehouseRequest{Code: "AMS-1", Name: "Amsterdam"})
+	require.NoError(t, err)
+	berlin, err := warehouseService.CreateWarehouse(ctx, model.WarehouseRequest{Code: "BER-1", Name: "Berlin"})
+	require.NoError(t, err)
+	_, err = warehouseService.CreateWarehouse(ctx, model.WarehouseRequest{Code: "ams-1", Name: "Amsterdam 2"})
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err), "warehouse codes are unique ignoring case")
+
+	desk, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Desk", Quantity: 10, Price: model.MustParseMoney("120")})
+	require.NoError(t, err)
+
+	// Existing stock starts unallocated and is put into a warehouse with a transfer.
+	transfer, err := warehouseService.CreateTransfer(ctx, model.TransferRequest{
+		ToWarehouseId: &amsterdam.Id,
+		Items:         []model.TransferItemRequest{{ProductId: desk.Id, Quantity: 8}},
+	})
+	require.NoError(t, err)
+	assert.Nil(t, transfer.FromWarehouseId)
+
+	// Receiving into a warehouse raises the total and that location.
+	_, err = productService.AdjustStock(ctx, desk.Id, model.StockAdjustRequest{WarehouseId: &berlin.Id, Delta: 5, Reason: model.StockReasonReceive})
+	require.NoError(t, err)
+
+	product, err := productService.GetProduct(ctx, desk.Id)
+	require.NoError(t, err)
+	require.NotNil(t, product.Stock)
+	assert.Equal(t, int64(15), product.Stock.Total)
+	assert.Equal(t, int64(2), product.Stock.Unallocated)
+	assert.Equal(t, []model.StockLocationResponse{
+		{WarehouseId: amsterdam.Id, WarehouseCode: "AMS-1", Quantity: 8},
+		{WarehouseId: berlin.Id, WarehouseCode: "BER-1", Quantity: 5},
+	}, product.Stock.Locations)
+
+	// A transfer moves every item or none.
+	chair, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Chair", Quantity: 4, Price: model.MustParseMoney("45")})
+	require.NoError(t, err)
+	_, err = warehouseService.CreateTransfer(ctx, model.TransferRequest{
+		FromWarehouseId: &amsterdam.Id,
+		ToWarehouseId:   &berlin.Id,
+		Items: []model.TransferItemRequest{
+			{ProductId: desk.Id, Quantity: 3},
+			{ProductId: chair.Id, Quantity: 1},
+		},
+	})
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err), "Amsterdam holds no chairs")
+
+	transfer, err = warehouseService.CreateTransfer(ctx, model.TransferRequest{
+		FromWarehouseId: &amsterdam.Id,
+		ToWarehouseId:   &berlin.Id,
+		Items:           []model.TransferItemRequest{{ProductId: desk.Id, Quantity: 3}},
+		Reference:       "TR-1",
+	})
+	require.NoError(t, err)
+	transfer, err = warehouseService.GetTransfer(ctx, transfer.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "TR-1", transfer.Reference)
+	require.Len(t, transfer.Items, 1)
+
+	product, err = productService.GetProduct(ctx, desk.Id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(15), product.Quantity, "a transfer does not change the total")
+	assert.Equal(t, int64(5), product.Stock.Locations[0].Quantity)
+	assert.Equal(t, int64(8), product.Stock.Locations[1].Quantity)
+
+	history, err := productService.GetStockHistory(ctx, desk.Id, model.StockHistoryRequest{Limit: 20})
+	require.NoError(t, err)
+	assert.Equal(t, model.StockReasonTransferIn, history.Data[0].Reason)
+	assert.Equal(t, berlin.Id, *history.Data[0].WarehouseId)
+	assert.Equal(t, model.StockReasonTransferOut, history.Data[1].Reason)
+	assert.Equal(t, amsterdam.Id, *history.Data[1].WarehouseId)
+
+	// Stock held by warehouses cannot be taken without naming one.
+	_, err = productService.AdjustStock(ctx, desk.Id, model.StockAdjustRequest{Delta: -3, Reason: model.StockReasonShip})
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err), "only 2 units are unallocated")
+	_, err = productService.UpdateProduct(ctx, desk.Id, model.ProductRequest{Name: "Desk", Quantity: 12, Price: model.MustParseMoney("120")}, model.Precondition{})
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err))
+	_, err = productService.AdjustStock(ctx, desk.Id, model.StockAdjustRequest{WarehouseId: &amsterdam.Id, Delta: -6, Reason: model.StockReasonShip})
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err), "Amsterdam holds 5")
+
+	// Reservations take units from a warehouse and give them back to it.
+	reservation, err := reservationService.CreateReservation(ctx, model.ReservationRequest{Items: []model.ReservationItemRequest{
+		{ProductId: desk.Id, WarehouseId: &amsterdam.Id, Quantity: 5},
+	}})
+	require.NoError(t, err)
+	product, err = productService.GetProduct(ctx, desk.Id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), product.Quantity)
+	require.Len(t, product.Stock.Locations, 1, "a warehouse without units of the product is left out")
+	assert.Equal(t, berlin.Id, product.Stock.Locations[0].WarehouseId)
+
+	err = warehouseService.DeleteWarehouse(ctx, amsterdam.Id)
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err), "a warehouse with active reservations cannot be deleted")
+
+	reservation, err = reservationService.CancelReservation(ctx, reservation.Id)
+	require.NoError(t, err)
+	assert.Equal(t, amsterdam.Id, *reservation.Items[0].WarehouseId)
+	product, err = productService.GetProduct(ctx, desk.Id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), product.Stock.Locations[0].Quantity)
+
+	// Only an empty warehouse can be deleted.
+	err = warehouseService.DeleteWarehouse(ctx, amsterdam.Id)
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err))
+	_, err = warehouseService.CreateTransfer(ctx, model.TransferRequest{
+		FromWarehouseId: &amsterdam.Id,
+		Items:           []model.TransferItemRequest{{ProductId: desk.Id, Quantity: 5}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, warehouseService.DeleteWarehouse(ctx, amsterdam.Id))
+	_, err = warehouseService.GetWarehouse(ctx, amsterdam.Id)
+	assert.Equal(t, apperror.NotFound, apperror.KindOf(err))
+	_, err = warehouseService.CreateWarehouse(ctx, model.WarehouseRequest{Code: "AMS-1", Name: "Amsterdam"})
+	require.NoError(t, err, "a deleted warehouse gives up its code")
+
+	product, err = productService.GetProduct(ctx, desk.Id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), product.Stock.Unallocated)
+	assert.Len(t, product.Stock.Locations, 1)
+}
@@ -0,0 +1,150 @@
+//go:build sqlite_fts5
+
+package integration
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/indrabrata/observability-playground/apperror"
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/repository"
+	productrepository "github.com/indrabrata/observability-playground/repository/product"
+	"github.com/indrabrata/observability-playground/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestWebhookDeliveries(t *testing.T) {
+	propagator := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(propagator) })
+
+	var mu sync.Mutex
+	var received []*http.Request
+	var bodies [][]byte
+	var status atomic.Int32
+	status.Store(http.StatusNoContent)
+	partner := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received = append(received, r)
+		bodies = append(bodies, body)
+		mu.Unlock()
+		w.WriteHeader(int(status.Load()))
+	}))
+	t.Cleanup(partner.Close)
+
+	productService, db := newProductService(t)
+	tracer := noop.NewTracerProvider().Tracer("test")
+	webhookService := service.NewWebhookService(repository.NewBaseRepository(db, productrepository.New(db)), tracer, time.Second)
+	relay := service.NewOutboxRelay(repository.NewBaseRepository(db, productrepository.New(db)), tracer, webhookService)
+
+	traceId, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanId, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(newContext(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceId,
+		SpanID:     spanId,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	request := model.WebhookRequest{Url: partner.URL, EventTypes: []string{model.EventProductUpdated}, Secret: "0123456789abcdef0123"}
+	require.NoError(t, request.Validate())
+	webhook, err := webhookService.CreateWebhook(ctx, request)
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789abcdef0123", webhook.Secret)
+
+	fetched, err := webhookService.GetWebhook(ctx, webhook.Id)
+	require.NoError(t, err)
+	assert.Empty(t, fetched.Secret, "the secret is only returned when it is set")
+
+	// Only the event types the webhook subscribed to are delivered.
+	lamp, err := productService.CreateProduct(ctx, model.ProductRequest{Name: "Lamp", Quantity: 4, Price: model.MustParseMoney("20")})
+	require.NoError(t, err)
+	name := "Desk Lamp"
+	_, err = productService.PatchProduct(ctx, lamp.Id, model.ProductPatchRequest{Name: &name}, model.Precondition{})
+	require.NoError(t, err)
+
+	_, err = relay.RelayOutbox(ctx)
+	require.NoError(t, err)
+	_, err = relay.RelayOutbox(ctx)
+	require.NoError(t, err)
+
+	delivered, err := webhookService.DeliverWebhooks(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), delivered)
+
+	require.Len(t, received, 1)
+	req := received[0]
+	assert.Equal(t, model.EventProductUpdated, req.Header.Get(service.WebhookEventHeader))
+	timestamp := req.Header.Get(service.WebhookTimestampHeader)
+	assert.Equal(t, "sha256="+service.SignWebhook("0123456789abcdef0123", timestamp, bodies[0]), req.Header.Get(service.WebhookSignatureHeader))
+	assert.Contains(t, req.Header.Get("traceparent"), "4bf92f3577b34da6a3ce929d0e0e4736", "the delivery continues the trace of the request that changed the product")
+
+	var event model.DomainEvent
+	require.NoError(t, json.Unmarshal(bodies[0], &event))
+	assert.Equal(t, lamp.Id, event.AggregateId)
+
+	// A failing partner is retried until the delivery is dead.
+	status.Store(http.StatusServiceUnavailable)
+	_, err = productService.PatchProduct(ctx, lamp.Id, model.ProductPatchRequest{Name: &lamp.Name}, model.Precondition{})
+	require.NoError(t, err)
+	_, err = relay.RelayOutbox(ctx)
+	require.NoError(t, err)
+	delivered, err = webhookService.DeliverWebhooks(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), delivered)
+
+	deliveries, err := webhookService.ListWebhookDeliveries(ctx, webhook.Id, model.WebhookDeliveryListRequest{Status: model.WebhookDeliveryStatusPending, Limit: 20})
+	require.NoError(t, err)
+	require.Len(t, deliveries.Data, 1)
+	failed := deliveries.Data[0]
+	assert.Equal(t, int64(1), failed.Attempts)
+	require.NotNil(t, failed.ResponseStatus)
+	assert.Equal(t, int64(http.StatusServiceUnavailable), *failed.ResponseStatus)
+	require.NotNil(t, failed.NextAttemptAt)
+	assert.True(t, failed.NextAttemptAt.After(time.Now()), "a failed delivery waits before it is retried")
+
+	_, err = webhookService.RetryWebhookDelivery(ctx, webhook.Id, failed.Id)
+	assert.Equal(t, apperror.Conflict, apperror.KindOf(err), "only dead deliveries can be retried by hand")
+
+	_, err = db.Exec("UPDATE webhook_deliveries SET attempts = 7, next_attempt_at = ? WHERE id = ?", time.Now().UTC().Add(-time.Second), failed.Id)
+	require.NoError(t, err)
+	_, err = webhookService.DeliverWebhooks(ctx)
+	require.NoError(t, err)
+
+	deliveries, err = webhookService.ListWebhookDeliveries(ctx, webhook.Id, model.WebhookDeliveryListRequest{Status: model.WebhookDeliveryStatusDead, Limit: 20})
+	require.NoError(t, err)
+	require.Len(t, deliveries.Data, 1)
+	assert.Equal(t, int64(8), deliveries.Data[0].Attempts)
+	assert.Nil(t, deliveries.Data[0].NextAttemptAt)
+
+	// A dead delivery is sent again once it is retried by hand.
+	status.Store(http.StatusOK)
+	retried, err := webhookService.RetryWebhookDelivery(ctx, webhook.Id, failed.Id)
+	require.NoError(t, err)
+	assert.Equal(t, model.WebhookDeliveryStatusPending, retried.Status)
+	delivered, err = webhookService.DeliverWebhooks(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), delivered)
+
+	deliveries, err = webhookService.ListWebhookDeliveries(ctx, webhook.Id, model.WebhookDeliveryListRequest{Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, deliveries.Data, 1)
+	assert.Equal(t, model.WebhookDeliveryStatusDelivered, deliveries.Data[0].Status)
+	assert.True(t, deliveries.Paging.HasMore)
+
+	require.NoError(t, webhookService.DeleteWebhook(ctx, webhook.Id))
+	_, err = webhookService.ListWebhookDeliveries(ctx, webhook.Id, model.WebhookDeliveryListRequest{Limit: 20})
+	assert.Equal(t, apperror.NotFound, apperror.KindOf(err))
+}
@@ -0,0 +1,189 @@
+package unit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-chi/chi/v5"
+	"github.com/indrabrata/observability-playground/handler"
+	"github.com/indrabrata/observability-playground/model"
+	"github.com/indrabrata/observability-playground/repository"
+	productrepository "github.com/indrabrata/observability-playground/repository/product"
+	"github.com/indrabrata/observability-playground/service"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func newProductRouter(t *testing.T) (http.Handler, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	tracer := noop.NewTracerProvider().Tracer("test")
+	productService := service.New(repository.NewBaseRepository(db, productrepository.New(db)), tracer)
+	productHandler := handler.New(productService, tracer)
+
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), "requestId", "test-123")))
+		})
+	})
+	router.Post("/products", productHandler.CreateProduct)
+	router.Post("/products:import", productHandler.ImportProducts)
+	router.Get("/products/{id}", productHandler.GetProduct)
+	router.Patch("/products/{id}", productHandler.PatchProduct)
+
+	return router, mock
+}
+
+func TestCreateProductReportsEveryInvalidField(t *testing.T) {
+	router, _ := newProductRouter(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(`{"name":"","quantity":-1,"price":-1}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+
+	var problem model.ProblemDetail
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+	assert.Equal(t, http.StatusBadRequest, problem.Status)
+	assert.Equal(t, "urn:observability-playground:problem:validation", problem.Type)
+	assert.Equal(t, "test-123", problem.Instance)
+	assert.Equal(t, []model.FieldError{
+		{Field: "name", Message: "name is required"},
+		{Field: "quantity", Message: "quantity must not be negative"},
+		{Field: "price", Message: "price must be greater than 0"},
+	}, problem.Errors)
+}
+
+func TestCreateProductRejectsPriceFinerThanCurrency(t *testing.T) {
+	router, _ := newProductRouter(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(`{"name":"Tea","quantity":1,"price":"120.5","currency":"JPY"}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var problem model.ProblemDetail
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+	assert.Equal(t, []model.FieldError{{Field: "price", Message: "price must have at most 0 decimal places in JPY"}}, problem.Errors)
+
+	req = httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(`{"name":"Tea","quantity":1,"price":"1.20","currency":"usd"}`))
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+	assert.Equal(t, []model.FieldError{{Field: "currency", Message: "currency must be an ISO 4217 currency code"}}, problem.Errors)
+}
+
+func TestCreateProductRejectsInvalidIdentifiers(t *testing.T) {
+	router, _ := newProductRouter(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(`{"name":"Tea","quantity":1,"price":"4","sku":"TEA 01","barcode":"4006381333932"}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var problem model.ProblemDetail
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+	assert.Equal(t, []model.FieldError{
+		{Field: "sku", Message: "sku may only contain letters, digits, '-', '_' and '.'"},
+		{Field: "barcode", Message: "barcode must be an 8, 12, 13 or 14 digit GTIN with a valid check digit"},
+	}, problem.Errors)
+}
+
+func TestGetProductNotFoundProblem(t *testing.T) {
+	router, mock := newProductRouter(t)
+
+	mock.ExpectQuery("SELECT (.+) FROM products").
+		WithArgs(int64(999)).
+		WillReturnError(sql.ErrNoRows)
+
+	req := httptest.NewRequest(http.MethodGet, "/products/999", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	var problem model.ProblemDetail
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+	assert.Equal(t, "Not Found", problem.Title)
+	assert.Equal(t, "product 999 not found", problem.Detail)
+	assert.Empty(t, problem.Errors)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetProductNotModified(t *testing.T) {
+	router, mock := newProductRouter(t)
+
+	mock.ExpectQuery("SELECT (.+) FROM products").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "quantity", "created_at", "updated_at", "version", "deleted_at", "price_minor", "currency", "sku", "barcode", "reorder_point"}).
+			AddRow(1, "Test Product", 10, time.Now(), nil, 3, nil, 10000, "USD", nil, nil, nil))
+	mock.ExpectQuery("SELECT (.+) FROM warehouse_stock").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "code", "quantity"}))
+
+	req := httptest.NewRequest(http.MethodGet, "/products/1", nil)
+	req.Header.Set("If-None-Match", `W/"3"`)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Equal(t, `"3"`, rec.Header().Get("ETag"))
+	assert.Empty(t, rec.Body.String())
+}
+
+func TestPatchProductRejectsUnsupportedMediaType(t *testing.T) {
+	router, mock := newProductRouter(t)
+
+	req := httptest.NewRequest(http.MethodPatch, "/products/1", strings.NewReader(`{"price":10}`))
+	req.Header.Set("Content-Type", "text/plain")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+	assert.Equal(t, "application/merge-patch+json, application/json-patch+json", rec.Header().Get("Accept-Patch"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPatchProductRejectsRemoveOperation(t *testing.T) {
+	router, _ := newProductRouter(t)
+
+	req := httptest.NewRequest(http.MethodPatch, "/products/1", strings.NewReader(`[{"op":"remove","path":"/price"}]`))
+	req.Header.Set("Content-Type", "application/json-patch+json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var problem model.ProblemDetail
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+	assert.Equal(t, []model.FieldError{{Field: "price", Message: "price cannot be removed"}}, problem.Errors)
+}
+
+func TestImportProductsRejectsUnsupportedMediaType(t *testing.T) {
+	router, mock := newProductRouter(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/products:import", strings.NewReader(`[{"name":"Mug"}]`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+	assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
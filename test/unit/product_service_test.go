@@ -2,9 +2,13 @@ package unit
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"testing"
+	"time"
 
 	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/indrabrata/observability-playground/apperror"
 	"github.com/indrabrata/observability-playground/model"
 	"github.com/indrabrata/observability-playground/repository"
 	productrepository "github.com/indrabrata/observability-playground/repository/product"
@@ -14,10 +18,27 @@ import (
 )
 
 func TestCreateProduct(t *testing.T) {
-	db, _, err := sqlmock.New()
+	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
 	defer db.Close()
 
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO products").
+		WithArgs("Test Product", int64(10), int64(10000), "USD", nil, nil, nil, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "quantity", "created_at", "updated_at", "version", "deleted_at", "price_minor", "currency", "sku", "barcode", "reorder_point"}).
+			AddRow(1, "Test Product", 10, time.Now(), nil, 1, nil, 10000, "USD", nil, nil, nil))
+	mock.ExpectQuery("INSERT INTO stock_movements").
+		WithArgs(int64(1), nil, nil, int64(10), int64(10), "initial", nil, nil, "test-123", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "product_id", "delta", "quantity_after", "reason", "reference", "actor", "request_id", "created_at", "variant_id", "warehouse_id"}).
+			AddRow(1, 1, 10, 10, "initial", nil, nil, "test-123", time.Now(), nil, nil))
+	mock.ExpectExec("INSERT INTO outbox_events").
+		WithArgs("product", int64(1), "product.created", sqlmock.AnyArg(), "test-123", nil, nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO audit_log").
+		WithArgs("product", int64(1), "create", nil, nil, sqlmock.AnyArg(), "test-123", nil, nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
 	tracer := noop.NewTracerProvider().Tracer("test")
 
 	productRepository := productrepository.New(db)
@@ -25,11 +46,83 @@ func TestCreateProduct(t *testing.T) {
 
 	ctx := context.WithValue(context.Background(), "requestId", "test-123")
 
-	request := model.ProductRequest{Name: "Test Product", Quantity: 10, Price: 100.0}
+	request := model.ProductRequest{Name: "Test Product", Quantity: 10, Price: model.MustParseMoney("100.00")}
 	result, err := productService.CreateProduct(ctx, request)
 
 	assert.NoError(t, err)
 	assert.Equal(t, "Test Product", result.Name)
 	assert.Equal(t, int64(10), result.Quantity)
-	assert.Equal(t, 100.0, result.Price)
+	assert.Equal(t, "100.00", result.Price.String())
+	assert.Equal(t, "USD", result.Currency)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetProductNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM products").
+		WithArgs(int64(999)).
+		WillReturnError(sql.ErrNoRows)
+
+	tracer := noop.NewTracerProvider().Tracer("test")
+	productService := service.New(repository.NewBaseRepository(db, productrepository.New(db)), tracer)
+
+	ctx := context.WithValue(context.Background(), "requestId", "test-123")
+
+	_, err = productService.GetProduct(ctx, 999)
+
+	assert.Equal(t, apperror.NotFound, apperror.KindOf(err))
+	assert.Equal(t, "product 999 not found", apperror.MessageOf(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteProductNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM products").
+		WithArgs(int64(999)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "quantity", "created_at", "updated_at", "version", "deleted_at", "price_minor", "currency", "sku", "barcode", "reorder_point"}))
+	mock.ExpectCommit()
+
+	tracer := noop.NewTracerProvider().Tracer("test")
+	productService := service.New(repository.NewBaseRepository(db, productrepository.New(db)), tracer)
+
+	ctx := context.WithValue(context.Background(), "requestId", "test-123")
+
+	err = productService.DeleteProduct(ctx, 999, model.Precondition{})
+
+	assert.Equal(t, apperror.NotFound, apperror.KindOf(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateProductDatabaseFailureIsInternal(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM products").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "quantity", "created_at", "updated_at", "version", "deleted_at", "price_minor", "currency", "sku", "barcode", "reorder_point"}).
+			AddRow(1, "Test Product", 10, time.Now(), nil, 1, nil, 10000, "USD", nil, nil, nil))
+	mock.ExpectExec("INSERT INTO stock_movements").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("UPDATE products").
+		WillReturnError(errors.New("disk I/O error"))
+	mock.ExpectRollback()
+
+	tracer := noop.NewTracerProvider().Tracer("test")
+	productService := service.New(repository.NewBaseRepository(db, productrepository.New(db)), tracer)
+
+	ctx := context.WithValue(context.Background(), "requestId", "test-123")
+
+	_, err = productService.UpdateProduct(ctx, 1, model.ProductRequest{Name: "Test Product", Quantity: 10, Price: model.MustParseMoney("100.00")}, model.Precondition{})
+
+	assert.Equal(t, apperror.Internal, apperror.KindOf(err))
+	assert.Equal(t, "internal server error", apperror.MessageOf(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -0,0 +1,55 @@
+package utility
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// Note : A cursor is the position of the last row in a page, so the next page can continue after it (keyset pagination) instead of using OFFSET.
+type Cursor struct {
+	SortBy    string `json:"s"`
+	SortOrder string `json:"o"`
+	Value     any    `json:"v"`
+	Id        int64  `json:"i"`
+}
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+func EncodeCursor(cursor Cursor) (string, error) {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func DecodeCursor(value string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	// Note : Numbers are decoded as written, a float64 loses integer sort keys above 2^53 and the next page would
+	// start at the wrong row.
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var cursor Cursor
+	if err := decoder.Decode(&cursor); err != nil || cursor.Value == nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	if number, ok := cursor.Value.(json.Number); ok {
+		if value, err := number.Int64(); err == nil {
+			cursor.Value = value
+		} else if value, err := number.Float64(); err == nil {
+			cursor.Value = value
+		} else {
+			return Cursor{}, ErrInvalidCursor
+		}
+	}
+
+	return cursor, nil
+}
@@ -0,0 +1,39 @@
+package utility
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Note : ETags are strong validators derived from the row version, so version 3 is sent as "3".
+func FormatETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ParseETags returns the versions listed in an If-Match or If-None-Match header.
+// Weak tags (W/"3") are only accepted when weak is true, because If-Match requires strong comparison.
+// Malformed tags and "*" are skipped.
+func ParseETags(header string, weak bool) []int64 {
+	versions := make([]int64, 0)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if strings.HasPrefix(tag, "W/") {
+			if !weak {
+				continue
+			}
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+
+		if len(tag) < 2 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+			continue
+		}
+
+		version, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, version)
+	}
+
+	return versions
+}
@@ -23,3 +23,8 @@ func (crw *Interceptor) WriteHeader(statusCode int) {
 func (crw *Interceptor) Write(b []byte) (int, error) {
 	return crw.ResponseWriter.Write(b)
 }
+
+// Note : Unwrap lets http.ResponseController reach the underlying writer, e.g. to flush a streamed response.
+func (crw *Interceptor) Unwrap() http.ResponseWriter {
+	return crw.ResponseWriter
+}
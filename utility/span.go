@@ -0,0 +1,19 @@
+package utility
+
+import (
+	"github.com/indrabrata/observability-playground/apperror"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RecordSpanError records err on span but only marks the span as failed when the server is at fault,
+// so expected outcomes such as not found or validation errors do not show up as errors in Tempo.
+func RecordSpanError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetAttributes(attribute.String("error.kind", string(apperror.KindOf(err))))
+
+	if apperror.IsServerError(err) {
+		span.SetStatus(codes.Error, err.Error())
+	}
+}